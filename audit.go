@@ -0,0 +1,84 @@
+package realm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditIssue   = "issue"
+	AuditRevoke  = "revoke"
+	AuditSend    = "send"
+	AuditBind    = "bind"
+	AuditRotate  = "rotate"
+	AuditExport  = "export"
+	AuditImport  = "import"
+	AuditRestore = "restore"
+	AuditPurge   = "purge"
+)
+
+// AuditActor identifies who performed an audited operation.
+// Key is the thumbprint of the request key, Signer the thumbprint of the key that signed the mandate.
+type AuditActor struct {
+	Key       string `json:"key,omitempty"`
+	MandateID string `json:"mandateId,omitempty"`
+	Role      string `json:"role,omitempty"`
+	Signer    string `json:"signer,omitempty"`
+}
+
+type AuditChange struct {
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// AuditEntry is a record of an administrative change in a realm. Entries are chained
+// per realm: each entry holds the hash of the previous one, and its own hash covers
+// all fields, so removing or editing an entry breaks the chain.
+type AuditEntry struct {
+	ID           string                  `json:"@id"`
+	Realm        string                  `json:"realm"`
+	Sequence     int64                   `json:"sequence"`
+	Timestamp    time.Time               `json:"timestamp"`
+	Actor        AuditActor              `json:"actor"`
+	EntityType   string                  `json:"entityType"`
+	EntityID     string                  `json:"entityId,omitempty"`
+	Operation    string                  `json:"operation"`
+	Before       json.RawMessage         `json:"before,omitempty"`
+	After        json.RawMessage         `json:"after,omitempty"`
+	Diff         map[string]*AuditChange `json:"diff,omitempty"`
+	PreviousHash string                  `json:"previousHash,omitempty"`
+	Hash         string                  `json:"hash,omitempty"`
+}
+
+// ComputeHash returns the hex encoded SHA256 hash of the entry with the Hash field left out.
+func (e *AuditEntry) ComputeHash() (string, error) {
+	c := *e
+	c.Hash = ""
+
+	bytes, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(bytes)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+type AuditFilter struct {
+	From       *time.Time
+	To         *time.Time
+	Actor      string
+	EntityType string
+	EntityID   string
+}
+
+type AuditProvider interface {
+	// Append assigns the entry its sequence number and hashes, linking it to the last entry for the realm
+	Append(realmID string, entry *AuditEntry) error
+	List(realmID string, filter AuditFilter) ([]*AuditEntry, error)
+}
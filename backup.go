@@ -0,0 +1,75 @@
+package realm
+
+import (
+	"errors"
+	"time"
+)
+
+// BackupType and BackupVersion identify the format of realm backups. Archives with
+// a newer version than this release knows are refused on import.
+const (
+	BackupType    = "realm-backup"
+	BackupVersion = 1
+)
+
+// How an import handles a realm, or realm entities, that already exist. With
+// BackupConflictFail the import is refused if the realm exists, BackupConflictSkip
+// keeps the existing entities and BackupConflictReplace overwrites them with the
+// ones in the archive.
+const (
+	BackupConflictFail    = "fail"
+	BackupConflictSkip    = "skip"
+	BackupConflictReplace = "replace"
+)
+
+var (
+	// ErrBackupSignature is returned when the archive isn't signed by a key of the realm in it.
+	ErrBackupSignature = errors.New("invalid backup signature")
+	// ErrBackupPassphrase is returned when the keys in an archive can't be decrypted with the passphrase.
+	ErrBackupPassphrase = errors.New("wrong backup passphrase")
+	// ErrBackupConflict is returned when the realm in the archive already exists.
+	ErrBackupConflict = errors.New("realm already exists")
+)
+
+// Backup is the content of a realm export.
+type Backup struct {
+	Type        string                 `json:"@type"`
+	Version     int                    `json:"version"`
+	Created     time.Time              `json:"created"`
+	Realm       *Realm                 `json:"realm"`
+	Roles       []*RoleWithPermissions `json:"roles,omitempty"`
+	Controllers []*Controller          `json:"controllers,omitempty"`
+	Actions     []*ControllerAction    `json:"actions,omitempty"`
+	Mandates    []*IssuedMandate       `json:"mandates,omitempty"`
+	Revocations []*Revocation          `json:"revocations,omitempty"`
+	Tickets     []*MandateTicket       `json:"tickets,omitempty"`
+	Invites     []*Invite              `json:"invites,omitempty"`
+	// InviteTokens maps invite IDs to the hash of their link token, which isn't
+	// part of the serialized invite.
+	InviteTokens map[string]string `json:"inviteTokens,omitempty"`
+	Settings     map[string]string `json:"settings,omitempty"`
+	Files        []*BackupFile     `json:"files,omitempty"`
+	// Keys holds the private keys of the realm encrypted with a passphrase. It's
+	// only included on request, and never for keys that can't be exported.
+	Keys *BackupKeys `json:"keys,omitempty"`
+}
+
+type BackupFile struct {
+	Name string `json:"name"`
+	Data []byte `json:"data"`
+}
+
+// BackupKeys is a JSON object of private keys by key ID, encrypted with
+// AES-256-GCM using a key derived from the passphrase with scrypt.
+type BackupKeys struct {
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// BackupImport reports the entities of each kind that were imported and skipped.
+type BackupImport struct {
+	Realm    *Realm         `json:"realm"`
+	Imported map[string]int `json:"imported"`
+	Skipped  map[string]int `json:"skipped,omitempty"`
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/IpsoVeritas/realm/pkg/services"
+	"github.com/pkg/errors"
+)
+
+// out is where the admin commands print their results.
+var out io.Writer = os.Stdout
+
+func runRealmsCommand(args []string) error {
+	switch {
+	case len(args) > 0 && args[0] == "list":
+		return listRealms(args[1:])
+	case len(args) > 0 && args[0] == "create":
+		return createRealm(args[1:])
+	default:
+		return fmt.Errorf("Usage: realm realms list|create")
+	}
+}
+
+func listRealms(args []string) error {
+	flags := flag.NewFlagSet("realms list", flag.ContinueOnError)
+	deleted := flags.Bool("deleted", false, "list the deleted realms instead")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	contextProvider, err := loadCommandProvider()
+	if err != nil {
+		return err
+	}
+
+	list := contextProvider.ListRealms
+	if *deleted {
+		list = contextProvider.ListDeletedRealms
+	}
+
+	realms, err := list()
+	if err != nil {
+		return errors.Wrap(err, "failed to list realms")
+	}
+	sort.Slice(realms, func(i, j int) bool { return realms[i].ID < realms[j].ID })
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tLABEL\tDELETED")
+	for _, r := range realms {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.ID, r.Label, formatTime(r.Deleted))
+	}
+
+	return w.Flush()
+}
+
+func createRealm(args []string) error {
+	flags := flag.NewFlagSet("realms create", flag.ContinueOnError)
+	label := flags.String("label", "", "label of the realm")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("Usage: realm realms create [-label label] <realm>")
+	}
+
+	contextProvider, err := loadCommandProvider()
+	if err != nil {
+		return err
+	}
+
+	created, err := contextProvider.New(&realm.Realm{
+		ID:    flags.Arg(0),
+		Label: *label,
+	}, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create realm")
+	}
+
+	fmt.Fprintf(out, "Created realm %s with admin roles %s\n", created.ID, strings.Join(created.AdminRoles, ", "))
+
+	return nil
+}
+
+func runBootstrapCommand(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("Usage: realm bootstrap password|reset|ticket")
+	}
+
+	contextProvider, err := loadCommandProvider()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "password":
+		password, err := contextProvider.BootstrapPassword()
+		if err != nil {
+			return errors.Wrap(err, "failed to get bootstrap password")
+		}
+		fmt.Fprintln(out, password)
+
+	case "reset":
+		password, err := contextProvider.ResetBootstrap()
+		if err != nil {
+			return errors.Wrap(err, "failed to reset bootstrap password")
+		}
+		fmt.Fprintln(out, password)
+
+	case "ticket":
+		ticket, err := contextProvider.BootstrapTicket()
+		if err != nil {
+			return errors.Wrap(err, "failed to create bootstrap ticket")
+		}
+
+		tickets := contextProvider.Get(ticket.Realm).MandateTickets()
+		link, err := tickets.Link(ticket)
+		if err != nil {
+			return errors.Wrap(err, "failed to create link")
+		}
+
+		qr, err := services.QRCodeText(tickets.URL(ticket))
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "%s\nURL:  %s\nLink: %s\n", qr, tickets.URL(ticket), link)
+
+	default:
+		return fmt.Errorf("Usage: realm bootstrap password|reset|ticket")
+	}
+
+	return nil
+}
+
+func runMandatesCommand(args []string) error {
+	switch {
+	case len(args) > 0 && args[0] == "list":
+		return listMandates(args[1:])
+	case len(args) > 0 && args[0] == "revoke":
+		return revokeMandate(args[1:])
+	default:
+		return fmt.Errorf("Usage: realm mandates list|revoke")
+	}
+}
+
+func listMandates(args []string) error {
+	flags := flag.NewFlagSet("mandates list", flag.ContinueOnError)
+	role := flags.String("role", "", "only list mandates for the role")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("Usage: realm mandates list [-role role] <realm>")
+	}
+
+	contextProvider, err := loadCommandProvider()
+	if err != nil {
+		return err
+	}
+
+	mandates := contextProvider.Get(flags.Arg(0)).Mandates()
+
+	var issued []*realm.IssuedMandate
+	if *role != "" {
+		issued, err = mandates.ListForRole(*role)
+	} else {
+		issued, err = mandates.List()
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to list mandates")
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tROLE\tLABEL\tSTATUS\tVALID UNTIL")
+	for _, m := range issued {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", m.ID, m.Role, m.Label, m.Status, formatTime(m.ValidUntil))
+	}
+
+	return w.Flush()
+}
+
+func revokeMandate(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("Usage: realm mandates revoke <realm> <mandate>")
+	}
+
+	contextProvider, err := loadCommandProvider()
+	if err != nil {
+		return err
+	}
+
+	context := contextProvider.Get(args[0])
+
+	mandate, err := context.Mandates().Get(args[1])
+	if err != nil {
+		return errors.Wrap(err, "failed to get mandate")
+	}
+
+	before := *mandate
+
+	mandate, err = context.Mandates().Revoke(mandate)
+	if err != nil {
+		return errors.Wrap(err, "failed to revoke mandate")
+	}
+
+	if err := context.Audit().Record(realm.AuditActor{}, "mandate", mandate.ID, realm.AuditRevoke, &before, mandate); err != nil {
+		return errors.Wrap(err, "failed to write audit entry")
+	}
+
+	fmt.Fprintf(out, "Revoked mandate %s for role %s\n", mandate.ID, mandate.Role)
+
+	return nil
+}
+
+func runInvitesCommand(args []string) error {
+	if len(args) != 2 || args[0] != "list" {
+		return fmt.Errorf("Usage: realm invites list <realm>")
+	}
+
+	contextProvider, err := loadCommandProvider()
+	if err != nil {
+		return err
+	}
+
+	invites, err := contextProvider.Get(args[1]).Invites().List()
+	if err != nil {
+		return errors.Wrap(err, "failed to list invites")
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tROLE\tSTATUS\tMESSAGE URI\tVALID UNTIL")
+	for _, i := range invites {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", i.ID, i.Name, i.Role, i.Status, i.MessageURI, formatTime(i.ValidUntil))
+	}
+
+	return w.Flush()
+}
+
+// runSettingsCommand prints the settings of a realm, or the global settings if no
+// realm is given.
+func runSettingsCommand(args []string) error {
+	if len(args) < 1 || len(args) > 2 || args[0] != "dump" {
+		return fmt.Errorf("Usage: realm settings dump [realm]")
+	}
+
+	realmID := ""
+	if len(args) == 2 {
+		realmID = args[1]
+	}
+
+	contextProvider, err := loadCommandProvider()
+	if err != nil {
+		return err
+	}
+
+	settings, err := contextProvider.Get(realmID).Settings().List()
+	if err != nil {
+		return errors.Wrap(err, "failed to list settings")
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+
+	for _, s := range settings {
+		fmt.Fprintf(out, "%s=%s\n", s.Key, s.Value)
+	}
+
+	return nil
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
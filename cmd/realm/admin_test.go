@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IpsoVeritas/document"
+	realm "github.com/IpsoVeritas/realm"
+	gormprvdr "github.com/IpsoVeritas/realm/pkg/providers/gorm"
+	"github.com/spf13/viper"
+)
+
+// signedMandate is a compact JWS that parses, for revoking a stored mandate.
+const signedMandate = "eyJhbGciOiJFUzI1NiJ9.e30.c2ln"
+
+// seedAdminDB stores the bootstrap realm test.local, the realm test.realm with a
+// mandate and an invite, and the deleted realm old.realm in the test database.
+func seedAdminDB(t *testing.T) {
+	db, err := openDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	realms, err := gormprvdr.NewGormRealmService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deleted := time.Now().UTC()
+	for _, r := range []*realm.Realm{
+		{ID: "test.local", AdminRoles: []string{"admin@test.local"}},
+		{ID: "test.realm", Label: "Test realm", AdminRoles: []string{"admin@test.realm"}},
+		{ID: "old.realm", AdminRoles: []string{"admin@old.realm"}, Deleted: &deleted},
+	} {
+		r.Descriptor = &document.RealmDescriptor{}
+		if err := realms.Set(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mandates, err := gormprvdr.NewGormMandateService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mandate := &realm.IssuedMandate{Mandate: *document.NewMandate("admin@test.realm"), Label: "Alice", Signed: signedMandate}
+	mandate.ID = "m1"
+	mandate.Status = document.MandateActive
+	if err := mandates.Set("test.realm", mandate); err != nil {
+		t.Fatal(err)
+	}
+
+	invites, err := gormprvdr.NewGormInviteService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := invites.Set("test.realm", &realm.Invite{ID: "i1", Name: "Bob", Role: "admin@test.realm", MessageURI: "mailto:bob@example.com", Status: realm.InviteStatusSent}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestAdminCommands runs the admin commands in order against the test database,
+// so later commands see what the earlier ones changed.
+func TestAdminCommands(t *testing.T) {
+	viper.SetDefault("cryptoprovider", "gorm")
+	viper.SetDefault("email_provider", "dummy")
+	seedAdminDB(t)
+
+	var buf bytes.Buffer
+	defer func(w io.Writer) { out = w }(out)
+	out = &buf
+
+	tests := []struct {
+		name       string
+		args       []string
+		want       []string
+		wantAbsent []string
+		wantErr    bool
+	}{
+		{
+			name:    "realms_create_existing",
+			args:    []string{"realms", "create", "test.realm"},
+			wantErr: true,
+		},
+		{
+			name:    "realms_create_without_realm",
+			args:    []string{"realms", "create"},
+			wantErr: true,
+		},
+		{
+			name:       "realms_list",
+			args:       []string{"realms", "list"},
+			want:       []string{"ID", "test.local", "test.realm", "Test realm"},
+			wantAbsent: []string{"old.realm"},
+		},
+		{
+			name:       "realms_list_deleted",
+			args:       []string{"realms", "list", "-deleted"},
+			want:       []string{"ID", "old.realm"},
+			wantAbsent: []string{"test.realm"},
+		},
+		{
+			name:    "realms_unknown",
+			args:    []string{"realms", "delete", "test.realm"},
+			wantErr: true,
+		},
+		{
+			name: "mandates_list",
+			args: []string{"mandates", "list", "test.realm"},
+			want: []string{"ID", "m1", "admin@test.realm", "Alice"},
+		},
+		{
+			name:       "mandates_list_for_role",
+			args:       []string{"mandates", "list", "-role", "member@test.realm", "test.realm"},
+			want:       []string{"ID"},
+			wantAbsent: []string{"m1"},
+		},
+		{
+			name:    "mandates_list_without_realm",
+			args:    []string{"mandates", "list"},
+			wantErr: true,
+		},
+		{
+			name:    "mandates_revoke_unknown",
+			args:    []string{"mandates", "revoke", "test.realm", "unknown"},
+			wantErr: true,
+		},
+		{
+			name: "mandates_revoke",
+			args: []string{"mandates", "revoke", "test.realm", "m1"},
+			want: []string{"Revoked mandate m1 for role admin@test.realm"},
+		},
+		{
+			name: "invites_list",
+			args: []string{"invites", "list", "test.realm"},
+			want: []string{"ID", "i1", "Bob", "mailto:bob@example.com", "sent"},
+		},
+		{
+			name:    "invites_list_without_realm",
+			args:    []string{"invites", "list"},
+			wantErr: true,
+		},
+		{
+			name: "bootstrap_reset",
+			args: []string{"bootstrap", "reset"},
+		},
+		{
+			name: "bootstrap_password",
+			args: []string{"bootstrap", "password"},
+		},
+		{
+			name:    "bootstrap_unknown",
+			args:    []string{"bootstrap", "bootstrapped"},
+			wantErr: true,
+		},
+		{
+			name: "settings_dump",
+			args: []string{"settings", "dump", "test.local"},
+			want: []string{"bootstrapped=false"},
+		},
+		{
+			name:    "settings_unknown",
+			args:    []string{"settings", "set", "test.local"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown",
+			args:    []string{"realm", "list"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			err := runCommand(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runCommand(%v) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			}
+			got := buf.String()
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("runCommand(%v) = %q, want %q in it", tt.args, got, want)
+				}
+			}
+			for _, absent := range tt.wantAbsent {
+				if strings.Contains(got, absent) {
+					t.Errorf("runCommand(%v) = %q, want no %q in it", tt.args, got, absent)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	httphandler "github.com/IpsoVeritas/httphandler"
+	gormkeys "github.com/IpsoVeritas/keys/gorm"
+	logger "github.com/IpsoVeritas/logger"
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/IpsoVeritas/realm/pkg/api/openapi"
+	gormprvdr "github.com/IpsoVeritas/realm/pkg/providers/gorm"
+	"github.com/IpsoVeritas/realm/pkg/providers/signer"
+	"github.com/IpsoVeritas/realm/pkg/services"
+	"github.com/IpsoVeritas/realm/pkg/version"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+const usage = `Usage: realm [command]
+
+Without a command the server is started.
+
+Commands:
+  kek rotate                       re-encrypt the stored realm keys with the current KEK
+  export [-o file] <realm>         write a backup of the realm, with the keys if
+                                   BACKUP_PASSPHRASE is set
+  import [-conflict policy] <file> restore a realm from a backup, where the policy for
+                                   existing entities is fail, skip or replace
+  realms list [-deleted]           list the realms, or the deleted realms
+  realms create [-label l] <realm> create a realm with the default roles
+  bootstrap password               show the bootstrap password
+  bootstrap reset                  set a new bootstrap password and allow bootstrapping again
+  bootstrap ticket                 create an admin ticket for the bootstrap realm and
+                                   print its URL as a QR code
+  mandates list [-role r] <realm>  list the mandates of the realm
+  mandates revoke <realm> <id>     revoke a mandate
+  invites list <realm>             list the invites of the realm
+  settings dump [realm]            print the settings of the realm, or the global settings
+  openapi                          print the OpenAPI document of the API`
+
+// runCommand runs the maintenance command given on the command line instead of
+// starting the server.
+func runCommand(args []string) error {
+	switch {
+	case args[0] == "help" || args[0] == "-h" || args[0] == "--help":
+		fmt.Println(usage)
+		return nil
+	case len(args) == 2 && args[0] == "kek" && args[1] == "rotate":
+		return rotateKEK()
+	case args[0] == "export":
+		return exportRealm(args[1:])
+	case args[0] == "import":
+		return importRealm(args[1:])
+	case args[0] == "realms":
+		return runRealmsCommand(args[1:])
+	case args[0] == "bootstrap":
+		return runBootstrapCommand(args[1:])
+	case args[0] == "mandates":
+		return runMandatesCommand(args[1:])
+	case args[0] == "invites":
+		return runInvitesCommand(args[1:])
+	case args[0] == "settings":
+		return runSettingsCommand(args[1:])
+	case len(args) == 1 && args[0] == "openapi":
+		return printOpenAPI()
+	default:
+		return fmt.Errorf("Unknown command %q\n\n%s", args, usage)
+	}
+}
+
+// loadCommandProvider sets up the realm services for commands, with the
+// filestore but without the HTTP handlers and background workers.
+func loadCommandProvider() (*services.RealmsServiceProvider, error) {
+	contextProvider, base, bootRealmID := loadProvider()
+
+	if err := contextProvider.LoadBootstrapRealm(bootRealmID); err != nil {
+		logger.Warningf("Failed to load bootstrap realm %s: %s", bootRealmID, err)
+	}
+
+	files, err := loadFilestore(base, httphandler.NewWrapper(viper.GetBool("prod")), httphandler.NewRouter())
+	if err != nil {
+		return nil, err
+	}
+	contextProvider.SetFilestore(files)
+
+	return contextProvider, nil
+}
+
+func exportRealm(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	output := flags.String("o", "", "file to write the backup to, <realm>.backup by default")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("Usage: realm export [-o file] <realm>")
+	}
+
+	realmID := flags.Arg(0)
+	if *output == "" {
+		*output = realmID + ".backup"
+	}
+
+	contextProvider, err := loadCommandProvider()
+	if err != nil {
+		return err
+	}
+
+	archive, err := contextProvider.Get(realmID).Export(viper.GetString("backup_passphrase"))
+	if err != nil {
+		return errors.Wrap(err, "failed to export realm")
+	}
+
+	if err := ioutil.WriteFile(*output, archive, 0600); err != nil {
+		return err
+	}
+
+	logger.Infof("Exported realm %s to %s", realmID, *output)
+
+	return nil
+}
+
+func importRealm(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	conflict := flags.String("conflict", realm.BackupConflictFail, "what to do with existing entities: fail, skip or replace")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("Usage: realm import [-conflict policy] <file>")
+	}
+
+	archive, err := ioutil.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	backup, _, err := services.ReadBackup(archive)
+	if err != nil {
+		return err
+	}
+
+	contextProvider, err := loadCommandProvider()
+	if err != nil {
+		return err
+	}
+
+	result, err := contextProvider.Get(backup.Realm.ID).Import(archive, viper.GetString("backup_passphrase"), *conflict)
+	if err != nil {
+		return errors.Wrap(err, "failed to import realm")
+	}
+
+	logger.Infof("Imported realm %s: %v imported, %v skipped", backup.Realm.ID, result.Imported, result.Skipped)
+
+	return nil
+}
+
+// rotateKEK re-encrypts all stored realm keys that are not encrypted with the
+// current KEK version, in a single transaction.
+func rotateKEK() error {
+	if viper.GetString("cryptoprovider") != "gorm" {
+		return fmt.Errorf("KEK rotation is only supported by the gorm crypto provider, not %s", viper.GetString("cryptoprovider"))
+	}
+
+	keks, err := signer.NewKEKRing(viper.GetViper())
+	if err != nil {
+		return err
+	}
+
+	db, err := openDB()
+	if err != nil {
+		return err
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	count, err := reencryptKeys(tx, keks)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "failed to commit")
+	}
+
+	current, _ := keks.Current()
+	logger.Infof("Re-encrypted %d keys with KEK version %d", count, current)
+
+	return nil
+}
+
+func reencryptKeys(tx *gorm.DB, keks *signer.KEKRing) (int, error) {
+	sks, err := gormkeys.NewGormStoredKeyService(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	versions, err := gormprvdr.NewGormKEKVersionService(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	realms, err := gormprvdr.NewGormRealmService(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	list, err := realms.List()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to list realms")
+	}
+
+	tracked, err := versions.List()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to list KEK versions")
+	}
+
+	keyIDs := make(map[string]bool)
+	for keyID := range tracked {
+		keyIDs[keyID] = true
+	}
+	for _, r := range list {
+		keyIDs[r.SigningKeyID()] = true
+		for _, retired := range r.RetiredKeys {
+			keyIDs[retired.KeyID] = true
+		}
+	}
+
+	ids := make([]string, 0, len(keyIDs))
+	for keyID := range keyIDs {
+		ids = append(ids, keyID)
+	}
+	sort.Strings(ids)
+
+	return keks.Reencrypt(sks, versions, ids)
+}
+
+// printOpenAPI writes the OpenAPI document to stdout, for generating clients
+// without a running server.
+func printOpenAPI() error {
+	doc, err := openapi.Spec(viper.GetString("base"), version.Version)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(doc)
+}
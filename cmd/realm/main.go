@@ -3,7 +3,7 @@
 package main
 
 import (
-	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -17,6 +17,7 @@ import (
 
 	crypto "github.com/IpsoVeritas/crypto"
 	httphandler "github.com/IpsoVeritas/httphandler"
+	keys "github.com/IpsoVeritas/keys"
 	gormkeys "github.com/IpsoVeritas/keys/gorm"
 	logger "github.com/IpsoVeritas/logger"
 	realm "github.com/IpsoVeritas/realm"
@@ -24,9 +25,13 @@ import (
 	"github.com/IpsoVeritas/realm/pkg/providers/assets"
 	"github.com/IpsoVeritas/realm/pkg/providers/bindata"
 	"github.com/IpsoVeritas/realm/pkg/providers/dummy"
+	"github.com/IpsoVeritas/realm/pkg/providers/events"
 	filestore "github.com/IpsoVeritas/realm/pkg/providers/filestore"
 	gormprvdr "github.com/IpsoVeritas/realm/pkg/providers/gorm"
 	"github.com/IpsoVeritas/realm/pkg/providers/mailgun"
+	"github.com/IpsoVeritas/realm/pkg/providers/messaging"
+	"github.com/IpsoVeritas/realm/pkg/providers/signer"
+	"github.com/IpsoVeritas/realm/pkg/providers/smtp"
 	"github.com/IpsoVeritas/realm/pkg/services"
 	"github.com/IpsoVeritas/realm/pkg/version"
 	"github.com/jinzhu/gorm"
@@ -57,6 +62,15 @@ func main() {
 	viper.SetDefault("cache", "file")
 	viper.SetDefault("cache_dir", ".cache")
 	viper.SetDefault("redis", "localhost:6379")
+	viper.SetDefault("event_bus", "inprocess")
+	viper.SetDefault("realm_topic", "realm")
+	viper.SetDefault("webhooks", true)
+	viper.SetDefault("webhooks_interval", "15s")
+	viper.SetDefault("ticket_janitor_interval", "1h")
+	viper.SetDefault("realm_retention", "720h")
+	viper.SetDefault("realm_janitor_interval", "1h")
+	viper.SetDefault("outbox_workers", 4)
+	viper.SetDefault("outbox_interval", "30s")
 	viper.SetDefault("filestore_dir", ".files")
 	viper.SetDefault("stats", "none")
 	viper.SetDefault("adminui", "https://admin.integrity.app")
@@ -64,8 +78,11 @@ func main() {
 	viper.SetDefault("proxy_endpoint", "https://proxy.svc.integrity.app")
 	viper.SetDefault("email_provider", "dummy")
 	viper.SetDefault("mailgun_config", "./mailgun.yml")
+	viper.SetDefault("sms_provider", "none")
 	viper.SetDefault("key", "./realm.pem")
 	viper.SetDefault("allow_patching", false)
+	viper.SetDefault("issuers", "")
+	viper.SetDefault("trusted_proxies", "")
 
 	if runtime.GOOS == "windows" && viper.GetString("log_formatter") == "text" {
 		logger.SetOutput(colorable.NewColorableStdout())
@@ -81,6 +98,13 @@ func main() {
 	w = logger.GetLogger().Logger.Writer()
 	defer w.Close()
 
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1:]); err != nil {
+			logger.Fatal(err)
+		}
+		return
+	}
+
 	addr := viper.GetString("addr")
 	server := &graceful.Server{
 		Timeout: time.Duration(15) * time.Second,
@@ -125,141 +149,7 @@ func loadHandler() http.Handler {
 
 	wrapper := httphandler.NewWrapper(prod)
 
-	var err error
-	if db == nil {
-		db, err = gorm.Open(viper.GetString("gorm_dialect"), viper.GetString("gorm_options"))
-		if err != nil {
-			logger.Fatal(err)
-		}
-		if viper.GetBool("gorm_debug") {
-			db.LogMode(true)
-		}
-		db.SetLogger(log.New(w, "database", 0))
-		db.DB().SetMaxIdleConns(1)
-		db.DB().SetMaxOpenConns(5)
-	}
-
-	sks, err := gormkeys.NewGormStoredKeyService(db)
-	if err != nil {
-		logger.Fatal(err)
-	}
-
-	kek := sha256.Sum256([]byte(viper.GetString("kek")))
-	if len(kek) != 32 {
-		logger.Fatal(fmt.Errorf("SHA256 hash of KEK needs to be 32 byte long, current hash is %d bytes", len(kek)))
-	}
-
-	// initialize services
-	realms, err := gormprvdr.NewGormRealmService(db)
-	if err != nil {
-		logger.Fatal(err)
-	}
-
-	actions, err := gormprvdr.NewGormActionService(db)
-	if err != nil {
-		logger.Fatal(err)
-	}
-
-	controllers, err := gormprvdr.NewGormControllerService(db)
-	if err != nil {
-		logger.Fatal(err)
-	}
-
-	invites, err := gormprvdr.NewGormInviteService(db)
-	if err != nil {
-		logger.Fatal(err)
-	}
-
-	mandates, err := gormprvdr.NewGormMandateService(db)
-	if err != nil {
-		logger.Fatal(err)
-	}
-
-	mandateTickets, err := gormprvdr.NewGormMandateTicketService(db)
-	if err != nil {
-		logger.Fatal(err)
-	}
-
-	roles, err := gormprvdr.NewGormRoleService(db)
-	if err != nil {
-		logger.Fatal(err)
-	}
-
-	settings, err := gormprvdr.NewGormSettingService(db)
-	if err != nil {
-		logger.Fatal(err)
-	}
-
-	email, err := loadEmail()
-	if err != nil {
-		logger.Fatal(err)
-	}
-
-	// TODO: build key set
-	keyset := &jose.JsonWebKeySet{}
-
-	bootRealmID, err := settings.Get("", "bootRealmID")
-	if err != nil || bootRealmID == "" && viper.GetString("base") != "" {
-		baseURL, err := url.Parse(viper.GetString("base"))
-		if err != nil {
-			logger.Fatal(errors.Wrap(err, "failed to parse base URL"))
-		}
-
-		bootRealmID = baseURL.Host
-	}
-
-	var key *jose.JsonWebKey
-	if bootRealmID == "" || viper.GetString("base") == "" {
-		_, err := os.Stat(viper.GetString("key"))
-		if err != nil {
-			key, err = crypto.NewKey()
-			if err != nil {
-				logger.Fatal(err)
-			}
-
-			kb, err := crypto.MarshalToPEM(key)
-			if err != nil {
-				logger.Fatal(err)
-			}
-
-			if err := ioutil.WriteFile(viper.GetString("key"), kb, 0600); err != nil {
-				logger.Fatal(err)
-			}
-		} else {
-			kb, err := ioutil.ReadFile(viper.GetString("key"))
-			if err != nil {
-				logger.Fatal(err)
-			}
-
-			key, err = crypto.UnmarshalPEM(kb)
-			if err != nil {
-				logger.Fatal(err)
-			}
-		}
-	}
-
-	base := viper.GetString("base")
-	if base == "" {
-		base = fmt.Sprintf("https://%s", bootRealmID)
-		// contextProvider.SetBase(base)
-	}
-
-	contextProvider := services.NewRealmsServiceProvider(
-		base,
-		realms,
-		actions,
-		controllers,
-		invites,
-		mandates,
-		mandateTickets,
-		roles,
-		settings,
-		sks, kek[0:32],
-		viper.GetString("realm_topic"),
-		keyset,
-		email,
-		loadAssets(),
-	)
+	contextProvider, base, bootRealmID := loadProvider()
 
 	var bootContext *services.RealmService
 
@@ -278,11 +168,10 @@ func loadHandler() http.Handler {
 
 		bootContext = contextProvider.Get(bootRealmID)
 
-		pw, err := crypto.GenerateRandomString(16)
+		pw, err := services.NewBootstrapPassword()
 		if err != nil {
 			logger.Fatal(err)
 		}
-		pw = strings.Replace(strings.Replace(pw, "-", "", -1), "_", "", -1)
 		bootContext.Settings().Set("password", pw)
 		bootContext.Settings().Set("bootstrapped", "false")
 
@@ -324,6 +213,23 @@ func loadHandler() http.Handler {
 	}
 
 	contextProvider.SetFilestore(files)
+
+	eventBus, err := loadEventBus()
+	if err != nil {
+		logger.Fatal(err)
+	}
+	contextProvider.SetEventBus(eventBus)
+
+	if viper.GetBool("webhooks") {
+		services.NewWebhookDispatcher(contextProvider).Start(viper.GetDuration("webhooks_interval"))
+	}
+
+	services.NewTicketJanitor(contextProvider).Start(viper.GetDuration("ticket_janitor_interval"))
+
+	services.NewRealmJanitor(contextProvider).Start(viper.GetDuration("realm_janitor_interval"))
+
+	services.NewOutboxWorker(contextProvider, viper.GetInt("outbox_workers")).Start(viper.GetDuration("outbox_interval"))
+
 	logger.Infof("Go to %s#/%s to manage your realm", viper.GetString("adminui"), bootRealmID)
 
 	// Add bootstrap check middleware
@@ -346,6 +252,13 @@ func loadHandler() http.Handler {
 	// Version handler
 	r.GET("/", wrapper.Wrap(rest.Version))
 
+	// OpenAPI document, keep pkg/api/openapi in sync with the routes below
+	openAPIController, err := rest.NewOpenAPIController(base)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	r.GET("/realm/v2/openapi.json", wrapper.Wrap(openAPIController.Spec))
+
 	configController := rest.NewConfigController(contextProvider)
 	r.GET("/realm/v2/realms/:realmID/config", wrapper.Wrap(configController.Config))
 
@@ -358,23 +271,35 @@ func loadHandler() http.Handler {
 	r.GET("/realm/v2/realms/:realmID/realm.json", wrapper.Wrap(wellKnown.WellKnownForRealm))
 
 	// realms
-	realmsController := rest.NewRealmsController(base, contextProvider, keyset)
+	realmsController := rest.NewRealmsController(base, contextProvider)
 	r.GET("/realm/v2/realms", wrapper.Wrap(realmsController.ListRealms))
 	r.POST("/realm/v2/realms", wrapper.Wrap(realmsController.PostRealm))
 	r.GET("/realm/v2/realms/:realmID", wrapper.Wrap(realmsController.GetRealm))
 	r.PUT("/realm/v2/realms/:realmID", wrapper.Wrap(realmsController.UpdateRealm))
 	r.DELETE("/realm/v2/realms/:realmID", wrapper.Wrap(realmsController.DeleteRealm))
+	r.POST("/realm/v2/realms/:realmID/restore", wrapper.Wrap(realmsController.RestoreRealm))
 	r.POST("/realm/v2/realms/:realmID/icon", wrapper.Wrap(realmsController.IconHandler))
 	r.POST("/realm/v2/realms/:realmID/banner", wrapper.Wrap(realmsController.BannerHandler))
+	r.POST("/realm/v2/realms/:realmID/key/rotate", wrapper.Wrap(realmsController.RotateKey))
+	r.POST("/realm/v2/realms/:realmID/export", wrapper.Wrap(realmsController.Export))
+	r.POST("/realm/v2/realms/:realmID/import", wrapper.Wrap(realmsController.Import))
 
 	// realm actions
 	r.POST("/realm/v2/realms/:realmID/do/join", wrapper.Wrap(realmsController.JoinRealm))
+	r.POST("/realm/v2/realms/:realmID/do/join/callback", wrapper.Wrap(realmsController.JoinRealmCallback))
 
 	// bootstrap realm
 	r.POST("/realm/v2/realms/:realmID/bootstrap", wrapper.Wrap(realmsController.Bootstrap))
 
 	// mandate tickets
 	mandateTicketController := rest.NewMandateTicketController(base, contextProvider)
+	r.GET("/realm/v2/realms/:realmID/tickets", wrapper.Wrap(mandateTicketController.List))
+	r.POST("/realm/v2/realms/:realmID/tickets", wrapper.Wrap(mandateTicketController.Set))
+	r.GET("/realm/v2/realms/:realmID/tickets/:ticketID", wrapper.Wrap(mandateTicketController.Get))
+	r.PUT("/realm/v2/realms/:realmID/tickets/:ticketID", wrapper.Wrap(mandateTicketController.Set))
+	r.DELETE("/realm/v2/realms/:realmID/tickets/:ticketID", wrapper.Wrap(mandateTicketController.Delete))
+	r.GET("/realm/v2/realms/:realmID/tickets/:ticketID/url", wrapper.Wrap(mandateTicketController.URL))
+	r.GET("/realm/v2/realms/:realmID/tickets/:ticketID/qr", wrapper.Wrap(mandateTicketController.QRCode))
 	r.GET("/realm/v2/realms/:realmID/tickets/:ticketID/issue", wrapper.Wrap(mandateTicketController.IssueMandate))
 	r.POST("/realm/v2/realms/:realmID/tickets/:ticketID/callback", wrapper.Wrap(mandateTicketController.IssueMandateCallback))
 
@@ -385,19 +310,34 @@ func loadHandler() http.Handler {
 	r.GET("/realm/v2/realms/:realmID/mandate/:mandateID", wrapper.Wrap(mandatesController.Get))
 	r.PUT("/realm/v2/realms/:realmID/mandates/:mandateID/revoke", wrapper.Wrap(mandatesController.Revoke))
 	r.POST("/realm/v2/realms/:realmID/mandates/issue", wrapper.Wrap(mandatesController.Issue))
+	r.POST("/realm/v2/realms/:realmID/mandates/reissue", wrapper.Wrap(mandatesController.Reissue))
+
+	// revocations
+	revocationsController := rest.NewRevocationsController(contextProvider)
+	r.GET("/realm/v2/realms/:realmID/revocations.json", wrapper.Wrap(revocationsController.List))
 
 	// invites
-	invitesController := rest.NewInvitesController(contextProvider)
+	trustedProxies, err := rest.ParseTrustedProxies(viper.GetString("trusted_proxies"))
+	if err != nil {
+		logger.Fatal(err)
+	}
+	invitesController := rest.NewInvitesController(contextProvider, trustedProxies)
 	r.GET("/realm/v2/realms/:realmID/invites/role/:roleName", wrapper.Wrap(invitesController.List))
 	r.GET("/realm/v2/realms/:realmID/invites", wrapper.Wrap(invitesController.List))
 	r.GET("/realm/v2/realms/:realmID/invites/id/:inviteID", wrapper.Wrap(invitesController.Get))
 	r.POST("/realm/v2/realms/:realmID/invites", wrapper.Wrap(invitesController.Set))
+	r.POST("/realm/v2/realms/:realmID/invites/import", wrapper.Wrap(invitesController.Import))
 	r.POST("/realm/v2/realms/:realmID/invites/id/:inviteID", wrapper.Wrap(invitesController.Set))
 	r.PUT("/realm/v2/realms/:realmID/invites/id/:inviteID", wrapper.Wrap(invitesController.Set))
 	r.DELETE("/realm/v2/realms/:realmID/invites/id/:inviteID", wrapper.Wrap(invitesController.Delete))
 	r.PUT("/realm/v2/realms/:realmID/invites/id/:inviteID/send", wrapper.Wrap(invitesController.Send))
-	r.GET("/realm/v2/realms/:realmID/invites/id/:inviteID/fetch", wrapper.Wrap(invitesController.Fetch))
-	r.POST("/realm/v2/realms/:realmID/invites/id/:inviteID/callback", wrapper.Wrap(invitesController.Callback))
+	r.PUT("/realm/v2/realms/:realmID/invites/id/:inviteID/cancel", wrapper.Wrap(invitesController.Cancel))
+	r.POST("/realm/v2/realms/:realmID/invites/id/:inviteID/qr", wrapper.Wrap(invitesController.QRCode))
+	r.POST("/realm/v2/realms/:realmID/invites/id/:inviteID/qr/rotate", wrapper.Wrap(invitesController.RotateQRCode))
+	r.GET("/realm/v2/realms/:realmID/invites/id/:inviteID/deliveries", wrapper.Wrap(invitesController.Deliveries))
+	r.POST("/realm/v2/realms/:realmID/invites/id/:inviteID/deliveries/:deliveryID/redeliver", wrapper.Wrap(invitesController.Redeliver))
+	r.GET("/realm/v2/realms/:realmID/invites/token/:token/fetch", wrapper.Wrap(invitesController.Fetch))
+	r.POST("/realm/v2/realms/:realmID/invites/token/:token/callback", wrapper.Wrap(invitesController.Callback))
 
 	// controllers
 	controllersController := rest.NewControllersController(contextProvider)
@@ -419,6 +359,35 @@ func loadHandler() http.Handler {
 	r.PUT("/realm/v2/realms/:realmID/roles/:roleID", wrapper.Wrap(rolesController.Set))
 	r.DELETE("/realm/v2/realms/:realmID/roles/:roleID", wrapper.Wrap(rolesController.Delete))
 
+	// trusted issuers
+	issuersController := rest.NewIssuersController(contextProvider)
+	r.GET("/realm/v2/realms/:realmID/issuers", wrapper.Wrap(issuersController.List))
+	r.POST("/realm/v2/realms/:realmID/issuers", wrapper.Wrap(issuersController.Set))
+	r.GET("/realm/v2/realms/:realmID/issuers/:issuerID", wrapper.Wrap(issuersController.Get))
+	r.DELETE("/realm/v2/realms/:realmID/issuers/:issuerID", wrapper.Wrap(issuersController.Delete))
+
+	templatesController := rest.NewTemplatesController(contextProvider)
+	r.GET("/realm/v2/realms/:realmID/templates/invite", wrapper.Wrap(templatesController.Get))
+	r.PUT("/realm/v2/realms/:realmID/templates/invite", wrapper.Wrap(templatesController.Set))
+	r.POST("/realm/v2/realms/:realmID/templates/invite/preview", wrapper.Wrap(templatesController.Preview))
+	r.POST("/realm/v2/realms/:realmID/templates/invite/attachments", wrapper.Wrap(templatesController.AddAttachment))
+	r.DELETE("/realm/v2/realms/:realmID/templates/invite/attachments/:name", wrapper.Wrap(templatesController.RemoveAttachment))
+
+	// webhooks
+	webhooksController := rest.NewWebhooksController(contextProvider)
+	r.GET("/realm/v2/realms/:realmID/webhooks", wrapper.Wrap(webhooksController.List))
+	r.POST("/realm/v2/realms/:realmID/webhooks", wrapper.Wrap(webhooksController.Set))
+	r.GET("/realm/v2/realms/:realmID/webhooks/:webhookID", wrapper.Wrap(webhooksController.Get))
+	r.PUT("/realm/v2/realms/:realmID/webhooks/:webhookID", wrapper.Wrap(webhooksController.Set))
+	r.DELETE("/realm/v2/realms/:realmID/webhooks/:webhookID", wrapper.Wrap(webhooksController.Delete))
+	r.GET("/realm/v2/realms/:realmID/webhooks/:webhookID/deliveries", wrapper.Wrap(webhooksController.Deliveries))
+	r.POST("/realm/v2/realms/:realmID/webhooks/:webhookID/deliveries/:deliveryID/redeliver", wrapper.Wrap(webhooksController.Redeliver))
+
+	// audit log
+	auditController := rest.NewAuditController(contextProvider)
+	r.GET("/realm/v2/realms/:realmID/audit", wrapper.Wrap(auditController.List))
+	r.GET("/realm/v2/realms/:realmID/audit/verify", wrapper.Wrap(auditController.Verify))
+
 	// service listing
 	servicesController := rest.NewServicesController(contextProvider)
 	r.GET("/realm/v2/realms/:realmID/services", wrapper.Wrap(servicesController.ListServices))
@@ -457,10 +426,300 @@ func loadFilestore(base string, wrapper *httphandler.Wrapper, r *httprouter.Rout
 	}
 }
 
+func loadEventBus() (realm.EventBus, error) {
+	switch viper.GetString("event_bus") {
+	case "redis":
+		return events.NewRedisEventBus(viper.GetString("redis"))
+	default:
+		return events.NewInProcessEventBus(), nil
+	}
+}
+
+// loadKeyset loads the global trusted fact issuers from a JWKS file or URL.
+func loadKeyset() (*jose.JsonWebKeySet, error) {
+	keyset := &jose.JsonWebKeySet{}
+
+	source := viper.GetString("issuers")
+	if source == "" {
+		return keyset, nil
+	}
+
+	var b []byte
+	var err error
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		client := &http.Client{Timeout: 30 * time.Second}
+		resp, err := client.Get(source)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to fetch issuers keyset")
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to fetch issuers keyset: %s", resp.Status)
+		}
+
+		b, err = ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read issuers keyset")
+		}
+	} else {
+		b, err = ioutil.ReadFile(source)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read issuers keyset")
+		}
+	}
+
+	if err := json.Unmarshal(b, keyset); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal issuers keyset")
+	}
+
+	logger.Infof("Loaded %d trusted issuers from %s", len(keyset.Keys), source)
+
+	return keyset, nil
+}
+
+// loadSMS registers the transport used for invites sent to tel: URIs.
+func loadSMS() error {
+	switch provider := viper.GetString("sms_provider"); provider {
+	case "twilio":
+		messaging.AddTransport("tel", messaging.NewTwilioTransport(viper.GetViper()))
+	case "none", "":
+	default:
+		return fmt.Errorf("unknown sms_provider %s", provider)
+	}
+
+	return nil
+}
+
+// loadProvider sets up the realm services on the database, and returns them with
+// the base URL and the ID of the bootstrap realm. The bootstrap realm, filestore
+// and event bus are set up by the caller.
+func loadProvider() (*services.RealmsServiceProvider, string, string) {
+	db, err := openDB()
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	sks, err := gormkeys.NewGormStoredKeyService(db)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	keks, err := signer.NewKEKRing(viper.GetViper())
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	kekVersions, err := gormprvdr.NewGormKEKVersionService(db)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	// initialize services
+	realms, err := gormprvdr.NewGormRealmService(db)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	actions, err := gormprvdr.NewGormActionService(db)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	controllers, err := gormprvdr.NewGormControllerService(db)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	invites, err := gormprvdr.NewGormInviteService(db)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	mandates, err := gormprvdr.NewGormMandateService(db)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	revocations, err := gormprvdr.NewGormRevocationService(db)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	mandateTickets, err := gormprvdr.NewGormMandateTicketService(db)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	roles, err := gormprvdr.NewGormRoleService(db)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	permissions, err := gormprvdr.NewGormPermissionService(db)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	settings, err := gormprvdr.NewGormSettingService(db)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	webhooks, err := gormprvdr.NewGormWebhookService(db)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	webhookDeliveries, err := gormprvdr.NewGormWebhookDeliveryService(db)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	outbox, err := gormprvdr.NewGormOutboxService(db)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	audit, err := gormprvdr.NewGormAuditService(db)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	email, err := loadEmail()
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	if err := loadSMS(); err != nil {
+		logger.Fatal(err)
+	}
+
+	keySigner, err := loadSigner(sks, kekVersions, keks)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	keyset, err := loadKeyset()
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	bootRealmID, err := settings.Get("", "bootRealmID")
+	if err != nil || bootRealmID == "" && viper.GetString("base") != "" {
+		baseURL, err := url.Parse(viper.GetString("base"))
+		if err != nil {
+			logger.Fatal(errors.Wrap(err, "failed to parse base URL"))
+		}
+
+		bootRealmID = baseURL.Host
+	}
+
+	var key *jose.JsonWebKey
+	if bootRealmID == "" || viper.GetString("base") == "" {
+		_, err := os.Stat(viper.GetString("key"))
+		if err != nil {
+			key, err = crypto.NewKey()
+			if err != nil {
+				logger.Fatal(err)
+			}
+
+			kb, err := crypto.MarshalToPEM(key)
+			if err != nil {
+				logger.Fatal(err)
+			}
+
+			if err := ioutil.WriteFile(viper.GetString("key"), kb, 0600); err != nil {
+				logger.Fatal(err)
+			}
+		} else {
+			kb, err := ioutil.ReadFile(viper.GetString("key"))
+			if err != nil {
+				logger.Fatal(err)
+			}
+
+			key, err = crypto.UnmarshalPEM(kb)
+			if err != nil {
+				logger.Fatal(err)
+			}
+		}
+	}
+
+	base := viper.GetString("base")
+	if base == "" {
+		base = fmt.Sprintf("https://%s", bootRealmID)
+		// contextProvider.SetBase(base)
+	}
+
+	contextProvider := services.NewRealmsServiceProvider(
+		base,
+		realms,
+		actions,
+		controllers,
+		invites,
+		mandates,
+		revocations,
+		mandateTickets,
+		roles,
+		permissions,
+		settings,
+		webhooks,
+		webhookDeliveries,
+		outbox,
+		audit,
+		keySigner,
+		viper.GetString("realm_topic"),
+		keyset,
+		email,
+		loadAssets(),
+	)
+
+	contextProvider.SetPurger(gormprvdr.NewGormRealmPurger(db), viper.GetDuration("realm_retention"))
+	contextProvider.SetImporter(gormprvdr.NewGormRealmImporter(db))
+
+	return contextProvider, base, bootRealmID
+}
+
+// openDB opens the database once, and returns the same connection on later calls.
+func openDB() (*gorm.DB, error) {
+	if db != nil {
+		return db, nil
+	}
+
+	conn, err := gorm.Open(viper.GetString("gorm_dialect"), viper.GetString("gorm_options"))
+	if err != nil {
+		return nil, err
+	}
+	if viper.GetBool("gorm_debug") {
+		conn.LogMode(true)
+	}
+	conn.SetLogger(log.New(w, "database", 0))
+	conn.DB().SetMaxIdleConns(1)
+	conn.DB().SetMaxOpenConns(5)
+
+	db = conn
+	return db, nil
+}
+
+// loadSigner returns the signer holding the realm keys, which by default are kept
+// encrypted with the KEK in the database.
+func loadSigner(sks keys.StoredKeyService, versions realm.KEKVersionProvider, keks *signer.KEKRing) (realm.Signer, error) {
+	switch viper.GetString("cryptoprovider") {
+	case "pkcs11":
+		return signer.NewPKCS11Signer(viper.GetViper())
+	case "gorm":
+		return signer.NewStoredKeySigner(sks, versions, keks), nil
+	default:
+		return nil, fmt.Errorf("Unknown crypto provider %s", viper.GetString("cryptoprovider"))
+	}
+}
+
 func loadEmail() (realm.EmailProvider, error) {
 	switch viper.GetString("email_provider") {
 	case "mailgun":
 		return mailgun.NewMailgunProvider(viper.GetString("mailgun_config"))
+	case "smtp":
+		return smtp.NewSMTPProvider(viper.GetViper())
 	default:
 		return dummy.NewDummyEmailProvider()
 	}
@@ -2,11 +2,16 @@ package main
 
 import (
 	"flag"
+	"go/ast"
+	"go/parser"
+	"go/token"
 	"os"
 	"runtime"
+	"strconv"
 	"testing"
 
 	logger "github.com/IpsoVeritas/logger"
+	"github.com/IpsoVeritas/realm/pkg/api/openapi"
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
 )
@@ -54,3 +59,61 @@ func run(m *testing.M) int {
 // 		t.Fatal(err)
 // 	}
 // }
+
+// TestOpenAPIRoutes checks that the routes registered in main.go and the
+// operations in the OpenAPI document are the same.
+func TestOpenAPIRoutes(t *testing.T) {
+	f, err := parser.ParseFile(token.NewFileSet(), "main.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	routes := make(map[string]bool)
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) != 2 {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		switch sel.Sel.Name {
+		case "GET", "PUT", "POST", "DELETE":
+		default:
+			return true
+		}
+		lit, ok := call.Args[0].(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+		path, err := strconv.Unquote(lit.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		routes[sel.Sel.Name+" "+path] = true
+		return true
+	})
+
+	if len(routes) == 0 {
+		t.Fatal("no routes found in main.go")
+	}
+
+	operations := make(map[string]bool)
+	for _, o := range openapi.Operations {
+		route := o.Method + " " + o.Path
+		operations[route] = true
+		if !routes[route] {
+			t.Errorf("%s is in the OpenAPI document, but not routed", route)
+		}
+	}
+	for route := range routes {
+		if !operations[route] {
+			t.Errorf("%s is routed, but not in the OpenAPI document", route)
+		}
+	}
+
+	if _, err := openapi.Spec("http://test.local", "test"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,51 @@
+package realm
+
+import (
+	"io"
+	"time"
+)
+
+const (
+	EventRealmCreated      = "realm.created"
+	EventRealmUpdated      = "realm.updated"
+	EventRealmDeleted      = "realm.deleted"
+	EventRealmRestored     = "realm.restored"
+	EventRealmPurged       = "realm.purged"
+	EventRealmKeyRotated   = "realm.key_rotated"
+	EventRealmExported     = "realm.exported"
+	EventRealmImported     = "realm.imported"
+	EventMandateIssued     = "mandate.issued"
+	EventMandateRevoked    = "mandate.revoked"
+	EventInviteSent        = "invite.sent"
+	EventInviteAccepted    = "invite.accepted"
+	EventControllerBound   = "controller.bound"
+	EventControllerDeleted = "controller.deleted"
+	EventActionsUpdated    = "actions.updated"
+)
+
+type RealmEvent struct {
+	Type      string            `json:"type"`
+	Realm     string            `json:"realm"`
+	EntityID  string            `json:"entityId,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+	Data      map[string]string `json:"data,omitempty"`
+}
+
+func NewRealmEvent(eventType, realmID, entityID string) *RealmEvent {
+	return &RealmEvent{
+		Type:      eventType,
+		Realm:     realmID,
+		EntityID:  entityID,
+		Timestamp: time.Now().UTC(),
+		Data:      make(map[string]string),
+	}
+}
+
+type EventHandler func(event *RealmEvent)
+
+// EventBus publishes realm events on a topic.
+// Subscribe returns a closer that stops delivery to the handler.
+type EventBus interface {
+	Publish(topic string, event *RealmEvent) error
+	Subscribe(topic string, handler EventHandler) (io.Closer, error)
+}
@@ -9,10 +9,12 @@ require (
 	github.com/IpsoVeritas/httphandler v0.0.0-20211006192537-8f7b2d45c359
 	github.com/IpsoVeritas/keys v0.0.0-20211006192006-ff61e251a3d2
 	github.com/IpsoVeritas/logger v0.0.0-20211006181550-96416e0d030b
+	github.com/ThalesIgnite/crypto11 v1.2.5
 	github.com/denisenkom/go-mssqldb v0.10.0 // indirect
 	github.com/facebookgo/ensure v0.0.0-20200202191622-63f1cf65ac4c // indirect
 	github.com/facebookgo/stack v0.0.0-20160209184415-751773369052 // indirect
 	github.com/facebookgo/subset v0.0.0-20200203212716-c811ad88dec4 // indirect
+	github.com/go-redis/redis/v8 v8.11.4
 	github.com/go-sql-driver/mysql v1.6.0 // indirect
 	github.com/gobuffalo/envy v1.9.0 // indirect
 	github.com/jinzhu/gorm v1.9.16
@@ -29,9 +31,11 @@ require (
 	github.com/rogpeppe/go-internal v1.8.0 // indirect
 	github.com/satori/go.uuid v1.2.0
 	github.com/sirupsen/logrus v1.8.1
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 	github.com/spf13/viper v1.8.1
 	github.com/subosito/twilio v0.0.2-0.20160901001414-ef2f13504366
 	github.com/tylerb/graceful v1.2.16-0.20170221171003-d72b0151351a
+	golang.org/x/crypto v0.0.0-20210921155107-089bfa567519
 	golang.org/x/oauth2 v0.0.0-20210628180205-a41e5a781914
 	golang.org/x/sys v0.0.0-20210630005230-0f9fa26af87c // indirect
 	google.golang.org/api v0.50.1-0.20210702115825-985b53fdf9cd
@@ -1,6 +1,35 @@
 package realm
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+// Invite statuses. An invite starts as a draft, is sent to the recipient and
+// opened when the recipient fetches it. Accepting, expiring or cancelling an
+// invite closes it.
+const (
+	InviteStatusDraft     = "draft"
+	InviteStatusSent      = "sent"
+	InviteStatusOpened    = "opened"
+	InviteStatusAccepted  = "accepted"
+	InviteStatusExpired   = "expired"
+	InviteStatusCancelled = "cancelled"
+)
+
+var (
+	// ErrInviteExpired is returned when an invite is used after its TTL or validity has passed.
+	ErrInviteExpired = errors.New("invite has expired")
+	// ErrInviteClosed is returned when an invite has already been accepted or cancelled.
+	ErrInviteClosed = errors.New("invite is no longer open")
+	// ErrInviteToken is returned when no invite has the token of an invite link.
+	ErrInviteToken = errors.New("invalid invite token")
+	// ErrInviteNotYetValid is returned when an invite is accepted before its validity starts.
+	ErrInviteNotYetValid = errors.New("invite is not valid yet")
+	// ErrInviteLinkIssued is returned when a QR code is requested for an invite that
+	// already has a link, which only an explicit rotation replaces.
+	ErrInviteLinkIssued = errors.New("invite already has a link")
+)
 
 type Invite struct {
 	ID          string     `json:"@id,omitempty" gorm:"primary_key"`
@@ -20,11 +49,67 @@ type Invite struct {
 	KeyLevel    int        `json:"keyLevel,omitempty"`
 	ValidFrom   *time.Time `json:"validFrom,omitempty"`
 	ValidUntil  *time.Time `json:"validUntil,omitempty"`
+	Created     *time.Time `json:"created,omitempty"`
+	SentAt      *time.Time `json:"sentAt,omitempty"`
+	MandateID   string     `json:"mandateId,omitempty"`
+	Locale      string     `json:"locale,omitempty"`
+	// TokenHash is the SHA-256 hash of the token in the invite link. It's only
+	// stored by the provider and never serialized.
+	TokenHash string `json:"-"`
+}
+
+// Open returns true if the invite can still be sent, fetched and accepted.
+func (i *Invite) Open() bool {
+	switch i.Status {
+	case "", InviteStatusDraft, InviteStatusSent, InviteStatusOpened:
+		return true
+	}
+	return false
+}
+
+// NotYetValid returns true if the validity of the mandate the invite grants
+// has not started.
+func (i *Invite) NotYetValid(now time.Time) bool {
+	return i.ValidFrom != nil && now.Before(*i.ValidFrom)
+}
+
+// Expired returns true if the TTL of the invite, counted in seconds from when it
+// was sent, or the validity of the mandate it grants has passed.
+func (i *Invite) Expired(now time.Time) bool {
+	if i.Status == InviteStatusExpired {
+		return true
+	}
+
+	if i.ValidUntil != nil && !now.Before(*i.ValidUntil) {
+		return true
+	}
+
+	if i.TTL > 0 {
+		start := i.SentAt
+		if start == nil {
+			start = i.Created
+		}
+		if start != nil && !now.Before(start.Add(time.Duration(i.TTL)*time.Second)) {
+			return true
+		}
+	}
+
+	return false
 }
 
 type InviteProvider interface {
 	List(realmID string) ([]*Invite, error)
 	Get(realmID, id string) (*Invite, error)
+	// GetByToken returns the invite with the hash of an invite link token
+	GetByToken(realmID, tokenHash string) (*Invite, error)
+	// Claim marks the open invite with the token hash as accepted and clears the
+	// token in a single update, so only one caller can claim it. It returns false
+	// if no open invite has the token.
+	Claim(realmID, tokenHash string) (bool, error)
+	// SetIfOpen saves the invite only if the stored invite is still open, in a single
+	// update, so an invite accepted or cancelled meanwhile isn't opened again. It
+	// returns false if the stored invite isn't open.
+	SetIfOpen(realmID string, invite *Invite) (bool, error)
 	Set(realmID string, invite *Invite) error
 	Delete(realmID, id string) error
 	ListForRole(realmID, role string) ([]*Invite, error)
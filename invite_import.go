@@ -0,0 +1,34 @@
+package realm
+
+import "time"
+
+// Import result statuses. Rows of a dry-run, or of an import that was rejected
+// because some rows are invalid, are only validated. Failed rows could not be created.
+const (
+	InviteImportValid   = "valid"
+	InviteImportInvalid = "invalid"
+	InviteImportCreated = "created"
+	InviteImportQueued  = "queued"
+	InviteImportFailed  = "failed"
+)
+
+// InviteImportRow is one invite in a bulk import.
+type InviteImportRow struct {
+	Name       string     `json:"name,omitempty"`
+	MessageURI string     `json:"messageURI"`
+	Role       string     `json:"role"`
+	ValidFrom  *time.Time `json:"validFrom,omitempty"`
+	ValidUntil *time.Time `json:"validUntil,omitempty"`
+	Locale     string     `json:"locale,omitempty"`
+	Text       string     `json:"text,omitempty"`
+}
+
+// InviteImportResult reports the outcome for one row of a bulk import. Rows are
+// numbered from 1, not counting the CSV header.
+type InviteImportResult struct {
+	Row      int            `json:"row"`
+	Status   string         `json:"status"`
+	Invite   *Invite        `json:"invite,omitempty"`
+	Delivery *OutboxMessage `json:"delivery,omitempty"`
+	Error    string         `json:"error,omitempty"`
+}
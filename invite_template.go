@@ -0,0 +1,10 @@
+package realm
+
+// InviteTemplates are the templates a realm uses for invite emails. Empty
+// templates fall back to the defaults in the assets.
+type InviteTemplates struct {
+	Subject     string   `json:"subject,omitempty"`
+	Text        string   `json:"text,omitempty"`
+	HTML        string   `json:"html,omitempty"`
+	Attachments []string `json:"attachments,omitempty"`
+}
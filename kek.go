@@ -0,0 +1,13 @@
+package realm
+
+// KEKVersionProvider keeps track of which version of the key encryption key each
+// stored realm key is encrypted with.
+type KEKVersionProvider interface {
+	// Get returns the KEK version of a key, or 0 for keys stored before KEK
+	// versions were tracked, which are encrypted with the first version.
+	Get(keyID string) (int, error)
+	Set(keyID string, version int) error
+	Delete(keyID string) error
+	// List returns the KEK versions of all tracked keys
+	List() (map[string]int, error)
+}
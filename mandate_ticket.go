@@ -1,16 +1,26 @@
 package realm
 
 import (
+	"errors"
+	"time"
+
 	"github.com/IpsoVeritas/document"
 	uuid "github.com/satori/go.uuid"
 )
 
+// ErrTicketNotRedeemable is returned by MandateTicketProvider.Redeem when a ticket
+// has expired or has reached its maximum number of redemptions.
+var ErrTicketNotRedeemable = errors.New("ticket expired or fully redeemed")
+
 type MandateTicket struct {
 	document.Base
-	Mandate      *document.Mandate      `json:"mandate,omitempty"`
-	ScopeRequest *document.ScopeRequest `json:"scope-request,omitempty"`
-	Facts        map[string]string      `json:"facts,omitempty"`
-	Static       bool                   `json:"static,omitempty"`
+	Mandate        *document.Mandate      `json:"mandate,omitempty"`
+	ScopeRequest   *document.ScopeRequest `json:"scope-request,omitempty"`
+	Facts          map[string]string      `json:"facts,omitempty"`
+	Static         bool                   `json:"static,omitempty"`
+	ValidUntil     *time.Time             `json:"validUntil,omitempty"`
+	MaxRedemptions int                    `json:"maxRedemptions,omitempty"`
+	Redemptions    int                    `json:"redemptions,omitempty"`
 }
 
 func NewMandateTicket() *MandateTicket {
@@ -22,9 +32,19 @@ func NewMandateTicket() *MandateTicket {
 	}
 }
 
+// Expired returns true if the ticket can no longer be redeemed at the given time.
+func (t *MandateTicket) Expired(now time.Time) bool {
+	return t.ValidUntil != nil && !now.Before(*t.ValidUntil)
+}
+
 type MandateTicketProvider interface {
 	List(realmID string) ([]*MandateTicket, error)
 	Get(realmID, id string) (*MandateTicket, error)
 	Set(realmID string, ticket *MandateTicket) error
 	Delete(realmID, id string) error
+	// Redeem atomically counts a redemption of the ticket, failing with
+	// ErrTicketNotRedeemable if the ticket is expired or fully redeemed.
+	Redeem(realmID, id string, now time.Time) (*MandateTicket, error)
+	// DeleteExpired deletes the tickets of all realms that expired before the given time.
+	DeleteExpired(before time.Time) (int64, error)
 }
@@ -0,0 +1,44 @@
+package realm
+
+import "time"
+
+// Outbox message statuses. A message is pending until it's sent, or moved to the
+// dead-letter state when it has failed too many times.
+const (
+	OutboxPending = "pending"
+	OutboxSent    = "sent"
+	OutboxDead    = "dead"
+)
+
+// OutboxMessage is a queued invite message, delivered by the outbox worker
+// through the email provider or the messaging transport for the recipient URI.
+type OutboxMessage struct {
+	ID          string                 `json:"@id,omitempty"`
+	Realm       string                 `json:"realm,omitempty"`
+	InviteID    string                 `json:"inviteId"`
+	Recipient   string                 `json:"recipient"`
+	Locale      string                 `json:"locale,omitempty"`
+	Data        map[string]interface{} `json:"data,omitempty"`
+	Status      string                 `json:"status"`
+	Attempts    int                    `json:"attempts"`
+	Created     time.Time              `json:"created"`
+	LastAttempt *time.Time             `json:"lastAttempt,omitempty"`
+	NextAttempt *time.Time             `json:"nextAttempt,omitempty"`
+	SentAt      *time.Time             `json:"sentAt,omitempty"`
+	MessageID   string                 `json:"messageId,omitempty"`
+	Subject     string                 `json:"subject,omitempty"`
+	Error       string                 `json:"error,omitempty"`
+}
+
+type OutboxProvider interface {
+	// List returns the messages queued for an invite, newest first
+	List(realmID, inviteID string) ([]*OutboxMessage, error)
+	Get(realmID, id string) (*OutboxMessage, error)
+	Set(realmID string, msg *OutboxMessage) error
+	Delete(realmID, id string) error
+	// ListDue returns pending messages for all realms with a next attempt at or before the given time
+	ListDue(before time.Time) ([]*OutboxMessage, error)
+	// Claim moves the next attempt of a due message to until, so no other worker picks
+	// it up while it's being delivered. It returns false if the message was not due.
+	Claim(realmID, id string, now, until time.Time) (bool, error)
+}
@@ -0,0 +1,73 @@
+package realm
+
+import "github.com/IpsoVeritas/document"
+
+const (
+	PermissionRealmRead        = "realm:read"
+	PermissionRealmWrite       = "realm:write"
+	PermissionRealmDelete      = "realm:delete"
+	PermissionRealmExport      = "realm:export"
+	PermissionRolesRead        = "roles:read"
+	PermissionRolesWrite       = "roles:write"
+	PermissionInvitesRead      = "invites:read"
+	PermissionInvitesWrite     = "invites:write"
+	PermissionInvitesSend      = "invites:send"
+	PermissionMandatesRead     = "mandates:read"
+	PermissionMandatesIssue    = "mandates:issue"
+	PermissionMandatesRevoke   = "mandates:revoke"
+	PermissionControllersRead  = "controllers:read"
+	PermissionControllersWrite = "controllers:write"
+	PermissionControllersBind  = "controllers:bind"
+	PermissionWebhooksRead     = "webhooks:read"
+	PermissionWebhooksWrite    = "webhooks:write"
+	PermissionAuditRead        = "audit:read"
+	PermissionIssuersRead      = "issuers:read"
+	PermissionIssuersWrite     = "issuers:write"
+)
+
+// Permissions lists all permissions that can be granted to a role.
+// Mandates for the admin roles of a realm implicitly have all of them.
+var Permissions = []string{
+	PermissionRealmRead,
+	PermissionRealmWrite,
+	PermissionRealmDelete,
+	PermissionRealmExport,
+	PermissionRolesRead,
+	PermissionRolesWrite,
+	PermissionInvitesRead,
+	PermissionInvitesWrite,
+	PermissionInvitesSend,
+	PermissionMandatesRead,
+	PermissionMandatesIssue,
+	PermissionMandatesRevoke,
+	PermissionControllersRead,
+	PermissionControllersWrite,
+	PermissionControllersBind,
+	PermissionWebhooksRead,
+	PermissionWebhooksWrite,
+	PermissionAuditRead,
+	PermissionIssuersRead,
+	PermissionIssuersWrite,
+}
+
+func IsPermission(permission string) bool {
+	for _, p := range Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleWithPermissions is the role document together with the permissions granted to it.
+type RoleWithPermissions struct {
+	document.Role
+	Permissions []string `json:"permissions"`
+}
+
+// PermissionProvider stores the permissions granted to roles, keyed by role name.
+type PermissionProvider interface {
+	Get(realmID, role string) ([]string, error)
+	Set(realmID, role string, permissions []string) error
+	Delete(realmID, role string) error
+}
@@ -0,0 +1,266 @@
+// Package openapi describes the HTTP API of the realm server as an OpenAPI 3
+// document. The operations are listed in Operations, next to the route table in
+// cmd/realm, and the schemas are generated from the types they send and receive.
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Version is the OpenAPI version of the documents built by Spec.
+const Version = "3.0.3"
+
+// SecurityScheme is the name of the mandate authentication scheme in the document.
+const SecurityScheme = "mandate"
+
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Tags       []Tag                `json:"tags,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type PathItem struct {
+	Get    *Operation `json:"get,omitempty"`
+	Put    *Operation `json:"put,omitempty"`
+	Post   *Operation `json:"post,omitempty"`
+	Delete *Operation `json:"delete,omitempty"`
+}
+
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	// Permission is the realm permission the operation needs, if any
+	Permission string `json:"x-permission,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema                   `json:"schemas"`
+	SecuritySchemes map[string]*SecuritySchemeDefinition `json:"securitySchemes"`
+}
+
+type SecuritySchemeDefinition struct {
+	Type        string `json:"type"`
+	In          string `json:"in,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Spec returns the OpenAPI document for the API served at base.
+func Spec(base, version string) (*Document, error) {
+	doc := &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:       "Realm API",
+			Description: "Manages realms, their roles, mandates, invites and controllers.",
+			Version:     version,
+		},
+		Tags:  tags,
+		Paths: make(map[string]*PathItem),
+		Components: Components{
+			SecuritySchemes: map[string]*SecuritySchemeDefinition{
+				SecurityScheme: {
+					Type:        "apiKey",
+					In:          "header",
+					Name:        "Authorization",
+					Description: "A compact JWS of a mandate token, prefixed with \"Mandate \". The token holds the signed mandates of the caller and the URI of the request, and is signed by the key the mandates were issued to.",
+				},
+			},
+		},
+	}
+	if base != "" {
+		doc.Servers = []Server{{URL: base}}
+	}
+
+	schemas := newSchemaGenerator()
+
+	for _, o := range Operations {
+		path := pathTemplate(o.Path)
+
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+
+		op, err := o.operation(schemas)
+		if err != nil {
+			return nil, err
+		}
+
+		var slot **Operation
+		switch o.Method {
+		case http.MethodGet:
+			slot = &item.Get
+		case http.MethodPut:
+			slot = &item.Put
+		case http.MethodPost:
+			slot = &item.Post
+		case http.MethodDelete:
+			slot = &item.Delete
+		default:
+			return nil, fmt.Errorf("unsupported method %s for %s", o.Method, o.Path)
+		}
+		if *slot != nil {
+			return nil, fmt.Errorf("duplicate operation %s %s", o.Method, o.Path)
+		}
+		*slot = op
+	}
+
+	doc.Components.Schemas = schemas.components
+
+	return doc, nil
+}
+
+var routeParam = regexp.MustCompile(`[:*]([A-Za-z]+)`)
+
+// pathTemplate turns a route path like /realms/:realmID into the OpenAPI path
+// template /realms/{realmID}.
+func pathTemplate(path string) string {
+	return routeParam.ReplaceAllString(path, "{$1}")
+}
+
+// pathParams returns the names of the parameters in a route path.
+func pathParams(path string) []string {
+	params := make([]string, 0)
+	for _, m := range routeParam.FindAllStringSubmatch(path, -1) {
+		params = append(params, m[1])
+	}
+
+	return params
+}
+
+func (o *Op) operation(schemas *schemaGenerator) (*Operation, error) {
+	op := &Operation{
+		OperationID: o.ID,
+		Summary:     o.Summary,
+		Description: o.Description,
+		Tags:        []string{o.Tag},
+		Responses:   make(map[string]*Response),
+		Permission:  o.Permission,
+	}
+
+	for _, name := range pathParams(o.Path) {
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+	for _, p := range o.Query {
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:        p.Name,
+			In:          "query",
+			Description: p.Description,
+			Schema:      &Schema{Type: p.Type, Format: p.Format},
+		})
+	}
+	for _, p := range o.Headers {
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:        p.Name,
+			In:          "header",
+			Description: p.Description,
+			Schema:      &Schema{Type: p.Type, Format: p.Format},
+		})
+	}
+
+	if o.Request != nil {
+		schema, err := schemas.schema(o.Request)
+		if err != nil {
+			return nil, err
+		}
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{contentType(o.RequestType): {Schema: schema}},
+		}
+	}
+
+	success := &Response{Description: http.StatusText(o.Status)}
+	if o.Response != nil {
+		schema, err := schemas.schema(o.Response)
+		if err != nil {
+			return nil, err
+		}
+		success.Content = map[string]*MediaType{contentType(o.ResponseType): {Schema: schema}}
+	}
+	op.Responses[fmt.Sprint(o.Status)] = success
+
+	switch o.Auth {
+	case AuthMandate:
+		op.Security = []map[string][]string{{SecurityScheme: {}}}
+		op.Responses["403"] = &Response{Description: forbidden(o)}
+	case AuthOptional:
+		op.Security = []map[string][]string{{}, {SecurityScheme: {}}}
+	}
+
+	if strings.Contains(o.Path, ":realmID") {
+		op.Responses["400"] = &Response{Description: "Bad request"}
+	}
+
+	return op, nil
+}
+
+func forbidden(o *Op) string {
+	if o.Permission != "" {
+		return "Missing permission " + o.Permission
+	}
+
+	return "Missing mandate"
+}
+
+func contentType(t string) string {
+	if t == "" {
+		return "application/json"
+	}
+
+	return t
+}
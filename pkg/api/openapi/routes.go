@@ -0,0 +1,372 @@
+package openapi
+
+import (
+	"net/http"
+
+	"github.com/IpsoVeritas/document"
+	realm "github.com/IpsoVeritas/realm"
+	jose "gopkg.in/square/go-jose.v1"
+)
+
+// How an operation authenticates the caller. AuthMandate operations need a
+// mandate token, AuthOptional operations return more with one.
+const (
+	AuthNone     = ""
+	AuthMandate  = "mandate"
+	AuthOptional = "optional"
+)
+
+// Op describes an operation of the API. Request and Response are values of the
+// Go types of the bodies, or a *Schema for bodies that aren't JSON encoded types.
+type Op struct {
+	Method       string
+	Path         string
+	ID           string
+	Tag          string
+	Summary      string
+	Description  string
+	Auth         string
+	Permission   string
+	Query        []Param
+	Headers      []Param
+	Request      interface{}
+	RequestType  string
+	Status       int
+	Response     interface{}
+	ResponseType string
+}
+
+// Param is a query or header parameter of an operation.
+type Param struct {
+	Name        string
+	Type        string
+	Format      string
+	Description string
+}
+
+var (
+	// JWS is a document signed by the realm, in the JSON serialization.
+	JWS = &Schema{Type: "object", Description: "JWS in the JSON serialization"}
+	// Binary is a response body that isn't JSON.
+	Binary = &Schema{Type: "string", Format: "binary"}
+	// Upload is a multipart form with the uploaded file in the file field.
+	Upload = &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{"file": Binary},
+	}
+	// Text is a plain text body.
+	Text = &Schema{Type: "string"}
+)
+
+// LinkResponse is the URL of a mandate ticket and the link for opening it in the
+// wallet app of the realm.
+type LinkResponse struct {
+	document.URLResponse
+	Link string `json:"link,omitempty"`
+}
+
+// AuthResponse reports the permissions of the mandates a request was made with.
+type AuthResponse struct {
+	Authenticated bool     `json:"authenticated"`
+	Permissions   []string `json:"permissions"`
+}
+
+// AuditVerification is the result of verifying the hash chain of the audit log.
+type AuditVerification struct {
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+// Attachment is a file attached to the invite email template.
+type Attachment struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// Reissued is the number of mandates that were signed again with the current realm key.
+type Reissued struct {
+	Reissued int `json:"reissued"`
+}
+
+const (
+	tagServer      = "server"
+	tagRealms      = "realms"
+	tagTickets     = "tickets"
+	tagMandates    = "mandates"
+	tagInvites     = "invites"
+	tagControllers = "controllers"
+	tagRoles       = "roles"
+	tagIssuers     = "issuers"
+	tagTemplates   = "templates"
+	tagWebhooks    = "webhooks"
+	tagAudit       = "audit"
+)
+
+var tags = []Tag{
+	{Name: tagServer, Description: "Server information and the public realm documents"},
+	{Name: tagRealms, Description: "Realms, their keys and backups"},
+	{Name: tagTickets, Description: "Tickets that wallets redeem for mandates"},
+	{Name: tagMandates, Description: "Issued mandates"},
+	{Name: tagInvites, Description: "Invites to join a realm with a role"},
+	{Name: tagControllers, Description: "Controllers bound to a realm and their actions"},
+	{Name: tagRoles, Description: "Roles and their permissions"},
+	{Name: tagIssuers, Description: "Trusted issuers of facts"},
+	{Name: tagTemplates, Description: "Invite email templates"},
+	{Name: tagWebhooks, Description: "Webhooks for realm events"},
+	{Name: tagAudit, Description: "Audit log of administrative changes"},
+}
+
+var (
+	localeParam = Param{Name: "locale", Type: "string", Description: "Locale of the templates, the realm locale by default"}
+	sizeParam   = Param{Name: "size", Type: "integer", Description: "Size of the image in pixels, between 64 and 1024"}
+)
+
+// Operations lists the routes of the server. It has to be kept in sync with the
+// route table in cmd/realm.
+var Operations = []*Op{
+	// server
+	{Method: http.MethodGet, Path: "/", ID: "version", Tag: tagServer,
+		Summary: "Get the server version", Status: http.StatusOK, Response: Text, ResponseType: "text/plain"},
+	{Method: http.MethodGet, Path: "/realm/v2/openapi.json", ID: "openAPI", Tag: tagServer,
+		Summary: "Get this OpenAPI document", Status: http.StatusOK, Response: &Schema{Type: "object"}},
+	{Method: http.MethodGet, Path: "/.well-known/realm/realm.json", ID: "wellKnown", Tag: tagServer,
+		Summary: "Get the signed descriptor of the bootstrap realm", Status: http.StatusOK, Response: JWS},
+	{Method: http.MethodGet, Path: "/realm/v2/files/*filename", ID: "getFile", Tag: tagServer,
+		Summary:     "Get an uploaded file",
+		Description: "Only served by the filesystem filestore.",
+		Status:      http.StatusOK, Response: Binary, ResponseType: "application/octet-stream"},
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/realm.json", ID: "getRealmDescriptor", Tag: tagServer,
+		Summary: "Get the signed descriptor of a realm", Status: http.StatusOK, Response: JWS},
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/config", ID: "getConfig", Tag: tagServer,
+		Summary: "Get the configuration for the admin UI", Status: http.StatusOK, Response: realm.RealmConfig{}},
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/auth", ID: "authenticate", Tag: tagServer, Auth: AuthMandate,
+		Summary: "Get the permissions of the mandates of the request", Status: http.StatusOK, Response: AuthResponse{}},
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/revocations.json", ID: "listRevocations", Tag: tagServer,
+		Summary: "Get the signed revocation list of a realm",
+		Query:   []Param{{Name: "since", Type: "string", Format: "date-time", Description: "Only list revocations after this time"}},
+		Status:  http.StatusOK, Response: JWS},
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/services", ID: "listServices", Tag: tagServer, Auth: AuthOptional,
+		Summary:     "List the services of a realm",
+		Description: "Returns the action descriptors available to the mandates of the request.",
+		Status:      http.StatusOK, Response: document.Multipart{}},
+
+	// realms
+	{Method: http.MethodGet, Path: "/realm/v2/realms", ID: "listRealms", Tag: tagRealms, Auth: AuthMandate,
+		Summary:     "List realms",
+		Description: "With a mandate for the bootstrap realm all realms are listed, otherwise the realms of the mandates.",
+		Query:       []Param{{Name: "deleted", Type: "boolean", Description: "List the deleted realms instead, needs a mandate for the bootstrap realm"}},
+		Status:      http.StatusOK, Response: []*realm.Realm{}},
+	{Method: http.MethodPost, Path: "/realm/v2/realms", ID: "createRealm", Tag: tagRealms, Auth: AuthMandate,
+		Summary: "Create a realm", Description: "Needs a mandate for the bootstrap realm.",
+		Request: realm.Realm{}, Status: http.StatusCreated, Response: realm.Realm{}},
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID", ID: "getRealm", Tag: tagRealms, Auth: AuthMandate, Permission: realm.PermissionRealmRead,
+		Summary: "Get a realm", Status: http.StatusOK, Response: realm.Realm{}},
+	{Method: http.MethodPut, Path: "/realm/v2/realms/:realmID", ID: "updateRealm", Tag: tagRealms, Auth: AuthMandate, Permission: realm.PermissionRealmWrite,
+		Summary: "Update a realm", Description: "The keys of the realm are only changed by rotating them.",
+		Request: realm.Realm{}, Status: http.StatusCreated, Response: realm.Realm{}},
+	{Method: http.MethodDelete, Path: "/realm/v2/realms/:realmID", ID: "deleteRealm", Tag: tagRealms, Auth: AuthMandate, Permission: realm.PermissionRealmDelete,
+		Summary:     "Delete a realm",
+		Description: "The realm is purged after the retention period, and can be restored until then.",
+		Query:       []Param{{Name: "purge", Type: "boolean", Description: "Purge the realm right away, needs a mandate for the bootstrap realm"}},
+		Status:      http.StatusNoContent},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/restore", ID: "restoreRealm", Tag: tagRealms, Auth: AuthMandate,
+		Summary: "Restore a deleted realm", Description: "Needs a mandate for the bootstrap realm.",
+		Status: http.StatusOK, Response: realm.Realm{}},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/icon", ID: "uploadRealmIcon", Tag: tagRealms, Auth: AuthMandate, Permission: realm.PermissionRealmWrite,
+		Summary: "Upload the icon of a realm", Request: Upload, RequestType: "multipart/form-data", Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/banner", ID: "uploadRealmBanner", Tag: tagRealms, Auth: AuthMandate, Permission: realm.PermissionRealmWrite,
+		Summary: "Upload the banner of a realm", Request: Upload, RequestType: "multipart/form-data", Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/key/rotate", ID: "rotateRealmKey", Tag: tagRealms, Auth: AuthMandate, Permission: realm.PermissionRealmWrite,
+		Summary:     "Rotate the key of a realm",
+		Description: "Mandates signed by the previous key are accepted for the grace period, and are re-issued with the new key.",
+		Query:       []Param{{Name: "grace", Type: "string", Description: "Grace period as a Go duration, like 72h. At least 1h, and 720h by default"}},
+		Status:      http.StatusOK, Response: realm.Realm{}},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/export", ID: "exportRealm", Tag: tagRealms, Auth: AuthMandate, Permission: realm.PermissionRealmExport,
+		Summary: "Export a signed backup of a realm",
+		Headers: []Param{{Name: "X-Backup-Passphrase", Type: "string", Description: "Include the realm keys, encrypted with the passphrase. Needs an admin mandate for the realm"}},
+		Status:  http.StatusOK, Response: Binary, ResponseType: "application/gzip"},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/import", ID: "importRealm", Tag: tagRealms, Auth: AuthMandate,
+		Summary: "Import a realm backup", Description: "Needs a mandate for the bootstrap realm.",
+		Query:   []Param{{Name: "conflict", Type: "string", Description: "What to do with existing entities: fail, skip or replace"}},
+		Headers: []Param{{Name: "X-Backup-Passphrase", Type: "string", Description: "Passphrase the keys in the backup are encrypted with"}},
+		Request: Binary, RequestType: "application/gzip", Status: http.StatusOK, Response: realm.BackupImport{}},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/do/join", ID: "joinRealm", Tag: tagRealms,
+		Summary: "Get a signed request for joining a realm as a guest", Status: http.StatusOK, Response: JWS},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/do/join/callback", ID: "joinRealmCallback", Tag: tagRealms,
+		Summary: "Answer a join request", Request: JWS, Status: http.StatusCreated, Response: document.Multipart{}},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/bootstrap", ID: "bootstrap", Tag: tagRealms,
+		Summary: "Bootstrap the bootstrap realm with its password",
+		Request: Text, RequestType: "text/plain", Status: http.StatusCreated, Response: LinkResponse{}},
+
+	// mandate tickets
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/tickets", ID: "listTickets", Tag: tagTickets, Auth: AuthMandate, Permission: realm.PermissionMandatesRead,
+		Summary: "List mandate tickets", Status: http.StatusOK, Response: []*realm.MandateTicket{}},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/tickets", ID: "createTicket", Tag: tagTickets, Auth: AuthMandate, Permission: realm.PermissionMandatesIssue,
+		Summary: "Create a mandate ticket", Request: realm.MandateTicket{}, Status: http.StatusOK, Response: realm.MandateTicket{}},
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/tickets/:ticketID", ID: "getTicket", Tag: tagTickets, Auth: AuthMandate, Permission: realm.PermissionMandatesRead,
+		Summary: "Get a mandate ticket", Status: http.StatusOK, Response: realm.MandateTicket{}},
+	{Method: http.MethodPut, Path: "/realm/v2/realms/:realmID/tickets/:ticketID", ID: "updateTicket", Tag: tagTickets, Auth: AuthMandate, Permission: realm.PermissionMandatesIssue,
+		Summary: "Update a mandate ticket", Request: realm.MandateTicket{}, Status: http.StatusOK, Response: realm.MandateTicket{}},
+	{Method: http.MethodDelete, Path: "/realm/v2/realms/:realmID/tickets/:ticketID", ID: "deleteTicket", Tag: tagTickets, Auth: AuthMandate, Permission: realm.PermissionMandatesIssue,
+		Summary: "Delete a mandate ticket", Status: http.StatusNoContent},
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/tickets/:ticketID/url", ID: "getTicketURL", Tag: tagTickets, Auth: AuthMandate, Permission: realm.PermissionMandatesRead,
+		Summary: "Get the URL of a mandate ticket", Status: http.StatusOK, Response: LinkResponse{}},
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/tickets/:ticketID/qr", ID: "getTicketQRCode", Tag: tagTickets, Auth: AuthMandate, Permission: realm.PermissionMandatesRead,
+		Summary: "Get a QR code of the URL of a mandate ticket", Query: []Param{sizeParam},
+		Status: http.StatusOK, Response: Binary, ResponseType: "image/png"},
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/tickets/:ticketID/issue", ID: "redeemTicket", Tag: tagTickets,
+		Summary: "Get the signed scope request of a mandate ticket", Status: http.StatusOK, Response: JWS},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/tickets/:ticketID/callback", ID: "redeemTicketCallback", Tag: tagTickets,
+		Summary: "Answer the scope request of a mandate ticket and get the mandate",
+		Request: JWS, Status: http.StatusOK, Response: document.Multipart{}},
+
+	// mandates
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/mandates/role/:roleName", ID: "listMandatesForRole", Tag: tagMandates, Auth: AuthMandate, Permission: realm.PermissionMandatesRead,
+		Summary: "List the mandates for a role", Status: http.StatusOK, Response: []*realm.IssuedMandate{}},
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/mandates", ID: "listMandates", Tag: tagMandates, Auth: AuthMandate, Permission: realm.PermissionMandatesRead,
+		Summary: "List mandates", Status: http.StatusOK, Response: []*realm.IssuedMandate{}},
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/mandate/:mandateID", ID: "getMandate", Tag: tagMandates, Auth: AuthMandate, Permission: realm.PermissionMandatesRead,
+		Summary: "Get a mandate", Status: http.StatusOK, Response: realm.IssuedMandate{}},
+	{Method: http.MethodPut, Path: "/realm/v2/realms/:realmID/mandates/:mandateID/revoke", ID: "revokeMandate", Tag: tagMandates, Auth: AuthMandate, Permission: realm.PermissionMandatesRevoke,
+		Summary: "Revoke a mandate", Status: http.StatusOK, Response: realm.IssuedMandate{}},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/mandates/issue", ID: "issueMandate", Tag: tagMandates, Auth: AuthMandate, Permission: realm.PermissionMandatesIssue,
+		Summary: "Issue a mandate to the recipient key", Request: document.Mandate{}, Status: http.StatusCreated, Response: realm.IssuedMandate{}},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/mandates/reissue", ID: "reissueMandates", Tag: tagMandates, Auth: AuthMandate, Permission: realm.PermissionMandatesIssue,
+		Summary: "Sign the active mandates again with the current realm key", Status: http.StatusOK, Response: Reissued{}},
+
+	// invites
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/invites/role/:roleName", ID: "listInvitesForRole", Tag: tagInvites, Auth: AuthMandate, Permission: realm.PermissionInvitesRead,
+		Summary: "List the invites for a role", Status: http.StatusOK, Response: []*realm.Invite{}},
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/invites", ID: "listInvites", Tag: tagInvites, Auth: AuthMandate, Permission: realm.PermissionInvitesRead,
+		Summary: "List invites", Status: http.StatusOK, Response: []*realm.Invite{}},
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/invites/id/:inviteID", ID: "getInvite", Tag: tagInvites, Auth: AuthMandate, Permission: realm.PermissionInvitesRead,
+		Summary: "Get an invite", Status: http.StatusOK, Response: realm.Invite{}},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/invites", ID: "createInvite", Tag: tagInvites, Auth: AuthMandate, Permission: realm.PermissionInvitesWrite,
+		Summary: "Create an invite", Request: realm.Invite{}, Status: http.StatusOK, Response: realm.Invite{}},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/invites/import", ID: "importInvites", Tag: tagInvites, Auth: AuthMandate, Permission: realm.PermissionInvitesWrite,
+		Summary:     "Create invites in bulk",
+		Description: "Takes a JSON array or CSV rows. All rows are validated before any invite is created, and 422 is returned with the results if some are invalid. Sending needs the " + realm.PermissionInvitesSend + " permission.",
+		Query: []Param{
+			{Name: "send", Type: "boolean", Description: "Also send the invites"},
+			{Name: "dryRun", Type: "boolean", Description: "Only validate the rows"},
+		},
+		Request: []*realm.InviteImportRow{}, Status: http.StatusOK, Response: []*realm.InviteImportResult{}},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/invites/id/:inviteID", ID: "setInvite", Tag: tagInvites, Auth: AuthMandate, Permission: realm.PermissionInvitesWrite,
+		Summary: "Create or update an invite with an ID", Request: realm.Invite{}, Status: http.StatusOK, Response: realm.Invite{}},
+	{Method: http.MethodPut, Path: "/realm/v2/realms/:realmID/invites/id/:inviteID", ID: "updateInvite", Tag: tagInvites, Auth: AuthMandate, Permission: realm.PermissionInvitesWrite,
+		Summary: "Update an invite", Request: realm.Invite{}, Status: http.StatusOK, Response: realm.Invite{}},
+	{Method: http.MethodDelete, Path: "/realm/v2/realms/:realmID/invites/id/:inviteID", ID: "deleteInvite", Tag: tagInvites, Auth: AuthMandate, Permission: realm.PermissionInvitesWrite,
+		Summary: "Delete an invite", Status: http.StatusNoContent},
+	{Method: http.MethodPut, Path: "/realm/v2/realms/:realmID/invites/id/:inviteID/send", ID: "sendInvite", Tag: tagInvites, Auth: AuthMandate, Permission: realm.PermissionInvitesSend,
+		Summary:     "Send an invite",
+		Description: "Returns 201 when the message was sent, and 202 when it's queued for retry.",
+		Status:      http.StatusCreated, Response: realm.OutboxMessage{}},
+	{Method: http.MethodPut, Path: "/realm/v2/realms/:realmID/invites/id/:inviteID/cancel", ID: "cancelInvite", Tag: tagInvites, Auth: AuthMandate, Permission: realm.PermissionInvitesWrite,
+		Summary: "Cancel an invite", Status: http.StatusOK, Response: realm.Invite{}},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/invites/id/:inviteID/qr", ID: "createInviteQRCode", Tag: tagInvites, Auth: AuthMandate, Permission: realm.PermissionInvitesSend,
+		Summary:     "Create the link of an invite that has none yet and get a QR code of it",
+		Description: "Returns 409 when the invite already has a link, which can only be replaced with rotateInviteQRCode.",
+		Query:       []Param{sizeParam},
+		Status:      http.StatusOK, Response: Binary, ResponseType: "image/png"},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/invites/id/:inviteID/qr/rotate", ID: "rotateInviteQRCode", Tag: tagInvites, Auth: AuthMandate, Permission: realm.PermissionInvitesSend,
+		Summary:     "Replace the link of an invite and get a QR code of the new link",
+		Description: "The new link revokes the links sent to the recipient before, which stop working.",
+		Query:       []Param{sizeParam},
+		Status:      http.StatusOK, Response: Binary, ResponseType: "image/png"},
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/invites/id/:inviteID/deliveries", ID: "listInviteDeliveries", Tag: tagInvites, Auth: AuthMandate, Permission: realm.PermissionInvitesRead,
+		Summary: "List the messages sent for an invite", Status: http.StatusOK, Response: []*realm.OutboxMessage{}},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/invites/id/:inviteID/deliveries/:deliveryID/redeliver", ID: "redeliverInvite", Tag: tagInvites, Auth: AuthMandate, Permission: realm.PermissionInvitesSend,
+		Summary: "Send an invite message again", Status: http.StatusAccepted, Response: realm.OutboxMessage{}},
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/invites/token/:token/fetch", ID: "fetchInvite", Tag: tagInvites,
+		Summary: "Get the signed invite for the token of an invite link", Status: http.StatusOK, Response: JWS},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/invites/token/:token/callback", ID: "acceptInvite", Tag: tagInvites,
+		Summary: "Accept an invite and get the mandate", Request: JWS, Status: http.StatusCreated, Response: document.Multipart{}},
+
+	// controllers
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/controllers", ID: "listControllers", Tag: tagControllers, Auth: AuthMandate, Permission: realm.PermissionControllersRead,
+		Summary: "List controllers", Status: http.StatusOK, Response: []*realm.Controller{}},
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/controllers/id/:controllerID", ID: "getController", Tag: tagControllers, Auth: AuthMandate, Permission: realm.PermissionControllersRead,
+		Summary: "Get a controller", Status: http.StatusOK, Response: realm.Controller{}},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/controllers", ID: "createController", Tag: tagControllers, Auth: AuthMandate, Permission: realm.PermissionControllersWrite,
+		Summary: "Create a controller", Request: realm.Controller{}, Status: http.StatusOK, Response: realm.Controller{}},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/controllers/id/:controllerID", ID: "setController", Tag: tagControllers, Auth: AuthMandate, Permission: realm.PermissionControllersWrite,
+		Summary: "Create or update a controller with an ID", Request: realm.Controller{}, Status: http.StatusOK, Response: realm.Controller{}},
+	{Method: http.MethodPut, Path: "/realm/v2/realms/:realmID/controllers/id/:controllerID", ID: "updateController", Tag: tagControllers, Auth: AuthMandate, Permission: realm.PermissionControllersWrite,
+		Summary: "Update a controller", Request: realm.Controller{}, Status: http.StatusOK, Response: realm.Controller{}},
+	{Method: http.MethodDelete, Path: "/realm/v2/realms/:realmID/controllers/id/:controllerID", ID: "deleteController", Tag: tagControllers, Auth: AuthMandate, Permission: realm.PermissionControllersWrite,
+		Summary: "Delete a controller", Status: http.StatusNoContent},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/controllers/bind", ID: "bindController", Tag: tagControllers, Auth: AuthMandate, Permission: realm.PermissionControllersBind,
+		Summary: "Bind a controller to the realm", Description: "Returns the signed controller binding to send to the controller.",
+		Request: realm.Controller{}, Status: http.StatusOK, Response: JWS},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/controllers/id/:controllerID/actions", ID: "updateControllerActions", Tag: tagControllers, Auth: AuthMandate, Permission: realm.PermissionControllersWrite,
+		Summary: "Replace the actions of a controller", Description: "Takes a multipart of action descriptors signed by the controller.",
+		Request: document.Multipart{}, Status: http.StatusCreated},
+
+	// roles
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/roles", ID: "listRoles", Tag: tagRoles, Auth: AuthMandate, Permission: realm.PermissionRolesRead,
+		Summary: "List roles", Status: http.StatusOK, Response: []*realm.RoleWithPermissions{}},
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/roles/:roleID", ID: "getRole", Tag: tagRoles, Auth: AuthMandate, Permission: realm.PermissionRolesRead,
+		Summary: "Get a role", Status: http.StatusOK, Response: realm.RoleWithPermissions{}},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/roles", ID: "createRole", Tag: tagRoles, Auth: AuthMandate, Permission: realm.PermissionRolesWrite,
+		Summary: "Create a role", Request: realm.RoleWithPermissions{}, Status: http.StatusOK, Response: realm.RoleWithPermissions{}},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/roles/:roleID", ID: "setRole", Tag: tagRoles, Auth: AuthMandate, Permission: realm.PermissionRolesWrite,
+		Summary: "Create or update a role with an ID", Request: realm.RoleWithPermissions{}, Status: http.StatusOK, Response: realm.RoleWithPermissions{}},
+	{Method: http.MethodPut, Path: "/realm/v2/realms/:realmID/roles/:roleID", ID: "updateRole", Tag: tagRoles, Auth: AuthMandate, Permission: realm.PermissionRolesWrite,
+		Summary: "Update a role", Description: "Roles can't be renamed, since mandates, invites and permissions refer to them by name.", Request: realm.RoleWithPermissions{}, Status: http.StatusOK, Response: realm.RoleWithPermissions{}},
+	{Method: http.MethodDelete, Path: "/realm/v2/realms/:realmID/roles/:roleID", ID: "deleteRole", Tag: tagRoles, Auth: AuthMandate, Permission: realm.PermissionRolesWrite,
+		Summary: "Delete a role", Description: "Also deletes the invites and revokes the mandates for the role.", Status: http.StatusNoContent},
+
+	// trusted issuers
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/issuers", ID: "listIssuers", Tag: tagIssuers, Auth: AuthMandate, Permission: realm.PermissionIssuersRead,
+		Summary: "List trusted issuers", Status: http.StatusOK, Response: jose.JsonWebKeySet{}},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/issuers", ID: "addIssuer", Tag: tagIssuers, Auth: AuthMandate, Permission: realm.PermissionIssuersWrite,
+		Summary: "Add a trusted issuer", Description: "Needs an admin mandate for the realm.", Request: jose.JsonWebKey{}, Status: http.StatusOK, Response: jose.JsonWebKey{}},
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/issuers/:issuerID", ID: "getIssuer", Tag: tagIssuers, Auth: AuthMandate, Permission: realm.PermissionIssuersRead,
+		Summary: "Get a trusted issuer by its thumbprint", Status: http.StatusOK, Response: jose.JsonWebKey{}},
+	{Method: http.MethodDelete, Path: "/realm/v2/realms/:realmID/issuers/:issuerID", ID: "deleteIssuer", Tag: tagIssuers, Auth: AuthMandate, Permission: realm.PermissionIssuersWrite,
+		Summary: "Remove a trusted issuer", Description: "Needs an admin mandate for the realm.", Status: http.StatusNoContent},
+
+	// invite templates
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/templates/invite", ID: "getInviteTemplates", Tag: tagTemplates, Auth: AuthMandate, Permission: realm.PermissionRealmRead,
+		Summary: "Get the invite email templates", Query: []Param{localeParam}, Status: http.StatusOK, Response: realm.InviteTemplates{}},
+	{Method: http.MethodPut, Path: "/realm/v2/realms/:realmID/templates/invite", ID: "setInviteTemplates", Tag: tagTemplates, Auth: AuthMandate, Permission: realm.PermissionRealmWrite,
+		Summary: "Set the invite email templates", Query: []Param{localeParam}, Request: realm.InviteTemplates{}, Status: http.StatusOK, Response: realm.InviteTemplates{}},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/templates/invite/preview", ID: "previewInviteTemplates", Tag: tagTemplates, Auth: AuthMandate, Permission: realm.PermissionRealmRead,
+		Summary: "Render the invite email", Description: "Renders the templates in the body, or the stored ones if the body is empty.",
+		Query: []Param{localeParam}, Request: realm.InviteTemplates{}, Status: http.StatusOK, Response: realm.EmailStatus{}},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/templates/invite/attachments", ID: "addInviteAttachment", Tag: tagTemplates, Auth: AuthMandate, Permission: realm.PermissionRealmWrite,
+		Summary: "Attach a file to the invite email", Request: Upload, RequestType: "multipart/form-data",
+		Status: http.StatusCreated, Response: Attachment{}},
+	{Method: http.MethodDelete, Path: "/realm/v2/realms/:realmID/templates/invite/attachments/:name", ID: "removeInviteAttachment", Tag: tagTemplates, Auth: AuthMandate, Permission: realm.PermissionRealmWrite,
+		Summary: "Remove a file attached to the invite email", Status: http.StatusNoContent},
+
+	// webhooks
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/webhooks", ID: "listWebhooks", Tag: tagWebhooks, Auth: AuthMandate, Permission: realm.PermissionWebhooksRead,
+		Summary: "List webhooks", Status: http.StatusOK, Response: []*realm.Webhook{}},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/webhooks", ID: "createWebhook", Tag: tagWebhooks, Auth: AuthMandate, Permission: realm.PermissionWebhooksWrite,
+		Summary: "Create a webhook", Request: realm.Webhook{}, Status: http.StatusOK, Response: realm.Webhook{}},
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/webhooks/:webhookID", ID: "getWebhook", Tag: tagWebhooks, Auth: AuthMandate, Permission: realm.PermissionWebhooksRead,
+		Summary: "Get a webhook", Status: http.StatusOK, Response: realm.Webhook{}},
+	{Method: http.MethodPut, Path: "/realm/v2/realms/:realmID/webhooks/:webhookID", ID: "updateWebhook", Tag: tagWebhooks, Auth: AuthMandate, Permission: realm.PermissionWebhooksWrite,
+		Summary: "Update a webhook", Request: realm.Webhook{}, Status: http.StatusOK, Response: realm.Webhook{}},
+	{Method: http.MethodDelete, Path: "/realm/v2/realms/:realmID/webhooks/:webhookID", ID: "deleteWebhook", Tag: tagWebhooks, Auth: AuthMandate, Permission: realm.PermissionWebhooksWrite,
+		Summary: "Delete a webhook", Status: http.StatusNoContent},
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/webhooks/:webhookID/deliveries", ID: "listWebhookDeliveries", Tag: tagWebhooks, Auth: AuthMandate, Permission: realm.PermissionWebhooksRead,
+		Summary: "List the deliveries of a webhook", Status: http.StatusOK, Response: []*realm.WebhookDelivery{}},
+	{Method: http.MethodPost, Path: "/realm/v2/realms/:realmID/webhooks/:webhookID/deliveries/:deliveryID/redeliver", ID: "redeliverWebhook", Tag: tagWebhooks, Auth: AuthMandate, Permission: realm.PermissionWebhooksWrite,
+		Summary: "Deliver an event again", Status: http.StatusAccepted, Response: realm.WebhookDelivery{}},
+
+	// audit log
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/audit", ID: "listAuditEntries", Tag: tagAudit, Auth: AuthMandate, Permission: realm.PermissionAuditRead,
+		Summary: "List audit log entries",
+		Query: []Param{
+			{Name: "actor", Type: "string", Description: "Thumbprint of the key the change was made with"},
+			{Name: "entityType", Type: "string"},
+			{Name: "entityId", Type: "string"},
+			{Name: "from", Type: "string", Format: "date-time"},
+			{Name: "to", Type: "string", Format: "date-time"},
+		},
+		Status: http.StatusOK, Response: []*realm.AuditEntry{}},
+	{Method: http.MethodGet, Path: "/realm/v2/realms/:realmID/audit/verify", ID: "verifyAuditLog", Tag: tagAudit, Auth: AuthMandate, Permission: realm.PermissionAuditRead,
+		Summary: "Verify the hash chain of the audit log", Status: http.StatusOK, Response: AuditVerification{}},
+}
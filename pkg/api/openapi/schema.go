@@ -0,0 +1,203 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	jose "gopkg.in/square/go-jose.v1"
+)
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+}
+
+// knownSchemas are the schemas of types that marshal themselves.
+var knownSchemas = map[reflect.Type]*Schema{
+	reflect.TypeOf(time.Time{}): {Type: "string", Format: "date-time"},
+	reflect.TypeOf(json.RawMessage{}): {
+		Description: "Any JSON value",
+	},
+	reflect.TypeOf(jose.JsonWebKey{}): {
+		Type:                 "object",
+		Description:          "JSON Web Key (RFC 7517)",
+		AdditionalProperties: &Schema{},
+	},
+}
+
+// schemaGenerator builds schemas from Go types, following their JSON encoding.
+// Named structs are added to the components and referenced.
+type schemaGenerator struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{
+		components: make(map[string]*Schema),
+		names:      make(map[reflect.Type]string),
+	}
+}
+
+// schema returns the schema for the type of v, or v itself if it's a *Schema.
+func (g *schemaGenerator) schema(v interface{}) (*Schema, error) {
+	if s, ok := v.(*Schema); ok {
+		return s, nil
+	}
+
+	return g.typeSchema(reflect.TypeOf(v))
+}
+
+func (g *schemaGenerator) typeSchema(t reflect.Type) (*Schema, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if s, ok := knownSchemas[t]; ok {
+		return s, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}, nil
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}, nil
+	case reflect.String:
+		return &Schema{Type: "string"}, nil
+	case reflect.Interface:
+		return &Schema{}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}, nil
+		}
+		items, err := g.typeSchema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %s", t.Key())
+		}
+		values, err := g.typeSchema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "object", AdditionalProperties: values}, nil
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return g.ref(t, func() (*Schema, error) { return g.object(t) })
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+// ref adds the schema of a named type to the components, once, and returns a
+// reference to it.
+func (g *schemaGenerator) ref(t reflect.Type, build func() (*Schema, error)) (*Schema, error) {
+	name, ok := g.names[t]
+	if !ok {
+		name = g.componentName(t)
+		g.names[t] = name
+		// registered before it's built, for types that refer to themselves
+		g.components[name] = &Schema{}
+
+		s, err := build()
+		if err != nil {
+			return nil, err
+		}
+		*g.components[name] = *s
+	}
+
+	return &Schema{Ref: "#/components/schemas/" + name}, nil
+}
+
+// componentName returns the name of the type, prefixed with its package if
+// another type with the same name is already a component.
+func (g *schemaGenerator) componentName(t reflect.Type) string {
+	name := t.Name()
+	if _, taken := g.components[name]; !taken {
+		return name
+	}
+
+	return strings.Title(path.Base(t.PkgPath())) + name
+}
+
+// object returns the schema of a struct, with the fields of embedded structs
+// inlined as encoding/json does.
+func (g *schemaGenerator) object(t reflect.Type) (*Schema, error) {
+	s := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+
+	if err := g.fields(t, s.Properties); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (g *schemaGenerator) fields(t reflect.Type, properties map[string]*Schema) error {
+	// fields of the struct itself hide the ones of embedded structs
+	promoted := make(map[string]*Schema)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		if f.Anonymous && name == "" {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if err := g.fields(embedded, promoted); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		schema, err := g.typeSchema(f.Type)
+		if err != nil {
+			return fmt.Errorf("field %s of %s: %s", f.Name, t, err)
+		}
+		properties[name] = schema
+	}
+
+	for name, schema := range promoted {
+		if _, ok := properties[name]; !ok {
+			properties[name] = schema
+		}
+	}
+
+	return nil
+}
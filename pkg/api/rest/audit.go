@@ -0,0 +1,93 @@
+package rest
+
+import (
+	"net/http"
+	"time"
+
+	httphandler "github.com/IpsoVeritas/httphandler"
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/IpsoVeritas/realm/pkg/services"
+	"github.com/pkg/errors"
+)
+
+type AuditController struct {
+	contextProvider *services.RealmsServiceProvider
+}
+
+func NewAuditController(contextProvider *services.RealmsServiceProvider) *AuditController {
+	return &AuditController{
+		contextProvider: contextProvider,
+	}
+}
+
+// List returns the audit log of the realm, filtered by the from and to (RFC3339),
+// actor (key thumbprint), entityType and entityId query parameters.
+func (c *AuditController) List(req httphandler.AuthenticatedRequest) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	if !context.HasPermission(req.Mandates(), realm.PermissionAuditRead) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionAuditRead))
+	}
+
+	query := req.OriginalRequest().URL.Query()
+	filter := realm.AuditFilter{
+		Actor:      query.Get("actor"),
+		EntityType: query.Get("entityType"),
+		EntityID:   query.Get("entityId"),
+	}
+
+	if from := query.Get("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to parse from"))
+		}
+		filter.From = &t
+	}
+
+	if to := query.Get("to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to parse to"))
+		}
+		filter.To = &t
+	}
+
+	entries, err := context.Audit().List(filter)
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to list audit log"))
+	}
+
+	return httphandler.NewJsonResponse(http.StatusOK, entries)
+}
+
+func (c *AuditController) Verify(req httphandler.AuthenticatedRequest) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	if !context.HasPermission(req.Mandates(), realm.PermissionAuditRead) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionAuditRead))
+	}
+
+	resp := struct {
+		Valid bool   `json:"valid"`
+		Error string `json:"error,omitempty"`
+	}{
+		Valid: true,
+	}
+
+	if err := context.Audit().Verify(); err != nil {
+		resp.Valid = false
+		resp.Error = err.Error()
+	}
+
+	return httphandler.NewJsonResponse(http.StatusOK, resp)
+}
@@ -27,14 +27,17 @@ func (c *AuthController) Authenticated(req httphandler.AuthenticatedRequest) htt
 
 	context := c.contextProvider.Get(realmID)
 
-	if !context.HasMandateForRealm(req.Mandates()) {
+	permissions := context.PermissionsFor(req.Mandates())
+	if len(permissions) == 0 {
 		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("No mandate for realm"))
 	}
 
 	resp := struct {
-		Authenticated bool `json:"authenticated"`
+		Authenticated bool     `json:"authenticated"`
+		Permissions   []string `json:"permissions"`
 	}{
 		Authenticated: true,
+		Permissions:   permissions,
 	}
 
 	return httphandler.NewJsonResponse(http.StatusOK, resp)
@@ -30,8 +30,8 @@ func (c *ControllersController) ListControllers(req httphandler.AuthenticatedReq
 
 	context := c.contextProvider.Get(realmID)
 
-	if !context.HasMandateForRealm(req.Mandates()) {
-		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("No mandate for realm"))
+	if !context.HasPermission(req.Mandates(), realm.PermissionControllersRead) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionControllersRead))
 	}
 
 	list, err := context.Controllers().List()
@@ -51,8 +51,8 @@ func (c *ControllersController) GetController(req httphandler.AuthenticatedReque
 
 	context := c.contextProvider.Get(realmID)
 
-	if !context.HasMandateForRealm(req.Mandates()) {
-		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("No mandate for realm"))
+	if !context.HasPermission(req.Mandates(), realm.PermissionControllersRead) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionControllersRead))
 	}
 
 	controllerID := req.Params().ByName("controllerID")
@@ -76,8 +76,8 @@ func (c *ControllersController) Set(req httphandler.AuthenticatedRequest) httpha
 
 	context := c.contextProvider.Get(realmID)
 
-	if !context.HasMandateForRealm(req.Mandates()) {
-		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("No mandate for realm"))
+	if !context.HasPermission(req.Mandates(), realm.PermissionControllersWrite) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionControllersWrite))
 	}
 
 	body, err := req.Body()
@@ -99,10 +99,17 @@ func (c *ControllersController) Set(req httphandler.AuthenticatedRequest) httpha
 		action = "update"
 	}
 
+	var before *realm.Controller
+	if controller.ID != "" {
+		before, _ = context.Controllers().Get(controller.ID)
+	}
+
 	if err := context.Controllers().Set(controller); err != nil {
 		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrapf(err, "failed to %s controller", action))
 	}
 
+	audit(req, context, "controller", controller.ID, action, before, controller)
+
 	return httphandler.NewJsonResponse(http.StatusOK, controller)
 }
 
@@ -114,8 +121,8 @@ func (c *ControllersController) Bind(req httphandler.AuthenticatedRequest) httph
 
 	context := c.contextProvider.Get(realmID)
 
-	if !context.HasMandateForRealm(req.Mandates()) {
-		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("No mandate for realm"))
+	if !context.HasPermission(req.Mandates(), realm.PermissionControllersBind) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionControllersBind))
 	}
 
 	body, err := req.Body()
@@ -128,11 +135,18 @@ func (c *ControllersController) Bind(req httphandler.AuthenticatedRequest) httph
 		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to unmarshal controller"))
 	}
 
+	// the controller gets a mandate for the role
+	if err := context.CanGrant(req.Mandates(), controller.MandateRole); err != nil {
+		return httphandler.NewErrorResponse(http.StatusForbidden, err)
+	}
+
 	jws, err := context.Controllers().Bind(controller)
 	if err != nil {
 		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to bind controller"))
 	}
 
+	audit(req, context, "controller", controller.ID, realm.AuditBind, nil, controller)
+
 	return httphandler.NewStandardResponse(http.StatusOK, "application/json", jws.FullSerialize())
 }
 
@@ -144,8 +158,8 @@ func (c *ControllersController) Delete(req httphandler.AuthenticatedRequest) htt
 
 	context := c.contextProvider.Get(realmID)
 
-	if !context.HasMandateForRealm(req.Mandates()) {
-		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("No mandate for realm"))
+	if !context.HasPermission(req.Mandates(), realm.PermissionControllersWrite) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionControllersWrite))
 	}
 
 	controllerID := req.Params().ByName("controllerID")
@@ -153,10 +167,14 @@ func (c *ControllersController) Delete(req httphandler.AuthenticatedRequest) htt
 		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify controller ID"))
 	}
 
+	before, _ := context.Controllers().Get(controllerID)
+
 	if err := context.Controllers().Delete(controllerID); err != nil {
 		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to delete controller"))
 	}
 
+	audit(req, context, "controller", controllerID, realm.AuditDelete, before, nil)
+
 	return httphandler.NewEmptyResponse(http.StatusNoContent)
 }
 
@@ -168,8 +186,8 @@ func (c *ControllersController) UpdateActions(req httphandler.AuthenticatedReque
 
 	context := c.contextProvider.Get(realmID)
 
-	if !context.HasMandateForRealm(req.Mandates()) {
-		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("No mandate for realm"))
+	if !context.HasPermission(req.Mandates(), realm.PermissionControllersWrite) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionControllersWrite))
 	}
 
 	controllerID := req.Params().ByName("controllerID")
@@ -207,10 +225,15 @@ func (c *ControllersController) UpdateActions(req httphandler.AuthenticatedReque
 	// 	return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to unmarshal document"))
 	// }
 
+	before, _ := context.Actions().ListForController(controllerID)
+
 	if err := context.Controllers().UpdateActions(controllerID, mp, req.Key()); err != nil {
 		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to update actions"))
 	}
 
+	after, _ := context.Actions().ListForController(controllerID)
+	audit(req, context, "actions", controllerID, realm.AuditUpdate, before, after)
+
 	return httphandler.NewEmptyResponse(http.StatusCreated)
 }
 
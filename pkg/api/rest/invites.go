@@ -2,7 +2,10 @@ package rest
 
 import (
 	"encoding/json"
+	"net"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/IpsoVeritas/crypto"
 	httphandler "github.com/IpsoVeritas/httphandler"
@@ -11,16 +14,34 @@ import (
 	"github.com/pkg/errors"
 )
 
+const (
+	inviteTokenMaxFailures   = 10
+	inviteTokenFailureWindow = 15 * time.Minute
+)
+
 type InvitesController struct {
 	contextProvider *services.RealmsServiceProvider
+	limiter         *services.RateLimiter
+	trustedProxies  []*net.IPNet
 }
 
-func NewInvitesController(contextProvider *services.RealmsServiceProvider) *InvitesController {
+// NewInvitesController returns the controller for invites. The X-Forwarded-For
+// header is only trusted for the address of a client when the request comes
+// from one of the trustedProxies.
+func NewInvitesController(contextProvider *services.RealmsServiceProvider, trustedProxies []*net.IPNet) *InvitesController {
 	return &InvitesController{
 		contextProvider: contextProvider,
+		limiter:         services.NewRateLimiter(inviteTokenMaxFailures, inviteTokenFailureWindow),
+		trustedProxies:  trustedProxies,
 	}
 }
 
+// limiterKey identifies the client of an unauthenticated invite request, so
+// clients guessing tokens are blocked per realm.
+func (c *InvitesController) limiterKey(req httphandler.Request, realmID string) string {
+	return realmID + "/" + clientAddr(req.OriginalRequest(), c.trustedProxies)
+}
+
 func (c *InvitesController) List(req httphandler.AuthenticatedRequest) httphandler.Response {
 	realmID := req.Params().ByName("realmID")
 	if realmID == "" {
@@ -29,8 +50,8 @@ func (c *InvitesController) List(req httphandler.AuthenticatedRequest) httphandl
 
 	context := c.contextProvider.Get(realmID)
 
-	if !context.HasMandateForRealm(req.Mandates()) {
-		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("No mandate for realm"))
+	if !context.HasPermission(req.Mandates(), realm.PermissionInvitesRead) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionInvitesRead))
 	}
 
 	var err error
@@ -60,8 +81,8 @@ func (c *InvitesController) Get(req httphandler.AuthenticatedRequest) httphandle
 
 	context := c.contextProvider.Get(realmID)
 
-	if !context.HasMandateForRealm(req.Mandates()) {
-		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("No mandate for realm"))
+	if !context.HasPermission(req.Mandates(), realm.PermissionInvitesRead) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionInvitesRead))
 	}
 
 	inviteID := req.Params().ByName("inviteID")
@@ -85,8 +106,8 @@ func (c *InvitesController) Set(req httphandler.AuthenticatedRequest) httphandle
 
 	context := c.contextProvider.Get(realmID)
 
-	if !context.HasMandateForRealm(req.Mandates()) {
-		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("No mandate for realm"))
+	if !context.HasPermission(req.Mandates(), realm.PermissionInvitesWrite) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionInvitesWrite))
 	}
 
 	body, err := req.Body()
@@ -108,13 +129,88 @@ func (c *InvitesController) Set(req httphandler.AuthenticatedRequest) httphandle
 		action = "update"
 	}
 
+	var before *realm.Invite
+	if invite.ID != "" {
+		before, _ = context.Invites().Get(invite.ID)
+	}
+
+	if err := context.CanGrant(req.Mandates(), invite.Role); err != nil {
+		return httphandler.NewErrorResponse(http.StatusForbidden, err)
+	}
+	if before != nil {
+		if err := context.CanGrant(req.Mandates(), before.Role); err != nil {
+			return httphandler.NewErrorResponse(http.StatusForbidden, err)
+		}
+	}
+
 	if err := context.Invites().Set(invite); err != nil {
 		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrapf(err, "failed to %s invite", action))
 	}
 
+	audit(req, context, "invite", invite.ID, action, before, invite)
+
 	return httphandler.NewJsonResponse(http.StatusOK, invite)
 }
 
+// Import creates invites in bulk from CSV or JSON rows. All rows are validated
+// before any invite is created. With send=true the invites are also sent, and
+// with dryRun=true the rows are only validated.
+func (c *InvitesController) Import(req httphandler.AuthenticatedRequest) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	if !context.HasPermission(req.Mandates(), realm.PermissionInvitesWrite) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionInvitesWrite))
+	}
+
+	query := req.OriginalRequest().URL.Query()
+	send, _ := strconv.ParseBool(query.Get("send"))
+	dryRun, _ := strconv.ParseBool(query.Get("dryRun"))
+
+	if send && !context.HasPermission(req.Mandates(), realm.PermissionInvitesSend) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionInvitesSend))
+	}
+
+	body, err := req.Body()
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to read request body"))
+	}
+
+	rows, err := services.ParseInviteImport(req.OriginalRequest().Header.Get("Content-Type"), body)
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to parse rows"))
+	}
+
+	for _, row := range rows {
+		if err := context.CanGrant(req.Mandates(), row.Role); err != nil {
+			return httphandler.NewErrorResponse(http.StatusForbidden, err)
+		}
+	}
+
+	results, valid := context.Invites().Import(rows, send, dryRun)
+	if !valid {
+		return httphandler.NewJsonResponse(http.StatusUnprocessableEntity, results)
+	}
+
+	if !dryRun {
+		for _, result := range results {
+			if result.Status == realm.InviteImportFailed {
+				continue
+			}
+			audit(req, context, "invite", result.Invite.ID, realm.AuditCreate, nil, result.Invite)
+			if result.Delivery != nil {
+				audit(req, context, "invite", result.Invite.ID, realm.AuditSend, nil, result.Delivery)
+			}
+		}
+	}
+
+	return httphandler.NewJsonResponse(http.StatusOK, results)
+}
+
 func (c *InvitesController) Delete(req httphandler.AuthenticatedRequest) httphandler.Response {
 	realmID := req.Params().ByName("realmID")
 	if realmID == "" {
@@ -123,8 +219,8 @@ func (c *InvitesController) Delete(req httphandler.AuthenticatedRequest) httphan
 
 	context := c.contextProvider.Get(realmID)
 
-	if !context.HasMandateForRealm(req.Mandates()) {
-		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("No mandate for realm"))
+	if !context.HasPermission(req.Mandates(), realm.PermissionInvitesWrite) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionInvitesWrite))
 	}
 
 	inviteID := req.Params().ByName("inviteID")
@@ -132,13 +228,52 @@ func (c *InvitesController) Delete(req httphandler.AuthenticatedRequest) httphan
 		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify invite ID"))
 	}
 
+	before, _ := context.Invites().Get(inviteID)
+
 	if err := context.Invites().Delete(inviteID); err != nil {
 		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to delete invite"))
 	}
 
+	audit(req, context, "invite", inviteID, realm.AuditDelete, before, nil)
+
 	return httphandler.NewEmptyResponse(http.StatusNoContent)
 }
 
+func (c *InvitesController) Cancel(req httphandler.AuthenticatedRequest) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	if !context.HasPermission(req.Mandates(), realm.PermissionInvitesWrite) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionInvitesWrite))
+	}
+
+	inviteID := req.Params().ByName("inviteID")
+	if inviteID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify invite ID"))
+	}
+
+	before, err := context.Invites().Get(inviteID)
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusNotFound, errors.Wrap(err, "failed to get invite"))
+	}
+
+	invite, err := context.Invites().Cancel(inviteID)
+	if err != nil {
+		if err == realm.ErrInviteClosed {
+			return httphandler.NewErrorResponse(http.StatusConflict, err)
+		}
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to cancel invite"))
+	}
+
+	audit(req, context, "invite", inviteID, realm.AuditUpdate, before, invite)
+
+	return httphandler.NewJsonResponse(http.StatusOK, invite)
+}
+
 func (c *InvitesController) Send(req httphandler.AuthenticatedRequest) httphandler.Response {
 	realmID := req.Params().ByName("realmID")
 	if realmID == "" {
@@ -147,8 +282,8 @@ func (c *InvitesController) Send(req httphandler.AuthenticatedRequest) httphandl
 
 	context := c.contextProvider.Get(realmID)
 
-	if !context.HasMandateForRealm(req.Mandates()) {
-		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("No mandate for realm"))
+	if !context.HasPermission(req.Mandates(), realm.PermissionInvitesSend) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionInvitesSend))
 	}
 
 	inviteID := req.Params().ByName("inviteID")
@@ -161,15 +296,37 @@ func (c *InvitesController) Send(req httphandler.AuthenticatedRequest) httphandl
 		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to get invite"))
 	}
 
-	status, err := context.Invites().Send(invite)
+	msg, err := context.Invites().Send(invite)
 	if err != nil {
-		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to delete invite"))
+		if err == realm.ErrInviteExpired || err == realm.ErrInviteClosed {
+			return httphandler.NewErrorResponse(http.StatusGone, err)
+		}
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to send invite"))
 	}
 
-	return httphandler.NewJsonResponse(http.StatusCreated, status)
+	audit(req, context, "invite", invite.ID, realm.AuditSend, nil, msg)
+
+	// a message that could not be sent right away stays queued for retry
+	if msg.Status != realm.OutboxSent {
+		return httphandler.NewJsonResponse(http.StatusAccepted, msg)
+	}
+
+	return httphandler.NewJsonResponse(http.StatusCreated, msg)
 }
 
-func (c *InvitesController) Fetch(req httphandler.Request) httphandler.Response {
+// QRCode returns a PNG image of a QR code holding the link of an invite that has
+// no link yet, for handing it over in person.
+func (c *InvitesController) QRCode(req httphandler.AuthenticatedRequest) httphandler.Response {
+	return c.qrCode(req, false)
+}
+
+// RotateQRCode returns a PNG image of a QR code holding a new link for the
+// invite. Links sent to the recipient before stop working.
+func (c *InvitesController) RotateQRCode(req httphandler.AuthenticatedRequest) httphandler.Response {
+	return c.qrCode(req, true)
+}
+
+func (c *InvitesController) qrCode(req httphandler.AuthenticatedRequest, rotate bool) httphandler.Response {
 	realmID := req.Params().ByName("realmID")
 	if realmID == "" {
 		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
@@ -177,20 +334,46 @@ func (c *InvitesController) Fetch(req httphandler.Request) httphandler.Response
 
 	context := c.contextProvider.Get(realmID)
 
+	if !context.HasPermission(req.Mandates(), realm.PermissionInvitesSend) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionInvitesSend))
+	}
+
 	inviteID := req.Params().ByName("inviteID")
 	if inviteID == "" {
 		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify invite ID"))
 	}
 
-	jws, err := context.Invites().Fetch(inviteID)
+	invite, err := context.Invites().Get(inviteID)
 	if err != nil {
-		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to get invite"))
+		return httphandler.NewErrorResponse(http.StatusNotFound, errors.Wrap(err, "failed to get invite"))
 	}
 
-	return httphandler.NewStandardResponse(http.StatusOK, "application/json", jws.FullSerialize())
+	// the QR code can be redeemed by whoever scans it
+	if err := context.CanGrant(req.Mandates(), invite.Role); err != nil {
+		return httphandler.NewErrorResponse(http.StatusForbidden, err)
+	}
+
+	qrCode := context.Invites().QRCode
+	if rotate {
+		qrCode = context.Invites().RotateQRCode
+	}
+	png, err := qrCode(inviteID, qrSize(req))
+	if err != nil {
+		if err == realm.ErrInviteExpired || err == realm.ErrInviteClosed {
+			return httphandler.NewErrorResponse(http.StatusGone, err)
+		}
+		if err == realm.ErrInviteLinkIssued {
+			return httphandler.NewErrorResponse(http.StatusConflict, err)
+		}
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to create QR code"))
+	}
+
+	audit(req, context, "invite", inviteID, realm.AuditSend, nil, map[string]interface{}{"channel": "qr", "rotate": rotate})
+
+	return httphandler.NewStandardResponse(http.StatusOK, "image/png", png)
 }
 
-func (c *InvitesController) Callback(req httphandler.Request) httphandler.Response {
+func (c *InvitesController) Deliveries(req httphandler.AuthenticatedRequest) httphandler.Response {
 	realmID := req.Params().ByName("realmID")
 	if realmID == "" {
 		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
@@ -198,11 +381,97 @@ func (c *InvitesController) Callback(req httphandler.Request) httphandler.Respon
 
 	context := c.contextProvider.Get(realmID)
 
+	if !context.HasPermission(req.Mandates(), realm.PermissionInvitesRead) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionInvitesRead))
+	}
+
 	inviteID := req.Params().ByName("inviteID")
 	if inviteID == "" {
 		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify invite ID"))
 	}
 
+	deliveries, err := context.Invites().Deliveries(inviteID)
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to list deliveries"))
+	}
+
+	return httphandler.NewJsonResponse(http.StatusOK, deliveries)
+}
+
+func (c *InvitesController) Redeliver(req httphandler.AuthenticatedRequest) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	if !context.HasPermission(req.Mandates(), realm.PermissionInvitesSend) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionInvitesSend))
+	}
+
+	inviteID := req.Params().ByName("inviteID")
+	deliveryID := req.Params().ByName("deliveryID")
+	if inviteID == "" || deliveryID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify invite and delivery ID"))
+	}
+
+	msg, err := context.Invites().Delivery(deliveryID)
+	if err != nil || msg.InviteID != inviteID {
+		return httphandler.NewErrorResponse(http.StatusNotFound, errors.New("Delivery not found"))
+	}
+
+	msg, err = context.Invites().Redeliver(deliveryID)
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to redeliver"))
+	}
+
+	audit(req, context, "invite", inviteID, realm.AuditSend, nil, msg)
+
+	return httphandler.NewJsonResponse(http.StatusAccepted, msg)
+}
+
+func (c *InvitesController) Fetch(req httphandler.Request) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	key := c.limiterKey(req, realmID)
+	if c.limiter.Blocked(key) {
+		return httphandler.NewErrorResponse(http.StatusTooManyRequests, errors.New("Too many failed attempts"))
+	}
+
+	jws, err := context.Invites().Fetch(req.Params().ByName("token"))
+	if err != nil {
+		if err == realm.ErrInviteToken {
+			c.limiter.Fail(key)
+			return httphandler.NewErrorResponse(http.StatusNotFound, err)
+		}
+		if err == realm.ErrInviteExpired || err == realm.ErrInviteClosed {
+			return httphandler.NewErrorResponse(http.StatusGone, err)
+		}
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to get invite"))
+	}
+
+	return httphandler.NewStandardResponse(http.StatusOK, "application/json", jws.FullSerialize())
+}
+
+func (c *InvitesController) Callback(req httphandler.Request) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	key := c.limiterKey(req, realmID)
+	if c.limiter.Blocked(key) {
+		return httphandler.NewErrorResponse(http.StatusTooManyRequests, errors.New("Too many failed attempts"))
+	}
+
 	body, err := req.Body()
 	if err != nil {
 		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to read request body"))
@@ -213,8 +482,18 @@ func (c *InvitesController) Callback(req httphandler.Request) httphandler.Respon
 		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to unmarshal JWS"))
 	}
 
-	mp, err := context.Invites().Callback(inviteID, jws)
+	mp, err := context.Invites().Callback(req.Params().ByName("token"), jws)
 	if err != nil {
+		if err == realm.ErrInviteToken {
+			c.limiter.Fail(key)
+			return httphandler.NewErrorResponse(http.StatusNotFound, err)
+		}
+		if err == realm.ErrInviteExpired || err == realm.ErrInviteClosed {
+			return httphandler.NewErrorResponse(http.StatusGone, err)
+		}
+		if err == realm.ErrInviteNotYetValid {
+			return httphandler.NewErrorResponse(http.StatusForbidden, err)
+		}
 		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to process invite callback"))
 	}
 
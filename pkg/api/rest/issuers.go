@@ -0,0 +1,140 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/IpsoVeritas/crypto"
+	httphandler "github.com/IpsoVeritas/httphandler"
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/IpsoVeritas/realm/pkg/services"
+	"github.com/pkg/errors"
+	jose "gopkg.in/square/go-jose.v1"
+)
+
+type IssuersController struct {
+	contextProvider *services.RealmsServiceProvider
+}
+
+func NewIssuersController(contextProvider *services.RealmsServiceProvider) *IssuersController {
+	return &IssuersController{
+		contextProvider: contextProvider,
+	}
+}
+
+func (c *IssuersController) List(req httphandler.AuthenticatedRequest) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	if !context.HasPermission(req.Mandates(), realm.PermissionIssuersRead) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionIssuersRead))
+	}
+
+	keys, err := context.Issuers().List()
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to list issuers"))
+	}
+
+	return httphandler.NewJsonResponse(http.StatusOK, &jose.JsonWebKeySet{Keys: keys})
+}
+
+func (c *IssuersController) Get(req httphandler.AuthenticatedRequest) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	if !context.HasPermission(req.Mandates(), realm.PermissionIssuersRead) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionIssuersRead))
+	}
+
+	issuerID := req.Params().ByName("issuerID")
+	if issuerID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify issuer ID"))
+	}
+
+	key, err := context.Issuers().Get(issuerID)
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusNotFound, errors.Wrap(err, "failed to get issuer"))
+	}
+
+	return httphandler.NewJsonResponse(http.StatusOK, key)
+}
+
+func (c *IssuersController) Set(req httphandler.AuthenticatedRequest) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	if !context.HasPermission(req.Mandates(), realm.PermissionIssuersWrite) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionIssuersWrite))
+	}
+
+	// facts signed by trusted issuers grant mandates through tickets, so only admins change them
+	if !context.HasAdminMandateForRealm(req.Mandates()) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Only admins can change trusted issuers"))
+	}
+
+	body, err := req.Body()
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to read request body"))
+	}
+
+	key := &jose.JsonWebKey{}
+	if err := json.Unmarshal(body, key); err != nil {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to unmarshal key"))
+	}
+
+	if err := context.Issuers().Set(key); err != nil {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to add issuer"))
+	}
+
+	audit(req, context, "issuer", crypto.Thumbprint(key), realm.AuditCreate, nil, key)
+
+	return httphandler.NewJsonResponse(http.StatusOK, key)
+}
+
+func (c *IssuersController) Delete(req httphandler.AuthenticatedRequest) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	if !context.HasPermission(req.Mandates(), realm.PermissionIssuersWrite) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionIssuersWrite))
+	}
+
+	// facts signed by trusted issuers grant mandates through tickets, so only admins change them
+	if !context.HasAdminMandateForRealm(req.Mandates()) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Only admins can change trusted issuers"))
+	}
+
+	issuerID := req.Params().ByName("issuerID")
+	if issuerID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify issuer ID"))
+	}
+
+	before, err := context.Issuers().Get(issuerID)
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusNotFound, errors.Wrap(err, "failed to get issuer"))
+	}
+
+	if err := context.Issuers().Delete(issuerID); err != nil {
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to delete issuer"))
+	}
+
+	audit(req, context, "issuer", issuerID, realm.AuditDelete, before, nil)
+
+	return httphandler.NewEmptyResponse(http.StatusNoContent)
+}
@@ -4,10 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/IpsoVeritas/crypto"
 	"github.com/IpsoVeritas/document"
 	httphandler "github.com/IpsoVeritas/httphandler"
+	realm "github.com/IpsoVeritas/realm"
 	"github.com/IpsoVeritas/realm/pkg/services"
 	"github.com/pkg/errors"
 	jose "gopkg.in/square/go-jose.v1"
@@ -50,6 +52,10 @@ func (c *MandateTicketController) IssueMandate(req httphandler.Request) httphand
 		return httphandler.NewErrorResponse(http.StatusNotFound, errors.Wrap(err, "ticket not found"))
 	}
 
+	if ticket.Expired(time.Now()) || (ticket.MaxRedemptions > 0 && ticket.Redemptions >= ticket.MaxRedemptions) {
+		return httphandler.NewErrorResponse(http.StatusGone, realm.ErrTicketNotRedeemable)
+	}
+
 	ticket.ScopeRequest.ReplyTo = []string{fmt.Sprintf("%s/realm/v2/realms/%s/tickets/%s/callback", c.base, ticket.Realm, ticket.ID)}
 
 	scopeReqBytes, err := json.Marshal(ticket.ScopeRequest)
@@ -102,6 +108,13 @@ func (c *MandateTicketController) IssueMandateCallback(req httphandler.Request)
 		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to verify JWS signature"))
 	}
 
+	if err := context.MandateTickets().VerifyFacts(ticket, jws); err != nil {
+		if _, ok := err.(services.FactErrors); ok {
+			return httphandler.NewErrorResponse(http.StatusForbidden, err)
+		}
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to verify facts"))
+	}
+
 	var userKey *jose.JsonWebKey
 	var mp document.Multipart
 
@@ -168,6 +181,13 @@ func (c *MandateTicketController) IssueMandateCallback(req httphandler.Request)
 		}
 	}
 
+	if _, err := context.MandateTickets().Redeem(ticket.ID); err != nil {
+		if err == realm.ErrTicketNotRedeemable {
+			return httphandler.NewErrorResponse(http.StatusGone, err)
+		}
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to redeem ticket"))
+	}
+
 	mandate := ticket.Mandate
 	mandate.Recipient = userKey
 
@@ -191,3 +211,233 @@ func (c *MandateTicketController) IssueMandateCallback(req httphandler.Request)
 
 	return httphandler.NewJsonResponse(http.StatusOK, respMp)
 }
+
+func (c *MandateTicketController) List(req httphandler.AuthenticatedRequest) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	if !context.HasPermission(req.Mandates(), realm.PermissionMandatesRead) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionMandatesRead))
+	}
+
+	tickets, err := context.MandateTickets().List()
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to list tickets"))
+	}
+
+	// tickets for roles the caller can't grant are left out, since their IDs can be redeemed
+	out := make([]*realm.MandateTicket, 0)
+	for _, ticket := range tickets {
+		if context.CanGrant(req.Mandates(), ticketRole(ticket)) == nil {
+			out = append(out, ticket)
+		}
+	}
+
+	return httphandler.NewJsonResponse(http.StatusOK, out)
+}
+
+func (c *MandateTicketController) Get(req httphandler.AuthenticatedRequest) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	if !context.HasPermission(req.Mandates(), realm.PermissionMandatesRead) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionMandatesRead))
+	}
+
+	ticketID := req.Params().ByName("ticketID")
+	if ticketID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify ticket ID"))
+	}
+
+	ticket, err := context.MandateTickets().Get(ticketID)
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusNotFound, errors.Wrap(err, "ticket not found"))
+	}
+
+	// anyone who knows the ticket ID can redeem it
+	if err := context.CanGrant(req.Mandates(), ticketRole(ticket)); err != nil {
+		return httphandler.NewErrorResponse(http.StatusForbidden, err)
+	}
+
+	return httphandler.NewJsonResponse(http.StatusOK, ticket)
+}
+
+func (c *MandateTicketController) Set(req httphandler.AuthenticatedRequest) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	if !context.HasPermission(req.Mandates(), realm.PermissionMandatesIssue) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionMandatesIssue))
+	}
+
+	body, err := req.Body()
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to read request body"))
+	}
+
+	ticket := &realm.MandateTicket{}
+	if err := json.Unmarshal(body, &ticket); err != nil {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to unmarshal ticket"))
+	}
+
+	ticketID := req.Params().ByName("ticketID")
+	if ticketID != "" && ticketID != ticket.ID {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("tried to update ticket with other ID than in payload"))
+	}
+
+	operation := realm.AuditCreate
+	var before *realm.MandateTicket
+	if ticket.ID != "" {
+		if before, err = context.MandateTickets().Get(ticket.ID); err == nil {
+			operation = realm.AuditUpdate
+		}
+	}
+
+	if err := context.CanGrant(req.Mandates(), ticketRole(ticket)); err != nil {
+		return httphandler.NewErrorResponse(http.StatusForbidden, err)
+	}
+	if before != nil {
+		if err := context.CanGrant(req.Mandates(), ticketRole(before)); err != nil {
+			return httphandler.NewErrorResponse(http.StatusForbidden, err)
+		}
+	}
+
+	if err := context.MandateTickets().Set(ticket); err != nil {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to store ticket"))
+	}
+
+	audit(req, context, "ticket", ticket.ID, operation, before, ticket)
+
+	return httphandler.NewJsonResponse(http.StatusOK, ticket)
+}
+
+func (c *MandateTicketController) Delete(req httphandler.AuthenticatedRequest) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	if !context.HasPermission(req.Mandates(), realm.PermissionMandatesIssue) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionMandatesIssue))
+	}
+
+	ticketID := req.Params().ByName("ticketID")
+	if ticketID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify ticket ID"))
+	}
+
+	before, err := context.MandateTickets().Get(ticketID)
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusNotFound, errors.Wrap(err, "ticket not found"))
+	}
+
+	if err := context.MandateTickets().Delete(ticketID); err != nil {
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to delete ticket"))
+	}
+
+	audit(req, context, "ticket", ticketID, realm.AuditDelete, before, nil)
+
+	return httphandler.NewEmptyResponse(http.StatusNoContent)
+}
+
+// URL returns the shareable URL of a ticket, to be sent as a link or shown as a QR code.
+func (c *MandateTicketController) URL(req httphandler.AuthenticatedRequest) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	if !context.HasPermission(req.Mandates(), realm.PermissionMandatesRead) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionMandatesRead))
+	}
+
+	ticketID := req.Params().ByName("ticketID")
+	if ticketID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify ticket ID"))
+	}
+
+	ticket, err := context.MandateTickets().Get(ticketID)
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusNotFound, errors.Wrap(err, "ticket not found"))
+	}
+
+	// anyone who knows the ticket ID can redeem it
+	if err := context.CanGrant(req.Mandates(), ticketRole(ticket)); err != nil {
+		return httphandler.NewErrorResponse(http.StatusForbidden, err)
+	}
+
+	link, err := context.MandateTickets().Link(ticket)
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to create link"))
+	}
+
+	url := linkResponse{
+		URLResponse: document.URLResponse{
+			URL: context.MandateTickets().URL(ticket),
+		},
+		Link: link,
+	}
+
+	return httphandler.NewJsonResponse(http.StatusOK, url)
+}
+
+// QRCode returns a PNG image of a QR code holding the URL of a ticket.
+func (c *MandateTicketController) QRCode(req httphandler.AuthenticatedRequest) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	if !context.HasPermission(req.Mandates(), realm.PermissionMandatesRead) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionMandatesRead))
+	}
+
+	ticketID := req.Params().ByName("ticketID")
+	if ticketID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify ticket ID"))
+	}
+
+	ticket, err := context.MandateTickets().Get(ticketID)
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusNotFound, errors.Wrap(err, "ticket not found"))
+	}
+
+	// anyone who knows the ticket ID can redeem it
+	if err := context.CanGrant(req.Mandates(), ticketRole(ticket)); err != nil {
+		return httphandler.NewErrorResponse(http.StatusForbidden, err)
+	}
+
+	png, err := services.QRCode(context.MandateTickets().URL(ticket), qrSize(req))
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, err)
+	}
+
+	return httphandler.NewStandardResponse(http.StatusOK, "image/png", png)
+}
+
+// ticketRole returns the role of the mandate the ticket hands out.
+func ticketRole(ticket *realm.MandateTicket) string {
+	if ticket.Mandate == nil {
+		return ""
+	}
+
+	return ticket.Mandate.Role
+}
@@ -29,8 +29,8 @@ func (c *MandatesController) List(req httphandler.AuthenticatedRequest) httphand
 
 	context := c.contextProvider.Get(realmID)
 
-	if !context.HasMandateForRealm(req.Mandates()) {
-		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("No mandate for realm"))
+	if !context.HasPermission(req.Mandates(), realm.PermissionMandatesRead) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionMandatesRead))
 	}
 
 	var err error
@@ -60,8 +60,8 @@ func (c *MandatesController) Get(req httphandler.AuthenticatedRequest) httphandl
 
 	context := c.contextProvider.Get(realmID)
 
-	if !context.HasMandateForRealm(req.Mandates()) {
-		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("No mandate for realm"))
+	if !context.HasPermission(req.Mandates(), realm.PermissionMandatesRead) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionMandatesRead))
 	}
 
 	mandateID := req.Params().ByName("mandateID")
@@ -85,8 +85,8 @@ func (c *MandatesController) Revoke(req httphandler.AuthenticatedRequest) httpha
 
 	context := c.contextProvider.Get(realmID)
 
-	if !context.HasMandateForRealm(req.Mandates()) {
-		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("No mandate for realm"))
+	if !context.HasPermission(req.Mandates(), realm.PermissionMandatesRevoke) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionMandatesRevoke))
 	}
 
 	mandateID := req.Params().ByName("mandateID")
@@ -99,11 +99,15 @@ func (c *MandatesController) Revoke(req httphandler.AuthenticatedRequest) httpha
 		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "could not get mandates"))
 	}
 
+	before := *mandate
+
 	mandate, err = context.Mandates().Revoke(mandate)
 	if err != nil {
 		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "could not revoke mandate"))
 	}
 
+	audit(req, context, "mandate", mandate.ID, realm.AuditRevoke, &before, mandate)
+
 	return httphandler.NewJsonResponse(http.StatusOK, mandate)
 }
 
@@ -115,10 +119,13 @@ func (c *MandatesController) Issue(req httphandler.AuthenticatedRequest) httphan
 
 	context := c.contextProvider.Get(realmID)
 
-	if !context.HasMandateForRealm(req.Mandates()) {
+	// mandates for the bootstrap realm can issue any mandate, others only for roles they could grant
+	bootstrap := false
+	if !context.HasPermission(req.Mandates(), realm.PermissionMandatesIssue) {
 		if !c.contextProvider.HasMandateForBootstrapRealm(req.Mandates()) {
 			return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("No access to issue mandates"))
 		}
+		bootstrap = true
 	}
 
 	body, err := req.Body()
@@ -131,10 +138,41 @@ func (c *MandatesController) Issue(req httphandler.AuthenticatedRequest) httphan
 		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to unmarshal mandate json"))
 	}
 
+	if !bootstrap {
+		if err := context.CanGrant(req.Mandates(), mandate.Role); err != nil {
+			return httphandler.NewErrorResponse(http.StatusForbidden, err)
+		}
+	}
+
 	issued, err := context.Mandates().Issue(mandate, mandate.Recipient.KeyID)
 	if err != nil {
 		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "could not issue mandate"))
 	}
 
+	audit(req, context, "mandate", issued.ID, realm.AuditIssue, nil, issued)
+
 	return httphandler.NewJsonResponse(http.StatusCreated, issued)
 }
+
+// Reissue signs the active mandates of the realm again with the current realm key.
+func (c *MandatesController) Reissue(req httphandler.AuthenticatedRequest) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	if !context.HasPermission(req.Mandates(), realm.PermissionMandatesIssue) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionMandatesIssue))
+	}
+
+	count, err := context.Mandates().Reissue()
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to re-issue mandates"))
+	}
+
+	audit(req, context, "mandate", "", realm.AuditIssue, nil, map[string]int{"reissued": count})
+
+	return httphandler.NewJsonResponse(http.StatusOK, map[string]int{"reissued": count})
+}
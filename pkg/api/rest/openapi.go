@@ -0,0 +1,37 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	httphandler "github.com/IpsoVeritas/httphandler"
+	"github.com/IpsoVeritas/realm/pkg/api/openapi"
+	"github.com/IpsoVeritas/realm/pkg/version"
+	"github.com/pkg/errors"
+)
+
+// OpenAPIController serves the OpenAPI document of the API.
+type OpenAPIController struct {
+	spec []byte
+}
+
+// NewOpenAPIController builds the OpenAPI document for the API served at base.
+func NewOpenAPIController(base string) (*OpenAPIController, error) {
+	doc, err := openapi.Spec(base, version.Version)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build OpenAPI document")
+	}
+
+	spec, err := json.Marshal(doc)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal OpenAPI document")
+	}
+
+	return &OpenAPIController{
+		spec: spec,
+	}, nil
+}
+
+func (c *OpenAPIController) Spec(req httphandler.Request) httphandler.Response {
+	return httphandler.NewStandardResponse(http.StatusOK, "application/json", c.spec)
+}
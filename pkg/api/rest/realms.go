@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/IpsoVeritas/crypto"
 	"github.com/IpsoVeritas/document"
@@ -13,25 +15,21 @@ import (
 	realm "github.com/IpsoVeritas/realm"
 	"github.com/IpsoVeritas/realm/pkg/services"
 	"github.com/pkg/errors"
-	jose "gopkg.in/square/go-jose.v1"
 )
 
 type RealmsController struct {
 	base            string
 	contextProvider *services.RealmsServiceProvider
-	keyset          *jose.JsonWebKeySet
 }
 
 func NewRealmsController(
 	base string,
 	contextProvider *services.RealmsServiceProvider,
-	keyset *jose.JsonWebKeySet,
 ) *RealmsController {
 
 	r := &RealmsController{
 		base:            base,
 		contextProvider: contextProvider,
-		keyset:          keyset,
 	}
 
 	return r
@@ -41,7 +39,12 @@ func (c *RealmsController) ListRealms(req httphandler.AuthenticatedRequest) http
 
 	if c.contextProvider.HasMandateForBootstrapRealm(req.Mandates()) {
 
-		realms, err := c.contextProvider.ListRealms()
+		list := c.contextProvider.ListRealms
+		if deleted, _ := strconv.ParseBool(req.OriginalRequest().URL.Query().Get("deleted")); deleted {
+			list = c.contextProvider.ListDeletedRealms
+		}
+
+		realms, err := list()
 		if err != nil {
 			return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to list realms"))
 		}
@@ -75,8 +78,8 @@ func (c *RealmsController) GetRealm(req httphandler.AuthenticatedRequest) httpha
 
 	context := c.contextProvider.Get(realmID)
 
-	if !context.HasMandateForRealm(req.Mandates()) {
-		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("No mandate for realm"))
+	if !context.HasPermission(req.Mandates(), realm.PermissionRealmRead) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionRealmRead))
 	}
 
 	realm, err := context.Realm()
@@ -111,6 +114,10 @@ func (c *RealmsController) PostRealm(req httphandler.AuthenticatedRequest) httph
 		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Bad realm ID"))
 	}
 
+	if err := services.ValidateDeepLink(realm.DeepLink); err != nil {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, err)
+	}
+
 	_, err = c.contextProvider.Get(realm.ID).Realm()
 	if err == nil {
 		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Realm already exists"))
@@ -121,6 +128,8 @@ func (c *RealmsController) PostRealm(req httphandler.AuthenticatedRequest) httph
 		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to create realm"))
 	}
 
+	audit(req, c.contextProvider.Get(createdRealm.ID), "realm", createdRealm.ID, "create", nil, createdRealm)
+
 	return httphandler.NewJsonResponse(http.StatusCreated, createdRealm)
 }
 
@@ -132,8 +141,8 @@ func (c *RealmsController) UpdateRealm(req httphandler.AuthenticatedRequest) htt
 
 	context := c.contextProvider.Get(realmID)
 
-	if !context.HasMandateForRealm(req.Mandates()) {
-		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("No mandate for realm"))
+	if !context.HasPermission(req.Mandates(), realm.PermissionRealmWrite) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionRealmWrite))
 	}
 
 	body, err := req.Body()
@@ -146,12 +155,45 @@ func (c *RealmsController) UpdateRealm(req httphandler.AuthenticatedRequest) htt
 		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to unmarshal realm json"))
 	}
 
+	// the permissions were checked for the realm in the URL, so that's the one updated
+	realm.ID = realmID
+
+	if err := services.ValidateDeepLink(realm.DeepLink); err != nil {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, err)
+	}
+
+	if err := context.CanSetAdminRoles(req.Mandates(), realm.AdminRoles); err != nil {
+		return httphandler.NewErrorResponse(http.StatusForbidden, err)
+	}
+
 	realm.Descriptor.Label = realm.Label
 
+	before, _ := context.Realm()
+
+	// anyone who joins the realm gets a mandate for the guest role
+	if realm.GuestRole != "" && (before == nil || realm.GuestRole != before.GuestRole) {
+		if err := context.CanGrant(req.Mandates(), realm.GuestRole); err != nil {
+			return httphandler.NewErrorResponse(http.StatusForbidden, err)
+		}
+	}
+
+	// keys are only changed by rotating them, and realms are only deleted and
+	// restored through DeleteRealm and RestoreRealm
+	realm.Deleted = nil
+	if before != nil {
+		realm.Deleted = before.Deleted
+		realm.PublicKey = before.PublicKey
+		realm.KeyID = before.KeyID
+		realm.RetiredKeys = before.RetiredKeys
+		realm.Descriptor.PublicKey = before.PublicKey
+	}
+
 	if err := context.Set(realm); err != nil {
 		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to save realm"))
 	}
 
+	audit(req, context, "realm", realmID, "update", before, realm)
+
 	return httphandler.NewJsonResponse(http.StatusCreated, realm)
 }
 
@@ -163,47 +205,114 @@ func (c *RealmsController) DeleteRealm(req httphandler.AuthenticatedRequest) htt
 
 	context := c.contextProvider.Get(realmID)
 
-	if !context.HasMandateForRealm(req.Mandates()) {
-		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("No mandate for realm"))
+	// realms are purged right away only by the bootstrap realm, which can also purge deleted realms
+	purge, _ := strconv.ParseBool(req.OriginalRequest().URL.Query().Get("purge"))
+	if purge {
+		if !c.contextProvider.HasMandateForBootstrapRealm(req.Mandates()) {
+			return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("No access to purge realms"))
+		}
+	} else if !context.HasPermission(req.Mandates(), realm.PermissionRealmDelete) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionRealmDelete))
 	}
 
-	if err := context.Delete(); err != nil {
-		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to delete realm"))
+	before, err := context.Realm()
+	if err == nil {
+		if err := context.Delete(); err != nil {
+			return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to delete realm"))
+		}
+
+		audit(req, context, "realm", realmID, "delete", before, nil)
+	} else if !purge || err != realm.ErrRealmDeleted {
+		return httphandler.NewErrorResponse(http.StatusNotFound, errors.Wrap(err, "could not find realm"))
+	}
+
+	if purge {
+		if err := context.Purge(); err != nil {
+			return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to purge realm"))
+		}
+
+		audit(req, context, "realm", realmID, realm.AuditPurge, nil, nil)
 	}
 
 	return httphandler.NewEmptyResponse(http.StatusNoContent)
 }
 
-// // ===============================================================
-// // this method is publicly accessible.
-// //
-func (c *RealmsController) JoinRealm(req httphandler.Request) httphandler.Response {
+// RestoreRealm undoes the deletion of a realm that hasn't been purged yet. Since
+// the mandates for a deleted realm aren't valid, it needs an admin mandate for the bootstrap realm.
+func (c *RealmsController) RestoreRealm(req httphandler.AuthenticatedRequest) httphandler.Response {
 	realmID := req.Params().ByName("realmID")
 	if realmID == "" {
 		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("No realm specified"))
 	}
 
+	if !c.contextProvider.HasMandateForBootstrapRealm(req.Mandates()) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("No access to restore realms"))
+	}
+
 	context := c.contextProvider.Get(realmID)
 
-	body, err := req.Body()
+	restored, err := context.Restore()
 	if err != nil {
-		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to read request body"))
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to restore realm"))
 	}
 
-	jws, err := crypto.UnmarshalSignature(body)
+	audit(req, context, "realm", realmID, realm.AuditRestore, nil, restored)
+
+	return httphandler.NewJsonResponse(http.StatusOK, restored)
+}
+
+// RotateKey creates a new key for the realm. Mandates signed by the previous key
+// are accepted for the grace period, and are re-issued with the new key before responding.
+func (c *RealmsController) RotateKey(req httphandler.AuthenticatedRequest) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("No realm specified"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	if !context.HasPermission(req.Mandates(), realm.PermissionRealmWrite) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionRealmWrite))
+	}
+
+	grace := services.DefaultKeyGrace
+	if g := req.OriginalRequest().URL.Query().Get("grace"); g != "" {
+		var err error
+		if grace, err = time.ParseDuration(g); err != nil {
+			return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Errorf("Invalid grace period %s", g))
+		}
+	}
+	if grace < services.MinKeyGrace {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, services.ErrKeyGrace)
+	}
+
+	before, err := context.Realm()
 	if err != nil {
-		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to parse JWS"))
+		return httphandler.NewErrorResponse(http.StatusNotFound, errors.Wrap(err, "could not find realm"))
 	}
+	beforeKeys := map[string]interface{}{"publicKey": before.PublicKey, "retiredKeys": before.RetiredKeys}
 
-	multipart, err := context.Join(jws)
+	rotated, err := context.RotateKey(grace)
 	if err != nil {
-		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to join realm"))
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to rotate key"))
 	}
 
-	return httphandler.NewJsonResponse(http.StatusOK, multipart)
+	audit(req, context, "realm", realmID, realm.AuditRotate, beforeKeys, map[string]interface{}{"publicKey": rotated.PublicKey, "retiredKeys": rotated.RetiredKeys})
+
+	// the key is rotated even if this fails, and re-issuing can be retried until the grace period ends
+	if _, err := context.Mandates().Reissue(); err != nil {
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "key rotated, but failed to re-issue mandates"))
+	}
+
+	return httphandler.NewJsonResponse(http.StatusOK, rotated)
 }
 
-func (c *RealmsController) JoinRealmCallback(req httphandler.Request) httphandler.Response {
+// backupPassphraseHeader holds the passphrase that the realm keys in a backup are encrypted with.
+const backupPassphraseHeader = "X-Backup-Passphrase"
+
+// Export returns a signed archive of the realm. The realm keys are included if
+// a passphrase to encrypt them with is given, which needs an admin mandate.
+func (c *RealmsController) Export(req httphandler.AuthenticatedRequest) httphandler.Response {
 	realmID := req.Params().ByName("realmID")
 	if realmID == "" {
 		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("No realm specified"))
@@ -211,18 +320,40 @@ func (c *RealmsController) JoinRealmCallback(req httphandler.Request) httphandle
 
 	context := c.contextProvider.Get(realmID)
 
-	realmData, err := context.Realm()
-	if err != nil {
-		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "could not find realm"))
+	if !context.HasPermission(req.Mandates(), realm.PermissionRealmExport) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionRealmExport))
 	}
 
-	if realmData.GuestRole == "" {
-		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("No public invite for realm"))
+	passphrase := req.OriginalRequest().Header.Get(backupPassphraseHeader)
+
+	// the realm keys can sign any mandate, so only admins get them
+	if passphrase != "" && !context.HasAdminMandateForRealm(req.Mandates()) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Only admins can export the realm keys"))
 	}
 
-	guestRole, err := context.Roles().ByName(realmData.GuestRole)
+	archive, err := context.Export(passphrase)
 	if err != nil {
-		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "could not get guest role"))
+		if errors.Cause(err) == realm.ErrKeyNotExportable {
+			return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to export realm, export without a passphrase"))
+		}
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to export realm"))
+	}
+
+	audit(req, context, "realm", realmID, realm.AuditExport, nil, map[string]bool{"keys": passphrase != ""})
+
+	return httphandler.NewStandardResponse(http.StatusOK, "application/gzip", archive)
+}
+
+// Import restores a realm from an archive created by Export. Since it can create
+// realms and import their keys, it needs an admin mandate for the bootstrap realm.
+func (c *RealmsController) Import(req httphandler.AuthenticatedRequest) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("No realm specified"))
+	}
+
+	if !c.contextProvider.HasMandateForBootstrapRealm(req.Mandates()) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("No access to import realms"))
 	}
 
 	body, err := req.Body()
@@ -230,135 +361,77 @@ func (c *RealmsController) JoinRealmCallback(req httphandler.Request) httphandle
 		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to read request body"))
 	}
 
-	jws, err := crypto.UnmarshalSignature(body)
+	context := c.contextProvider.Get(realmID)
+	before, _ := context.Realm()
+
+	passphrase := req.OriginalRequest().Header.Get(backupPassphraseHeader)
+	conflict := req.OriginalRequest().URL.Query().Get("conflict")
+
+	result, err := context.Import(body, passphrase, conflict)
 	if err != nil {
-		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to parse JWS"))
+		switch errors.Cause(err) {
+		case realm.ErrBackupConflict:
+			return httphandler.NewErrorResponse(http.StatusConflict, err)
+		case realm.ErrBackupSignature, realm.ErrBackupPassphrase:
+			return httphandler.NewErrorResponse(http.StatusBadRequest, err)
+		}
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to import realm"))
 	}
 
-	if len(jws.Signatures) < 1 {
-		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("No signatures on JWS"))
-	}
+	audit(req, context, "realm", realmID, realm.AuditImport, before, result)
 
-	var userKey *jose.JsonWebKey
-	var scopeResponse document.Multipart
-	var certificate *document.Certificate
+	return httphandler.NewJsonResponse(http.StatusOK, result)
+}
 
-	scopeDataBytes, err := jws.Verify(jws.Signatures[0].Header.JsonWebKey)
-	if err != nil {
-		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to verify JWS signature"))
+// // ===============================================================
+// // this method is publicly accessible.
+// //
+func (c *RealmsController) JoinRealm(req httphandler.Request) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("No realm specified"))
 	}
 
-	if err := json.Unmarshal(scopeDataBytes, &scopeResponse); err != nil {
-		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to unmarshal multipart in JWS"))
-	}
+	context := c.contextProvider.Get(realmID)
 
-	if scopeResponse.Certificate != "" {
-		certificate, err = crypto.VerifyCertificate(scopeResponse.Certificate, guestRole.KeyLevel)
-		if err != nil {
-			return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to verify certificate chain"))
-		}
+	jws, err := context.JoinRequest()
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to create join request"))
 	}
 
-	if certificate != nil {
-		userKey = certificate.Issuer
-	} else {
-		userKey = jws.Signatures[0].Header.JsonWebKey
-	}
+	return httphandler.NewStandardResponse(http.StatusOK, "application/json", jws.FullSerialize())
+}
 
-	var contract *document.Contract
-	userData := make(map[string]interface{})
-	for _, part := range scopeResponse.Parts {
-		if part.Name == "contract" {
-			if err := json.Unmarshal([]byte(part.Document), &contract); err != nil {
-				req.Log().Error(err)
-			}
-		} else if part.Encoding == "application/json+jws" {
-			partJWS, err := crypto.UnmarshalSignature([]byte(part.Document))
-			if err == nil && len(partJWS.Signatures) > 0 {
-				ok := validSig(c.keyset, partJWS)
-				// TODO: more info on matching keys
-				if ok {
-					b, err := partJWS.Verify(partJWS.Signatures[0].Header.JsonWebKey)
-					if err == nil {
-						var fact document.Fact
-						err := json.Unmarshal(b, &fact)
-						if err == nil {
-							for k, v := range fact.Data {
-								userData[k] = v
-							}
-						}
-					}
-				} else {
-					req.Log().Warn("No matching keys for TODO")
-				}
-			}
-		}
+// // ===============================================================
+// // this method is publicly accessible.
+// //
+func (c *RealmsController) JoinRealmCallback(req httphandler.Request) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("No realm specified"))
 	}
 
-	if contract == nil {
-		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("User did not accept the contract"))
-	}
-
-	// for key, value := range ticket.Facts {
-	// 	userValue, ok := userData[key]
-	// 	if ok {
-	// 		if userValue != value {
-	// 			errStr := fmt.Sprintf("Fact %s does not match value provided by user", key)
-	// 			logger.Warn(errStr)
-	// 			http.Error(w, errStr, http.StatusBadRequest)
-	// 			return
-	// 		}
-	// 	} else {
-	// 		errStr := fmt.Sprintf("Fact %s not provided by user", key)
-	// 		logger.Warn(errStr)
-	// 		http.Error(w, errStr, http.StatusBadRequest)
-	// 		return
-	// 	}
-	// }
-
-	// TODO: get users root key when we have key lists
-	mandate := document.NewMandate(guestRole.Name)
-	mandate.RoleName = guestRole.Description
-	mandate.Recipient = userKey
-	mandate.Sender = realmID
-	mandate.Realm = realmID
-
-	// if userName, ok := userData["name"]; ok {
-	// 	m.RecipientName = userName.(string)
-	// }
-	// if m.RecipientName == "" {
-	// 	if userEmail, ok := userData["email"]; ok {
-	// 		m.RecipientName = userEmail.(string)
-	// 	}
-	// }
-	// if m.RecipientName == "" {
-	// 	if userPhone, ok := userData["phone"]; ok {
-	// 		m.RecipientName = userPhone.(string)
-	// 	}
-	// }
-	// if m.RecipientName == "" {
-	// 	m.RecipientName = userID
-	// }
-
-	issued, err := context.Mandates().Issue(mandate, userKey.KeyID)
+	context := c.contextProvider.Get(realmID)
+
+	body, err := req.Body()
 	if err != nil {
-		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to issue mandate"))
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to read request body"))
 	}
 
-	part := document.Part{
-		Encoding: "application/json+jws",
-		Name:     "mandate",
-		Document: issued.Signed,
+	jws, err := crypto.UnmarshalSignature(body)
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to parse JWS"))
 	}
-	multipart := document.NewMultipart()
-	multipart.Append(part)
 
-	multipartBytes, err := json.Marshal(multipart)
+	multipart, err := context.JoinCallback(jws)
 	if err != nil {
-		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to marshal response"))
+		if errors.Cause(err) == services.ErrJoinRejected {
+			return httphandler.NewErrorResponse(http.StatusForbidden, err)
+		}
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to join realm"))
 	}
 
-	return httphandler.NewJsonResponse(http.StatusCreated, multipartBytes)
+	return httphandler.NewJsonResponse(http.StatusCreated, multipart)
 }
 
 func (c *RealmsController) IconHandler(req httphandler.AuthenticatedRequest) httphandler.Response {
@@ -370,8 +443,8 @@ func (c *RealmsController) IconHandler(req httphandler.AuthenticatedRequest) htt
 
 	context := c.contextProvider.Get(realmID)
 
-	if !context.HasMandateForRealm(req.Mandates()) {
-		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("No mandate for realm"))
+	if !context.HasPermission(req.Mandates(), realm.PermissionRealmWrite) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionRealmWrite))
 	}
 
 	realm, err := context.Realm()
@@ -400,12 +473,15 @@ func (c *RealmsController) IconHandler(req httphandler.AuthenticatedRequest) htt
 		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to write file to storage"))
 	}
 
+	before := realm.Descriptor.Icon
 	realm.Descriptor.Icon = name
 
 	if err := context.Set(realm); err != nil {
 		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to save changes"))
 	}
 
+	audit(req, context, "realm", realmID, "update", map[string]string{"icon": before}, map[string]string{"icon": name})
+
 	return httphandler.NewEmptyResponse(http.StatusCreated)
 }
 
@@ -418,8 +494,8 @@ func (c *RealmsController) BannerHandler(req httphandler.AuthenticatedRequest) h
 
 	context := c.contextProvider.Get(realmID)
 
-	if !context.HasMandateForRealm(req.Mandates()) {
-		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("No mandate for realm"))
+	if !context.HasPermission(req.Mandates(), realm.PermissionRealmWrite) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionRealmWrite))
 	}
 
 	realm, err := context.Realm()
@@ -448,12 +524,15 @@ func (c *RealmsController) BannerHandler(req httphandler.AuthenticatedRequest) h
 		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to write file to storage"))
 	}
 
+	before := realm.Descriptor.Banner
 	realm.Descriptor.Banner = name
 
 	if err := context.Set(realm); err != nil {
 		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to save realm"))
 	}
 
+	audit(req, context, "realm", realmID, "update", map[string]string{"banner": before}, map[string]string{"banner": name})
+
 	return httphandler.NewEmptyResponse(http.StatusCreated)
 }
 
@@ -473,8 +552,17 @@ func (c *RealmsController) Bootstrap(req httphandler.Request) httphandler.Respon
 		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to bootstrap"))
 	}
 
-	url := document.URLResponse{
-		URL: fmt.Sprintf("%s/realm/v2/realms/%s/tickets/%s/issue", c.base, ticket.Realm, ticket.ID),
+	tickets := c.contextProvider.Get(ticket.Realm).MandateTickets()
+	link, err := tickets.Link(ticket)
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to create link"))
+	}
+
+	url := linkResponse{
+		URLResponse: document.URLResponse{
+			URL: tickets.URL(ticket),
+		},
+		Link: link,
 	}
 
 	return httphandler.NewJsonResponse(http.StatusCreated, url)
@@ -0,0 +1,56 @@
+package rest
+
+import (
+	"net/http"
+	"time"
+
+	httphandler "github.com/IpsoVeritas/httphandler"
+	"github.com/IpsoVeritas/realm/pkg/services"
+	"github.com/pkg/errors"
+)
+
+type RevocationsController struct {
+	contextProvider *services.RealmsServiceProvider
+}
+
+func NewRevocationsController(contextProvider *services.RealmsServiceProvider) *RevocationsController {
+	return &RevocationsController{
+		contextProvider: contextProvider,
+	}
+}
+
+// List returns the signed revocation list for the realm.
+// The optional since parameter (RFC3339) limits the list to revocations made after that time.
+func (c *RevocationsController) List(req httphandler.Request) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	req.Log().AddField("realm", realmID)
+
+	context := c.contextProvider.Get(realmID)
+
+	if _, err := context.Realm(); err != nil {
+		return httphandler.NewErrorResponse(http.StatusNotFound, errors.Wrap(err, "realm not found"))
+	}
+
+	var since *time.Time
+	if s := req.OriginalRequest().URL.Query().Get("since"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to parse since"))
+		}
+		since = &t
+	}
+
+	jws, err := context.Revocations().SignedList(since)
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to sign revocation list"))
+	}
+
+	r := httphandler.NewStandardResponse(http.StatusOK, "application/json", jws.FullSerialize())
+	r.Header().Set("Cache-Control", "max-age=60")
+
+	return r
+}
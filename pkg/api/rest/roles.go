@@ -4,8 +4,8 @@ import (
 	"encoding/json"
 	"net/http"
 
-	document "github.com/IpsoVeritas/document"
 	httphandler "github.com/IpsoVeritas/httphandler"
+	realm "github.com/IpsoVeritas/realm"
 	"github.com/IpsoVeritas/realm/pkg/services"
 	"github.com/pkg/errors"
 )
@@ -28,8 +28,8 @@ func (c *RolesController) List(req httphandler.AuthenticatedRequest) httphandler
 
 	context := c.contextProvider.Get(realmID)
 
-	if !context.HasMandateForRealm(req.Mandates()) {
-		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("No mandate for realm"))
+	if !context.HasPermission(req.Mandates(), realm.PermissionRolesRead) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionRolesRead))
 	}
 
 	list, err := context.Roles().List()
@@ -37,7 +37,16 @@ func (c *RolesController) List(req httphandler.AuthenticatedRequest) httphandler
 		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to list roles"))
 	}
 
-	return httphandler.NewJsonResponse(http.StatusOK, list)
+	roles := make([]*realm.RoleWithPermissions, 0, len(list))
+	for _, role := range list {
+		r, err := context.Roles().WithPermissions(role)
+		if err != nil {
+			return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to list roles"))
+		}
+		roles = append(roles, r)
+	}
+
+	return httphandler.NewJsonResponse(http.StatusOK, roles)
 }
 
 func (c *RolesController) Get(req httphandler.AuthenticatedRequest) httphandler.Response {
@@ -48,8 +57,8 @@ func (c *RolesController) Get(req httphandler.AuthenticatedRequest) httphandler.
 
 	context := c.contextProvider.Get(realmID)
 
-	if !context.HasMandateForRealm(req.Mandates()) {
-		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("No mandate for realm"))
+	if !context.HasPermission(req.Mandates(), realm.PermissionRolesRead) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionRolesRead))
 	}
 
 	roleID := req.Params().ByName("roleID")
@@ -62,7 +71,12 @@ func (c *RolesController) Get(req httphandler.AuthenticatedRequest) httphandler.
 		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to get role"))
 	}
 
-	return httphandler.NewJsonResponse(http.StatusOK, role)
+	withPermissions, err := context.Roles().WithPermissions(role)
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to get role"))
+	}
+
+	return httphandler.NewJsonResponse(http.StatusOK, withPermissions)
 }
 
 func (c *RolesController) Set(req httphandler.AuthenticatedRequest) httphandler.Response {
@@ -73,8 +87,8 @@ func (c *RolesController) Set(req httphandler.AuthenticatedRequest) httphandler.
 
 	context := c.contextProvider.Get(realmID)
 
-	if !context.HasMandateForRealm(req.Mandates()) {
-		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("No mandate for realm"))
+	if !context.HasPermission(req.Mandates(), realm.PermissionRolesWrite) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionRolesWrite))
 	}
 
 	body, err := req.Body()
@@ -82,20 +96,65 @@ func (c *RolesController) Set(req httphandler.AuthenticatedRequest) httphandler.
 		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to read request body"))
 	}
 
-	role := &document.Role{}
-	if err := json.Unmarshal(body, &role); err != nil {
+	data := &realm.RoleWithPermissions{}
+	if err := json.Unmarshal(body, &data); err != nil {
 		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to unmarshal role"))
 	}
+	role := &data.Role
 
 	if role.KeyLevel < 10 {
 		role.KeyLevel = 1000
 	}
 
+	operation := realm.AuditCreate
+	var before *realm.RoleWithPermissions
+	if role.ID != "" {
+		if existing, err := context.Roles().Get(role.ID); err == nil {
+			operation = realm.AuditUpdate
+			before, _ = context.Roles().WithPermissions(existing)
+		}
+	}
+
+	if before != nil && before.Name != role.Name {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, services.ErrRoleRenamed)
+	}
+
+	for _, p := range data.Permissions {
+		if !realm.IsPermission(p) {
+			return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Errorf("unknown permission %s", p))
+		}
+	}
+
+	// a role can't be given permissions the caller doesn't have, and admin roles are only changed by admins
+	isAdmin, err := context.IsAdminRole(role.Name)
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, err)
+	}
+	if isAdmin && !context.HasAdminMandateForRealm(req.Mandates()) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Only admins can change admin roles"))
+	}
+	if err := context.CanGrantPermissions(req.Mandates(), data.Permissions); err != nil {
+		return httphandler.NewErrorResponse(http.StatusForbidden, err)
+	}
+
 	if err := context.Roles().Set(role); err != nil {
 		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrapf(err, "failed to store role"))
 	}
 
-	return httphandler.NewJsonResponse(http.StatusOK, role)
+	if data.Permissions != nil {
+		if err := context.Roles().SetPermissions(role.Name, data.Permissions); err != nil {
+			return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to store role permissions"))
+		}
+	}
+
+	audit(req, context, "role", role.ID, operation, before, data)
+
+	withPermissions, err := context.Roles().WithPermissions(role)
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to get role"))
+	}
+
+	return httphandler.NewJsonResponse(http.StatusOK, withPermissions)
 }
 
 func (c *RolesController) Delete(req httphandler.AuthenticatedRequest) httphandler.Response {
@@ -106,8 +165,8 @@ func (c *RolesController) Delete(req httphandler.AuthenticatedRequest) httphandl
 
 	context := c.contextProvider.Get(realmID)
 
-	if !context.HasMandateForRealm(req.Mandates()) {
-		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("No mandate for realm"))
+	if !context.HasPermission(req.Mandates(), realm.PermissionRolesWrite) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionRolesWrite))
 	}
 
 	roleID := req.Params().ByName("roleID")
@@ -120,6 +179,14 @@ func (c *RolesController) Delete(req httphandler.AuthenticatedRequest) httphandl
 		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to get role"))
 	}
 
+	isAdmin, err := context.IsAdminRole(role.Name)
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, err)
+	}
+	if isAdmin && !context.HasAdminMandateForRealm(req.Mandates()) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Only admins can delete admin roles"))
+	}
+
 	invites, err := context.Invites().ListForRole(role.Name)
 	if err != nil {
 		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to get invites"))
@@ -148,5 +215,7 @@ func (c *RolesController) Delete(req httphandler.AuthenticatedRequest) httphandl
 		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to delete role"))
 	}
 
+	audit(req, context, "role", roleID, realm.AuditDelete, role, nil)
+
 	return httphandler.NewEmptyResponse(http.StatusNoContent)
 }
@@ -0,0 +1,177 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	httphandler "github.com/IpsoVeritas/httphandler"
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/IpsoVeritas/realm/pkg/services"
+	"github.com/pkg/errors"
+)
+
+type TemplatesController struct {
+	contextProvider *services.RealmsServiceProvider
+}
+
+func NewTemplatesController(contextProvider *services.RealmsServiceProvider) *TemplatesController {
+	return &TemplatesController{
+		contextProvider: contextProvider,
+	}
+}
+
+func (c *TemplatesController) Get(req httphandler.AuthenticatedRequest) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	if !context.HasPermission(req.Mandates(), realm.PermissionRealmRead) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionRealmRead))
+	}
+
+	templates, err := context.Templates().Email(context.Locales(req.OriginalRequest().URL.Query().Get("locale")))
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to get templates"))
+	}
+
+	return httphandler.NewJsonResponse(http.StatusOK, templates)
+}
+
+func (c *TemplatesController) Set(req httphandler.AuthenticatedRequest) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	if !context.HasPermission(req.Mandates(), realm.PermissionRealmWrite) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionRealmWrite))
+	}
+
+	body, err := req.Body()
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to read request body"))
+	}
+
+	templates := &realm.InviteTemplates{}
+	if err := json.Unmarshal(body, &templates); err != nil {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to unmarshal templates"))
+	}
+
+	locale := req.OriginalRequest().URL.Query().Get("locale")
+	before, _ := context.Templates().Custom(locale)
+
+	if err := context.Templates().Set(templates, locale); err != nil {
+		if errors.Cause(err) == services.ErrInvalidTemplate {
+			return httphandler.NewErrorResponse(http.StatusBadRequest, err)
+		}
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to save templates"))
+	}
+
+	audit(req, context, "templates", "invite_email", realm.AuditUpdate, before, templates)
+
+	return httphandler.NewJsonResponse(http.StatusOK, templates)
+}
+
+func (c *TemplatesController) Preview(req httphandler.AuthenticatedRequest) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	if !context.HasPermission(req.Mandates(), realm.PermissionRealmRead) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionRealmRead))
+	}
+
+	body, err := req.Body()
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to read request body"))
+	}
+
+	templates := &realm.InviteTemplates{}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &templates); err != nil {
+			return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to unmarshal templates"))
+		}
+	}
+
+	status, err := context.Templates().Preview(templates, context.Locales(req.OriginalRequest().URL.Query().Get("locale")))
+	if err != nil {
+		if errors.Cause(err) == services.ErrInvalidTemplate {
+			return httphandler.NewErrorResponse(http.StatusBadRequest, err)
+		}
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to render preview"))
+	}
+
+	return httphandler.NewJsonResponse(http.StatusOK, status)
+}
+
+func (c *TemplatesController) AddAttachment(req httphandler.AuthenticatedRequest) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	if !context.HasPermission(req.Mandates(), realm.PermissionRealmWrite) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionRealmWrite))
+	}
+
+	file, handler, err := req.OriginalRequest().FormFile("file")
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to get file from request"))
+	}
+	defer file.Close()
+
+	if handler.Filename == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("No filename"))
+	}
+
+	location, err := context.Templates().AddAttachment(handler.Filename, file)
+	if err != nil {
+		if errors.Cause(err) == services.ErrInvalidTemplate {
+			return httphandler.NewErrorResponse(http.StatusBadRequest, err)
+		}
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to save attachment"))
+	}
+
+	audit(req, context, "templates", "invite_email", realm.AuditUpdate, nil, map[string]string{"attachment": handler.Filename})
+
+	return httphandler.NewJsonResponse(http.StatusCreated, map[string]string{
+		"name": handler.Filename,
+		"url":  location,
+	})
+}
+
+func (c *TemplatesController) RemoveAttachment(req httphandler.AuthenticatedRequest) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	if !context.HasPermission(req.Mandates(), realm.PermissionRealmWrite) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionRealmWrite))
+	}
+
+	name := req.Params().ByName("name")
+	if name == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify attachment name"))
+	}
+
+	if err := context.Templates().RemoveAttachment(name); err != nil {
+		return httphandler.NewErrorResponse(http.StatusNotFound, err)
+	}
+
+	audit(req, context, "templates", "invite_email", realm.AuditUpdate, map[string]string{"attachment": name}, nil)
+
+	return httphandler.NewEmptyResponse(http.StatusNoContent)
+}
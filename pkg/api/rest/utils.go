@@ -1,9 +1,16 @@
 package rest
 
 import (
-	"github.com/IpsoVeritas/crypto"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/IpsoVeritas/document"
 	httphandler "github.com/IpsoVeritas/httphandler"
-	jose "gopkg.in/square/go-jose.v1"
+	logger "github.com/IpsoVeritas/logger"
+	"github.com/IpsoVeritas/realm/pkg/services"
+	"github.com/pkg/errors"
 )
 
 func hasMandateForRealm(mandates []httphandler.AuthenticatedMandate, realmID string) bool {
@@ -16,18 +23,89 @@ func hasMandateForRealm(mandates []httphandler.AuthenticatedMandate, realmID str
 	return false
 }
 
-func validSig(keyset *jose.JsonWebKeySet, sig *jose.JsonWebSignature) bool {
-	for _, key := range keyset.Keys {
-		pkey, err := crypto.NewPublicKey(&key)
-		if err == nil {
-			var count = 0
-			count, _, _, err = sig.VerifyMulti(pkey.Key)
-			if err == nil {
-				if count >= 0 {
-					return true
-				}
+// audit records an administrative operation in the audit log of the realm.
+// The operation has already been performed, so a failure is only logged.
+func audit(req httphandler.AuthenticatedRequest, context *services.RealmService, entityType, entityID, operation string, before, after interface{}) {
+	actor := context.AuditActor(req.Key(), req.Mandates())
+	if err := context.Audit().Record(actor, entityType, entityID, operation, before, after); err != nil {
+		logger.Warningf("Failed to write audit entry for %s %s %s: %s", operation, entityType, entityID, err)
+	}
+}
+
+// linkResponse is a URLResponse that also holds the link for opening the URL in
+// the wallet app of the realm.
+type linkResponse struct {
+	document.URLResponse
+	Link string `json:"link,omitempty"`
+}
+
+// qrSize returns the QR code size requested with the size query parameter.
+func qrSize(req httphandler.Request) int {
+	size, err := strconv.Atoi(req.OriginalRequest().URL.Query().Get("size"))
+	if err != nil || size < 64 || size > 1024 {
+		return services.QRCodeSize
+	}
+
+	return size
+}
+
+// ParseTrustedProxies parses a comma separated list of the IP addresses or CIDR
+// networks of the reverse proxies in front of the realm.
+func ParseTrustedProxies(s string) ([]*net.IPNet, error) {
+	proxies := make([]*net.IPNet, 0)
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if !strings.Contains(p, "/") {
+			if ip := net.ParseIP(p); ip != nil && ip.To4() != nil {
+				p += "/32"
+			} else {
+				p += "/128"
 			}
 		}
+		_, network, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid trusted proxy %s", p)
+		}
+		proxies = append(proxies, network)
 	}
+
+	return proxies, nil
+}
+
+// clientAddr returns the IP address of the client of a request. Behind trusted
+// proxies it's the last address of the X-Forwarded-For header that isn't a
+// trusted proxy, since the addresses before it can be set by the client.
+func clientAddr(r *http.Request, trustedProxies []*net.IPNet) string {
+	addr, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		addr = r.RemoteAddr
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header["X-Forwarded-For"], ","), ",")
+	for i := len(forwarded) - 1; i >= 0 && trusted(addr, trustedProxies); i-- {
+		ip := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if ip == nil {
+			break
+		}
+		addr = ip.String()
+	}
+
+	return addr
+}
+
+func trusted(addr string, trustedProxies []*net.IPNet) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
 	return false
 }
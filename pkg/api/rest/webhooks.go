@@ -0,0 +1,195 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	httphandler "github.com/IpsoVeritas/httphandler"
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/IpsoVeritas/realm/pkg/services"
+	"github.com/pkg/errors"
+)
+
+type WebhooksController struct {
+	contextProvider *services.RealmsServiceProvider
+}
+
+func NewWebhooksController(contextProvider *services.RealmsServiceProvider) *WebhooksController {
+	return &WebhooksController{
+		contextProvider: contextProvider,
+	}
+}
+
+func (c *WebhooksController) List(req httphandler.AuthenticatedRequest) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	if !context.HasPermission(req.Mandates(), realm.PermissionWebhooksRead) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionWebhooksRead))
+	}
+
+	webhooks, err := context.Webhooks().List()
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to list webhooks"))
+	}
+
+	return httphandler.NewJsonResponse(http.StatusOK, webhooks)
+}
+
+func (c *WebhooksController) Get(req httphandler.AuthenticatedRequest) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	if !context.HasPermission(req.Mandates(), realm.PermissionWebhooksRead) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionWebhooksRead))
+	}
+
+	webhookID := req.Params().ByName("webhookID")
+	if webhookID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify webhook ID"))
+	}
+
+	webhook, err := context.Webhooks().Get(webhookID)
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusNotFound, errors.Wrap(err, "failed to get webhook"))
+	}
+
+	return httphandler.NewJsonResponse(http.StatusOK, webhook)
+}
+
+func (c *WebhooksController) Set(req httphandler.AuthenticatedRequest) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	if !context.HasPermission(req.Mandates(), realm.PermissionWebhooksWrite) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionWebhooksWrite))
+	}
+
+	body, err := req.Body()
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to read request body"))
+	}
+
+	webhook := &realm.Webhook{}
+	if err := json.Unmarshal(body, &webhook); err != nil {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrap(err, "failed to unmarshal webhook"))
+	}
+
+	action := "create"
+	webhookID := req.Params().ByName("webhookID")
+	if webhookID != "" {
+		if webhookID != webhook.ID {
+			return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("tried to update webhook with other ID than in payload"))
+		}
+		action = "update"
+	}
+
+	var before *realm.Webhook
+	if webhook.ID != "" {
+		before, _ = context.Webhooks().Get(webhook.ID)
+	}
+
+	if err := context.Webhooks().Set(webhook); err != nil {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.Wrapf(err, "failed to %s webhook", action))
+	}
+
+	audit(req, context, "webhook", webhook.ID, action, before, webhook)
+
+	return httphandler.NewJsonResponse(http.StatusOK, webhook)
+}
+
+func (c *WebhooksController) Delete(req httphandler.AuthenticatedRequest) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	if !context.HasPermission(req.Mandates(), realm.PermissionWebhooksWrite) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionWebhooksWrite))
+	}
+
+	webhookID := req.Params().ByName("webhookID")
+	if webhookID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify webhook ID"))
+	}
+
+	before, _ := context.Webhooks().Get(webhookID)
+
+	if err := context.Webhooks().Delete(webhookID); err != nil {
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to delete webhook"))
+	}
+
+	audit(req, context, "webhook", webhookID, realm.AuditDelete, before, nil)
+
+	return httphandler.NewEmptyResponse(http.StatusNoContent)
+}
+
+func (c *WebhooksController) Deliveries(req httphandler.AuthenticatedRequest) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	if !context.HasPermission(req.Mandates(), realm.PermissionWebhooksRead) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionWebhooksRead))
+	}
+
+	webhookID := req.Params().ByName("webhookID")
+	if webhookID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify webhook ID"))
+	}
+
+	deliveries, err := context.Webhooks().Deliveries(webhookID)
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to list deliveries"))
+	}
+
+	return httphandler.NewJsonResponse(http.StatusOK, deliveries)
+}
+
+func (c *WebhooksController) Redeliver(req httphandler.AuthenticatedRequest) httphandler.Response {
+	realmID := req.Params().ByName("realmID")
+	if realmID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify realm"))
+	}
+
+	context := c.contextProvider.Get(realmID)
+
+	if !context.HasPermission(req.Mandates(), realm.PermissionWebhooksWrite) {
+		return httphandler.NewErrorResponse(http.StatusForbidden, errors.New("Missing permission "+realm.PermissionWebhooksWrite))
+	}
+
+	webhookID := req.Params().ByName("webhookID")
+	deliveryID := req.Params().ByName("deliveryID")
+	if webhookID == "" || deliveryID == "" {
+		return httphandler.NewErrorResponse(http.StatusBadRequest, errors.New("Need to specify webhook and delivery ID"))
+	}
+
+	delivery, err := context.Webhooks().Delivery(deliveryID)
+	if err != nil || delivery.WebhookID != webhookID {
+		return httphandler.NewErrorResponse(http.StatusNotFound, errors.New("Delivery not found"))
+	}
+
+	delivery, err = context.Webhooks().Redeliver(deliveryID)
+	if err != nil {
+		return httphandler.NewErrorResponse(http.StatusInternalServerError, errors.Wrap(err, "failed to redeliver"))
+	}
+
+	return httphandler.NewJsonResponse(http.StatusAccepted, delivery)
+}
@@ -0,0 +1,184 @@
+// Package client is a Go client for the /realm/v2 API of a realm server, as
+// described by its OpenAPI document. Requests are authenticated with mandates
+// set with SetMandates. The endpoints that wallets use to join realms, accept
+// invites and redeem tickets are not covered.
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/IpsoVeritas/crypto"
+	"github.com/IpsoVeritas/document"
+	"github.com/pkg/errors"
+	resty "gopkg.in/resty.v1"
+	jose "gopkg.in/square/go-jose.v1"
+)
+
+// DefaultTokenTTL is how long, in seconds, the mandate token of a request is valid.
+const DefaultTokenTTL = 60
+
+// Error is returned for responses with an error status.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// IsNotFound returns true if err is an error response with status 404.
+func IsNotFound(err error) bool {
+	e, ok := errors.Cause(err).(*Error)
+	return ok && e.StatusCode == http.StatusNotFound
+}
+
+type Client struct {
+	base     string
+	client   *resty.Client
+	key      *jose.JsonWebKey
+	mandates []string
+	ttl      int
+}
+
+// New returns a client for the realm server at base, like https://realm.example.com.
+func New(base string) *Client {
+	return &Client{
+		base:   strings.TrimSuffix(base, "/"),
+		client: resty.New().SetTimeout(30 * time.Second),
+		ttl:    DefaultTokenTTL,
+	}
+}
+
+// SetMandates makes the client authenticate its requests with the signed
+// mandates, which must have been issued to the key.
+func (c *Client) SetMandates(key *jose.JsonWebKey, mandates []string) {
+	c.key = key
+	c.mandates = mandates
+}
+
+// SetTokenTTL sets how long, in seconds, the mandate token of a request is valid.
+func (c *Client) SetTokenTTL(ttl int) {
+	c.ttl = ttl
+}
+
+// SetTimeout sets the timeout of requests.
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.client.SetTimeout(timeout)
+}
+
+// upload is a file sent as a multipart form.
+type upload struct {
+	name   string
+	reader io.Reader
+}
+
+// request sends a request and returns the response body. A body that isn't a
+// []byte, an upload or nil is sent as JSON.
+func (c *Client) request(method, path string, query url.Values, header map[string]string, body interface{}) ([]byte, error) {
+	uri := c.base + path
+	if len(query) > 0 {
+		uri += "?" + query.Encode()
+	}
+
+	req := c.client.R()
+
+	if c.key != nil {
+		token, err := c.token(uri)
+		if err != nil {
+			return nil, err
+		}
+		req.SetHeader("Authorization", "Mandate "+token)
+	}
+
+	for k, v := range header {
+		req.SetHeader(k, v)
+	}
+
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		req.SetBody(b)
+	case *upload:
+		req.SetFileReader("file", b.name, b.reader)
+	default:
+		bytes, err := json.Marshal(b)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to marshal request body")
+		}
+		req.SetHeader("Content-Type", "application/json")
+		req.SetBody(bytes)
+	}
+
+	res, err := req.Execute(method, uri)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to %s %s", method, path)
+	}
+
+	if res.StatusCode() >= 400 {
+		return nil, &Error{
+			StatusCode: res.StatusCode(),
+			Message:    strings.TrimSpace(res.String()),
+		}
+	}
+
+	return res.Body(), nil
+}
+
+// do sends a request and unmarshals the JSON response into result, unless it's nil.
+func (c *Client) do(method, path string, query url.Values, body, result interface{}) error {
+	data, err := c.request(method, path, query, nil, body)
+	if err != nil {
+		return err
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return errors.Wrapf(unmarshal(data, result), "failed to read response of %s %s", method, path)
+}
+
+func unmarshal(data []byte, result interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	return errors.Wrap(json.Unmarshal(data, result), "failed to unmarshal response")
+}
+
+// token returns a compact JWS of a mandate token for a request to uri.
+func (c *Client) token(uri string) (string, error) {
+	payload, err := json.Marshal(document.NewMandateToken(c.mandates, uri, c.ttl))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal mandate token")
+	}
+
+	signer, err := crypto.NewSigner(c.key)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create signer")
+	}
+
+	jws, err := signer.Sign(payload)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to sign mandate token")
+	}
+
+	return jws.CompactSerialize()
+}
+
+// realmPath returns the path of a realm, followed by the escaped elements.
+func realmPath(realmID string, elements ...string) string {
+	path := "/realm/v2/realms/" + url.PathEscape(realmID)
+	for _, e := range elements {
+		path += "/" + url.PathEscape(e)
+	}
+
+	return path
+}
@@ -0,0 +1,303 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IpsoVeritas/document"
+	realm "github.com/IpsoVeritas/realm"
+	jose "gopkg.in/square/go-jose.v1"
+)
+
+// request is a request received by the test server.
+type request struct {
+	method        string
+	uri           string
+	authorization string
+	contentType   string
+	body          string
+}
+
+// newServer returns a server that records the requests it gets and answers
+// them with the status and body.
+func newServer(t *testing.T, status int, body string) (*httptest.Server, *[]request) {
+	requests := make([]request, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		requests = append(requests, request{
+			method:        r.Method,
+			uri:           r.URL.RequestURI(),
+			authorization: r.Header.Get("Authorization"),
+			contentType:   r.Header.Get("Content-Type"),
+			body:          string(b),
+		})
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
+
+func newKey(t *testing.T) *jose.JsonWebKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &jose.JsonWebKey{Key: key, Algorithm: "ES256"}
+}
+
+// mandateToken returns the mandate token in the Authorization header.
+func mandateToken(t *testing.T, authorization string) *document.MandateToken {
+	if !strings.HasPrefix(authorization, "Mandate ") {
+		t.Fatalf("Authorization = %q, want a Mandate token", authorization)
+	}
+
+	parts := strings.Split(strings.TrimPrefix(authorization, "Mandate "), ".")
+	if len(parts) != 3 {
+		t.Fatalf("Authorization = %q, want a compact JWS", authorization)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := &document.MandateToken{}
+	if err := json.Unmarshal(payload, token); err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
+func TestClient_requests(t *testing.T) {
+	tests := []struct {
+		name     string
+		call     func(*Client) error
+		status   int
+		response string
+		method   string
+		uri      string
+		body     string
+		wantErr  bool
+	}{
+		{
+			name:     "ListRealms",
+			call:     func(c *Client) error { _, err := c.ListRealms(); return err },
+			status:   http.StatusOK,
+			response: `[{"id":"test.realm"}]`,
+			method:   http.MethodGet,
+			uri:      "/realm/v2/realms",
+		},
+		{
+			name:     "ListDeletedRealms",
+			call:     func(c *Client) error { _, err := c.ListDeletedRealms(); return err },
+			status:   http.StatusOK,
+			response: `[]`,
+			method:   http.MethodGet,
+			uri:      "/realm/v2/realms?deleted=true",
+		},
+		{
+			name:     "GetRealm_escaped",
+			call:     func(c *Client) error { _, err := c.GetRealm("test/realm"); return err },
+			status:   http.StatusOK,
+			response: `{"id":"test/realm"}`,
+			method:   http.MethodGet,
+			uri:      "/realm/v2/realms/test%2Frealm",
+		},
+		{
+			name:   "DeleteRealm_purge",
+			call:   func(c *Client) error { return c.DeleteRealm("test.realm", true) },
+			status: http.StatusNoContent,
+			method: http.MethodDelete,
+			uri:    "/realm/v2/realms/test.realm?purge=true",
+		},
+		{
+			name:     "RotateKey",
+			call:     func(c *Client) error { _, err := c.RotateKey("test.realm", 72*time.Hour); return err },
+			status:   http.StatusOK,
+			response: `{"id":"test.realm"}`,
+			method:   http.MethodPost,
+			uri:      "/realm/v2/realms/test.realm/key/rotate?grace=72h0m0s",
+		},
+		{
+			name: "SetInvite",
+			call: func(c *Client) error {
+				_, err := c.SetInvite("test.realm", &realm.Invite{Role: "member@test.realm"})
+				return err
+			},
+			status:   http.StatusCreated,
+			response: `{"id":"abc"}`,
+			method:   http.MethodPost,
+			uri:      "/realm/v2/realms/test.realm/invites",
+			body:     `"role":"member@test.realm"`,
+		},
+		{
+			name: "ImportInvites",
+			call: func(c *Client) error {
+				_, err := c.ImportInvites("test.realm", []*realm.InviteImportRow{{Role: "member@test.realm"}}, false, true)
+				return err
+			},
+			status:   http.StatusOK,
+			response: `[{"row":1,"status":"valid"}]`,
+			method:   http.MethodPost,
+			uri:      "/realm/v2/realms/test.realm/invites/import?dryRun=true",
+			body:     `"role":"member@test.realm"`,
+		},
+		{
+			name:     "InviteQRCode",
+			call:     func(c *Client) error { _, err := c.InviteQRCode("test.realm", "abc", 128); return err },
+			status:   http.StatusOK,
+			response: "png",
+			method:   http.MethodPost,
+			uri:      "/realm/v2/realms/test.realm/invites/id/abc/qr?size=128",
+		},
+		{
+			name:     "RotateInviteQRCode",
+			call:     func(c *Client) error { _, err := c.RotateInviteQRCode("test.realm", "abc", 0); return err },
+			status:   http.StatusOK,
+			response: "png",
+			method:   http.MethodPost,
+			uri:      "/realm/v2/realms/test.realm/invites/id/abc/qr/rotate",
+		},
+		{
+			name:     "InviteTemplates_locale",
+			call:     func(c *Client) error { _, err := c.InviteTemplates("test.realm", "sv"); return err },
+			status:   http.StatusOK,
+			response: `{}`,
+			method:   http.MethodGet,
+			uri:      "/realm/v2/realms/test.realm/templates/invite?locale=sv",
+		},
+		{
+			name: "AddInviteAttachment",
+			call: func(c *Client) error {
+				_, err := c.AddInviteAttachment("test.realm", "logo.png", strings.NewReader("png"))
+				return err
+			},
+			status:   http.StatusOK,
+			response: `{}`,
+			method:   http.MethodPost,
+			uri:      "/realm/v2/realms/test.realm/templates/invite/attachments",
+			body:     `filename="logo.png"`,
+		},
+		{
+			name:     "GetRole_not_found",
+			call:     func(c *Client) error { _, err := c.GetRole("test.realm", "abc"); return err },
+			status:   http.StatusNotFound,
+			response: "role not found",
+			method:   http.MethodGet,
+			uri:      "/realm/v2/realms/test.realm/roles/abc",
+			wantErr:  true,
+		},
+		{
+			name:     "ListRoles_bad_response",
+			call:     func(c *Client) error { _, err := c.ListRoles("test.realm"); return err },
+			status:   http.StatusOK,
+			response: "not json",
+			method:   http.MethodGet,
+			uri:      "/realm/v2/realms/test.realm/roles",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		server, requests := newServer(t, tt.status, tt.response)
+		mandates := []string{"signed-mandate"}
+		c := New(server.URL + "/")
+		c.SetMandates(newKey(t), mandates)
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call(c)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Client.%s() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if len(*requests) != 1 {
+				t.Fatalf("Client.%s() sent %d requests, want 1", tt.name, len(*requests))
+			}
+
+			got := (*requests)[0]
+			if got.method != tt.method || got.uri != tt.uri {
+				t.Errorf("Client.%s() = %s %s, want %s %s", tt.name, got.method, got.uri, tt.method, tt.uri)
+			}
+			if !strings.Contains(got.body, tt.body) {
+				t.Errorf("Client.%s() body = %q, want %q in it", tt.name, got.body, tt.body)
+			}
+
+			token := mandateToken(t, got.authorization)
+			if token.URI != server.URL+tt.uri {
+				t.Errorf("Client.%s() token URI = %s, want %s", tt.name, token.URI, server.URL+tt.uri)
+			}
+			if len(token.Mandates) != 1 || token.Mandates[0] != mandates[0] {
+				t.Errorf("Client.%s() token mandates = %v, want %v", tt.name, token.Mandates, mandates)
+			}
+			if token.TTL != DefaultTokenTTL {
+				t.Errorf("Client.%s() token TTL = %d, want %d", tt.name, token.TTL, DefaultTokenTTL)
+			}
+		})
+	}
+}
+
+func TestClient_without_mandates(t *testing.T) {
+	server, requests := newServer(t, http.StatusOK, `[]`)
+	c := New(server.URL)
+
+	if _, err := c.ListRealms(); err != nil {
+		t.Fatal(err)
+	}
+	if len(*requests) != 1 || (*requests)[0].authorization != "" {
+		t.Errorf("Client.ListRealms() = %+v, want one request without Authorization", *requests)
+	}
+}
+
+func TestClient_error(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		wantNotFound bool
+	}{
+		{name: "error_not_found", status: http.StatusNotFound, wantNotFound: true},
+		{name: "error_forbidden", status: http.StatusForbidden, wantNotFound: false},
+		{name: "error_internal", status: http.StatusInternalServerError, wantNotFound: false},
+	}
+	for _, tt := range tests {
+		server, _ := newServer(t, tt.status, "failed\n")
+		c := New(server.URL)
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := c.GetRealm("test.realm")
+			e, ok := err.(*Error)
+			if !ok {
+				t.Fatalf("Client.GetRealm() error = %v, want *Error", err)
+			}
+			if e.StatusCode != tt.status || e.Message != "failed" {
+				t.Errorf("Client.GetRealm() error = %d %q, want %d %q", e.StatusCode, e.Message, tt.status, "failed")
+			}
+			if IsNotFound(err) != tt.wantNotFound {
+				t.Errorf("IsNotFound() = %v, want %v", IsNotFound(err), tt.wantNotFound)
+			}
+		})
+	}
+}
+
+func TestClient_ImportInvites_invalid(t *testing.T) {
+	server, _ := newServer(t, http.StatusUnprocessableEntity, `[{"row":1,"status":"invalid","error":"unknown role"}]`)
+	c := New(server.URL)
+
+	results, err := c.ImportInvites("test.realm", []*realm.InviteImportRow{{Role: "other@test.realm"}}, false, false)
+	if e, ok := err.(*Error); !ok || e.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("Client.ImportInvites() error = %v, want status %d", err, http.StatusUnprocessableEntity)
+	}
+	if len(results) != 1 || results[0].Status != realm.InviteImportInvalid {
+		t.Errorf("Client.ImportInvites() = %+v, want the invalid row", results)
+	}
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"net/http"
+
+	"github.com/IpsoVeritas/document"
+	realm "github.com/IpsoVeritas/realm"
+	jose "gopkg.in/square/go-jose.v1"
+)
+
+func (c *Client) ListControllers(realmID string) ([]*realm.Controller, error) {
+	controllers := make([]*realm.Controller, 0)
+	return controllers, c.do(http.MethodGet, realmPath(realmID, "controllers"), nil, nil, &controllers)
+}
+
+func (c *Client) GetController(realmID, controllerID string) (*realm.Controller, error) {
+	controller := &realm.Controller{}
+	return controller, c.do(http.MethodGet, realmPath(realmID, "controllers", "id", controllerID), nil, nil, controller)
+}
+
+// SetController creates the controller, or updates it if it has an ID.
+func (c *Client) SetController(realmID string, controller *realm.Controller) (*realm.Controller, error) {
+	method, path := http.MethodPost, realmPath(realmID, "controllers")
+	if controller.ID != "" {
+		method, path = http.MethodPut, realmPath(realmID, "controllers", "id", controller.ID)
+	}
+
+	result := &realm.Controller{}
+	return result, c.do(method, path, nil, controller, result)
+}
+
+func (c *Client) DeleteController(realmID, controllerID string) error {
+	return c.do(http.MethodDelete, realmPath(realmID, "controllers", "id", controllerID), nil, nil, nil)
+}
+
+// BindController binds the controller to the realm, and returns the signed
+// controller binding to send to the controller.
+func (c *Client) BindController(realmID string, controller *realm.Controller) ([]byte, error) {
+	return c.request(http.MethodPost, realmPath(realmID, "controllers", "bind"), nil, nil, controller)
+}
+
+// UpdateControllerActions replaces the actions of the controller with the action
+// descriptors in the multipart, which must be signed by the controller.
+func (c *Client) UpdateControllerActions(realmID, controllerID string, actions *document.Multipart) error {
+	return c.do(http.MethodPost, realmPath(realmID, "controllers", "id", controllerID, "actions"), nil, actions, nil)
+}
+
+// Services returns the action descriptors available to the mandates of the client.
+func (c *Client) Services(realmID string) (*document.Multipart, error) {
+	mp := &document.Multipart{}
+	return mp, c.do(http.MethodGet, realmPath(realmID, "services"), nil, nil, mp)
+}
+
+func (c *Client) ListIssuers(realmID string) ([]jose.JsonWebKey, error) {
+	set := &jose.JsonWebKeySet{}
+	err := c.do(http.MethodGet, realmPath(realmID, "issuers"), nil, nil, set)
+	return set.Keys, err
+}
+
+// GetIssuer returns the trusted issuer with the thumbprint.
+func (c *Client) GetIssuer(realmID, issuerID string) (*jose.JsonWebKey, error) {
+	key := &jose.JsonWebKey{}
+	return key, c.do(http.MethodGet, realmPath(realmID, "issuers", issuerID), nil, nil, key)
+}
+
+func (c *Client) AddIssuer(realmID string, key *jose.JsonWebKey) (*jose.JsonWebKey, error) {
+	added := &jose.JsonWebKey{}
+	return added, c.do(http.MethodPost, realmPath(realmID, "issuers"), nil, key, added)
+}
+
+func (c *Client) DeleteIssuer(realmID, issuerID string) error {
+	return c.do(http.MethodDelete, realmPath(realmID, "issuers", issuerID), nil, nil, nil)
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"net/http"
+	"net/url"
+
+	realm "github.com/IpsoVeritas/realm"
+)
+
+func (c *Client) ListInvites(realmID string) ([]*realm.Invite, error) {
+	invites := make([]*realm.Invite, 0)
+	return invites, c.do(http.MethodGet, realmPath(realmID, "invites"), nil, nil, &invites)
+}
+
+func (c *Client) ListInvitesForRole(realmID, role string) ([]*realm.Invite, error) {
+	invites := make([]*realm.Invite, 0)
+	return invites, c.do(http.MethodGet, realmPath(realmID, "invites", "role", role), nil, nil, &invites)
+}
+
+func (c *Client) GetInvite(realmID, inviteID string) (*realm.Invite, error) {
+	invite := &realm.Invite{}
+	return invite, c.do(http.MethodGet, realmPath(realmID, "invites", "id", inviteID), nil, nil, invite)
+}
+
+// SetInvite creates the invite, or updates it if it has an ID.
+func (c *Client) SetInvite(realmID string, invite *realm.Invite) (*realm.Invite, error) {
+	method, path := http.MethodPost, realmPath(realmID, "invites")
+	if invite.ID != "" {
+		method, path = http.MethodPut, realmPath(realmID, "invites", "id", invite.ID)
+	}
+
+	result := &realm.Invite{}
+	return result, c.do(method, path, nil, invite, result)
+}
+
+func (c *Client) DeleteInvite(realmID, inviteID string) error {
+	return c.do(http.MethodDelete, realmPath(realmID, "invites", "id", inviteID), nil, nil, nil)
+}
+
+func (c *Client) CancelInvite(realmID, inviteID string) (*realm.Invite, error) {
+	invite := &realm.Invite{}
+	return invite, c.do(http.MethodPut, realmPath(realmID, "invites", "id", inviteID, "cancel"), nil, nil, invite)
+}
+
+// SendInvite sends the invite. A message that couldn't be sent right away is
+// queued for retry, and has the realm.OutboxPending status.
+func (c *Client) SendInvite(realmID, inviteID string) (*realm.OutboxMessage, error) {
+	msg := &realm.OutboxMessage{}
+	return msg, c.do(http.MethodPut, realmPath(realmID, "invites", "id", inviteID, "send"), nil, nil, msg)
+}
+
+// InviteQRCode returns a PNG image of a QR code with the link of an invite that
+// has no link yet. An invite that was already sent gives an *Error with status 409.
+// A size of zero gives the default size.
+func (c *Client) InviteQRCode(realmID, inviteID string, size int) ([]byte, error) {
+	return c.request(http.MethodPost, realmPath(realmID, "invites", "id", inviteID, "qr"), sizeQuery(size), nil, nil)
+}
+
+// RotateInviteQRCode returns a PNG image of a QR code with a new link for the invite.
+// Links sent to the recipient before stop working. A size of zero gives the default size.
+func (c *Client) RotateInviteQRCode(realmID, inviteID string, size int) ([]byte, error) {
+	return c.request(http.MethodPost, realmPath(realmID, "invites", "id", inviteID, "qr", "rotate"), sizeQuery(size), nil, nil)
+}
+
+func (c *Client) InviteDeliveries(realmID, inviteID string) ([]*realm.OutboxMessage, error) {
+	deliveries := make([]*realm.OutboxMessage, 0)
+	return deliveries, c.do(http.MethodGet, realmPath(realmID, "invites", "id", inviteID, "deliveries"), nil, nil, &deliveries)
+}
+
+func (c *Client) RedeliverInvite(realmID, inviteID, deliveryID string) (*realm.OutboxMessage, error) {
+	msg := &realm.OutboxMessage{}
+	return msg, c.do(http.MethodPost, realmPath(realmID, "invites", "id", inviteID, "deliveries", deliveryID, "redeliver"), nil, nil, msg)
+}
+
+// ImportInvites creates invites in bulk. With send the invites are also sent,
+// and with dryRun the rows are only validated. If some rows are invalid no
+// invite is created, and the results are returned with an *Error with status 422.
+func (c *Client) ImportInvites(realmID string, rows []*realm.InviteImportRow, send, dryRun bool) ([]*realm.InviteImportResult, error) {
+	query := url.Values{}
+	if send {
+		query.Set("send", "true")
+	}
+	if dryRun {
+		query.Set("dryRun", "true")
+	}
+
+	results := make([]*realm.InviteImportResult, 0)
+	err := c.do(http.MethodPost, realmPath(realmID, "invites", "import"), query, rows, &results)
+	if e, ok := err.(*Error); ok && e.StatusCode == http.StatusUnprocessableEntity {
+		unmarshal([]byte(e.Message), &results)
+	}
+
+	return results, err
+}
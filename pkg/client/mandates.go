@@ -0,0 +1,90 @@
+package client
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/IpsoVeritas/document"
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/IpsoVeritas/realm/pkg/api/openapi"
+)
+
+func (c *Client) ListMandates(realmID string) ([]*realm.IssuedMandate, error) {
+	mandates := make([]*realm.IssuedMandate, 0)
+	return mandates, c.do(http.MethodGet, realmPath(realmID, "mandates"), nil, nil, &mandates)
+}
+
+func (c *Client) ListMandatesForRole(realmID, role string) ([]*realm.IssuedMandate, error) {
+	mandates := make([]*realm.IssuedMandate, 0)
+	return mandates, c.do(http.MethodGet, realmPath(realmID, "mandates", "role", role), nil, nil, &mandates)
+}
+
+func (c *Client) GetMandate(realmID, mandateID string) (*realm.IssuedMandate, error) {
+	mandate := &realm.IssuedMandate{}
+	return mandate, c.do(http.MethodGet, realmPath(realmID, "mandate", mandateID), nil, nil, mandate)
+}
+
+func (c *Client) RevokeMandate(realmID, mandateID string) (*realm.IssuedMandate, error) {
+	mandate := &realm.IssuedMandate{}
+	return mandate, c.do(http.MethodPut, realmPath(realmID, "mandates", mandateID, "revoke"), nil, nil, mandate)
+}
+
+// IssueMandate issues the mandate to its recipient key.
+func (c *Client) IssueMandate(realmID string, mandate *document.Mandate) (*realm.IssuedMandate, error) {
+	issued := &realm.IssuedMandate{}
+	return issued, c.do(http.MethodPost, realmPath(realmID, "mandates", "issue"), nil, mandate, issued)
+}
+
+// ReissueMandates signs the active mandates of the realm again with the current
+// realm key, and returns how many were re-issued.
+func (c *Client) ReissueMandates(realmID string) (int, error) {
+	result := &openapi.Reissued{}
+	err := c.do(http.MethodPost, realmPath(realmID, "mandates", "reissue"), nil, nil, result)
+	return result.Reissued, err
+}
+
+func (c *Client) ListTickets(realmID string) ([]*realm.MandateTicket, error) {
+	tickets := make([]*realm.MandateTicket, 0)
+	return tickets, c.do(http.MethodGet, realmPath(realmID, "tickets"), nil, nil, &tickets)
+}
+
+func (c *Client) GetTicket(realmID, ticketID string) (*realm.MandateTicket, error) {
+	ticket := &realm.MandateTicket{}
+	return ticket, c.do(http.MethodGet, realmPath(realmID, "tickets", ticketID), nil, nil, ticket)
+}
+
+// SetTicket creates the ticket, or updates it if it has an ID.
+func (c *Client) SetTicket(realmID string, ticket *realm.MandateTicket) (*realm.MandateTicket, error) {
+	method, path := http.MethodPost, realmPath(realmID, "tickets")
+	if ticket.ID != "" {
+		method, path = http.MethodPut, realmPath(realmID, "tickets", ticket.ID)
+	}
+
+	result := &realm.MandateTicket{}
+	return result, c.do(method, path, nil, ticket, result)
+}
+
+func (c *Client) DeleteTicket(realmID, ticketID string) error {
+	return c.do(http.MethodDelete, realmPath(realmID, "tickets", ticketID), nil, nil, nil)
+}
+
+// TicketURL returns the URL of the ticket and the link that opens it in the wallet app.
+func (c *Client) TicketURL(realmID, ticketID string) (*openapi.LinkResponse, error) {
+	link := &openapi.LinkResponse{}
+	return link, c.do(http.MethodGet, realmPath(realmID, "tickets", ticketID, "url"), nil, nil, link)
+}
+
+// TicketQRCode returns a PNG image of a QR code of the ticket URL. A size of
+// zero gives the default size.
+func (c *Client) TicketQRCode(realmID, ticketID string, size int) ([]byte, error) {
+	return c.request(http.MethodGet, realmPath(realmID, "tickets", ticketID, "qr"), sizeQuery(size), nil, nil)
+}
+
+func sizeQuery(size int) url.Values {
+	if size == 0 {
+		return nil
+	}
+
+	return url.Values{"size": {strconv.Itoa(size)}}
+}
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/IpsoVeritas/realm/pkg/api/openapi"
+)
+
+// ListRealms returns the realms of the mandates, or all realms with a mandate
+// for the bootstrap realm.
+func (c *Client) ListRealms() ([]*realm.Realm, error) {
+	realms := make([]*realm.Realm, 0)
+	return realms, c.do(http.MethodGet, "/realm/v2/realms", nil, nil, &realms)
+}
+
+// ListDeletedRealms returns the realms that are deleted but not yet purged.
+func (c *Client) ListDeletedRealms() ([]*realm.Realm, error) {
+	realms := make([]*realm.Realm, 0)
+	return realms, c.do(http.MethodGet, "/realm/v2/realms", boolQuery("deleted", true), nil, &realms)
+}
+
+func (c *Client) CreateRealm(r *realm.Realm) (*realm.Realm, error) {
+	created := &realm.Realm{}
+	return created, c.do(http.MethodPost, "/realm/v2/realms", nil, r, created)
+}
+
+func (c *Client) GetRealm(realmID string) (*realm.Realm, error) {
+	r := &realm.Realm{}
+	return r, c.do(http.MethodGet, realmPath(realmID), nil, nil, r)
+}
+
+func (c *Client) UpdateRealm(r *realm.Realm) (*realm.Realm, error) {
+	updated := &realm.Realm{}
+	return updated, c.do(http.MethodPut, realmPath(r.ID), nil, r, updated)
+}
+
+// DeleteRealm deletes the realm, which is purged after the retention period.
+// With purge it's purged right away.
+func (c *Client) DeleteRealm(realmID string, purge bool) error {
+	return c.do(http.MethodDelete, realmPath(realmID), boolQuery("purge", purge), nil, nil)
+}
+
+func (c *Client) RestoreRealm(realmID string) (*realm.Realm, error) {
+	r := &realm.Realm{}
+	return r, c.do(http.MethodPost, realmPath(realmID, "restore"), nil, nil, r)
+}
+
+// RotateKey creates a new key for the realm. Mandates signed by the previous key
+// are accepted for the grace period, or the default one if it's zero.
+func (c *Client) RotateKey(realmID string, grace time.Duration) (*realm.Realm, error) {
+	var query url.Values
+	if grace > 0 {
+		query = url.Values{"grace": {grace.String()}}
+	}
+
+	r := &realm.Realm{}
+	return r, c.do(http.MethodPost, realmPath(realmID, "key", "rotate"), query, nil, r)
+}
+
+// SetIcon uploads the icon of the realm. The file name must have an extension.
+func (c *Client) SetIcon(realmID, name string, file io.Reader) error {
+	return c.do(http.MethodPost, realmPath(realmID, "icon"), nil, &upload{name: name, reader: file}, nil)
+}
+
+// SetBanner uploads the banner of the realm. The file name must have an extension.
+func (c *Client) SetBanner(realmID, name string, file io.Reader) error {
+	return c.do(http.MethodPost, realmPath(realmID, "banner"), nil, &upload{name: name, reader: file}, nil)
+}
+
+// Export returns a signed backup of the realm. The realm keys are included,
+// encrypted with the passphrase, if it's not empty.
+func (c *Client) Export(realmID, passphrase string) ([]byte, error) {
+	header := make(map[string]string)
+	if passphrase != "" {
+		header["X-Backup-Passphrase"] = passphrase
+	}
+
+	archive, err := c.request(http.MethodPost, realmPath(realmID, "export"), nil, header, nil)
+	return archive, err
+}
+
+// Import restores a realm from a backup, where conflict is one of the
+// realm.BackupConflict policies for existing entities.
+func (c *Client) Import(realmID string, archive []byte, passphrase, conflict string) (*realm.BackupImport, error) {
+	header := map[string]string{"Content-Type": "application/gzip"}
+	if passphrase != "" {
+		header["X-Backup-Passphrase"] = passphrase
+	}
+
+	data, err := c.request(http.MethodPost, realmPath(realmID, "import"), url.Values{"conflict": {conflict}}, header, archive)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &realm.BackupImport{}
+	return result, unmarshal(data, result)
+}
+
+// Authenticate returns the permissions that the mandates of the client have in the realm.
+func (c *Client) Authenticate(realmID string) (*openapi.AuthResponse, error) {
+	auth := &openapi.AuthResponse{}
+	return auth, c.do(http.MethodGet, realmPath(realmID, "auth"), nil, nil, auth)
+}
+
+func (c *Client) Config(realmID string) (*realm.RealmConfig, error) {
+	cfg := &realm.RealmConfig{}
+	return cfg, c.do(http.MethodGet, realmPath(realmID, "config"), nil, nil, cfg)
+}
+
+// Revocations returns the signed revocation list of the realm, with the
+// revocations after since if it's not zero.
+func (c *Client) Revocations(realmID string, since time.Time) ([]byte, error) {
+	var query url.Values
+	if !since.IsZero() {
+		query = url.Values{"since": {since.Format(time.RFC3339)}}
+	}
+
+	jws, err := c.request(http.MethodGet, realmPath(realmID, "revocations.json"), query, nil, nil)
+	return jws, err
+}
+
+// Bootstrap bootstraps the bootstrap realm with its password, and returns the
+// URL of the ticket for the admin mandate.
+func (c *Client) Bootstrap(realmID, password string) (*openapi.LinkResponse, error) {
+	data, err := c.request(http.MethodPost, realmPath(realmID, "bootstrap"), nil, map[string]string{"Content-Type": "text/plain"}, []byte(password))
+	if err != nil {
+		return nil, err
+	}
+
+	link := &openapi.LinkResponse{}
+	return link, unmarshal(data, link)
+}
+
+// ServerVersion returns the name and version of the server.
+func (c *Client) ServerVersion() (string, error) {
+	data, err := c.request(http.MethodGet, "/", nil, nil, nil)
+	return string(data), err
+}
+
+// OpenAPI returns the OpenAPI document of the server.
+func (c *Client) OpenAPI() (*openapi.Document, error) {
+	doc := &openapi.Document{}
+	return doc, c.do(http.MethodGet, "/realm/v2/openapi.json", nil, nil, doc)
+}
+
+func boolQuery(name string, value bool) url.Values {
+	if !value {
+		return nil
+	}
+
+	return url.Values{name: {strconv.FormatBool(value)}}
+}
@@ -0,0 +1,34 @@
+package client
+
+import (
+	"net/http"
+
+	realm "github.com/IpsoVeritas/realm"
+)
+
+func (c *Client) ListRoles(realmID string) ([]*realm.RoleWithPermissions, error) {
+	roles := make([]*realm.RoleWithPermissions, 0)
+	return roles, c.do(http.MethodGet, realmPath(realmID, "roles"), nil, nil, &roles)
+}
+
+func (c *Client) GetRole(realmID, roleID string) (*realm.RoleWithPermissions, error) {
+	role := &realm.RoleWithPermissions{}
+	return role, c.do(http.MethodGet, realmPath(realmID, "roles", roleID), nil, nil, role)
+}
+
+// SetRole creates the role, or updates it if it has an ID. The permissions of
+// the role are left as they are if Permissions is nil.
+func (c *Client) SetRole(realmID string, role *realm.RoleWithPermissions) (*realm.RoleWithPermissions, error) {
+	method, path := http.MethodPost, realmPath(realmID, "roles")
+	if role.ID != "" {
+		method, path = http.MethodPut, realmPath(realmID, "roles", role.ID)
+	}
+
+	result := &realm.RoleWithPermissions{}
+	return result, c.do(method, path, nil, role, result)
+}
+
+// DeleteRole deletes the role, the invites for it, and revokes its mandates.
+func (c *Client) DeleteRole(realmID, roleID string) error {
+	return c.do(http.MethodDelete, realmPath(realmID, "roles", roleID), nil, nil, nil)
+}
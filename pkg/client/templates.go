@@ -0,0 +1,53 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/IpsoVeritas/realm/pkg/api/openapi"
+)
+
+// InviteTemplates returns the invite email templates for the locale, or the
+// realm locale if it's empty.
+func (c *Client) InviteTemplates(realmID, locale string) (*realm.InviteTemplates, error) {
+	templates := &realm.InviteTemplates{}
+	return templates, c.do(http.MethodGet, realmPath(realmID, "templates", "invite"), localeQuery(locale), nil, templates)
+}
+
+// SetInviteTemplates customizes the invite email templates for the locale, or
+// the realm locale if it's empty.
+func (c *Client) SetInviteTemplates(realmID, locale string, templates *realm.InviteTemplates) (*realm.InviteTemplates, error) {
+	result := &realm.InviteTemplates{}
+	return result, c.do(http.MethodPut, realmPath(realmID, "templates", "invite"), localeQuery(locale), templates, result)
+}
+
+// PreviewInviteTemplates renders the invite email with the templates, or with the
+// stored ones if templates is nil.
+func (c *Client) PreviewInviteTemplates(realmID, locale string, templates *realm.InviteTemplates) (*realm.EmailStatus, error) {
+	var body interface{}
+	if templates != nil {
+		body = templates
+	}
+
+	status := &realm.EmailStatus{}
+	return status, c.do(http.MethodPost, realmPath(realmID, "templates", "invite", "preview"), localeQuery(locale), body, status)
+}
+
+func (c *Client) AddInviteAttachment(realmID, name string, file io.Reader) (*openapi.Attachment, error) {
+	attachment := &openapi.Attachment{}
+	return attachment, c.do(http.MethodPost, realmPath(realmID, "templates", "invite", "attachments"), nil, &upload{name: name, reader: file}, attachment)
+}
+
+func (c *Client) RemoveInviteAttachment(realmID, name string) error {
+	return c.do(http.MethodDelete, realmPath(realmID, "templates", "invite", "attachments", name), nil, nil, nil)
+}
+
+func localeQuery(locale string) url.Values {
+	if locale == "" {
+		return nil
+	}
+
+	return url.Values{"locale": {locale}}
+}
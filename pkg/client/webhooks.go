@@ -0,0 +1,74 @@
+package client
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/IpsoVeritas/realm/pkg/api/openapi"
+)
+
+func (c *Client) ListWebhooks(realmID string) ([]*realm.Webhook, error) {
+	webhooks := make([]*realm.Webhook, 0)
+	return webhooks, c.do(http.MethodGet, realmPath(realmID, "webhooks"), nil, nil, &webhooks)
+}
+
+func (c *Client) GetWebhook(realmID, webhookID string) (*realm.Webhook, error) {
+	webhook := &realm.Webhook{}
+	return webhook, c.do(http.MethodGet, realmPath(realmID, "webhooks", webhookID), nil, nil, webhook)
+}
+
+// SetWebhook creates the webhook, or updates it if it has an ID.
+func (c *Client) SetWebhook(realmID string, webhook *realm.Webhook) (*realm.Webhook, error) {
+	method, path := http.MethodPost, realmPath(realmID, "webhooks")
+	if webhook.ID != "" {
+		method, path = http.MethodPut, realmPath(realmID, "webhooks", webhook.ID)
+	}
+
+	result := &realm.Webhook{}
+	return result, c.do(method, path, nil, webhook, result)
+}
+
+func (c *Client) DeleteWebhook(realmID, webhookID string) error {
+	return c.do(http.MethodDelete, realmPath(realmID, "webhooks", webhookID), nil, nil, nil)
+}
+
+func (c *Client) WebhookDeliveries(realmID, webhookID string) ([]*realm.WebhookDelivery, error) {
+	deliveries := make([]*realm.WebhookDelivery, 0)
+	return deliveries, c.do(http.MethodGet, realmPath(realmID, "webhooks", webhookID, "deliveries"), nil, nil, &deliveries)
+}
+
+func (c *Client) RedeliverWebhook(realmID, webhookID, deliveryID string) (*realm.WebhookDelivery, error) {
+	delivery := &realm.WebhookDelivery{}
+	return delivery, c.do(http.MethodPost, realmPath(realmID, "webhooks", webhookID, "deliveries", deliveryID, "redeliver"), nil, nil, delivery)
+}
+
+// AuditLog returns the entries of the audit log that match the filter.
+func (c *Client) AuditLog(realmID string, filter realm.AuditFilter) ([]*realm.AuditEntry, error) {
+	query := url.Values{}
+	if filter.Actor != "" {
+		query.Set("actor", filter.Actor)
+	}
+	if filter.EntityType != "" {
+		query.Set("entityType", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		query.Set("entityId", filter.EntityID)
+	}
+	if filter.From != nil {
+		query.Set("from", filter.From.Format(time.RFC3339))
+	}
+	if filter.To != nil {
+		query.Set("to", filter.To.Format(time.RFC3339))
+	}
+
+	entries := make([]*realm.AuditEntry, 0)
+	return entries, c.do(http.MethodGet, realmPath(realmID, "audit"), query, nil, &entries)
+}
+
+// VerifyAuditLog verifies the hash chain of the audit log of the realm.
+func (c *Client) VerifyAuditLog(realmID string) (*openapi.AuditVerification, error) {
+	result := &openapi.AuditVerification{}
+	return result, c.do(http.MethodGet, realmPath(realmID, "audit", "verify"), nil, nil, result)
+}
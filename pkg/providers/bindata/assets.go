@@ -1,12 +1,22 @@
 // Code generated by go-bindata.
 // sources:
+// ../../../assets/invite_contract/sv/template.txt
+// ../../../assets/invite_contract/template.txt
 // ../../../assets/invite_email/attachments/colors.jpg
 // ../../../assets/invite_email/attachments/email-logo-brickchain.png
 // ../../../assets/invite_email/attachments/email-logo-integrity.png
 // ../../../assets/invite_email/attachments/email-powered-by.png
 // ../../../assets/invite_email/attachments/spacer.png
+// ../../../assets/invite_email/subject.txt
+// ../../../assets/invite_email/sv/subject.txt
+// ../../../assets/invite_email/sv/template.html
+// ../../../assets/invite_email/sv/template.txt
 // ../../../assets/invite_email/template.html
 // ../../../assets/invite_email/template.txt
+// ../../../assets/invite_sms/sv/template.txt
+// ../../../assets/invite_sms/template.txt
+// ../../../assets/join_contract/sv/template.txt
+// ../../../assets/join_contract/template.txt
 // DO NOT EDIT!
 
 package bindata
@@ -74,6 +84,46 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
+var _AssetsInvite_contractSvTemplateTxt = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x73\xca\xc9\x54\xc8\x4d\x4d\xc9\x49\xcd\x55\x28\xce\xcf\x55\xa8\xae\x56\xd0\x2b\xca\xcf\x49\xf5\x4b\xcc\x4d\x55\xa8\xad\x55\xc8\xc8\x2f\x86\x88\xa5\x26\xe6\xe4\x02\x05\xec\xb9\x00\xa2\xf2\xcd\x6b\x31\x00\x00\x00")
+
+func AssetsInvite_contractSvTemplateTxtBytes() ([]byte, error) {
+	return bindataRead(
+		_AssetsInvite_contractSvTemplateTxt,
+		"../../../assets/invite_contract/sv/template.txt",
+	)
+}
+
+func AssetsInvite_contractSvTemplateTxt() (*asset, error) {
+	bytes, err := AssetsInvite_contractSvTemplateTxtBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "../../../assets/invite_contract/sv/template.txt", size: 49, mode: os.FileMode(420), modTime: time.Unix(1792321565, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _AssetsInvite_contractTemplateTxt = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x73\x4a\x4d\xce\xcf\x4d\x55\x48\x54\xc8\x4d\xcd\x4d\x4a\x2d\x52\xc8\x4f\x53\xa8\xae\x56\xd0\x2b\xca\xcf\x49\xf5\x4b\x04\x4a\xd4\xd6\x2a\x24\x96\x40\x84\x52\x13\x73\x72\x81\x7c\x7b\x2e\x00\x60\x9f\xe9\x5e\x34\x00\x00\x00")
+
+func AssetsInvite_contractTemplateTxtBytes() ([]byte, error) {
+	return bindataRead(
+		_AssetsInvite_contractTemplateTxt,
+		"../../../assets/invite_contract/template.txt",
+	)
+}
+
+func AssetsInvite_contractTemplateTxt() (*asset, error) {
+	bytes, err := AssetsInvite_contractTemplateTxtBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "../../../assets/invite_contract/template.txt", size: 52, mode: os.FileMode(420), modTime: time.Unix(1792321565, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 var _AssetsInvite_emailAttachmentsColorsJpg = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x92\xdd\x6b\xe4\x64\x14\xc6\xcf\x3b\xc9\x64\x66\xa7\xb3\xdd\x89\xdd\x85\xda\x2e\xf4\x25\x05\x85\xd5\x99\x7c\xcd\x74\x3a\x71\x32\x75\x77\xb2\xc5\x5e\x44\xca\x58\xd0\x1b\x2d\x69\xf2\xce\x36\x4c\xf3\xb1\x49\x6a\xd3\x0a\xe2\x85\x57\x82\x82\xde\x78\x23\x7b\xa7\x08\x82\x5a\xc1\x2b\x41\xab\x78\xa1\x08\x5e\x28\xea\xfa\x81\xb0\x20\x8b\x2c\xc5\xfe\x09\x91\xcc\x76\xda\x61\xea\x2c\xf8\x5c\x25\xe7\x3c\xbf\x93\x73\x4e\x4e\x72\x3b\xb9\x03\xd3\xd7\x63\xbb\x0b\xb0\xb2\x72\x05\xf2\x70\xaa\xe4\x10\x58\x6d\xdb\xec\xed\x02\x02\x1a\x00\x9a\x00\xc9\x1d\x8a\xdf\x8c\x22\x5f\xe1\x79\x37\xac\x18\x96\xb7\x41\x2a\xa6\xe7\xf0\xb1\xe1\xf3\x62\x45\xe0\xa1\xb9\x14\xfb\x86\xd9\x23\x11\xde\x20\x37\x6c\x57\xe5\x8e\x3e\xfb\x82\xc3\xb6\xa5\x72\xcf\xd6\x74\x41\xf7\xdb\x64\xd3\x7e\x6a\x2f\x20\xcf\xec\x3d\xbd\x66\xee\xf5\xcc\x86\xc5\x2d\xb5\x70\x33\x56\x62\xc7\x77\x48\x64\xe0\xd8\xd9\x72\x43\x25\x56\xb9\x7e\x71\xc5\x0d\x95\x34\xcc\x73\xb8\x6f\x89\x7a\x2a\x77\x35\x4d\xe0\xe7\xf4\x55\xdc\xf6\x02\x82\x6b\x95\x85\xb2\x29\x56\x05\x5c\x6f\x54\xc4\x05\xa1\x5a\x13\x1f\xc7\x92\x20\xd6\x79\xa1\xc6\x0b\x0b\x65\x41\x54\x84\x45\x45\x12\xf1\xb1\xb8\x16\x6e\x06\x56\x57\xe9\x68\xcb\xc7\xdf\x0a\xac\xae\xca\x1d\x0f\xb5\xb3\xb3\x53\xd9\x91\x2b\x5e\x70\x83\x17\x1b\x8d\x06\x2f\x48\xbc\x24\x95\x03\xab\x5b\x0e\x77\xdd\xc8\x88\xcb\x6e\x38\x3f\xa8\xa0\x91\xd0\x0c\x6c\x3f\xb2\x3d\x17\xa7\xef\xc6\x86\xb7\x1d\xa9\x1c\x37\x18\xc1\xf1\x4f\xca\xfe\xe7\xae\x86\x8c\xba\xfe\x60\xab\xe3\x9c\xb8\xc3\xa8\x43\xba\x0f\x76\x87\x6b\xbb\x3e\xe1\x3b\x24\xf4\xb6\x03\x93\x74\x48\x77\x3e\x85\x7d\xa5\x1d\x10\x23\xf2\x82\x35\xcf\xdb\x1a\x6c\x71\x75\xd3\x8b\xbc\x70\xd3\xf3\x71\xbb\x9d\x6e\x6d\x11\xeb\x86\x69\xbb\x69\xac\xcf\xe8\xba\xb2\xe2\x86\x91\xe1\x9a\x64\x45\x53\xb9\xd8\xf1\x2b\xb6\x6d\x29\x92\x2c\x5e\x95\x84\xba\x5c\xd3\x04\x59\x14\xaf\x2f\x5e\xab\x6b\x35\x4d\xb8\x26\xc8\x5a\x4d\xae\xcb\x55\x69\xc0\x6a\x9e\xb9\xed\x10\x37\x1a\xb0\xd6\x29\x5b\x1d\xcb\xa6\xe7\x70\x9f\x26\x81\xfd\x22\xb1\x96\x03\xcf\xc1\xfd\xb1\x15\x7b\x7c\x2f\xe2\xf8\x5e\xee\xb3\xd6\xf8\x5e\xa4\xb1\x2c\xdf\xc2\x4d\x7e\xe4\x67\x0f\x42\x1d\x6d\x39\x7d\x3c\x39\xdd\x16\x3e\x3d\x7e\xe2\x5a\x2a\x17\x70\x4b\xad\xe4\x1f\x98\xec\xaf\x1a\xac\x03\x00\x40\xc9\xef\xf0\x2a\x30\x34\x4d\x67\x69\x26\x9b\x65\xce\x31\x59\xe6\xdc\x44\x9e\x61\xf2\x13\xc5\x42\x61\xa2\x50\x28\x96\x8a\x7d\x95\x8a\x93\x17\x4a\x17\x26\x8b\x53\x53\x17\x2f\x4e\x4d\x5d\x9e\x9d\x9d\xbd\x3c\x77\x22\x94\xcb\xe5\xce\x17\xcf\x4f\x97\x4a\xd3\x33\x97\xd8\x4b\x33\x73\xff\x57\xc9\x01\xb0\x79\x60\xe8\x8f\x29\xc4\x42\x86\x45\x14\x8b\x92\xaf\xc1\x05\x04\x14\x82\x61\x65\x68\x26\x87\x10\x64\x28\x34\x1c\xa7\x18\x94\xa1\x73\xd9\x12\x02\x84\xf2\xd4\x50\x02\x65\x6e\xee\x4b\xc1\x27\x74\xb6\x46\xc9\x21\x8b\x00\x65\x98\xe1\x74\x06\xdd\x14\xf7\x25\x4a\xa6\x83\x6a\x36\xf9\x0d\x8a\x14\x82\x0c\x4b\xb1\xb0\x04\x7f\x7d\xf7\xc3\x3b\x70\x46\x87\xb7\xe6\xf4\x87\x37\xee\xbe\xf9\xe5\x0b\x7f\xfe\xf2\xd8\x4f\x8f\x7c\x73\x30\x92\x47\x57\xc4\x1f\xb7\x1a\x2f\xfd\x31\x7b\xeb\xa1\x6f\xf9\xf5\xb7\xea\xef\xf5\x8e\xa6\x9f\x7f\xe5\x4c\x99\x99\x77\x9d\x7b\xf8\x4c\xb4\xb8\x3e\x7f\xdb\x7d\xf9\xd3\x7b\x6f\xaf\xed\x7f\x70\x74\xf8\xf7\xeb\xef\x7f\xf8\xd5\xea\xa8\x07\xbd\x76\xf7\x09\xf5\xfb\xdd\xf5\xcf\xbd\x8f\x26\x1e\x7d\xa3\xe0\xfe\xfc\xe4\xa8\x23\x97\xfc\xfa\x6f\x00\x00\x00\xff\xff\x16\x6d\x96\x28\x43\x05\x00\x00")
 
 func AssetsInvite_emailAttachmentsColorsJpgBytes() ([]byte, error) {
@@ -174,7 +224,87 @@ func AssetsInvite_emailAttachmentsSpacerPng() (*asset, error) {
 	return a, nil
 }
 
-var _AssetsInvite_emailTemplateHtml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xcc\x59\x6d\x6f\xdb\xba\xf5\x7f\xef\x4f\x71\xfe\x2c\xfe\xeb\x8b\x5a\x96\x9c\x34\x69\xe0\x4a\x1a\x2e\x0a\x6c\xbb\xc0\x86\x15\xb8\xd9\x86\xbd\x1a\x28\xf2\x58\xe2\x0d\x4d\x6a\x24\xed\xc4\xb7\xcb\x77\x1f\x48\x5a\xb2\xec\xf8\xa1\x69\xb3\xa0\x36\x5a\x5b\xd2\x39\x87\xbf\xf3\x3b\x0f\xe4\x71\xf2\xff\xe3\x9a\xb9\x75\x8b\xf0\xa7\xdb\xbf\xfc\xb9\x1c\xe5\x8d\x5b\xc8\x72\x34\xca\x1b\xa4\xbc\x1c\x01\x00\xe4\x0b\x74\x14\x1a\xe7\xda\x04\xff\xbd\x14\xab\x82\x7c\xd2\xca\xa1\x72\xc9\xed\xba\x45\x02\x2c\x5e\x15\xc4\xe1\x83\x4b\xbd\x3e\xb0\x86\x1a\x8b\xae\xf8\xdb\xed\x1f\x92\x1b\x02\xe9\xd0\x90\xa2\x0b\x2c\xc8\x4a\xe0\x7d\xab\x8d\x1b\xa8\xdf\x0b\xee\x9a\x82\xe3\x4a\x30\x4c\xc2\xc5\x18\x84\x12\x4e\x50\x99\x58\x46\x25\x16\xd3\x49\xb6\x35\xe6\x84\x93\x58\xb6\x72\x69\x85\x72\x58\x1b\xe1\xd6\x79\x1a\x6f\x46\x01\x29\xd4\x1d\x34\x06\xe7\x05\xf1\xe0\xed\x2c\x4d\xe7\x5a\x39\x3b\xa9\xb5\xae\x25\xd2\x56\xd8\x09\xd3\x8b\x94\x59\xfb\xfb\x39\x5d\x08\xb9\x2e\x7e\xd1\x4b\xc3\xf0\xdd\x2f\x54\xd9\x77\x9f\x8d\x9e\x5d\x66\xd9\xf8\x7d\x96\x11\x30\x28\x0b\x62\xdd\x5a\xa2\x6d\x10\x1d\xd9\x2c\x11\xee\xc4\xef\xfe\x55\x69\xbe\x86\x2f\xfd\xa5\x7f\xf9\x15\x93\x68\x7d\x06\x6f\xa3\x7d\xf0\xf6\xe1\xb3\xd1\x6f\xc7\xf0\x77\x34\x9c\x2a\x3a\x86\x3f\xa2\xc2\x15\x1d\x83\xa5\xca\x26\x16\x8d\x98\x7f\x7c\x6a\xc8\x8a\xdf\x70\x06\xd3\xeb\xf6\x61\xf7\xa1\x14\x0a\x93\x06\x45\xdd\xb8\x19\x4c\x27\xd7\x97\x07\x74\xef\x37\x8f\x95\x36\x0b\x2a\x77\x05\x98\x96\xda\xcc\xe0\xcd\x87\xb9\x7f\x6f\x9f\x3d\x8e\xfa\xaf\x74\xcf\x31\x1f\xec\x84\x23\xd3\x86\x3a\xa1\x95\xb7\xab\xf0\x88\xa6\x58\xd4\x7b\xda\x95\x36\x1c\xcd\x09\xa5\x66\x7a\x88\xc8\xe8\xff\xc5\xcd\xbe\xff\x3b\x0e\x5e\x66\xd9\x09\x76\x2e\x9f\x28\x2f\xa8\xa9\x85\x9a\xc1\xc5\x55\xfb\x00\x7b\xaa\x2d\xe5\x5c\xa8\x7a\x36\xbc\x3f\x40\x39\x59\x50\xa1\xf6\x80\x86\xcc\x9d\xc1\x75\x96\xed\x2f\x14\x9d\x4e\x24\xce\x7d\x94\xda\x07\xb0\x5a\x0a\x0e\x6f\x30\xf3\xef\x83\xb2\x66\x13\xd2\xe3\xc2\x43\x34\x4c\xcb\xc4\x0a\x8e\x87\x11\xdd\xec\x00\x1a\xea\x55\xff\xaa\x0d\xee\x67\x6e\x45\xd9\x5d\x6d\xf4\x52\xf1\xa4\x4b\x8f\x79\xe6\xdf\x87\x6d\xb8\x07\x97\x68\x43\x55\xbd\xbf\x7a\xaf\xfc\xe1\x9a\x66\xf4\xb8\x72\x25\x29\xbb\x3b\xa2\x9b\x85\xd7\x11\xf0\x4e\x25\x4c\xab\xb9\x30\x8b\x43\x29\x4a\xa5\xa8\xd5\x0c\x18\x2a\x87\xe6\xbc\x85\xfd\x34\xdf\x20\xb8\x6f\x84\x3b\x9c\xa8\x93\xb9\xd6\x0e\xcd\x13\xc5\x61\xbd\x4e\x4f\xd6\xeb\xc5\xe4\xf2\xfa\xd9\xe5\xd8\x2d\x3b\xb1\xd8\x52\x43\x9d\x36\x27\xd6\xbf\xd8\x0d\xbc\xff\x3f\x4f\x37\xbd\x2b\x4f\x63\xa7\x1f\xe5\xa1\x7b\x55\x75\x58\xbc\x20\xc1\xe3\xae\xcf\x39\x5a\x49\x8c\x79\x54\x90\x69\x96\xfd\x3f\xd9\x36\xbd\xdc\x99\x72\x67\xe5\xdc\x71\x08\xac\x17\x24\xb2\x4e\x76\x9f\x0f\x2c\x32\x49\xad\x2d\x88\xaf\x22\x02\x0c\xa5\xb4\x2d\x65\x42\xd5\x05\xc9\xe2\xf5\xa6\xfe\xc2\x75\x2c\x89\xf8\xf5\x10\xca\xa7\x6b\x18\x88\x1c\x17\xe4\xf2\x88\x4c\x87\x97\x69\xbf\xb4\x3a\x2d\xd8\x2b\xf8\x7e\x66\x0d\x2b\x08\x13\x7c\x16\x90\xd8\xc9\xaf\x6d\x4d\x80\x4a\x57\x10\xd2\x11\x75\x9d\x65\xe4\xac\xb1\x2d\x42\x08\x11\x29\x08\x17\xb6\x95\x74\x3d\xab\xa4\x66\x77\x1f\x49\x7a\x02\x79\xea\xf8\x11\xdf\xd3\xfd\xa8\x6c\xbd\x3d\xf2\x00\x3a\x2a\x62\x4c\xba\x5e\x42\xca\x5d\x77\x7d\x84\xd0\x4c\x5a\xf5\xc4\xdd\x9b\x33\xde\x76\x9e\x4e\xfd\x1e\x7e\x1c\x7a\x87\xa3\x13\xbf\xb9\x22\x7b\xe9\x04\xab\x73\xe9\xb5\x63\x8b\xee\x1d\x02\x76\x8e\x0c\xfe\x04\xf0\xec\x90\xe3\x82\x0a\x99\x48\x5d\xeb\x64\x6b\x68\xcb\xc8\xbb\xfe\x66\xcf\xcd\x74\x7a\x75\x3e\x15\x86\x24\x5d\x7c\xe8\x4f\x3a\x47\x31\xa5\xf4\x5b\x52\x03\x7e\x90\x38\x9f\x4c\xd1\x0e\x5d\xdc\x99\xc8\xd6\xe6\x87\xab\x73\x95\xfc\xc4\xad\xb3\x4c\x74\xe9\xe4\x74\x4b\xca\xd1\x69\xce\x9b\x69\xf9\xe5\x0b\x4c\xfc\xd6\x02\x8f\x8f\x79\xda\x4c\xbf\x42\xe3\x9f\x7a\x09\x0d\x5d\x21\x54\x88\x0a\x84\x5a\x09\x87\x1c\x9c\x06\x6f\xca\x20\x95\x0b\x78\x7c\x04\x8b\xc6\x1f\x7d\xed\xd7\x18\xdd\x69\x9f\x83\xfd\xab\x0f\xd2\x85\x3f\xb5\x76\xac\x5d\x0d\x5b\xe6\x66\x23\x7e\x4e\xbf\xfd\x8a\xfa\x38\xd5\x55\x76\x05\xf9\x0e\xac\x67\x95\xf2\x8e\xa1\xae\xac\x3d\x87\xe1\xa8\xff\xf8\x48\xca\x9f\x18\x43\x6b\xc1\x35\x38\xa0\xf3\x44\x99\xec\x58\x3c\x99\x28\x03\xa9\x33\xae\xe6\x69\x88\xce\xcb\xd6\xe6\xcb\x96\xd1\xe5\xa9\x98\x7e\x5b\x15\x95\xbf\x53\x95\x6d\x3f\xbe\x9e\x6f\xff\xb3\x36\xf0\xd5\xa9\x98\x57\x26\x94\xf6\xbd\x90\x12\x14\xc6\x92\x5e\x5a\x0c\xe9\x97\xfb\xf3\x44\x07\x63\x7b\x38\x26\x65\x5e\x95\x3f\x77\xfb\x03\xd0\xb6\xcd\xd3\xaa\xcc\x53\x2f\x5d\x82\x56\xb0\xd6\x4b\x03\x71\x08\x06\xa1\x20\xd4\xa0\xb7\x2b\x38\x2a\x27\xe6\xeb\x20\x60\x51\xce\x81\x2a\x0e\x34\xe6\xfb\xb0\x8f\xbc\xb5\x7d\xea\x4f\x3c\x42\xff\xef\xe7\xb9\x57\x03\xae\x41\x69\x17\x5b\x91\xc7\x48\xdb\x16\xd6\xe8\xc6\xe1\x22\xb4\xa5\x30\xcb\x41\x28\xa7\xe0\x95\x41\x2e\x0c\x32\x17\xd4\x9d\x0e\x82\x5c\xdf\x2b\xa9\x29\x87\x96\xd6\x38\x81\xbf\x2a\xb6\xb5\x26\x2c\x08\x65\x1d\x95\x12\xf9\x18\x7e\x5d\x5a\x07\x4c\x0a\x76\x17\x05\x2a\xbd\xc2\x68\x9c\xd6\x7e\x70\x72\x1a\xc2\x67\xf4\xa2\x47\xfb\x84\xba\x30\x1a\x90\xf2\xa7\x4a\x2f\x5d\xb0\xb4\x47\x60\x20\xaf\xd3\xbe\xdd\x7f\xee\x41\x05\x56\x2d\xb2\xa5\x41\xe0\xa2\x16\x8e\x4a\x68\xa9\xb5\xad\x36\x6e\x02\x3e\x88\x3e\x6e\xc2\x05\xa6\x7d\xf8\x29\x73\x70\x2f\x5c\xd3\x73\x09\xae\xa1\x2e\x48\xf5\xc6\xdf\x5a\xe0\xe8\xb3\xc5\x50\x29\x7e\x43\x0e\x42\xcd\x0d\xb5\xce\x2c\x99\x5b\x1a\x8c\x76\x03\xd9\x4c\x2b\x67\xb4\x04\x3d\xdf\x50\x3d\xf7\x43\x78\xe0\xda\xd3\xd0\x60\x0f\x66\xec\x47\x0d\xd6\x40\x85\x52\xab\xda\x7a\x3c\x9e\x7a\x1f\x6b\x83\x3e\x93\x3d\xc1\xc3\x24\x19\x87\x67\xc2\x79\x2f\x75\x8b\x86\xba\x00\x84\x63\x8b\xca\xa7\x8c\x5c\xc3\xdc\xe8\x45\x58\x24\xa4\xc8\xc6\x93\x60\x35\xf2\x0e\xff\x68\x30\xd8\x04\xdb\x50\x4f\x10\x75\x34\x3a\x4f\x3b\xf7\xc7\xa0\x95\x5c\xf7\x50\x06\xfd\x75\xa0\x17\x54\x18\x55\x60\x31\x66\x84\x33\x54\x59\xca\xbc\x9f\x7d\x70\x7f\xb8\xae\x08\x71\x92\x7a\xf9\x63\x45\xd7\x6c\xdf\x5f\x6d\xb7\xb9\x4a\x3b\x77\xf6\xc0\x79\xfe\xc4\x9a\x3a\x34\x0b\x9b\xd0\x30\x9b\x2b\x2e\x3c\xc3\x36\x7d\xb3\x09\x49\x12\x9e\xf6\xf3\xc4\xf7\xfc\x04\xb5\x3f\x8a\x3e\x19\x6b\x49\x79\xeb\x17\x0b\x59\xf1\x49\x2b\x1e\x90\xf8\x2d\x17\xe2\x7e\xb0\xf9\x38\xed\xef\xb0\xde\xfb\x79\x96\x94\xff\xd9\x54\xf6\x33\x2c\x7d\x2b\x73\xad\x11\x2b\xca\xd6\xaf\xc5\xd9\xe7\xb8\x1c\x7c\xd6\x52\xb0\x35\xfc\xc8\x7c\xf9\xd6\x45\x99\x4b\x5f\x8b\x9a\x4f\x71\xbd\x17\x1f\x6e\x5e\xe4\x00\xf5\x2a\x3d\x22\x8e\x21\xc7\xb4\xe0\x47\x19\x69\xaf\xa7\x83\xb1\xec\xfd\xf7\x4d\xab\xc7\xf0\xb4\xfa\x1e\x0d\xf2\xa4\x1a\x62\xd9\xdc\x84\x6a\x8b\xe5\xe6\xe2\x79\x58\xf6\xf9\xab\x8c\x60\x77\xac\xa1\x42\x7d\x2f\x79\x03\x4b\x5b\xc4\xdb\x9b\x3d\xe2\xab\x9b\xe7\xfd\x1e\xf0\xbd\x0c\xbf\x42\xc9\x1c\x9c\x7b\x76\x8d\x6c\x15\x7b\xe1\x3c\xad\x34\x5f\x97\xa3\x51\x9e\xc6\x3f\x3d\xfd\x37\x00\x00\xff\xff\xc4\xf8\x95\x63\x92\x1a\x00\x00")
+var _AssetsInvite_emailSubjectTxt = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xf3\xcc\x2b\xcb\x2c\x49\x2c\xc9\xcc\xcf\x53\x28\xc9\x57\xc8\xca\xcf\xcc\x53\xa8\xae\x56\xd0\x2b\x4a\x4d\xcc\xc9\x55\xa8\xad\x55\x48\x2c\x86\xf0\xf3\x73\x52\xfd\x12\x73\x53\x81\x42\x5c\x00\xaf\xed\x9e\x51\x33\x00\x00\x00")
+
+func AssetsInvite_emailSubjectTxtBytes() ([]byte, error) {
+	return bindataRead(
+		_AssetsInvite_emailSubjectTxt,
+		"../../../assets/invite_email/subject.txt",
+	)
+}
+
+func AssetsInvite_emailSubjectTxt() (*asset, error) {
+	bytes, err := AssetsInvite_emailSubjectTxtBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "../../../assets/invite_email/subject.txt", size: 51, mode: os.FileMode(420), modTime: time.Unix(1792321565, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _AssetsInvite_emailSvSubjectTxt = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xf3\xcc\x4b\xca\x2a\x4d\x49\xcc\x53\x28\xc9\xcc\xc9\x51\xa8\xae\x56\xd0\x2b\x4a\x4d\xcc\xc9\x55\xa8\xad\x55\x28\xce\xcf\x85\x08\xe4\xe7\xa4\xfa\x25\xe6\xa6\x02\xc5\xb8\x00\x12\xfb\xe0\x9a\x2f\x00\x00\x00")
+
+func AssetsInvite_emailSvSubjectTxtBytes() ([]byte, error) {
+	return bindataRead(
+		_AssetsInvite_emailSvSubjectTxt,
+		"../../../assets/invite_email/sv/subject.txt",
+	)
+}
+
+func AssetsInvite_emailSvSubjectTxt() (*asset, error) {
+	bytes, err := AssetsInvite_emailSvSubjectTxtBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "../../../assets/invite_email/sv/subject.txt", size: 47, mode: os.FileMode(420), modTime: time.Unix(1792321565, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _AssetsInvite_emailSvTemplateHtml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xcd\x59\xd9\x76\xdb\x36\x10\x7d\xf7\x57\xa0\xcc\x69\xf3\x10\x53\x94\xec\x78\x39\x8a\xa4\x3e\x74\x49\x7b\xba\x37\x69\x5e\x7b\x40\x72\x44\x22\x02\x01\x06\x80\xe4\xa8\xa9\xff\x26\xf9\x85\xfc\x80\x7e\xac\x03\x50\x5c\xb5\xd9\xb1\xeb\x13\xe9\xc4\x22\x09\xcc\xcc\x9d\x3b\x0b\x00\x66\xf4\x45\x2c\x23\xb3\xcc\x81\xfc\xf0\xf2\x97\x9f\x27\x47\xa3\xd4\x64\x7c\x72\x84\xbf\x40\xe3\xc9\x11\xc1\xcf\x28\x03\x43\x49\x6a\x4c\xee\xc3\x9b\x39\x5b\x8c\xbd\x6f\xa4\x30\x20\x8c\xff\x12\xe5\x3c\x12\x15\x77\x63\xcf\xc0\x5b\x13\x58\x79\x12\xa5\x54\x69\x30\xe3\xbf\x5e\x7e\xef\x5f\x7a\x24\x68\x2a\x12\x34\x83\xb1\xb7\x60\x70\x95\x4b\x65\x1a\xe2\x57\x2c\x36\xe9\x38\x86\x05\x8b\xc0\x77\x37\xc7\x84\x09\x66\x18\xe5\xbe\x8e\x28\x87\xf1\xa0\xd7\xaf\x95\x19\x66\x38\x4c\x72\x3e\xd7\x0c\x15\x24\x8a\x99\xe5\x28\x28\x1e\x16\x13\x38\x13\x33\x92\x2a\x98\x8e\x3d\x0b\x5e\x0f\x83\x60\x8a\xb6\x74\x2f\x91\x32\xe1\x40\x73\xa6\x7b\x91\xcc\x82\x48\xeb\xaf\xa7\x34\x63\x7c\x39\x7e\x21\xe7\x2a\x82\x27\x2f\xa8\xd0\x4f\x7e\x57\x72\x78\xda\xef\x1f\x3f\xed\xa3\x51\x05\x7c\xec\x69\xb3\xe4\xa0\x53\x00\xe3\xad\x4d\xb8\x27\xc5\xb5\xfd\x84\x32\x5e\x92\x77\xd5\xad\xfd\x58\x8b\x7e\xa1\x7d\x48\x1e\x17\xfa\x89\xd5\x4f\x50\xff\xe3\x63\xf2\x0a\x54\x4c\x05\x3d\x26\xcf\x41\xc0\x02\x7f\x35\x8e\xf9\x1a\x14\x9b\x3e\xdb\x54\xa4\xd9\x3f\x30\x24\x83\xf3\xfc\x6d\x7b\x10\x5d\x05\x3f\x05\x96\xa4\x06\x87\x7b\xe7\xa7\x5b\x64\xaf\xd6\xc3\x42\xaa\x8c\xf2\xf6\x84\x48\x72\xa9\x86\xe4\xd1\xc5\xd4\x7e\xeb\xb1\xeb\xa3\xea\x92\x76\x1c\xb3\xc1\xf6\x63\x88\xa4\xa2\x86\x49\x61\xf5\x0a\xd8\x21\xc9\xb2\xa4\x23\x1d\x4a\x15\x83\xda\x23\x94\x0e\xb6\x11\x59\xf8\x7f\x72\xd9\xf5\xbf\xe5\x20\x06\x6d\x0f\x3b\xa7\x1b\xc2\x19\x55\x09\x43\x07\x4e\xce\xf2\xb7\xa4\x23\x9a\xd3\x38\x66\x22\x19\x36\x9f\x37\x50\xf6\x32\xca\x44\x07\xa8\xcb\xdc\x21\x39\xef\xf7\xbb\x86\x0a\xa7\x7d\x0e\x53\x1b\x25\x34\xa6\x25\x67\x31\x79\x04\x7d\xfb\xdd\x3a\x57\xad\x43\xba\x7b\x72\x13\x0d\x86\x11\x29\x8a\x61\x3b\xa2\xcb\x16\xa0\xa6\x5c\xf8\x77\xa2\xa0\x9b\xb9\x21\x8d\x66\x89\x92\x73\x11\xfb\x65\x7a\x4c\xfb\xf6\xbb\x5d\x87\xc1\x74\xc0\x5c\x10\x49\xd7\x7a\x25\x7c\x71\x4e\xfb\x74\xb7\x70\xc8\xd1\xe0\x0e\xd9\xbe\xfb\xec\x00\x6f\x04\x02\x14\x53\xa6\xb2\x6d\x29\x4a\x39\x4b\x30\xb8\x11\xb6\x18\x50\x87\x35\x74\xd3\x7c\x8d\xe0\x2a\x65\x66\x7b\xa2\xf6\xa6\x52\xa2\xe6\x0d\xc1\x66\xbd\x0e\xf6\xd6\xeb\x49\xef\xf4\xfc\xd6\xe5\x58\x9a\xed\x69\xc8\x29\x96\xa0\x54\x7b\xec\x9f\xb4\x03\x6f\xff\x8e\x82\x75\xef\x1a\x05\x45\xa7\x3f\x1a\xb9\xee\x15\x26\xce\x38\x76\x63\xeb\x71\xd9\xe7\x0c\x0d\x39\x14\x79\x34\xf6\x06\xfd\xfe\x97\x5e\xdd\xf4\x46\x46\x4d\x5a\x96\x47\x26\x26\x8e\xf5\xb1\x57\xb0\xee\xb5\xc7\x1b\x1a\x23\x4e\xb5\x1e\x7b\xb6\x8a\x70\x21\x00\xce\x75\x4e\x23\xac\xb7\xb1\xd7\x2f\xee\xd7\xf5\xe7\xee\x8b\x92\x28\x2e\xb7\xa1\xdc\xb4\xa1\x48\xc1\xf1\xd8\x3b\xdd\x31\xa7\xc4\x8b\xea\xd0\xb4\xd8\x3f\xb1\x12\xb0\xfd\x4c\xab\x08\xfd\x63\xf1\xd0\x21\xd1\xbd\xd7\x79\xe2\xa1\xdb\x68\xcb\x2b\x89\xc2\x16\xe0\x1d\x54\x56\x23\x24\x2e\x22\x63\x2f\x66\x3a\xe7\x74\x39\x0c\xb9\x8c\x66\xcf\xbc\x60\x0f\xf2\xc0\xc4\x3b\x7c\x0f\xba\x51\xa9\xbd\xdd\x31\x50\x0c\xc6\x65\x4c\xca\x5e\xe2\x4d\xda\xee\xda\x08\x81\xea\xe5\x62\xc3\xdd\xcb\x03\xde\x96\x9e\x0e\xec\x1a\xbe\x1b\x7a\x89\xa3\x9c\x7e\x79\xe6\x75\xd2\x89\x2c\x0e\xa5\x57\x4b\x17\xed\x6c\x02\x5a\x5b\x06\xbb\x03\xb8\x75\xc8\x01\x13\x96\xfb\x5c\x26\xd2\xaf\x15\xd5\x8c\x3c\xa9\x1e\x56\xdc\x0c\x06\x67\x87\x53\xa1\x49\xd2\xc9\x45\xb5\xd3\xd9\x89\x29\xa0\x9f\x92\x1a\x6e\xf4\x33\x88\xf3\xde\x14\x2d\xd1\x15\x2b\x93\x57\xeb\xbc\x38\x3b\x54\xc9\x1b\x6e\x1d\x64\xa2\x4c\x27\x23\x73\x54\xbe\x9f\xf3\x74\x30\x79\xf7\x0e\x17\x2c\x5c\x5a\xc8\xf5\x35\x36\xcf\xc1\x0d\x24\xbe\x9d\x13\xdc\x10\x93\x90\xb3\x05\x33\xb8\xa7\x0d\x5f\xcf\x63\x10\xc4\x30\xce\x89\x79\xbd\x7a\x2f\x34\xa6\xb1\xc0\x3c\x95\x9a\x58\xed\x0a\x28\xcf\x6e\xa8\xbe\xd5\x48\x1b\x2b\x59\x15\xae\x13\xbb\x7f\x2d\xf9\x3b\x6b\x36\xcf\xf5\x92\x7c\x9b\xce\x7b\x83\x4a\xd9\xd7\x5f\xda\x13\xe3\x16\xac\x5b\x15\x75\x4b\x51\x59\xe0\x96\x3a\xb7\xe9\xbf\xbe\xf6\x26\xcf\x57\x1f\x36\x08\xde\x5b\x31\x2d\x95\x7b\x73\xa6\x31\xeb\x80\xaf\x38\xc3\x86\xe7\x50\x0c\xf3\xc9\x77\x9c\xe3\x62\xae\x67\x54\x60\x1a\xcc\xa4\xcd\x8e\x0c\x70\x19\xcd\x73\x10\xc3\x51\x78\x03\x4a\xdb\xd5\xcb\x04\x66\x1a\xf8\x6f\x9a\x05\xfc\xc7\x9f\xb8\xcc\x61\x45\x6c\x4d\x0c\x77\x73\xb0\xe1\xe4\xf7\xdb\x70\xee\xb7\x37\x9c\xee\x4b\xcf\x4f\x6b\x0d\x93\xaf\x44\xa8\xf3\x67\x0f\xe7\xdb\xff\xd6\xdb\x6e\x5c\x55\x36\xd9\xb0\x5f\x85\x90\xae\x3e\x2e\xec\xbe\x56\x2c\xb0\x7e\x62\x8a\x87\x5d\xdc\x20\x95\x10\xea\xdd\x3e\x82\x08\x27\x3f\x96\x0b\x9e\xef\x32\x76\x14\x84\x08\xcd\xce\x9f\x90\x1c\xeb\x10\x1b\x09\x01\x91\x82\x21\xd3\xd5\x47\x54\x69\xb0\x09\x62\x8a\x1b\x36\x65\xa0\x28\x0e\x27\x44\x46\x29\xe6\x7d\x96\x51\xb2\xfa\x60\xf6\x36\xc5\x9e\x45\x68\xff\xfd\x96\x91\x78\x4e\xec\x5a\xeb\xba\xab\xb3\x4c\x50\x4e\xcc\xb1\x90\x58\x34\xc3\x67\x45\xab\x15\xd8\xc7\x34\xc7\x91\x19\x4e\xb0\xc6\x5c\x5f\x10\x10\x73\xec\x71\x6e\x10\xd9\xa4\xa2\x47\x7e\x5d\xbd\xaf\xf5\x58\x69\x6d\xa8\x2d\x4c\x1a\x93\x19\x2f\x34\xa2\x45\xeb\x51\xa9\x4d\x2e\x90\x13\x96\xe0\x55\xe5\xd9\x74\xdd\x78\x92\xd5\x07\x91\x54\x60\x37\xd8\x73\xc7\x1d\xcf\x3a\xb1\x41\x9e\x23\xae\x14\xec\x8c\x3a\x60\x31\x43\x3b\x7a\xf5\x7e\x56\x90\xc7\x10\x25\xc5\xa3\xaa\xd6\x3d\x82\xa1\x5b\x47\x0c\x63\x17\x37\x71\x59\xa2\x14\x4d\x2c\xe1\xb6\xb5\x54\x14\xe3\xc1\x32\x6b\xc8\x54\xf6\x34\x8a\xdb\xac\x51\x98\x41\xda\x72\x00\xa8\x62\xaa\xa8\x36\x6a\x3e\x33\x73\xe5\x6c\x59\xe2\x67\x78\xd2\x50\x12\x19\x2d\x12\x06\x27\xd9\x37\x0c\xf6\xa5\x00\x9a\x67\x0e\x17\x98\x63\x67\xc6\xf2\x92\x5a\x40\x65\xc8\xa7\x4c\x08\xdd\xce\x91\x63\x37\x60\xa1\xa7\xd4\x41\xd6\x44\x86\xa0\x24\x20\x3c\x42\x17\x38\x42\x5c\x2a\x68\x1b\x8b\x0a\xf7\x3a\x78\xf8\x28\x06\x4e\x5b\x28\x9c\xbb\x76\xb9\x2d\x3c\x26\xd8\x64\xf1\x70\x6b\xa9\x9b\x3b\x53\x25\x13\xa2\x96\xb6\x12\x1a\x08\xfa\x2e\x34\x9d\x15\xae\x54\xa1\xfc\xec\x5a\x20\x29\xce\x82\xf7\xbf\x31\x2a\x3b\xeb\xd3\xb3\x7a\x79\x0e\xa5\x31\x07\xb7\xcc\x87\xf7\xdc\x01\x02\xce\xb4\x4f\xdd\xdb\x05\x81\x09\x8d\x1c\xeb\xe0\x11\x26\x9a\x7b\xfd\xe7\x46\xab\x13\xd1\x5d\x5e\xa2\x75\x0f\xd3\x1b\x07\x73\x6f\xf2\x0a\xb3\x72\x26\x95\xdd\x1d\x90\xa2\xe1\xaf\x7f\xf6\xfb\xd8\xac\xe8\xea\x14\xee\x4d\xfe\x5d\x17\xf0\x2d\x34\x7d\x2a\x5b\xb9\x62\x0b\x1a\x2d\x1f\x8a\xa7\xb2\x35\x80\xd1\xb9\xc4\x7e\xb8\x24\x9f\x33\x65\xf6\xbd\x32\x8d\x4c\xf0\x50\xec\xfc\x64\xed\xcd\xcc\xbd\x9f\xca\xee\x65\x93\xf4\x20\xad\xa1\x38\x3f\xed\x92\x72\x92\x9f\xc3\x59\xfc\x7c\xd0\x38\x4f\x3e\xbd\xdb\x31\x7b\x17\x9e\x5c\x5e\x81\x82\xd8\x0f\x9b\x58\xd6\x0f\x49\x58\x63\xb9\x3c\xb9\x1d\x96\x2e\x7f\xa1\xc2\x6d\x49\x94\x52\x26\xee\x4a\x5e\x43\x53\x8d\xb8\x7e\x58\x21\x3e\xbb\xbc\xdd\x8b\x8c\xbb\x32\xfc\x00\x25\x53\x9d\xd2\x76\xdb\xad\x05\xab\xc9\xb8\xc9\x95\xf1\xd2\xbe\x38\x0d\x8a\xff\x33\xfb\x0f\xd5\xa3\xb1\xcc\x4b\x1b\x00\x00")
+
+func AssetsInvite_emailSvTemplateHtmlBytes() ([]byte, error) {
+	return bindataRead(
+		_AssetsInvite_emailSvTemplateHtml,
+		"../../../assets/invite_email/sv/template.html",
+	)
+}
+
+func AssetsInvite_emailSvTemplateHtml() (*asset, error) {
+	bytes, err := AssetsInvite_emailSvTemplateHtmlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "../../../assets/invite_email/sv/template.html", size: 6987, mode: os.FileMode(420), modTime: time.Unix(1792322080, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _AssetsInvite_emailSvTemplateTxt = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6d\x92\xcd\x8d\xdc\x30\x0c\x85\xef\xaa\x82\x05\x4c\x5c\x43\x0e\x01\x82\x5c\x92\x1a\xe8\x91\x6c\x73\x25\x53\x86\x48\x1b\x09\x06\xdb\xcd\x4c\x0b\xdb\x80\x1b\x0b\x65\xcf\x78\x76\x17\x7b\x13\xc4\x9f\xc7\xf7\x91\x97\x0b\x34\x1a\xfe\x2a\xbc\xbe\x3a\xf7\x63\x86\x01\x0b\xb4\x89\x16\x52\x20\x6e\x5f\x66\x1f\x18\x94\x52\x02\x7d\x59\xaf\x2c\x1a\x0a\x23\x0c\x59\xe0\x62\x85\x25\x60\x1a\xad\xb2\x71\xee\xe7\x7a\xdb\xf3\xea\x7f\x22\x8e\xf6\x0d\xdd\xfa\x56\x00\x55\x21\xe6\x71\x44\x58\x6f\xfa\xbe\x4d\xb3\x09\xb6\x61\x58\xdf\x96\x60\x79\xbc\x58\xc8\x23\xfc\x62\x0d\x7d\x21\xfd\xf7\x0d\xa7\xc9\xe4\x27\x6b\xed\x89\x21\xf0\x10\xf4\xe8\xe9\xc8\x46\x53\xea\x28\x14\xb4\x70\x0f\xf9\x3c\x7c\xad\xf3\xd5\xb8\x7f\x46\xf0\xb3\x39\xd4\xb0\x39\xde\x85\xac\x82\x67\x90\x48\xe7\x68\x7f\xbb\x7d\x26\xee\x25\x59\x24\x5a\x42\x95\xa9\x26\x1d\x07\x9f\xd0\xef\x41\x21\x8f\xdc\xc0\xef\xf5\xfa\xec\x53\xab\x45\x31\x25\x1b\xce\x43\x4c\x7b\x47\x53\x34\x2f\xee\xd1\x2d\x2f\xc8\x40\xbd\xbd\x0e\x4e\xdd\x9d\x62\xbf\xde\xb8\xdf\xc7\xfc\x44\xc3\xb9\xcf\x78\xaa\x9a\x27\x2b\x96\xf5\x1a\x77\x16\x64\xd2\x08\x13\x8a\x34\x60\x88\xef\x64\x8d\xb1\x21\x73\x87\x58\x75\x5f\xb0\xaf\xfc\xc6\xe0\x9f\xc4\x40\xf2\xf8\xae\xe6\xd0\x13\xe7\xc3\xd9\x98\x17\x4c\x24\xd5\x58\xb0\x16\x5d\x41\xd1\x32\x47\x9d\xcb\xa6\x55\x69\xc6\x6c\x49\xd9\x6e\x61\x5f\xac\x25\xe5\x32\xa2\x52\x66\x93\xa7\x6d\xae\xa0\xa7\x4d\xa6\x9a\x1d\xea\x40\x8f\x0d\x76\xc4\x2c\x1f\x57\x7e\xda\x02\xf5\x10\x07\xdc\x46\x16\x97\xdb\x50\x72\xb0\xf1\x00\x17\x8b\xc0\xb6\x59\xa9\x80\x8f\xb9\xef\x1b\xb1\x2f\x1f\x12\x7e\x98\xa2\xda\x75\xf5\xae\x77\xc7\x10\x6d\x0f\x2d\x56\x74\xf3\x26\xf5\x20\xc1\xcf\xea\x0a\x48\x02\x98\x77\x16\x8c\xbb\x15\xdb\x4f\xd5\x4a\xd4\xdb\x89\xad\xd7\x24\xf5\x1c\xb0\x9c\x9c\xcc\xd3\x94\x8b\x7e\x9f\xd2\x2c\xf4\xa0\xd7\x9c\xf3\xe8\xfe\x03\xa8\x79\xaa\x05\x6e\x03\x00\x00")
+
+func AssetsInvite_emailSvTemplateTxtBytes() ([]byte, error) {
+	return bindataRead(
+		_AssetsInvite_emailSvTemplateTxt,
+		"../../../assets/invite_email/sv/template.txt",
+	)
+}
+
+func AssetsInvite_emailSvTemplateTxt() (*asset, error) {
+	bytes, err := AssetsInvite_emailSvTemplateTxtBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "../../../assets/invite_email/sv/template.txt", size: 878, mode: os.FileMode(420), modTime: time.Unix(1792321565, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _AssetsInvite_emailTemplateHtml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xcd\x59\x6d\x6f\xdb\x36\x10\xfe\x9e\x5f\xc1\xa9\xd8\xf2\xa1\x96\x65\x27\xcd\x0b\x5c\xdb\xc3\x50\x60\x5b\x81\x0d\xed\xd6\x6c\xc3\x3e\x0d\x94\x74\x92\xd8\xd2\xa4\x4a\xd2\x4e\xdd\x2e\xff\x7d\x47\xd2\x92\x25\xf9\x2d\x69\xb2\x20\x36\x1a\x59\x22\xef\xee\xb9\x57\xde\xa9\xe3\x6f\x52\x99\x98\x65\x09\xe4\xe7\xab\x5f\x7f\x99\x1e\x8d\x0b\x33\xe3\xd3\x23\xbc\x02\x4d\xa7\x47\x04\x3f\xe3\x19\x18\x4a\x0a\x63\xca\x10\x3e\xce\xd9\x62\x12\xbc\x92\xc2\x80\x30\xe1\x15\xd2\x05\x24\xf1\x77\x93\xc0\xc0\x27\x13\x59\x7a\x92\x14\x54\x69\x30\x93\x3f\xae\x7e\x0c\x2f\x03\x12\x35\x19\x09\x3a\x83\x49\xb0\x60\x70\x5d\x4a\x65\x1a\xe4\xd7\x2c\x35\xc5\x24\x85\x05\x4b\x20\x74\x37\x3d\xc2\x04\x33\x8c\xf2\x50\x27\x94\xc3\x64\xd8\x1f\xac\x99\x19\x66\x38\x4c\x4b\x3e\xd7\x0c\x19\xe4\x8a\x99\xe5\x38\xf2\x0f\xfd\x06\xce\xc4\x07\x52\x28\xc8\x26\x81\x05\xaf\x47\x51\x94\xa1\x2c\xdd\xcf\xa5\xcc\x39\xd0\x92\xe9\x7e\x22\x67\x51\xa2\xf5\xf7\x19\x9d\x31\xbe\x9c\xbc\x93\x73\x95\xc0\xf3\x77\x54\xe8\xe7\x6f\x95\x1c\x9d\x0e\x06\xbd\x17\x03\x14\xaa\x80\x4f\x02\x6d\x96\x1c\x74\x01\x60\x82\x95\x08\xf7\xc4\xff\xb6\x9f\x58\xa6\x4b\xf2\xa5\xbe\xb5\x1f\x2b\x31\xf4\xdc\x47\xe4\xd8\xf3\x27\x96\x3f\x41\xfe\xc7\x3d\xf2\x27\xa8\x94\x0a\xda\x23\x3f\x81\x80\x05\x5e\x35\xae\x85\x1a\x14\xcb\x5e\x6e\x32\xd2\xec\x33\x8c\xc8\xf0\xbc\xfc\xd4\x5e\x44\x55\x21\x2c\x80\xe5\x85\xc1\xe5\xfe\xf9\xe9\x16\xda\xeb\xd5\xb2\x90\x6a\x46\x79\x7b\x43\x22\xb9\x54\x23\xf2\xec\x22\xb3\xdf\xf5\xda\xcd\x51\xfd\x93\x76\x14\xb3\xce\x0e\x53\x48\xa4\xa2\x86\x49\x61\xf9\x0a\xd8\x41\xc9\x66\x79\x87\x3a\x96\x2a\x05\xb5\x87\xa8\x18\x6e\x33\xa4\xd7\xff\xe4\xb2\xab\x7f\x4b\x41\x74\xda\x1e\xeb\x9c\x6e\x10\xcf\xa8\xca\x19\x2a\x70\x72\x56\x7e\x22\x1d\xd2\x92\xa6\x29\x13\xf9\xa8\xf9\xbc\x81\xb2\x3f\xa3\x4c\x74\x80\xba\xc8\x1d\x91\xf3\xc1\xa0\x2b\xc8\x2b\x1d\x72\xc8\xac\x97\x50\x98\x96\x9c\xa5\xe4\x19\x0c\xec\x77\xeb\x5e\xb5\x72\xe9\xee\xcd\x4d\x34\xe8\x46\x34\x51\x0a\xdb\x11\x5d\xb6\x00\x35\xe9\xe2\x7f\x72\x05\xdd\xc8\x8d\x69\xf2\x21\x57\x72\x2e\xd2\xb0\x0a\x8f\x6c\x60\xbf\xdb\x79\x18\x0c\x07\x8c\x05\x91\x77\xa5\xd7\xc4\x17\xe7\x74\x40\x77\x13\xc7\x1c\x05\xee\xa0\x1d\xb8\xcf\x0e\xf0\x46\x20\x40\x91\x31\x35\xdb\x16\xa2\x94\xb3\x1c\x9d\x9b\x60\x89\x01\x75\x98\x43\x37\xcc\x57\x08\xae\x0b\x66\xb6\x07\x6a\x3f\x93\x12\x39\x6f\x10\x36\xf3\x75\xb8\x37\x5f\x4f\xfa\xa7\xe7\x77\x4e\xc7\x4a\x6c\x5f\x43\x49\x31\x05\xa5\xda\x23\xff\xa4\xed\x78\xfb\x77\x1c\xad\x6a\xd7\x38\xf2\x95\xfe\x68\xec\xaa\x57\x9c\x3b\xe1\x58\x8d\xad\xc6\x55\x9d\x33\x34\xe6\xe0\xe3\x68\x12\x0c\x07\x83\x6f\x83\x75\xd1\x1b\x1b\x35\x6d\x49\x1e\x9b\x94\x38\xab\x4f\x02\x6f\xf5\xa0\xbd\xde\xe0\x98\x70\xaa\xf5\x24\xb0\x59\x84\x07\x01\x70\xae\x4b\x9a\x60\xbe\x4d\x82\x81\xbf\x5f\xe5\x9f\xbb\xf7\x29\xe1\x7f\x6e\x43\xb9\x29\x43\x11\x6f\xe3\x49\x70\xba\x63\x4f\x85\x17\xd9\xa1\x68\xb1\x7f\x63\x4d\x60\xeb\x99\x56\x09\xea\xc7\xd2\x91\x43\xa2\xfb\xef\xcb\x3c\x40\xb5\x51\x56\x50\x19\x0a\x4b\x40\x70\x90\xd9\x1a\x21\x71\x1e\x99\x04\x29\xd3\x25\xa7\xcb\x51\xcc\x65\xf2\xe1\x65\x10\xed\x41\x1e\x99\x74\x87\xee\x51\xd7\x2b\x6b\x6d\x77\x2c\xf8\xc5\xb4\xf2\x49\x55\x4b\x82\x69\x5b\x5d\xeb\x21\x50\xfd\x52\x6c\xa8\x7b\x79\x40\xdb\x4a\xd3\xa1\x3d\xc3\x77\x43\xaf\x70\x54\xdb\x2f\xcf\x82\x4e\x38\x91\xc5\xa1\xf0\x6a\xf1\xa2\x9d\x26\xa0\xd5\x32\xd8\x0e\xe0\xce\x2e\x07\x0c\x58\x1e\x72\x99\xcb\x70\xcd\x68\x6d\x91\xe7\xf5\xc3\xda\x36\xc3\xe1\xd9\xe1\x50\x68\x1a\xe9\xe4\xa2\xee\x74\x76\x62\x8a\xe8\xd7\x84\x86\x5b\x7d\x02\x7e\xde\x1b\xa2\x15\x3a\x7f\x32\x05\x6b\x9e\x17\x67\x87\x32\x79\x43\xad\x83\x96\xa8\xc2\xc9\xc8\x12\x99\xef\xb7\x79\x31\x9c\x7e\xf9\x82\x07\x16\x1e\x2d\xe4\xe6\x06\x8b\xe7\xf0\x16\x14\x7f\xcb\x39\x29\xe8\x02\x48\x0c\x20\xb0\xa7\x5d\x60\xd1\x4a\x89\x91\xc4\xb2\x52\x40\xf9\x0c\x79\x11\x6c\xfa\x6c\xeb\xab\x6f\xc3\xb4\x55\x3e\x1b\xe7\x57\xed\xa4\x13\xdb\xb5\x56\x56\x3b\x6b\x96\xcc\xd5\x41\x7c\x97\x7a\x7b\x8b\xfc\xd8\x57\x55\xda\x1b\xd3\x16\xac\x3b\xa5\x72\x8b\x51\x95\xd6\xd6\x86\xae\xd5\xbf\xb9\x09\xa6\x3f\x24\x68\x40\x4d\x4c\x01\x0d\x73\xee\x49\x93\x16\xc7\xbd\x81\xd2\xd8\x75\x40\x55\xdc\x61\xbd\x73\xc8\x85\xe5\xf4\x8d\x22\x38\xd7\x08\x87\x36\x91\xa9\x3d\x60\x4d\xe1\xee\x68\x59\x8e\xc6\xf1\x2d\x4c\xda\xce\x59\x1f\x59\xe1\xc7\x66\xda\xfe\xf6\xbb\xe3\xbd\x3d\x30\xdc\xcd\xc1\x32\x53\x3e\x6c\x99\x79\xd8\x8a\x70\xba\x2f\x3c\xbf\xae\x20\x4c\xbf\x13\xb1\x2e\x5f\x3e\x9e\x6e\xff\x5b\x45\xbb\x75\x56\xd9\x60\xb3\x55\xea\x9a\x71\x4e\x04\xf8\xea\x34\xd7\xe0\xa2\x71\x6c\x5b\xa3\x0a\xc6\xba\xcf\x47\x20\xf1\xf4\x75\x75\xd4\xd9\x98\x1d\x47\x31\x82\xb3\xbb\xa7\x44\x0a\xb2\xc4\x69\x97\xf8\x79\x1e\x8b\x1e\x71\xe5\xc4\xf2\x45\x25\x84\x61\xd9\xd2\x6d\xd0\xc0\x33\x42\x05\xb6\x8c\x3e\x75\x9b\x25\xf1\x58\xd7\x59\xdc\xb7\x08\xed\xbf\xd7\x99\x25\x23\xa9\xc4\xd9\xd1\xf8\xaa\xba\xca\x18\xb2\x04\xd3\x73\x37\x2e\x0f\xdc\x58\x4a\x5c\x65\x70\x5a\x29\x48\x99\x82\xc4\x38\x72\x44\x61\x37\xa6\xf2\x5a\x70\x49\x53\x9c\xf3\x72\xe8\x93\x37\x22\x59\x73\x63\x1a\xf9\x68\x43\x39\x87\xb4\x47\xde\xcf\xb5\x41\x13\x30\x1c\x51\xdc\x86\x58\xa2\x60\xc7\x9c\xe6\x76\x06\x44\x86\xee\xea\xb5\xa8\xd1\x6e\x98\xce\x4d\x39\x58\xa7\x62\x39\x37\x8e\x53\xc7\x80\xce\x78\x15\xf5\x55\x77\xdd\x82\x72\x56\xd5\x90\xcc\x15\xe2\x67\x39\x2a\xca\x11\xbe\xd6\xf6\x6d\x4a\x9f\x58\x27\x5a\xbf\x31\xe3\x2c\x6d\xdd\x4f\x51\x67\x57\x59\x2a\x5b\xa2\x5c\x6a\xdc\xae\x9a\x39\x5a\x1a\x67\x79\x74\x8b\xc2\xc8\xf9\x8c\xee\x67\x22\x53\x54\x1b\x35\x4f\x0c\x0a\xf2\x7c\x9d\xb1\xed\xeb\x1a\x25\x39\x91\xd9\xca\xd4\x99\x7d\x9f\xe0\x6c\xcd\x7c\x29\xab\xc0\xf4\xec\xd4\x94\x14\x78\xe8\x71\x29\x72\x6d\xf1\x58\xd3\x5b\x5f\x2b\xb0\x91\x6c\x0d\xdc\x0c\x92\x9e\x5b\x43\xe4\xa8\xa5\x2c\x11\xb8\x71\x40\x52\x28\x41\xd8\x90\xe1\x4b\x92\x29\x39\x73\x42\x5c\x88\xac\x34\x71\x5c\xbd\xdd\xc9\x5f\x05\x38\x9e\x44\x17\xd4\x1a\x88\x1a\xea\x95\xa7\x95\xfa\x3d\x0c\x4d\xbe\xac\xa1\x34\x8e\x8a\x06\x9d\x23\xb1\xb5\x59\x83\x8f\x08\x34\x8c\xd0\x68\x49\xd4\xb3\x76\xee\x93\xab\x8a\xc4\x0f\x85\x0f\xdf\x21\x55\xc5\xf6\xc5\xd9\xfa\xc4\x8e\xa5\x31\x07\x7b\xe7\xc3\xcd\x77\x84\x80\x67\x3a\xa4\xee\x35\x83\x48\x99\xb5\xb0\x8e\x9e\xad\x5c\x12\xba\xd5\x7a\x34\xba\xcf\xdb\xb4\xee\x54\xbd\x31\xa1\x07\xd3\x2b\x2b\xcc\x45\xc5\x2b\x84\xe2\x90\xd8\xee\x81\xf8\xf3\x60\x75\xd9\xaf\x6f\x33\xdf\xeb\xd1\x3c\x98\xfe\xbb\xca\xec\x3b\x70\xfa\x5a\xcb\x95\x8a\x2d\x68\xb2\x7c\x2c\x9b\xbd\xf5\xe2\xc8\x5b\x89\xc5\x71\x49\x9e\xb2\xbd\x6c\xe9\xc2\x14\x8e\x1e\xcb\x34\xaf\xbc\xbc\x07\x9f\xd3\x1e\xa4\x81\x7a\x94\x1a\xe1\x27\xaa\x5d\x54\x8e\xf2\x29\x4c\xe7\xe7\xc3\xc6\x84\xf9\xe2\x7e\x83\xf7\x2e\x3c\xa5\xbc\x06\x6c\x41\xc2\xb8\x89\x65\xf5\x90\xc4\x6b\x2c\x97\x27\x77\xc3\xd2\xb5\x5f\xac\xb0\x47\x49\x0a\xec\x45\xee\x6b\xbc\x06\xa7\x35\xe2\xf5\xc3\x1a\xf1\xd9\xe5\xdd\x5e\x6d\xdc\xd7\xc2\x8f\x90\x32\xf5\x08\xb7\x5b\xee\x9a\xb0\xde\x8c\xed\xaf\x4c\x97\xf6\x55\x6a\xe4\xff\x17\xed\x3f\x1d\x1a\xac\xec\x5d\x1b\x00\x00")
 
 func AssetsInvite_emailTemplateHtmlBytes() ([]byte, error) {
 	return bindataRead(
@@ -189,7 +319,7 @@ func AssetsInvite_emailTemplateHtml() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "../../../assets/invite_email/template.html", size: 6802, mode: os.FileMode(420), modTime: time.Unix(1533736706, 0)}
+	info := bindataFileInfo{name: "../../../assets/invite_email/template.html", size: 7005, mode: os.FileMode(420), modTime: time.Unix(1792322080, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -214,6 +344,86 @@ func AssetsInvite_emailTemplateTxt() (*asset, error) {
 	return a, nil
 }
 
+var _AssetsInvite_smsSvTemplateTxt = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x3d\x8c\xc1\x0d\xc2\x30\x10\x04\xff\x54\xb1\x0d\xe0\x2a\xf8\xf0\xa1\x87\x4b\x72\x81\x83\xf3\xc5\x32\x17\x04\xb2\xd2\x46\x4a\xa1\x81\x34\x86\x0d\x12\xbf\xd1\x68\x76\x4b\x81\x8c\x08\xce\x4f\xc7\xb2\x94\xf2\x47\x54\x66\x1b\x2a\x1d\x66\x5c\x28\xa3\x53\x79\x88\x43\xac\xbb\xce\x03\x1b\x5c\x54\x5b\x14\x32\x93\xc6\xb6\xb8\x4f\xf1\x27\x26\xe5\x13\x45\xae\x2e\x60\x5b\x53\x32\xfa\x7a\x15\xbb\xb5\x4e\x70\x34\xe7\x73\x16\x7f\xed\x29\xa5\xfa\x35\x6e\xef\x0c\x72\x07\xf5\x3d\x27\xe7\x4c\x61\xf7\x01\x19\x5b\xdb\x59\x9a\x00\x00\x00")
+
+func AssetsInvite_smsSvTemplateTxtBytes() ([]byte, error) {
+	return bindataRead(
+		_AssetsInvite_smsSvTemplateTxt,
+		"../../../assets/invite_sms/sv/template.txt",
+	)
+}
+
+func AssetsInvite_smsSvTemplateTxt() (*asset, error) {
+	bytes, err := AssetsInvite_smsSvTemplateTxtBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "../../../assets/invite_sms/sv/template.txt", size: 154, mode: os.FileMode(420), modTime: time.Unix(1792321565, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _AssetsInvite_smsTemplateTxt = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x3d\x8c\x41\x0e\xc2\x30\x10\x03\xef\xbc\xc2\x2f\xc8\x3f\x7a\x81\x33\xc7\x6d\x6b\xe8\x8a\x74\x13\x95\xa5\x02\x45\xfd\x3b\x59\x21\x71\x1b\x5b\x63\xb7\x06\xbd\x21\x39\xdf\x8e\xe3\x68\xed\x8f\xe8\x4c\x9b\x3b\x5d\xcb\x0b\x8b\xec\xc4\x48\x1a\xd4\x76\x75\xce\xf0\x12\x46\xda\x28\x79\x0d\x5d\x9e\xbf\x5c\x32\xcf\xb2\xb2\x57\x09\x97\xda\x07\xd1\x66\xb5\x47\x48\x6a\xf0\x85\x18\xcc\x79\xdf\xd4\x3f\x90\x5a\xe3\x49\xa6\x89\xd5\xd3\xe9\x0b\xd1\xd1\x68\x66\x8e\x00\x00\x00")
+
+func AssetsInvite_smsTemplateTxtBytes() ([]byte, error) {
+	return bindataRead(
+		_AssetsInvite_smsTemplateTxt,
+		"../../../assets/invite_sms/template.txt",
+	)
+}
+
+func AssetsInvite_smsTemplateTxt() (*asset, error) {
+	bytes, err := AssetsInvite_smsTemplateTxtBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "../../../assets/invite_sms/template.txt", size: 142, mode: os.FileMode(420), modTime: time.Unix(1792320870, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _AssetsJoin_contractSvTemplateTxt = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x73\x3f\xbc\x54\x21\x37\x35\x45\x21\x53\xa1\xba\x5a\x41\xaf\x28\x35\x31\x27\x57\xa1\xb6\x56\xa1\x38\x3f\x17\x22\x90\x9f\x93\xea\x97\x98\x9b\x0a\x14\xb3\xe7\x02\x00\x0c\x5b\x96\x5b\x2c\x00\x00\x00")
+
+func AssetsJoin_contractSvTemplateTxtBytes() ([]byte, error) {
+	return bindataRead(
+		_AssetsJoin_contractSvTemplateTxt,
+		"../../../assets/join_contract/sv/template.txt",
+	)
+}
+
+func AssetsJoin_contractSvTemplateTxt() (*asset, error) {
+	bytes, err := AssetsJoin_contractSvTemplateTxtBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "../../../assets/join_contract/sv/template.txt", size: 44, mode: os.FileMode(420), modTime: time.Unix(1792321565, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _AssetsJoin_contractTemplateTxt = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xf3\xca\xcf\xcc\x53\xa8\xae\x56\xd0\x2b\x4a\x4d\xcc\xc9\x55\xa8\xad\x55\x48\x2c\x86\xf0\xf3\x73\x52\xfd\x12\x73\x53\x81\x42\xf6\x5c\x00\xfa\xef\xbd\x39\x26\x00\x00\x00")
+
+func AssetsJoin_contractTemplateTxtBytes() ([]byte, error) {
+	return bindataRead(
+		_AssetsJoin_contractTemplateTxt,
+		"../../../assets/join_contract/template.txt",
+	)
+}
+
+func AssetsJoin_contractTemplateTxt() (*asset, error) {
+	bytes, err := AssetsJoin_contractTemplateTxtBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "../../../assets/join_contract/template.txt", size: 38, mode: os.FileMode(420), modTime: time.Unix(1792321565, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -266,13 +476,23 @@ func AssetNames() []string {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
+	"../../../assets/invite_contract/sv/template.txt": AssetsInvite_contractSvTemplateTxt,
+	"../../../assets/invite_contract/template.txt": AssetsInvite_contractTemplateTxt,
 	"../../../assets/invite_email/attachments/colors.jpg": AssetsInvite_emailAttachmentsColorsJpg,
 	"../../../assets/invite_email/attachments/email-logo-brickchain.png": AssetsInvite_emailAttachmentsEmailLogoBrickchainPng,
 	"../../../assets/invite_email/attachments/email-logo-integrity.png": AssetsInvite_emailAttachmentsEmailLogoIntegrityPng,
 	"../../../assets/invite_email/attachments/email-powered-by.png": AssetsInvite_emailAttachmentsEmailPoweredByPng,
 	"../../../assets/invite_email/attachments/spacer.png": AssetsInvite_emailAttachmentsSpacerPng,
+	"../../../assets/invite_email/subject.txt": AssetsInvite_emailSubjectTxt,
+	"../../../assets/invite_email/sv/subject.txt": AssetsInvite_emailSvSubjectTxt,
+	"../../../assets/invite_email/sv/template.html": AssetsInvite_emailSvTemplateHtml,
+	"../../../assets/invite_email/sv/template.txt": AssetsInvite_emailSvTemplateTxt,
 	"../../../assets/invite_email/template.html": AssetsInvite_emailTemplateHtml,
 	"../../../assets/invite_email/template.txt": AssetsInvite_emailTemplateTxt,
+	"../../../assets/invite_sms/sv/template.txt": AssetsInvite_smsSvTemplateTxt,
+	"../../../assets/invite_sms/template.txt": AssetsInvite_smsTemplateTxt,
+	"../../../assets/join_contract/sv/template.txt": AssetsJoin_contractSvTemplateTxt,
+	"../../../assets/join_contract/template.txt": AssetsJoin_contractTemplateTxt,
 }
 
 // AssetDir returns the file names below a certain
@@ -319,6 +539,12 @@ var _bintree = &bintree{nil, map[string]*bintree{
 		"..": &bintree{nil, map[string]*bintree{
 			"..": &bintree{nil, map[string]*bintree{
 				"assets": &bintree{nil, map[string]*bintree{
+					"invite_contract": &bintree{nil, map[string]*bintree{
+						"sv": &bintree{nil, map[string]*bintree{
+							"template.txt": &bintree{AssetsInvite_contractSvTemplateTxt, map[string]*bintree{}},
+						}},
+						"template.txt": &bintree{AssetsInvite_contractTemplateTxt, map[string]*bintree{}},
+					}},
 					"invite_email": &bintree{nil, map[string]*bintree{
 						"attachments": &bintree{nil, map[string]*bintree{
 							"colors.jpg": &bintree{AssetsInvite_emailAttachmentsColorsJpg, map[string]*bintree{}},
@@ -327,9 +553,27 @@ var _bintree = &bintree{nil, map[string]*bintree{
 							"email-powered-by.png": &bintree{AssetsInvite_emailAttachmentsEmailPoweredByPng, map[string]*bintree{}},
 							"spacer.png": &bintree{AssetsInvite_emailAttachmentsSpacerPng, map[string]*bintree{}},
 						}},
+						"subject.txt": &bintree{AssetsInvite_emailSubjectTxt, map[string]*bintree{}},
+						"sv": &bintree{nil, map[string]*bintree{
+							"subject.txt": &bintree{AssetsInvite_emailSvSubjectTxt, map[string]*bintree{}},
+							"template.html": &bintree{AssetsInvite_emailSvTemplateHtml, map[string]*bintree{}},
+							"template.txt": &bintree{AssetsInvite_emailSvTemplateTxt, map[string]*bintree{}},
+						}},
 						"template.html": &bintree{AssetsInvite_emailTemplateHtml, map[string]*bintree{}},
 						"template.txt": &bintree{AssetsInvite_emailTemplateTxt, map[string]*bintree{}},
 					}},
+					"invite_sms": &bintree{nil, map[string]*bintree{
+						"sv": &bintree{nil, map[string]*bintree{
+							"template.txt": &bintree{AssetsInvite_smsSvTemplateTxt, map[string]*bintree{}},
+						}},
+						"template.txt": &bintree{AssetsInvite_smsTemplateTxt, map[string]*bintree{}},
+					}},
+					"join_contract": &bintree{nil, map[string]*bintree{
+						"sv": &bintree{nil, map[string]*bintree{
+							"template.txt": &bintree{AssetsJoin_contractSvTemplateTxt, map[string]*bintree{}},
+						}},
+						"template.txt": &bintree{AssetsJoin_contractTemplateTxt, map[string]*bintree{}},
+					}},
 				}},
 			}},
 		}},
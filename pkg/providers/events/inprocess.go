@@ -0,0 +1,96 @@
+package events
+
+import (
+	"io"
+	"sync"
+
+	logger "github.com/IpsoVeritas/logger"
+	realm "github.com/IpsoVeritas/realm"
+)
+
+const subscriberBuffer = 100
+
+// InProcessEventBus delivers events to subscribers within the same process.
+// Each subscriber gets its own goroutine so a slow handler doesn't block publishers
+// or other subscribers, and events are delivered to a handler in the order they were published.
+// Events for a subscriber that has fallen more than subscriberBuffer events behind are dropped.
+type InProcessEventBus struct {
+	mu            sync.RWMutex
+	subscriptions map[string]map[*subscription]struct{}
+}
+
+type subscription struct {
+	bus    *InProcessEventBus
+	topic  string
+	events chan *realm.RealmEvent
+	done   chan struct{}
+	once   sync.Once
+}
+
+func NewInProcessEventBus() *InProcessEventBus {
+	return &InProcessEventBus{
+		subscriptions: make(map[string]map[*subscription]struct{}),
+	}
+}
+
+func (b *InProcessEventBus) Publish(topic string, event *realm.RealmEvent) error {
+	b.mu.RLock()
+	subscriptions := make([]*subscription, 0, len(b.subscriptions[topic]))
+	for s := range b.subscriptions[topic] {
+		subscriptions = append(subscriptions, s)
+	}
+	b.mu.RUnlock()
+
+	// publishers are often request handlers, so the event is dropped rather than
+	// waiting for a subscriber that has fallen behind
+	for _, s := range subscriptions {
+		select {
+		case s.events <- event:
+		case <-s.done:
+		default:
+			logger.Warningf("Dropped %s event for realm %s, subscriber on %s is full", event.Type, event.Realm, topic)
+		}
+	}
+
+	return nil
+}
+
+func (b *InProcessEventBus) Subscribe(topic string, handler realm.EventHandler) (io.Closer, error) {
+	s := &subscription{
+		bus:    b,
+		topic:  topic,
+		events: make(chan *realm.RealmEvent, subscriberBuffer),
+		done:   make(chan struct{}),
+	}
+
+	b.mu.Lock()
+	if b.subscriptions[topic] == nil {
+		b.subscriptions[topic] = make(map[*subscription]struct{})
+	}
+	b.subscriptions[topic][s] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		for {
+			select {
+			case event := <-s.events:
+				handler(event)
+			case <-s.done:
+				return
+			}
+		}
+	}()
+
+	return s, nil
+}
+
+func (s *subscription) Close() error {
+	s.once.Do(func() {
+		s.bus.mu.Lock()
+		delete(s.bus.subscriptions[s.topic], s)
+		s.bus.mu.Unlock()
+		close(s.done)
+	})
+
+	return nil
+}
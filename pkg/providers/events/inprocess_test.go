@@ -0,0 +1,102 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	realm "github.com/IpsoVeritas/realm"
+)
+
+func TestInProcessEventBus_Publish(t *testing.T) {
+	bus := NewInProcessEventBus()
+
+	received := make(chan *realm.RealmEvent, 10)
+	sub, err := bus.Subscribe("test", func(event *realm.RealmEvent) {
+		received <- event
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	for _, id := range []string{"a", "b", "c"} {
+		if err := bus.Publish("test", realm.NewRealmEvent(realm.EventRealmCreated, id, id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := bus.Publish("other", realm.NewRealmEvent(realm.EventRealmDeleted, "d", "d")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"a", "b", "c"} {
+		select {
+		case event := <-received:
+			if event.Realm != id {
+				t.Errorf("InProcessEventBus delivered realm %s, want %s", event.Realm, id)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("InProcessEventBus did not deliver event for %s", id)
+		}
+	}
+
+	select {
+	case event := <-received:
+		t.Errorf("InProcessEventBus delivered event from another topic: %v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestInProcessEventBus_Close(t *testing.T) {
+	bus := NewInProcessEventBus()
+
+	received := make(chan *realm.RealmEvent, 10)
+	sub, err := bus.Subscribe("test", func(event *realm.RealmEvent) {
+		received <- event
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sub.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := bus.Publish("test", realm.NewRealmEvent(realm.EventRealmCreated, "a", "a")); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case event := <-received:
+		t.Errorf("InProcessEventBus delivered event after close: %v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestInProcessEventBus_Publish_slow_subscriber(t *testing.T) {
+	bus := NewInProcessEventBus()
+
+	block := make(chan struct{})
+	defer close(block)
+	sub, err := bus.Subscribe("test", func(event *realm.RealmEvent) {
+		<-block
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < subscriberBuffer*2; i++ {
+			bus.Publish("test", realm.NewRealmEvent(realm.EventRealmCreated, "a", "a"))
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("InProcessEventBus.Publish() blocked on a slow subscriber")
+	}
+}
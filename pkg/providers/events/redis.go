@@ -0,0 +1,69 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	logger "github.com/IpsoVeritas/logger"
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+// RedisEventBus publishes events using Redis pub/sub, letting several realm
+// instances and external consumers share the same event stream.
+type RedisEventBus struct {
+	client *redis.Client
+}
+
+func NewRedisEventBus(addr string) (*RedisEventBus, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr: addr,
+	})
+
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to redis at %s", addr)
+	}
+
+	return &RedisEventBus{
+		client: client,
+	}, nil
+}
+
+func (b *RedisEventBus) Publish(topic string, event *realm.RealmEvent) error {
+	bytes, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal event")
+	}
+
+	return b.client.Publish(context.Background(), topic, bytes).Err()
+}
+
+func (b *RedisEventBus) Subscribe(topic string, handler realm.EventHandler) (io.Closer, error) {
+	ctx := context.Background()
+	pubsub := b.client.Subscribe(ctx, topic)
+
+	// wait for the subscription to be confirmed before returning
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, errors.Wrapf(err, "failed to subscribe to %s", topic)
+	}
+
+	go func() {
+		for msg := range pubsub.Channel() {
+			event := &realm.RealmEvent{}
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				logger.Warningf("Failed to unmarshal event on %s: %s", topic, err)
+				continue
+			}
+			handler(event)
+		}
+	}()
+
+	return pubsub, nil
+}
+
+func (b *RedisEventBus) Close() error {
+	return b.client.Close()
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/IpsoVeritas/logger"
 
@@ -34,10 +35,18 @@ func NewFilesystem(base, dir string) (*Filesystem, error) {
 }
 
 func (f *Filesystem) Read(name string) ([]byte, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+
 	return ioutil.ReadFile(fmt.Sprintf("%s/%s", f.dir, name))
 }
 
 func (f *Filesystem) Write(name string, input io.Reader) (string, error) {
+	if err := ValidateName(name); err != nil {
+		return "", err
+	}
+
 	dir := filepath.Dir(name)
 	err := os.MkdirAll(fmt.Sprintf("%s/%s", f.dir, dir), 0755)
 	if err != nil {
@@ -63,8 +72,41 @@ func (f *Filesystem) Write(name string, input io.Reader) (string, error) {
 	return fullname, err
 }
 
+func (f *Filesystem) Delete(name string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+
+	return os.Remove(fmt.Sprintf("%s/%s", f.dir, name))
+}
+
+func (f *Filesystem) List(prefix string) ([]string, error) {
+	names := make([]string, 0)
+	err := filepath.Walk(f.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		name, err := filepath.Rel(f.dir, path)
+		if err != nil {
+			return err
+		}
+		name = filepath.ToSlash(name)
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+
+		return nil
+	})
+
+	return names, err
+}
+
 func (f *Filesystem) Handler(w http.ResponseWriter, r *http.Request, params httprouter.Params) error {
-	name := params.ByName("filename")
+	name := strings.TrimPrefix(params.ByName("filename"), "/")
 	if name == "" {
 		logger.Error("No filename given")
 		http.Error(w, "No filename given", http.StatusBadRequest)
@@ -72,6 +114,10 @@ func (f *Filesystem) Handler(w http.ResponseWriter, r *http.Request, params http
 	}
 
 	bytes, err := f.Read(name)
+	if err == ErrInvalidName {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil
+	}
 	if err != nil {
 		logger.Error(err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
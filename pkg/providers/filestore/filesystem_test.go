@@ -41,3 +41,70 @@ func TestFilesystem_WriteWithDir(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestFilesystem_List(t *testing.T) {
+	f, err := NewFilesystem("", ".test-files")
+	defer os.RemoveAll(".test-files")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"realm_a/icon.png", "realm_a/templates/templates.json", "realm_b/icon.png"} {
+		if _, err := f.Write(name, bytes.NewBufferString(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names, err := f.List("realm_a/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(names) != 2 || names[0] != "realm_a/icon.png" || names[1] != "realm_a/templates/templates.json" {
+		t.Errorf("Filesystem.List() = %v", names)
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		wantErr bool
+	}{
+		{name: "ValidateName", file: "icon.png", wantErr: false},
+		{name: "ValidateName_with_dir", file: "realm_a/templates/invite_email/sv/templates.json", wantErr: false},
+		{name: "ValidateName_dots_in_name", file: "realm_a/icon..png", wantErr: false},
+		{name: "ValidateName_empty", file: "", wantErr: true},
+		{name: "ValidateName_absolute", file: "/etc/passwd", wantErr: true},
+		{name: "ValidateName_parent", file: "../../etc/passwd", wantErr: true},
+		{name: "ValidateName_parent_inside", file: "realm_a/../realm_b/icon.png", wantErr: true},
+		{name: "ValidateName_current", file: "realm_a/./icon.png", wantErr: true},
+		{name: "ValidateName_empty_element", file: "realm_a//icon.png", wantErr: true},
+		{name: "ValidateName_backslash", file: "realm_a\\..\\icon.png", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateName(tt.file); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFilesystem_Write_outside(t *testing.T) {
+	f, err := NewFilesystem("", ".test-files/store")
+	defer os.RemoveAll(".test-files")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.Write("../outside.txt", bytes.NewBufferString("outside")); err != ErrInvalidName {
+		t.Errorf("Filesystem.Write() error = %v, want %v", err, ErrInvalidName)
+	}
+	if _, err := os.Stat(".test-files/outside.txt"); !os.IsNotExist(err) {
+		t.Errorf("Filesystem.Write() wrote outside the store")
+	}
+	if err := f.Delete("../store"); err != ErrInvalidName {
+		t.Errorf("Filesystem.Delete() error = %v, want %v", err, ErrInvalidName)
+	}
+}
@@ -111,6 +111,23 @@ func (g *GCS) Write(name string, data io.Reader) (string, error) {
 	return fmt.Sprintf("https://storage.googleapis.com/%s/%s", g.bucketName, name), w.Close()
 }
 
+func (g *GCS) List(prefix string) ([]string, error) {
+	names := make([]string, 0)
+	it := g.bucket.Objects(context.Background(), &storage.Query{Prefix: prefix})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, attrs.Name)
+	}
+
+	return names, nil
+}
+
 func (g *GCS) Delete(name string) error {
 	return g.bucket.Object(name).Delete(context.Background())
 }
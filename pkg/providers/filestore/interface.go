@@ -1,8 +1,33 @@
 package filestore
 
-import "io"
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrInvalidName is returned for file names that could point outside the store.
+var ErrInvalidName = errors.New("invalid file name")
 
 type Filestore interface {
 	Read(string) ([]byte, error)
 	Write(string, io.Reader) (string, error)
+	// List returns the names of the files whose names start with the prefix
+	List(prefix string) ([]string, error)
+	Delete(string) error
+}
+
+// ValidateName checks that the name is a relative path of slash separated
+// elements, none of which is empty, . or .., so it stays inside the store.
+func ValidateName(name string) error {
+	if name == "" || strings.HasPrefix(name, "/") || strings.ContainsAny(name, "\\\x00") {
+		return ErrInvalidName
+	}
+	for _, element := range strings.Split(name, "/") {
+		if element == "" || element == "." || element == ".." {
+			return ErrInvalidName
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,131 @@
+package gorm
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+)
+
+// GormAuditService provider using a database
+type GormAuditService struct {
+	db *gorm.DB
+	mu sync.Mutex
+}
+
+type auditData struct {
+	ID         string    `gorm:"primary_key"`
+	Realm      string    `gorm:"index;unique_index:idx_audit_realm_sequence"`
+	Sequence   int64     `gorm:"unique_index:idx_audit_realm_sequence"`
+	Timestamp  time.Time `gorm:"index"`
+	Actor      string    `gorm:"index"`
+	EntityType string    `gorm:"index"`
+	EntityID   string    `gorm:"index"`
+	Hash       string
+	Data       []byte
+}
+
+func (auditData) TableName() string {
+	return "audit"
+}
+
+func NewGormAuditService(db *gorm.DB) (realm.AuditProvider, error) {
+	p := &GormAuditService{
+		db: db,
+	}
+
+	if err := p.Migrate(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *GormAuditService) Migrate() error {
+	return p.db.AutoMigrate(&auditData{}).Error
+}
+
+func (p *GormAuditService) Append(realmID string, e *realm.AuditEntry) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.db.Transaction(func(tx *gorm.DB) error {
+		last := &auditData{}
+		err := tx.Where("realm = ?", realmID).Order("sequence desc").First(&last).Error
+		if err != nil && !gorm.IsRecordNotFoundError(err) {
+			return err
+		}
+
+		if e.ID == "" {
+			e.ID = uuid.NewV4().String()
+		}
+		if e.Timestamp.IsZero() {
+			e.Timestamp = time.Now()
+		}
+		e.Timestamp = e.Timestamp.UTC()
+		e.Realm = realmID
+		e.Sequence = last.Sequence + 1
+		e.PreviousHash = last.Hash
+
+		e.Hash, err = e.ComputeHash()
+		if err != nil {
+			return err
+		}
+
+		bytes, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+
+		ad := &auditData{
+			ID:         e.ID,
+			Realm:      realmID,
+			Sequence:   e.Sequence,
+			Timestamp:  e.Timestamp,
+			Actor:      e.Actor.Key,
+			EntityType: e.EntityType,
+			EntityID:   e.EntityID,
+			Hash:       e.Hash,
+			Data:       bytes,
+		}
+
+		return tx.Create(&ad).Error
+	})
+}
+
+func (p *GormAuditService) List(realmID string, filter realm.AuditFilter) ([]*realm.AuditEntry, error) {
+	q := p.db.Where("realm = ?", realmID)
+	if filter.From != nil {
+		q = q.Where("timestamp >= ?", filter.From.UTC())
+	}
+	if filter.To != nil {
+		q = q.Where("timestamp <= ?", filter.To.UTC())
+	}
+	if filter.Actor != "" {
+		q = q.Where("actor = ?", filter.Actor)
+	}
+	if filter.EntityType != "" {
+		q = q.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		q = q.Where("entity_id = ?", filter.EntityID)
+	}
+
+	entries := make([]*auditData, 0)
+	if err := q.Order("sequence").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	out := make([]*realm.AuditEntry, 0)
+	for _, ad := range entries {
+		e := &realm.AuditEntry{}
+		if err := json.Unmarshal(ad.Data, &e); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
@@ -0,0 +1,130 @@
+package gorm
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	realm "github.com/IpsoVeritas/realm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+)
+
+func TestAuditService_Append(t *testing.T) {
+	svc := newService(t, false).audit
+
+	for i, id := range []string{"a", "b", "c"} {
+		e := &realm.AuditEntry{
+			Actor:      realm.AuditActor{Key: "key"},
+			EntityType: "role",
+			EntityID:   id,
+			Operation:  realm.AuditCreate,
+			After:      json.RawMessage(`{"name":"` + id + `"}`),
+		}
+		if err := svc.Append("abc", e); err != nil {
+			t.Fatal(err)
+		}
+		if e.Sequence != int64(i+1) {
+			t.Errorf("AuditService.Append() = sequence: %d, want sequence: %d", e.Sequence, i+1)
+		}
+	}
+
+	if err := svc.Append("cde", &realm.AuditEntry{Operation: realm.AuditCreate}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := svc.List("abc", realm.AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("AuditService.List() = count: %d, want count: 3", len(entries))
+	}
+
+	previous := ""
+	for _, e := range entries {
+		if e.PreviousHash != previous {
+			t.Errorf("AuditService entry %d previous hash = %s, want %s", e.Sequence, e.PreviousHash, previous)
+		}
+		hash, err := e.ComputeHash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hash != e.Hash {
+			t.Errorf("AuditService entry %d hash = %s, want %s", e.Sequence, e.Hash, hash)
+		}
+		previous = e.Hash
+	}
+}
+
+func TestAuditService_List(t *testing.T) {
+	type test struct {
+		name    string
+		svc     realm.AuditProvider
+		prepare func(*testing.T, *test)
+		filter  realm.AuditFilter
+		count   int
+		wantErr bool
+	}
+	now := time.Now()
+	hourAgo := now.Add(-time.Hour)
+	prepare := func(t *testing.T, tt *test) {
+		entries := []*realm.AuditEntry{
+			{Timestamp: now.Add(-2 * time.Hour), Actor: realm.AuditActor{Key: "a"}, EntityType: "role", EntityID: "1", Operation: realm.AuditCreate},
+			{Timestamp: now, Actor: realm.AuditActor{Key: "a"}, EntityType: "invite", EntityID: "2", Operation: realm.AuditCreate},
+			{Timestamp: now, Actor: realm.AuditActor{Key: "b"}, EntityType: "role", EntityID: "1", Operation: realm.AuditDelete},
+		}
+		for _, e := range entries {
+			if err := tt.svc.Append("abc", e); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	tests := []test{
+		{
+			name:    "List_all",
+			prepare: prepare,
+			count:   3,
+		},
+		{
+			name:    "List_from",
+			prepare: prepare,
+			filter:  realm.AuditFilter{From: &hourAgo},
+			count:   2,
+		},
+		{
+			name:    "List_to",
+			prepare: prepare,
+			filter:  realm.AuditFilter{To: &hourAgo},
+			count:   1,
+		},
+		{
+			name:    "List_actor",
+			prepare: prepare,
+			filter:  realm.AuditFilter{Actor: "a"},
+			count:   2,
+		},
+		{
+			name:    "List_entity",
+			prepare: prepare,
+			filter:  realm.AuditFilter{EntityType: "role", EntityID: "1"},
+			count:   2,
+		},
+	}
+	for _, tt := range tests {
+		tt.svc = newService(t, false).audit
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.prepare != nil {
+				tt.prepare(t, &tt)
+			}
+			got, err := tt.svc.List("abc", tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AuditService.List() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && len(got) != tt.count {
+				t.Errorf("AuditService.List() = count: %d, want count: %d", len(got), tt.count)
+			}
+		})
+	}
+}
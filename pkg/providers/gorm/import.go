@@ -0,0 +1,46 @@
+package gorm
+
+import (
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/jinzhu/gorm"
+)
+
+// GormRealmImporter writes restored realms to the tables of the gorm providers
+type GormRealmImporter struct {
+	db *gorm.DB
+}
+
+func NewGormRealmImporter(db *gorm.DB) realm.RealmImporter {
+	return &GormRealmImporter{
+		db: db,
+	}
+}
+
+// Import calls write with gorm providers on one transaction. The tables must
+// have been migrated by the constructors of the providers.
+func (p *GormRealmImporter) Import(write func(store *realm.RealmStore) error) error {
+	tx := p.db.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	store := &realm.RealmStore{
+		Realms:         &GormRealmService{db: tx},
+		Roles:          &GormRoleService{db: tx},
+		Permissions:    &GormPermissionService{db: tx},
+		Controllers:    &GormControllerService{db: tx},
+		Actions:        &GormActionService{db: tx},
+		Mandates:       &GormMandateService{db: tx},
+		Revocations:    &GormRevocationService{db: tx},
+		MandateTickets: &GormMandateTicketService{db: tx},
+		Invites:        &GormInviteService{db: tx},
+		Settings:       &GormSettingService{db: tx},
+	}
+
+	if err := write(store); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
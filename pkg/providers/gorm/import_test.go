@@ -0,0 +1,53 @@
+package gorm
+
+import (
+	"errors"
+	"testing"
+
+	document "github.com/IpsoVeritas/document"
+	realm "github.com/IpsoVeritas/realm"
+)
+
+func TestRealmImporter_Import(t *testing.T) {
+	tests := []struct {
+		name    string
+		realmID string
+		err     error
+		want    bool
+	}{
+		{name: "Import_commit", realmID: "abc", want: true},
+		{name: "Import_rollback", realmID: "cde", err: errors.New("failed"), want: false},
+	}
+	for _, tt := range tests {
+		svc := newService(t, false)
+		if _, err := NewGormSettingService(svc.db); err != nil {
+			t.Fatal(err)
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewGormRealmImporter(svc.db).Import(func(store *realm.RealmStore) error {
+				if err := store.Realms.Set(&realm.Realm{ID: tt.realmID}); err != nil {
+					return err
+				}
+				if err := store.Roles.Set(tt.realmID, &document.Role{Name: "admin@" + tt.realmID}); err != nil {
+					return err
+				}
+				if err := store.Settings.Set(tt.realmID, "key", "value"); err != nil {
+					return err
+				}
+				return tt.err
+			})
+			if err != tt.err {
+				t.Fatalf("RealmImporter.Import() error = %v, want %v", err, tt.err)
+			}
+
+			_, err = svc.realms.Get(tt.realmID)
+			if got := err == nil; got != tt.want {
+				t.Errorf("RealmImporter.Import() wrote realm = %v, want %v", got, tt.want)
+			}
+			roles, _ := svc.roles.List(tt.realmID)
+			if got := len(roles) == 1; got != tt.want {
+				t.Errorf("RealmImporter.Import() wrote role = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
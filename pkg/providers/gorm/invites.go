@@ -8,16 +8,21 @@ import (
 	uuid "github.com/satori/go.uuid"
 )
 
+// openInviteStatuses are the statuses of invites that can still be accepted
+var openInviteStatuses = []string{"", realm.InviteStatusDraft, realm.InviteStatusSent, realm.InviteStatusOpened}
+
 // GormInviteService provider using a database
 type GormInviteService struct {
 	db *gorm.DB
 }
 
 type inviteData struct {
-	ID    string `gorm:"primary_key"`
-	Realm string `gorm:"index"`
-	Role  string `gorm:"index"`
-	Data  []byte
+	ID     string `gorm:"primary_key"`
+	Realm  string `gorm:"index"`
+	Role   string `gorm:"index"`
+	Token  string `gorm:"index"`
+	Status string
+	Data   []byte
 }
 
 func (inviteData) TableName() string {
@@ -54,6 +59,10 @@ func (p *GormInviteService) List(realmID string) ([]*realm.Invite, error) {
 		if err != nil {
 			return nil, err
 		}
+		c.TokenHash = cd.Token
+		if cd.Status != "" {
+			c.Status = cd.Status
+		}
 		out = append(out, c)
 	}
 	return out, nil
@@ -73,6 +82,10 @@ func (p *GormInviteService) ListForRole(realmID, role string) ([]*realm.Invite,
 		if err != nil {
 			return nil, err
 		}
+		c.TokenHash = cd.Token
+		if cd.Status != "" {
+			c.Status = cd.Status
+		}
 		out = append(out, c)
 	}
 	return out, nil
@@ -88,10 +101,73 @@ func (p *GormInviteService) Get(realmID, id string) (*realm.Invite, error) {
 	var c *realm.Invite
 	err = json.Unmarshal(ad.Data, &c)
 	c.Realm = ad.Realm
+	c.TokenHash = ad.Token
+	if ad.Status != "" {
+		c.Status = ad.Status
+	}
 
 	return c, err
 }
 
+func (p *GormInviteService) GetByToken(realmID, tokenHash string) (*realm.Invite, error) {
+	if tokenHash == "" {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	ad := &inviteData{}
+	err := p.db.Where("token = ? AND realm = ?", tokenHash, realmID).First(&ad).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var c *realm.Invite
+	if err := json.Unmarshal(ad.Data, &c); err != nil {
+		return nil, err
+	}
+	c.Realm = ad.Realm
+	c.TokenHash = ad.Token
+	if ad.Status != "" {
+		c.Status = ad.Status
+	}
+
+	return c, nil
+}
+
+func (p *GormInviteService) Claim(realmID, tokenHash string) (bool, error) {
+	if tokenHash == "" {
+		return false, nil
+	}
+
+	// The conditional update makes the check and the claim a single statement,
+	// so concurrent callbacks can not both accept the invite.
+	res := p.db.Model(&inviteData{}).
+		Where("token = ? AND realm = ?", tokenHash, realmID).
+		Where("status IN (?)", openInviteStatuses).
+		Updates(map[string]interface{}{"status": realm.InviteStatusAccepted, "token": ""})
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected > 0, nil
+}
+
+func (p *GormInviteService) SetIfOpen(realmID string, c *realm.Invite) (bool, error) {
+	bytes, err := json.Marshal(c)
+	if err != nil {
+		return false, err
+	}
+
+	res := p.db.Model(&inviteData{}).
+		Where("id = ? AND realm = ?", c.ID, realmID).
+		Where("status IN (?)", openInviteStatuses).
+		Updates(map[string]interface{}{"role": c.Role, "token": c.TokenHash, "status": c.Status, "data": bytes})
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected > 0, nil
+}
+
 func (p *GormInviteService) Set(realmID string, c *realm.Invite) error {
 	if c.ID == "" {
 		c.ID = uuid.NewV4().String()
@@ -103,10 +179,12 @@ func (p *GormInviteService) Set(realmID string, c *realm.Invite) error {
 	}
 
 	ad := &inviteData{
-		ID:    c.ID,
-		Realm: realmID,
-		Role:  c.Role,
-		Data:  bytes,
+		ID:     c.ID,
+		Realm:  realmID,
+		Role:   c.Role,
+		Token:  c.TokenHash,
+		Status: c.Status,
+		Data:   bytes,
 	}
 
 	err = p.db.Save(&ad).Error
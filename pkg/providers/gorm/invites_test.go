@@ -1,6 +1,7 @@
 package gorm
 
 import (
+	"sync"
 	"testing"
 
 	_ "github.com/jinzhu/gorm/dialects/sqlite"
@@ -180,3 +181,225 @@ func TestInviteService_ListForRole(t *testing.T) {
 		})
 	}
 }
+
+func TestInviteService_GetByToken(t *testing.T) {
+	type test struct {
+		name    string
+		svc     realm.InviteProvider
+		realm   string
+		token   string
+		wantErr bool
+	}
+	tests := []test{
+		{
+			name:    "GetByToken",
+			realm:   "abc",
+			token:   "hash",
+			wantErr: false,
+		},
+		{
+			name:    "GetByToken_wrong_token",
+			realm:   "abc",
+			token:   "other",
+			wantErr: true,
+		},
+		{
+			name:    "GetByToken_other_realm",
+			realm:   "cde",
+			token:   "hash",
+			wantErr: true,
+		},
+		{
+			name:    "GetByToken_empty_token",
+			realm:   "abc",
+			token:   "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt.svc = newService(t, false).invites
+		t.Run(tt.name, func(t *testing.T) {
+			for _, r := range []realm.Invite{{ID: "abc", TokenHash: "hash"}, {ID: "cde"}} {
+				if err := tt.svc.Set("abc", &r); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := tt.svc.GetByToken(tt.realm, tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("InviteService.GetByToken() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && (got.ID != "abc" || got.TokenHash != "hash") {
+				t.Errorf("InviteService.GetByToken() = ID: %v, TokenHash: %v", got.ID, got.TokenHash)
+			}
+		})
+	}
+}
+
+func TestInviteService_Claim(t *testing.T) {
+	tests := []struct {
+		name   string
+		invite realm.Invite
+		realm  string
+		token  string
+		want   bool
+	}{
+		{
+			name:   "Claim",
+			invite: realm.Invite{ID: "abc", TokenHash: "hash", Status: realm.InviteStatusOpened},
+			realm:  "abc",
+			token:  "hash",
+			want:   true,
+		},
+		{
+			name:   "Claim_without_status",
+			invite: realm.Invite{ID: "abc", TokenHash: "hash"},
+			realm:  "abc",
+			token:  "hash",
+			want:   true,
+		},
+		{
+			name:   "Claim_cancelled",
+			invite: realm.Invite{ID: "abc", TokenHash: "hash", Status: realm.InviteStatusCancelled},
+			realm:  "abc",
+			token:  "hash",
+			want:   false,
+		},
+		{
+			name:   "Claim_wrong_token",
+			invite: realm.Invite{ID: "abc", TokenHash: "hash", Status: realm.InviteStatusSent},
+			realm:  "abc",
+			token:  "other",
+			want:   false,
+		},
+		{
+			name:   "Claim_other_realm",
+			invite: realm.Invite{ID: "abc", TokenHash: "hash", Status: realm.InviteStatusSent},
+			realm:  "cde",
+			token:  "hash",
+			want:   false,
+		},
+		{
+			name:   "Claim_empty_token",
+			invite: realm.Invite{ID: "abc", Status: realm.InviteStatusSent},
+			realm:  "abc",
+			token:  "",
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		svc := newService(t, false).invites
+		t.Run(tt.name, func(t *testing.T) {
+			if err := svc.Set("abc", &tt.invite); err != nil {
+				t.Fatal(err)
+			}
+			got, err := svc.Claim(tt.realm, tt.token)
+			if err != nil {
+				t.Fatalf("InviteService.Claim() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("InviteService.Claim() = %v, want %v", got, tt.want)
+			}
+			if !got {
+				return
+			}
+
+			invite, err := svc.Get("abc", "abc")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if invite.Status != realm.InviteStatusAccepted || invite.TokenHash != "" {
+				t.Errorf("InviteService.Get() = Status: %v, TokenHash: %v, want Status: %v", invite.Status, invite.TokenHash, realm.InviteStatusAccepted)
+			}
+			if again, _ := svc.Claim(tt.realm, tt.token); again {
+				t.Error("InviteService.Claim() claimed the invite twice")
+			}
+		})
+	}
+}
+
+func TestInviteService_SetIfOpen(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+		want   bool
+	}{
+		{name: "SetIfOpen_sent", status: realm.InviteStatusSent, want: true},
+		{name: "SetIfOpen_opened", status: realm.InviteStatusOpened, want: true},
+		{name: "SetIfOpen_without_status", status: "", want: true},
+		{name: "SetIfOpen_accepted", status: realm.InviteStatusAccepted, want: false},
+		{name: "SetIfOpen_cancelled", status: realm.InviteStatusCancelled, want: false},
+	}
+	for _, tt := range tests {
+		svc := newService(t, false).invites
+		t.Run(tt.name, func(t *testing.T) {
+			if err := svc.Set("abc", &realm.Invite{ID: "abc", Name: "before", Status: tt.status}); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := svc.SetIfOpen("abc", &realm.Invite{ID: "abc", Name: "after", TokenHash: "hash", Status: realm.InviteStatusSent})
+			if err != nil {
+				t.Fatalf("InviteService.SetIfOpen() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("InviteService.SetIfOpen() = %v, want %v", got, tt.want)
+			}
+
+			invite, err := svc.Get("abc", "abc")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if updated := invite.Name == "after" && invite.TokenHash == "hash"; updated != tt.want {
+				t.Errorf("InviteService.Get() = Name: %v, TokenHash: %v, Status: %v, want updated %v", invite.Name, invite.TokenHash, invite.Status, tt.want)
+			}
+			if !tt.want && invite.Status != tt.status {
+				t.Errorf("InviteService.Get() = Status: %v, want %v", invite.Status, tt.status)
+			}
+		})
+	}
+}
+
+func TestInviteService_Claim_concurrent(t *testing.T) {
+	s := newService(t, false)
+	// every connection to an in-memory sqlite database gets its own database
+	s.db.DB().SetMaxOpenConns(1)
+	svc := s.invites
+	if err := svc.Set("abc", &realm.Invite{ID: "abc", TokenHash: "hash", Status: realm.InviteStatusOpened}); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	claimed := 0
+	for i := 0; i < 25; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, err := svc.Claim("abc", "hash"); err == nil && ok {
+				mu.Lock()
+				claimed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if claimed != 1 {
+		t.Errorf("InviteService.Claim() claimed %v times, want 1", claimed)
+	}
+}
+
+func TestInviteService_ListTokenHash(t *testing.T) {
+	svc := newService(t, false).invites
+	if err := svc.Set("abc", &realm.Invite{ID: "abc", TokenHash: "hash"}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := svc.List("abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].TokenHash != "hash" {
+		t.Errorf("InviteService.List() = %v, want the token hash", got)
+	}
+}
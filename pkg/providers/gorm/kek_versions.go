@@ -0,0 +1,76 @@
+package gorm
+
+import (
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/jinzhu/gorm"
+)
+
+// GormKEKVersionService provider using a database
+type GormKEKVersionService struct {
+	db *gorm.DB
+}
+
+type kekVersion struct {
+	ID      string `gorm:"primary_key"`
+	Version int    `gorm:"index"`
+}
+
+func (kekVersion) TableName() string {
+	return "kek_versions"
+}
+
+func NewGormKEKVersionService(db *gorm.DB) (realm.KEKVersionProvider, error) {
+	p := &GormKEKVersionService{
+		db: db,
+	}
+
+	if err := p.Migrate(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *GormKEKVersionService) Migrate() error {
+	return p.db.AutoMigrate(&kekVersion{}).Error
+}
+
+func (p *GormKEKVersionService) Get(keyID string) (int, error) {
+	v := &kekVersion{}
+	err := p.db.Where("id = ?", keyID).First(&v).Error
+	if err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return v.Version, nil
+}
+
+func (p *GormKEKVersionService) Set(keyID string, version int) error {
+	v := &kekVersion{
+		ID:      keyID,
+		Version: version,
+	}
+
+	return p.db.Save(&v).Error
+}
+
+func (p *GormKEKVersionService) Delete(keyID string) error {
+	return p.db.Delete(&kekVersion{}, "id = ?", keyID).Error
+}
+
+func (p *GormKEKVersionService) List() (map[string]int, error) {
+	versions := make([]*kekVersion, 0)
+	if err := p.db.Find(&versions).Error; err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]int)
+	for _, v := range versions {
+		out[v.ID] = v.Version
+	}
+
+	return out, nil
+}
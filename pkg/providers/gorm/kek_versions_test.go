@@ -0,0 +1,63 @@
+package gorm
+
+import (
+	"testing"
+
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+)
+
+func TestKEKVersionService_Get(t *testing.T) {
+	svc := newService(t, false).kekVersions
+
+	if err := svc.Set("abc", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		keyID string
+		want  int
+	}{
+		{name: "Tracked", keyID: "abc", want: 2},
+		{name: "Untracked", keyID: "cde", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.Get(tt.keyID)
+			if err != nil {
+				t.Fatalf("KEKVersionService.Get() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("KEKVersionService.Get() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKEKVersionService_Set(t *testing.T) {
+	svc := newService(t, false).kekVersions
+
+	for _, v := range []int{1, 3} {
+		if err := svc.Set("abc", v); err != nil {
+			t.Fatalf("KEKVersionService.Set() error = %v", err)
+		}
+	}
+	if err := svc.Set("cde", 1); err != nil {
+		t.Fatalf("KEKVersionService.Set() error = %v", err)
+	}
+
+	got, err := svc.List()
+	if err != nil {
+		t.Fatalf("KEKVersionService.List() error = %v", err)
+	}
+	if len(got) != 2 || got["abc"] != 3 || got["cde"] != 1 {
+		t.Errorf("KEKVersionService.List() = %v", got)
+	}
+
+	if err := svc.Delete("abc"); err != nil {
+		t.Fatalf("KEKVersionService.Delete() error = %v", err)
+	}
+	if v, _ := svc.Get("abc"); v != 0 {
+		t.Errorf("KEKVersionService.Get() after Delete = %d, want 0", v)
+	}
+}
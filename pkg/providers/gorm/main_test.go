@@ -14,7 +14,15 @@ type service struct {
 	actions     realm.ActionProvider
 	invites     realm.InviteProvider
 	mandates    realm.IssuedMandateProvider
+	revocations realm.RevocationProvider
 	roles       realm.RoleProvider
+	webhooks    realm.WebhookProvider
+	deliveries  realm.WebhookDeliveryProvider
+	audit       realm.AuditProvider
+	permissions realm.PermissionProvider
+	tickets     realm.MandateTicketProvider
+	outbox      realm.OutboxProvider
+	kekVersions realm.KEKVersionProvider
 }
 
 func newService(t *testing.T, dbLog bool) *service {
@@ -49,11 +57,51 @@ func newService(t *testing.T, dbLog bool) *service {
 		t.Fatal(err)
 	}
 
+	revocations, err := NewGormRevocationService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	roles, err := NewGormRoleService(db)
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	webhooks, err := NewGormWebhookService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deliveries, err := NewGormWebhookDeliveryService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	audit, err := NewGormAuditService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	permissions, err := NewGormPermissionService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tickets, err := NewGormMandateTicketService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outbox, err := NewGormOutboxService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kekVersions, err := NewGormKEKVersionService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	svc := &service{
 		db:          db,
 		realms:      realms,
@@ -61,7 +109,15 @@ func newService(t *testing.T, dbLog bool) *service {
 		actions:     actions,
 		invites:     invites,
 		mandates:    mandates,
+		revocations: revocations,
 		roles:       roles,
+		webhooks:    webhooks,
+		deliveries:  deliveries,
+		audit:       audit,
+		permissions: permissions,
+		tickets:     tickets,
+		outbox:      outbox,
+		kekVersions: kekVersions,
 	}
 	return svc
 }
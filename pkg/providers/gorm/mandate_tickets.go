@@ -2,6 +2,7 @@ package gorm
 
 import (
 	"encoding/json"
+	"time"
 
 	realm "github.com/IpsoVeritas/realm"
 	"github.com/jinzhu/gorm"
@@ -14,9 +15,12 @@ type GormMandateTicketService struct {
 }
 
 type mandateTicketData struct {
-	ID    string `gorm:"primary_key"`
-	Realm string `gorm:"index"`
-	Data  []byte
+	ID             string     `gorm:"primary_key"`
+	Realm          string     `gorm:"index"`
+	ValidUntil     *time.Time `gorm:"index"`
+	MaxRedemptions int
+	Redemptions    int
+	Data           []byte
 }
 
 func (mandateTicketData) TableName() string {
@@ -53,6 +57,7 @@ func (p *GormMandateTicketService) List(realmID string) ([]*realm.MandateTicket,
 		if err != nil {
 			return nil, err
 		}
+		c.Redemptions = cd.Redemptions
 		out = append(out, c)
 	}
 	return out, nil
@@ -67,9 +72,13 @@ func (p *GormMandateTicketService) Get(realmID, id string) (*realm.MandateTicket
 
 	var c *realm.MandateTicket
 	err = json.Unmarshal(ad.Data, &c)
+	if err != nil {
+		return nil, err
+	}
 	c.Realm = ad.Realm
+	c.Redemptions = ad.Redemptions
 
-	return c, err
+	return c, nil
 }
 
 func (p *GormMandateTicketService) Set(realmID string, c *realm.MandateTicket) error {
@@ -83,12 +92,17 @@ func (p *GormMandateTicketService) Set(realmID string, c *realm.MandateTicket) e
 	}
 
 	ad := &mandateTicketData{
-		ID:    c.ID,
-		Realm: realmID,
-		Data:  bytes,
+		ID:             c.ID,
+		Realm:          realmID,
+		ValidUntil:     c.ValidUntil,
+		MaxRedemptions: c.MaxRedemptions,
+		Redemptions:    c.Redemptions,
+		Data:           bytes,
 	}
 
-	err = p.db.Save(&ad).Error
+	// redemptions are only counted by Redeem, so saving a ticket that was read
+	// before a redemption can't reset the count
+	err = p.db.Omit("redemptions").Save(&ad).Error
 
 	return err
 }
@@ -96,3 +110,30 @@ func (p *GormMandateTicketService) Set(realmID string, c *realm.MandateTicket) e
 func (p *GormMandateTicketService) Delete(realmID, id string) error {
 	return p.db.Delete(&mandateTicketData{}, "id = ? AND realm = ?", id, realmID).Error
 }
+
+func (p *GormMandateTicketService) Redeem(realmID, id string, now time.Time) (*realm.MandateTicket, error) {
+	// The conditional update makes the check and the increment a single statement,
+	// so concurrent redemptions can not exceed the limit.
+	res := p.db.Model(&mandateTicketData{}).
+		Where("id = ? AND realm = ?", id, realmID).
+		Where("max_redemptions = 0 OR redemptions < max_redemptions").
+		Where("valid_until IS NULL OR valid_until > ?", now).
+		UpdateColumn("redemptions", gorm.Expr("redemptions + 1"))
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		if _, err := p.Get(realmID, id); err != nil {
+			return nil, err
+		}
+		return nil, realm.ErrTicketNotRedeemable
+	}
+
+	return p.Get(realmID, id)
+}
+
+func (p *GormMandateTicketService) DeleteExpired(before time.Time) (int64, error) {
+	res := p.db.Delete(&mandateTicketData{}, "valid_until IS NOT NULL AND valid_until <= ?", before)
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,203 @@
+package gorm
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	realm "github.com/IpsoVeritas/realm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+)
+
+func TestMandateTicketService_Redeem(t *testing.T) {
+	type test struct {
+		name    string
+		svc     realm.MandateTicketProvider
+		ticket  *realm.MandateTicket
+		redeem  int
+		wantErr bool
+	}
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	tests := []test{
+		{
+			name:    "Redeem_unlimited",
+			ticket:  &realm.MandateTicket{Static: true},
+			redeem:  5,
+			wantErr: false,
+		},
+		{
+			name:    "Redeem_within_limit",
+			ticket:  &realm.MandateTicket{MaxRedemptions: 2, ValidUntil: &future},
+			redeem:  2,
+			wantErr: false,
+		},
+		{
+			name:    "Redeem_over_limit",
+			ticket:  &realm.MandateTicket{MaxRedemptions: 2},
+			redeem:  3,
+			wantErr: true,
+		},
+		{
+			name:    "Redeem_expired",
+			ticket:  &realm.MandateTicket{ValidUntil: &past},
+			redeem:  1,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt.svc = newService(t, false).tickets
+		t.Run(tt.name, func(t *testing.T) {
+			tt.ticket.ID = "abc"
+			if err := tt.svc.Set("realm", tt.ticket); err != nil {
+				t.Fatal(err)
+			}
+
+			var err error
+			var got *realm.MandateTicket
+			for i := 0; i < tt.redeem; i++ {
+				if got, err = tt.svc.Redeem("realm", "abc", now); err != nil {
+					break
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MandateTicketService.Redeem() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr && err != realm.ErrTicketNotRedeemable {
+				t.Errorf("MandateTicketService.Redeem() error = %v, want %v", err, realm.ErrTicketNotRedeemable)
+			}
+			if !tt.wantErr && got.Redemptions != tt.redeem {
+				t.Errorf("MandateTicketService.Redeem() = Redemptions: %v, want Redemptions: %v", got.Redemptions, tt.redeem)
+			}
+		})
+	}
+}
+
+func TestMandateTicketService_Redeem_not_exist(t *testing.T) {
+	svc := newService(t, false).tickets
+	if _, err := svc.Redeem("realm", "abc", time.Now()); err == nil || err == realm.ErrTicketNotRedeemable {
+		t.Errorf("MandateTicketService.Redeem() error = %v, want not found", err)
+	}
+}
+
+func TestMandateTicketService_Set_keeps_redemptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		redemptions int
+		want        int
+	}{
+		{
+			name:        "Set_stale_ticket",
+			redemptions: 0,
+			want:        2,
+		},
+		{
+			name:        "Set_ticket_with_other_count",
+			redemptions: 10,
+			want:        2,
+		},
+	}
+	for _, tt := range tests {
+		svc := newService(t, false).tickets
+		t.Run(tt.name, func(t *testing.T) {
+			ticket := &realm.MandateTicket{MaxRedemptions: 3}
+			ticket.ID = "abc"
+			if err := svc.Set("realm", ticket); err != nil {
+				t.Fatal(err)
+			}
+
+			stale, err := svc.Get("realm", "abc")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 2; i++ {
+				if _, err := svc.Redeem("realm", "abc", time.Now()); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			stale.Redemptions = tt.redemptions
+			stale.MaxRedemptions = 5
+			if err := svc.Set("realm", stale); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := svc.Get("realm", "abc")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Redemptions != tt.want {
+				t.Errorf("MandateTicketService.Set() = Redemptions: %v, want Redemptions: %v", got.Redemptions, tt.want)
+			}
+			if got.MaxRedemptions != 5 {
+				t.Errorf("MandateTicketService.Set() = MaxRedemptions: %v, want MaxRedemptions: 5", got.MaxRedemptions)
+			}
+		})
+	}
+}
+
+func TestMandateTicketService_Redeem_concurrent(t *testing.T) {
+	s := newService(t, false)
+	// every connection to an in-memory sqlite database gets its own database
+	s.db.DB().SetMaxOpenConns(1)
+	svc := s.tickets
+	if err := svc.Set("realm", &realm.MandateTicket{MaxRedemptions: 10}); err != nil {
+		t.Fatal(err)
+	}
+	tickets, err := svc.List("realm")
+	if err != nil || len(tickets) != 1 {
+		t.Fatalf("MandateTicketService.List() = %v, %v", tickets, err)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	redeemed := 0
+	for i := 0; i < 25; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := svc.Redeem("realm", tickets[0].ID, time.Now()); err == nil {
+				mu.Lock()
+				redeemed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if redeemed != 10 {
+		t.Errorf("MandateTicketService.Redeem() redeemed %v times, want %v", redeemed, 10)
+	}
+}
+
+func TestMandateTicketService_DeleteExpired(t *testing.T) {
+	svc := newService(t, false).tickets
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	for id, validUntil := range map[string]*time.Time{"a": &past, "b": &future, "c": nil} {
+		ticket := &realm.MandateTicket{ValidUntil: validUntil}
+		ticket.ID = id
+		if err := svc.Set("realm", ticket); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	count, err := svc.DeleteExpired(now)
+	if err != nil {
+		t.Fatalf("MandateTicketService.DeleteExpired() error = %v", err)
+	}
+	if count != 1 {
+		t.Errorf("MandateTicketService.DeleteExpired() = %v, want %v", count, 1)
+	}
+
+	tickets, err := svc.List("realm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tickets) != 2 {
+		t.Errorf("MandateTicketService.List() = %v tickets, want %v", len(tickets), 2)
+	}
+}
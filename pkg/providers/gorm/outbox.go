@@ -0,0 +1,145 @@
+package gorm
+
+import (
+	"encoding/json"
+	"time"
+
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+)
+
+// GormOutboxService provider using a database
+type GormOutboxService struct {
+	db *gorm.DB
+}
+
+type outboxData struct {
+	ID          string     `gorm:"primary_key"`
+	Realm       string     `gorm:"index"`
+	Invite      string     `gorm:"index"`
+	Status      string     `gorm:"index"`
+	NextAttempt *time.Time `gorm:"index"`
+	Created     time.Time
+	Data        []byte
+}
+
+func (outboxData) TableName() string {
+	return "outbox"
+}
+
+func NewGormOutboxService(db *gorm.DB) (realm.OutboxProvider, error) {
+	p := &GormOutboxService{
+		db: db,
+	}
+
+	if err := p.Migrate(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *GormOutboxService) Migrate() error {
+	return p.db.AutoMigrate(&outboxData{}).Error
+}
+
+func (p *GormOutboxService) List(realmID, inviteID string) ([]*realm.OutboxMessage, error) {
+	messages := make([]*outboxData, 0)
+	err := p.db.Where("realm = ? AND invite = ?", realmID, inviteID).Order("created desc").Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return p.unmarshal(messages)
+}
+
+func (p *GormOutboxService) ListDue(before time.Time) ([]*realm.OutboxMessage, error) {
+	messages := make([]*outboxData, 0)
+	err := p.db.Where("status = ? AND next_attempt <= ?", realm.OutboxPending, before.UTC()).Order("next_attempt").Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return p.unmarshal(messages)
+}
+
+func (p *GormOutboxService) unmarshal(messages []*outboxData) ([]*realm.OutboxMessage, error) {
+	out := make([]*realm.OutboxMessage, 0)
+	for _, md := range messages {
+		m := &realm.OutboxMessage{}
+		if err := json.Unmarshal(md.Data, &m); err != nil {
+			return nil, err
+		}
+		m.Realm = md.Realm
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+func (p *GormOutboxService) Get(realmID, id string) (*realm.OutboxMessage, error) {
+	md := &outboxData{}
+	err := p.db.Where("id = ? AND realm = ?", id, realmID).First(&md).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var m *realm.OutboxMessage
+	err = json.Unmarshal(md.Data, &m)
+	if err != nil {
+		return nil, err
+	}
+	m.Realm = md.Realm
+
+	return m, nil
+}
+
+func (p *GormOutboxService) Set(realmID string, m *realm.OutboxMessage) error {
+	if m.ID == "" {
+		m.ID = uuid.NewV4().String()
+	}
+
+	if m.Created.IsZero() {
+		m.Created = time.Now().UTC()
+	}
+
+	var next *time.Time
+	if m.NextAttempt != nil {
+		t := m.NextAttempt.UTC()
+		next = &t
+	}
+
+	bytes, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	md := &outboxData{
+		ID:          m.ID,
+		Realm:       realmID,
+		Invite:      m.InviteID,
+		Status:      m.Status,
+		NextAttempt: next,
+		Created:     m.Created,
+		Data:        bytes,
+	}
+
+	return p.db.Save(&md).Error
+}
+
+func (p *GormOutboxService) Delete(realmID, id string) error {
+	return p.db.Delete(&outboxData{}, "id = ? AND realm = ?", id, realmID).Error
+}
+
+func (p *GormOutboxService) Claim(realmID, id string, now, until time.Time) (bool, error) {
+	// Only the worker whose update matches the due row gets to deliver it.
+	res := p.db.Model(&outboxData{}).
+		Where("id = ? AND realm = ?", id, realmID).
+		Where("status = ? AND next_attempt <= ?", realm.OutboxPending, now.UTC()).
+		UpdateColumn("next_attempt", until.UTC())
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected == 1, nil
+}
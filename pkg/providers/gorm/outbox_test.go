@@ -0,0 +1,138 @@
+package gorm
+
+import (
+	"testing"
+	"time"
+
+	realm "github.com/IpsoVeritas/realm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+)
+
+func TestOutboxService_List(t *testing.T) {
+	svc := newService(t, false).outbox
+
+	messages := []*realm.OutboxMessage{
+		{InviteID: "a", Status: realm.OutboxSent},
+		{InviteID: "a", Status: realm.OutboxPending},
+		{InviteID: "b", Status: realm.OutboxPending},
+	}
+	for _, m := range messages {
+		if err := svc.Set("abc", m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := svc.List("abc", "a")
+	if err != nil {
+		t.Fatalf("OutboxService.List() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Errorf("OutboxService.List() = count: %d, want count: %d", len(got), 2)
+	}
+
+	got, err = svc.List("cde", "a")
+	if err != nil {
+		t.Fatalf("OutboxService.List() error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("OutboxService.List() = count: %d, want count: %d", len(got), 0)
+	}
+}
+
+func TestOutboxService_ListDue(t *testing.T) {
+	type test struct {
+		name    string
+		svc     realm.OutboxProvider
+		prepare func(*testing.T, *test)
+		count   int
+		wantErr bool
+	}
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Hour)
+	tests := []test{
+		{
+			name: "ListDue",
+			prepare: func(t *testing.T, tt *test) {
+				messages := []*realm.OutboxMessage{
+					{InviteID: "a", Status: realm.OutboxPending, NextAttempt: &past},
+					{InviteID: "a", Status: realm.OutboxPending, NextAttempt: &future},
+					{InviteID: "a", Status: realm.OutboxSent, NextAttempt: &past},
+					{InviteID: "a", Status: realm.OutboxDead, NextAttempt: &past},
+				}
+				for _, m := range messages {
+					if err := tt.svc.Set("abc", m); err != nil {
+						t.Fatal(err)
+					}
+				}
+			},
+			count:   1,
+			wantErr: false,
+		},
+		{
+			name: "ListDue_all_realms",
+			prepare: func(t *testing.T, tt *test) {
+				for _, realmID := range []string{"abc", "cde"} {
+					m := &realm.OutboxMessage{InviteID: "a", Status: realm.OutboxPending, NextAttempt: &past}
+					if err := tt.svc.Set(realmID, m); err != nil {
+						t.Fatal(err)
+					}
+				}
+			},
+			count:   2,
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		tt.svc = newService(t, false).outbox
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.prepare != nil {
+				tt.prepare(t, &tt)
+			}
+			got, err := tt.svc.ListDue(now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("OutboxService.ListDue() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && len(got) != tt.count {
+				t.Errorf("OutboxService.ListDue() = count: %d, want count: %d", len(got), tt.count)
+			}
+		})
+	}
+}
+
+func TestOutboxService_Claim(t *testing.T) {
+	svc := newService(t, false).outbox
+
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	lease := now.Add(5 * time.Minute)
+
+	m := &realm.OutboxMessage{InviteID: "a", Status: realm.OutboxPending, NextAttempt: &past}
+	if err := svc.Set("abc", m); err != nil {
+		t.Fatal(err)
+	}
+
+	claimed, err := svc.Claim("abc", m.ID, now, lease)
+	if err != nil || !claimed {
+		t.Fatalf("OutboxService.Claim() = %v, error = %v, want true", claimed, err)
+	}
+
+	claimed, err = svc.Claim("abc", m.ID, now, lease)
+	if err != nil || claimed {
+		t.Errorf("OutboxService.Claim() second claim = %v, error = %v, want false", claimed, err)
+	}
+
+	due, err := svc.ListDue(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 0 {
+		t.Errorf("OutboxService.ListDue() after claim = count: %d, want count: %d", len(due), 0)
+	}
+
+	claimed, err = svc.Claim("cde", m.ID, now, lease)
+	if err != nil || claimed {
+		t.Errorf("OutboxService.Claim() other realm = %v, error = %v, want false", claimed, err)
+	}
+}
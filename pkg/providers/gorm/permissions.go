@@ -0,0 +1,87 @@
+package gorm
+
+import (
+	"encoding/json"
+	"fmt"
+
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/jinzhu/gorm"
+)
+
+// GormPermissionService provider using a database
+type GormPermissionService struct {
+	db *gorm.DB
+}
+
+type permissionData struct {
+	ID    string `gorm:"primary_key"`
+	Realm string `gorm:"index"`
+	Role  string `gorm:"index"`
+	Data  []byte
+}
+
+func (permissionData) TableName() string {
+	return "permissions"
+}
+
+func NewGormPermissionService(db *gorm.DB) (realm.PermissionProvider, error) {
+	p := &GormPermissionService{
+		db: db,
+	}
+
+	if err := p.Migrate(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *GormPermissionService) Migrate() error {
+	return p.db.AutoMigrate(&permissionData{}).Error
+}
+
+func (p *GormPermissionService) key(realmID, role string) string {
+	return fmt.Sprintf("%s_%s", realmID, role)
+}
+
+func (p *GormPermissionService) Get(realmID, role string) ([]string, error) {
+	pd := &permissionData{}
+	err := p.db.Where("id = ? AND realm = ?", p.key(realmID, role), realmID).First(&pd).Error
+	if err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	permissions := make([]string, 0)
+	if err := json.Unmarshal(pd.Data, &permissions); err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func (p *GormPermissionService) Set(realmID, role string, permissions []string) error {
+	if permissions == nil {
+		permissions = []string{}
+	}
+
+	bytes, err := json.Marshal(permissions)
+	if err != nil {
+		return err
+	}
+
+	pd := &permissionData{
+		ID:    p.key(realmID, role),
+		Realm: realmID,
+		Role:  role,
+		Data:  bytes,
+	}
+
+	return p.db.Save(&pd).Error
+}
+
+func (p *GormPermissionService) Delete(realmID, role string) error {
+	return p.db.Delete(&permissionData{}, "id = ? AND realm = ?", p.key(realmID, role), realmID).Error
+}
@@ -0,0 +1,85 @@
+package gorm
+
+import (
+	"reflect"
+	"testing"
+
+	realm "github.com/IpsoVeritas/realm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+)
+
+func TestPermissionService_Get(t *testing.T) {
+	type test struct {
+		name    string
+		svc     realm.PermissionProvider
+		prepare func(*testing.T, *test)
+		realm   string
+		role    string
+		want    []string
+		wantErr bool
+	}
+	tests := []test{
+		{
+			name: "Get",
+			prepare: func(t *testing.T, tt *test) {
+				if err := tt.svc.Set(tt.realm, tt.role, []string{realm.PermissionInvitesSend}); err != nil {
+					t.Fatal(err)
+				}
+			},
+			realm:   "abc",
+			role:    "helpdesk@abc",
+			want:    []string{realm.PermissionInvitesSend},
+			wantErr: false,
+		},
+		{
+			name:    "Get_no_permissions",
+			realm:   "abc",
+			role:    "helpdesk@abc",
+			want:    []string{},
+			wantErr: false,
+		},
+		{
+			name: "Get_another_realm",
+			prepare: func(t *testing.T, tt *test) {
+				if err := tt.svc.Set("cde", tt.role, []string{realm.PermissionInvitesSend}); err != nil {
+					t.Fatal(err)
+				}
+			},
+			realm:   "abc",
+			role:    "helpdesk@abc",
+			want:    []string{},
+			wantErr: false,
+		},
+		{
+			name: "Get_deleted",
+			prepare: func(t *testing.T, tt *test) {
+				if err := tt.svc.Set(tt.realm, tt.role, []string{realm.PermissionInvitesSend}); err != nil {
+					t.Fatal(err)
+				}
+				if err := tt.svc.Delete(tt.realm, tt.role); err != nil {
+					t.Fatal(err)
+				}
+			},
+			realm:   "abc",
+			role:    "helpdesk@abc",
+			want:    []string{},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		tt.svc = newService(t, false).permissions
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.prepare != nil {
+				tt.prepare(t, &tt)
+			}
+			got, err := tt.svc.Get(tt.realm, tt.role)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PermissionService.Get() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PermissionService.Get() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package gorm
+
+import (
+	gormkeys "github.com/IpsoVeritas/keys/gorm"
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// GormRealmPurger deletes realms from the tables of the gorm providers
+type GormRealmPurger struct {
+	db *gorm.DB
+}
+
+func NewGormRealmPurger(db *gorm.DB) realm.RealmPurger {
+	return &GormRealmPurger{
+		db: db,
+	}
+}
+
+// Purge deletes the realm from all tables except the audit log, which is kept as
+// the record of the deletion, and deletes the stored keys and their KEK versions.
+func (p *GormRealmPurger) Purge(realmID string, keyIDs []string) error {
+	tx := p.db.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	if err := purge(tx, realmID, keyIDs); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+func purge(tx *gorm.DB, realmID string, keyIDs []string) error {
+	tables := []interface{}{
+		&actionData{},
+		&controllerData{},
+		&inviteData{},
+		&mandateData{},
+		&mandateTicketData{},
+		&outboxData{},
+		&permissionData{},
+		&revocationData{},
+		&roleData{},
+		&setting{},
+		&webhookData{},
+		&webhookDeliveryData{},
+	}
+	for _, table := range tables {
+		if err := tx.Delete(table, "realm = ?", realmID).Error; err != nil {
+			return errors.Wrapf(err, "failed to delete from %s", tx.NewScope(table).TableName())
+		}
+	}
+
+	if len(keyIDs) > 0 {
+		sks, err := gormkeys.NewGormStoredKeyService(tx)
+		if err != nil {
+			return err
+		}
+		for _, keyID := range keyIDs {
+			if err := sks.Delete(keyID); err != nil {
+				return errors.Wrapf(err, "failed to delete key %s", keyID)
+			}
+		}
+
+		if err := tx.Delete(&kekVersion{}, "id IN (?)", keyIDs).Error; err != nil {
+			return errors.Wrap(err, "failed to delete KEK versions")
+		}
+	}
+
+	if err := tx.Delete(&realmData{}, "id = ?", realmID).Error; err != nil {
+		return errors.Wrap(err, "failed to delete realm")
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package gorm
+
+import (
+	"testing"
+
+	document "github.com/IpsoVeritas/document"
+	realm "github.com/IpsoVeritas/realm"
+)
+
+func TestRealmPurger_Purge(t *testing.T) {
+	svc := newService(t, false)
+	settings, err := NewGormSettingService(svc.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, realmID := range []string{"abc", "cde"} {
+		if err := svc.realms.Set(&realm.Realm{ID: realmID}); err != nil {
+			t.Fatal(err)
+		}
+		if err := svc.roles.Set(realmID, &document.Role{Name: "admin@" + realmID}); err != nil {
+			t.Fatal(err)
+		}
+		if err := svc.invites.Set(realmID, &realm.Invite{}); err != nil {
+			t.Fatal(err)
+		}
+		if err := settings.Set(realmID, "key", "value"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := NewGormRealmPurger(svc.db).Purge("abc", nil); err != nil {
+		t.Fatalf("RealmPurger.Purge() error = %v", err)
+	}
+
+	if _, err := svc.realms.Get("abc"); err == nil {
+		t.Errorf("RealmPurger.Purge() did not delete the realm")
+	}
+	if roles, _ := svc.roles.List("abc"); len(roles) != 0 {
+		t.Errorf("RealmPurger.Purge() left %d roles", len(roles))
+	}
+	if invites, _ := svc.invites.List("abc"); len(invites) != 0 {
+		t.Errorf("RealmPurger.Purge() left %d invites", len(invites))
+	}
+	if list, _ := settings.List("abc"); len(list) != 0 {
+		t.Errorf("RealmPurger.Purge() left %d settings", len(list))
+	}
+
+	if _, err := svc.realms.Get("cde"); err != nil {
+		t.Errorf("RealmPurger.Purge() deleted another realm")
+	}
+	if roles, _ := svc.roles.List("cde"); len(roles) != 1 {
+		t.Errorf("RealmPurger.Purge() deleted roles of another realm")
+	}
+}
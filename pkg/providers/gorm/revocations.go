@@ -0,0 +1,131 @@
+package gorm
+
+import (
+	"encoding/json"
+	"time"
+
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// GormRevocationService provider using a database
+type GormRevocationService struct {
+	db *gorm.DB
+}
+
+type revocationData struct {
+	ID       string    `gorm:"primary_key"`
+	Realm    string    `gorm:"index"`
+	Checksum string    `gorm:"index"`
+	Revoked  time.Time `gorm:"index"`
+	Data     []byte
+}
+
+func (revocationData) TableName() string {
+	return "revocations"
+}
+
+func NewGormRevocationService(db *gorm.DB) (realm.RevocationProvider, error) {
+	p := &GormRevocationService{
+		db: db,
+	}
+
+	if err := p.Migrate(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *GormRevocationService) Migrate() error {
+	return p.db.AutoMigrate(&revocationData{}).Error
+}
+
+func (p *GormRevocationService) List(realmID string) ([]*realm.Revocation, error) {
+	revocations := make([]*revocationData, 0)
+	err := p.db.Where("realm = ?", realmID).Order("revoked").Find(&revocations).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return p.unmarshal(revocations)
+}
+
+func (p *GormRevocationService) ListSince(realmID string, since time.Time) ([]*realm.Revocation, error) {
+	revocations := make([]*revocationData, 0)
+	err := p.db.Where("realm = ? AND revoked > ?", realmID, since.UTC()).Order("revoked").Find(&revocations).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return p.unmarshal(revocations)
+}
+
+func (p *GormRevocationService) unmarshal(revocations []*revocationData) ([]*realm.Revocation, error) {
+	out := make([]*realm.Revocation, 0)
+	for _, rd := range revocations {
+		r := &realm.Revocation{}
+		if err := json.Unmarshal(rd.Data, &r); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+func (p *GormRevocationService) Get(realmID, mandateID string) (*realm.Revocation, error) {
+	return p.get("id = ? AND realm = ?", mandateID, realmID)
+}
+
+// GetByChecksum returns the revocation of the signed mandate with the checksum.
+func (p *GormRevocationService) GetByChecksum(realmID, checksum string) (*realm.Revocation, error) {
+	return p.get("checksum = ? AND realm = ?", checksum, realmID)
+}
+
+func (p *GormRevocationService) get(query string, args ...interface{}) (*realm.Revocation, error) {
+	rd := &revocationData{}
+	err := p.db.Where(query, args...).First(&rd).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var r *realm.Revocation
+	err = json.Unmarshal(rd.Data, &r)
+	if err != nil {
+		return nil, err
+	}
+	r.Realm = rd.Realm
+
+	return r, nil
+}
+
+func (p *GormRevocationService) Set(realmID string, r *realm.Revocation) error {
+	if r.MandateID == "" {
+		return errors.New("revocation needs a mandate ID")
+	}
+
+	if r.Revoked.IsZero() {
+		r.Revoked = time.Now()
+	}
+	r.Revoked = r.Revoked.UTC()
+
+	bytes, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	rd := &revocationData{
+		ID:       r.MandateID,
+		Realm:    realmID,
+		Checksum: r.Checksum,
+		Revoked:  r.Revoked,
+		Data:     bytes,
+	}
+
+	return p.db.Save(&rd).Error
+}
+
+func (p *GormRevocationService) Delete(realmID, mandateID string) error {
+	return p.db.Delete(&revocationData{}, "id = ? AND realm = ?", mandateID, realmID).Error
+}
@@ -0,0 +1,195 @@
+package gorm
+
+import (
+	"testing"
+	"time"
+
+	realm "github.com/IpsoVeritas/realm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+)
+
+func TestRevocationService_Get(t *testing.T) {
+	type test struct {
+		name    string
+		svc     realm.RevocationProvider
+		prepare func(*testing.T, *test)
+		id      string
+		wantErr bool
+	}
+	tests := []test{
+		{
+			name: "Get",
+			prepare: func(t *testing.T, tt *test) {
+				r := realm.Revocation{MandateID: tt.id, Checksum: "abc"}
+				if err := tt.svc.Set("abc", &r); err != nil {
+					t.Fatal(err)
+				}
+			},
+			id:      "abc",
+			wantErr: false,
+		},
+		{
+			name: "Get_Revocation_not_exist",
+			prepare: func(t *testing.T, tt *test) {
+				r := realm.Revocation{MandateID: "abc"}
+				if err := tt.svc.Set("abc", &r); err != nil {
+					t.Fatal(err)
+				}
+			},
+			id:      "fdfgfgd",
+			wantErr: true,
+		},
+		{
+			name: "Get_Revocation_another_realm",
+			prepare: func(t *testing.T, tt *test) {
+				r := realm.Revocation{MandateID: tt.id}
+				if err := tt.svc.Set("cde", &r); err != nil {
+					t.Fatal(err)
+				}
+			},
+			id:      "abc",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt.svc = newService(t, false).revocations
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.prepare != nil {
+				tt.prepare(t, &tt)
+			}
+			got, err := tt.svc.Get("abc", tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RevocationService.Get() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got.MandateID != tt.id {
+				t.Errorf("RevocationService.Get() = MandateID: %v, want MandateID: %v", got.MandateID, tt.id)
+			}
+		})
+	}
+}
+
+func TestRevocationService_GetByChecksum(t *testing.T) {
+	tests := []struct {
+		name     string
+		realm    string
+		checksum string
+		wantErr  bool
+	}{
+		{
+			name:     "GetByChecksum",
+			realm:    "abc",
+			checksum: "sum",
+			wantErr:  false,
+		},
+		{
+			name:     "GetByChecksum_not_exist",
+			realm:    "abc",
+			checksum: "other",
+			wantErr:  true,
+		},
+		{
+			name:     "GetByChecksum_another_realm",
+			realm:    "cde",
+			checksum: "sum",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		svc := newService(t, false).revocations
+		if err := svc.Set("abc", &realm.Revocation{MandateID: "m1", Checksum: "sum"}); err != nil {
+			t.Fatal(err)
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.GetByChecksum(tt.realm, tt.checksum)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RevocationService.GetByChecksum() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got.MandateID != "m1" {
+				t.Errorf("RevocationService.GetByChecksum() = MandateID: %v, want MandateID: m1", got.MandateID)
+			}
+		})
+	}
+}
+
+func TestRevocationService_Set_without_mandate(t *testing.T) {
+	svc := newService(t, false).revocations
+	if err := svc.Set("abc", &realm.Revocation{}); err == nil {
+		t.Error("RevocationService.Set() expected error for revocation without mandate ID")
+	}
+}
+
+func TestRevocationService_ListSince(t *testing.T) {
+	type test struct {
+		name    string
+		svc     realm.RevocationProvider
+		prepare func(*testing.T, *test)
+		realm   string
+		since   time.Time
+		count   int
+		wantErr bool
+	}
+	now := time.Now()
+	tests := []test{
+		{
+			name: "ListSince",
+			prepare: func(t *testing.T, tt *test) {
+				for i, id := range []string{"a", "b", "c"} {
+					r := realm.Revocation{MandateID: id, Revoked: now.Add(time.Duration(i-1) * time.Hour)}
+					if err := tt.svc.Set(tt.realm, &r); err != nil {
+						t.Fatal(err)
+					}
+				}
+			},
+			realm:   "abc",
+			since:   now.Add(-time.Minute),
+			count:   2,
+			wantErr: false,
+		},
+		{
+			name: "ListSince_all",
+			prepare: func(t *testing.T, tt *test) {
+				for i, id := range []string{"a", "b", "c"} {
+					r := realm.Revocation{MandateID: id, Revoked: now.Add(time.Duration(i-1) * time.Hour)}
+					if err := tt.svc.Set(tt.realm, &r); err != nil {
+						t.Fatal(err)
+					}
+				}
+			},
+			realm:   "abc",
+			since:   time.Time{},
+			count:   3,
+			wantErr: false,
+		},
+		{
+			name: "ListSince_another_realm",
+			prepare: func(t *testing.T, tt *test) {
+				r := realm.Revocation{MandateID: "a", Revoked: now}
+				if err := tt.svc.Set("cde", &r); err != nil {
+					t.Fatal(err)
+				}
+			},
+			realm:   "abc",
+			since:   time.Time{},
+			count:   0,
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		tt.svc = newService(t, false).revocations
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.prepare != nil {
+				tt.prepare(t, &tt)
+			}
+			got, err := tt.svc.ListSince(tt.realm, tt.since)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RevocationService.ListSince() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && len(got) != tt.count {
+				t.Errorf("RevocationService.ListSince() = count: %d, want count: %d", len(got), tt.count)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package gorm
+
+import (
+	"encoding/json"
+	"time"
+
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+)
+
+// GormWebhookService provider using a database
+type GormWebhookService struct {
+	db *gorm.DB
+}
+
+type webhookData struct {
+	ID    string `gorm:"primary_key"`
+	Realm string `gorm:"index"`
+	Data  []byte
+}
+
+func (webhookData) TableName() string {
+	return "webhooks"
+}
+
+func NewGormWebhookService(db *gorm.DB) (realm.WebhookProvider, error) {
+	p := &GormWebhookService{
+		db: db,
+	}
+
+	if err := p.Migrate(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *GormWebhookService) Migrate() error {
+	return p.db.AutoMigrate(&webhookData{}).Error
+}
+
+func (p *GormWebhookService) List(realmID string) ([]*realm.Webhook, error) {
+	webhooks := make([]*webhookData, 0)
+	err := p.db.Where("realm = ?", realmID).Find(&webhooks).Error
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*realm.Webhook, 0)
+	for _, wd := range webhooks {
+		w := &realm.Webhook{}
+		err = json.Unmarshal(wd.Data, &w)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, w)
+	}
+	return out, nil
+}
+
+func (p *GormWebhookService) Get(realmID, id string) (*realm.Webhook, error) {
+	wd := &webhookData{}
+	err := p.db.Where("id = ? AND realm = ?", id, realmID).First(&wd).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var w *realm.Webhook
+	err = json.Unmarshal(wd.Data, &w)
+	if err != nil {
+		return nil, err
+	}
+	w.Realm = wd.Realm
+
+	return w, nil
+}
+
+func (p *GormWebhookService) Set(realmID string, w *realm.Webhook) error {
+	if w.ID == "" {
+		w.ID = uuid.NewV4().String()
+	}
+
+	if w.Created.IsZero() {
+		w.Created = time.Now().UTC()
+	}
+
+	bytes, err := json.Marshal(w)
+	if err != nil {
+		return err
+	}
+
+	wd := &webhookData{
+		ID:    w.ID,
+		Realm: realmID,
+		Data:  bytes,
+	}
+
+	return p.db.Save(&wd).Error
+}
+
+func (p *GormWebhookService) Delete(realmID, id string) error {
+	return p.db.Delete(&webhookData{}, "id = ? AND realm = ?", id, realmID).Error
+}
+
+// GormWebhookDeliveryService provider using a database
+type GormWebhookDeliveryService struct {
+	db *gorm.DB
+}
+
+type webhookDeliveryData struct {
+	ID          string     `gorm:"primary_key"`
+	Realm       string     `gorm:"index"`
+	Webhook     string     `gorm:"index"`
+	Status      string     `gorm:"index"`
+	NextAttempt *time.Time `gorm:"index"`
+	Created     time.Time
+	Data        []byte
+}
+
+func (webhookDeliveryData) TableName() string {
+	return "webhook_deliveries"
+}
+
+func NewGormWebhookDeliveryService(db *gorm.DB) (realm.WebhookDeliveryProvider, error) {
+	p := &GormWebhookDeliveryService{
+		db: db,
+	}
+
+	if err := p.Migrate(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *GormWebhookDeliveryService) Migrate() error {
+	return p.db.AutoMigrate(&webhookDeliveryData{}).Error
+}
+
+func (p *GormWebhookDeliveryService) List(realmID, webhookID string) ([]*realm.WebhookDelivery, error) {
+	deliveries := make([]*webhookDeliveryData, 0)
+	err := p.db.Where("realm = ? AND webhook = ?", realmID, webhookID).Order("created desc").Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return p.unmarshal(deliveries)
+}
+
+func (p *GormWebhookDeliveryService) ListDue(before time.Time) ([]*realm.WebhookDelivery, error) {
+	deliveries := make([]*webhookDeliveryData, 0)
+	err := p.db.Where("status = ? AND next_attempt <= ?", realm.WebhookDeliveryPending, before.UTC()).Order("next_attempt").Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return p.unmarshal(deliveries)
+}
+
+func (p *GormWebhookDeliveryService) unmarshal(deliveries []*webhookDeliveryData) ([]*realm.WebhookDelivery, error) {
+	out := make([]*realm.WebhookDelivery, 0)
+	for _, dd := range deliveries {
+		d := &realm.WebhookDelivery{}
+		if err := json.Unmarshal(dd.Data, &d); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+func (p *GormWebhookDeliveryService) Get(realmID, id string) (*realm.WebhookDelivery, error) {
+	dd := &webhookDeliveryData{}
+	err := p.db.Where("id = ? AND realm = ?", id, realmID).First(&dd).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var d *realm.WebhookDelivery
+	err = json.Unmarshal(dd.Data, &d)
+	if err != nil {
+		return nil, err
+	}
+	d.Realm = dd.Realm
+
+	return d, nil
+}
+
+func (p *GormWebhookDeliveryService) Set(realmID string, d *realm.WebhookDelivery) error {
+	dd, err := p.data(realmID, d)
+	if err != nil {
+		return err
+	}
+
+	return p.db.Save(&dd).Error
+}
+
+func (p *GormWebhookDeliveryService) Add(realmID string, d *realm.WebhookDelivery) (bool, error) {
+	dd, err := p.data(realmID, d)
+	if err != nil {
+		return false, err
+	}
+
+	// the primary key makes the insert fail if another instance got there first
+	if err := p.db.Create(&dd).Error; err != nil {
+		if _, getErr := p.Get(realmID, d.ID); getErr == nil {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (p *GormWebhookDeliveryService) data(realmID string, d *realm.WebhookDelivery) (*webhookDeliveryData, error) {
+	if d.ID == "" {
+		d.ID = uuid.NewV4().String()
+	}
+
+	if d.Created.IsZero() {
+		d.Created = time.Now().UTC()
+	}
+
+	var next *time.Time
+	if d.NextAttempt != nil {
+		t := d.NextAttempt.UTC()
+		next = &t
+	}
+
+	bytes, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+
+	return &webhookDeliveryData{
+		ID:          d.ID,
+		Realm:       realmID,
+		Webhook:     d.WebhookID,
+		Status:      d.Status,
+		NextAttempt: next,
+		Created:     d.Created,
+		Data:        bytes,
+	}, nil
+}
+
+func (p *GormWebhookDeliveryService) Delete(realmID, id string) error {
+	return p.db.Delete(&webhookDeliveryData{}, "id = ? AND realm = ?", id, realmID).Error
+}
+
+func (p *GormWebhookDeliveryService) DeleteForWebhook(realmID, webhookID string) error {
+	return p.db.Delete(&webhookDeliveryData{}, "realm = ? AND webhook = ?", realmID, webhookID).Error
+}
+
+func (p *GormWebhookDeliveryService) Claim(realmID, id string, now, until time.Time) (bool, error) {
+	// Only the instance whose update matches the due row gets to deliver it.
+	res := p.db.Model(&webhookDeliveryData{}).
+		Where("id = ? AND realm = ?", id, realmID).
+		Where("status = ? AND next_attempt <= ?", realm.WebhookDeliveryPending, now.UTC()).
+		UpdateColumn("next_attempt", until.UTC())
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected == 1, nil
+}
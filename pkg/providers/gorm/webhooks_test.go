@@ -0,0 +1,253 @@
+package gorm
+
+import (
+	"testing"
+	"time"
+
+	realm "github.com/IpsoVeritas/realm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+)
+
+func TestWebhookService_Get(t *testing.T) {
+	type test struct {
+		name    string
+		svc     realm.WebhookProvider
+		prepare func(*testing.T, *test)
+		id      string
+		wantErr bool
+	}
+	tests := []test{
+		{
+			name: "Get",
+			prepare: func(t *testing.T, tt *test) {
+				w := realm.Webhook{ID: tt.id, URL: "https://example.com/hook"}
+				if err := tt.svc.Set("abc", &w); err != nil {
+					t.Fatal(err)
+				}
+			},
+			id:      "abc",
+			wantErr: false,
+		},
+		{
+			name: "Get_Webhook_not_exist",
+			prepare: func(t *testing.T, tt *test) {
+				w := realm.Webhook{URL: "https://example.com/hook"}
+				if err := tt.svc.Set("abc", &w); err != nil {
+					t.Fatal(err)
+				}
+			},
+			id:      "fdfgfgd",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt.svc = newService(t, false).webhooks
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.prepare != nil {
+				tt.prepare(t, &tt)
+			}
+			got, err := tt.svc.Get("abc", tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WebhookService.Get() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got.ID != tt.id {
+				t.Errorf("WebhookService.Get() = ID: %v, want ID: %v", got.ID, tt.id)
+			}
+		})
+	}
+}
+
+func TestWebhookService_List(t *testing.T) {
+	type test struct {
+		name    string
+		svc     realm.WebhookProvider
+		prepare func(*testing.T, *test)
+		realm   string
+		count   int
+		wantErr bool
+	}
+	tests := []test{
+		{
+			name: "List",
+			prepare: func(t *testing.T, tt *test) {
+				w := realm.Webhook{URL: "https://example.com/hook"}
+				if err := tt.svc.Set(tt.realm, &w); err != nil {
+					t.Fatal(err)
+				}
+			},
+			realm:   "abc",
+			count:   1,
+			wantErr: false,
+		},
+		{
+			name: "List_another_realm",
+			prepare: func(t *testing.T, tt *test) {
+				w := realm.Webhook{URL: "https://example.com/hook"}
+				if err := tt.svc.Set("abc", &w); err != nil {
+					t.Fatal(err)
+				}
+			},
+			realm:   "cde",
+			count:   0,
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		tt.svc = newService(t, false).webhooks
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.prepare != nil {
+				tt.prepare(t, &tt)
+			}
+			got, err := tt.svc.List(tt.realm)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WebhookService.List() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && len(got) != tt.count {
+				t.Errorf("WebhookService.List() = count: %d, want count: %d", len(got), tt.count)
+			}
+		})
+	}
+}
+
+func TestWebhookDeliveryService_ListDue(t *testing.T) {
+	type test struct {
+		name    string
+		svc     realm.WebhookDeliveryProvider
+		prepare func(*testing.T, *test)
+		count   int
+		wantErr bool
+	}
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Hour)
+	tests := []test{
+		{
+			name: "ListDue",
+			prepare: func(t *testing.T, tt *test) {
+				deliveries := []*realm.WebhookDelivery{
+					{WebhookID: "a", Status: realm.WebhookDeliveryPending, NextAttempt: &past},
+					{WebhookID: "a", Status: realm.WebhookDeliveryPending, NextAttempt: &future},
+					{WebhookID: "a", Status: realm.WebhookDeliverySucceeded, NextAttempt: &past},
+				}
+				for _, d := range deliveries {
+					if err := tt.svc.Set("abc", d); err != nil {
+						t.Fatal(err)
+					}
+				}
+			},
+			count:   1,
+			wantErr: false,
+		},
+		{
+			name: "ListDue_all_realms",
+			prepare: func(t *testing.T, tt *test) {
+				for _, realmID := range []string{"abc", "cde"} {
+					d := &realm.WebhookDelivery{WebhookID: "a", Status: realm.WebhookDeliveryPending, NextAttempt: &past}
+					if err := tt.svc.Set(realmID, d); err != nil {
+						t.Fatal(err)
+					}
+				}
+			},
+			count:   2,
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		tt.svc = newService(t, false).deliveries
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.prepare != nil {
+				tt.prepare(t, &tt)
+			}
+			got, err := tt.svc.ListDue(now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WebhookDeliveryService.ListDue() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && len(got) != tt.count {
+				t.Errorf("WebhookDeliveryService.ListDue() = count: %d, want count: %d", len(got), tt.count)
+			}
+		})
+	}
+}
+
+func TestWebhookDeliveryService_Add(t *testing.T) {
+	svc := newService(t, false).deliveries
+
+	now := time.Now()
+	d := &realm.WebhookDelivery{ID: "d1", WebhookID: "a", Status: realm.WebhookDeliveryPending, NextAttempt: &now}
+	added, err := svc.Add("abc", d)
+	if err != nil || !added {
+		t.Fatalf("WebhookDeliveryService.Add() = %v, error = %v, want true", added, err)
+	}
+
+	dup := &realm.WebhookDelivery{ID: "d1", WebhookID: "a", Status: realm.WebhookDeliveryFailed}
+	added, err = svc.Add("abc", dup)
+	if err != nil || added {
+		t.Errorf("WebhookDeliveryService.Add() duplicate = %v, error = %v, want false", added, err)
+	}
+
+	got, err := svc.Get("abc", "d1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != realm.WebhookDeliveryPending {
+		t.Errorf("WebhookDeliveryService.Add() duplicate changed status to %s", got.Status)
+	}
+}
+
+func TestWebhookDeliveryService_Claim(t *testing.T) {
+	svc := newService(t, false).deliveries
+
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	lease := now.Add(time.Minute)
+
+	d := &realm.WebhookDelivery{WebhookID: "a", Status: realm.WebhookDeliveryPending, NextAttempt: &past}
+	if err := svc.Set("abc", d); err != nil {
+		t.Fatal(err)
+	}
+
+	claimed, err := svc.Claim("abc", d.ID, now, lease)
+	if err != nil || !claimed {
+		t.Fatalf("WebhookDeliveryService.Claim() = %v, error = %v, want true", claimed, err)
+	}
+
+	claimed, err = svc.Claim("abc", d.ID, now, lease)
+	if err != nil || claimed {
+		t.Errorf("WebhookDeliveryService.Claim() second claim = %v, error = %v, want false", claimed, err)
+	}
+
+	due, err := svc.ListDue(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 0 {
+		t.Errorf("WebhookDeliveryService.ListDue() after claim = count: %d, want count: %d", len(due), 0)
+	}
+}
+
+func TestWebhookDeliveryService_DeleteForWebhook(t *testing.T) {
+	svc := newService(t, false).deliveries
+
+	for _, webhookID := range []string{"a", "a", "b"} {
+		if err := svc.Set("abc", &realm.WebhookDelivery{WebhookID: webhookID, Status: realm.WebhookDeliveryPending}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := svc.DeleteForWebhook("abc", "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	for webhookID, count := range map[string]int{"a": 0, "b": 1} {
+		got, err := svc.List("abc", webhookID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != count {
+			t.Errorf("WebhookDeliveryService.List(%s) after DeleteForWebhook = count: %d, want count: %d", webhookID, len(got), count)
+		}
+	}
+}
@@ -26,6 +26,11 @@ func NewTwilioTransport(config *viper.Viper) (t *TwilioTransport) {
 	accountSid := config.GetString("twilio_account_sid")
 	authToken := config.GetString("twilio_authtoken")
 	tc := twilio.NewClient(accountSid, authToken, nil)
+	if baseURL := config.GetString("twilio_base_url"); baseURL != "" {
+		if u, err := url.Parse(baseURL); err == nil {
+			tc.BaseURL = u
+		}
+	}
 	t = &TwilioTransport{config.GetString("twilio_from"), tc}
 	return
 }
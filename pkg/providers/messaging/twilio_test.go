@@ -0,0 +1,132 @@
+package messaging
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func newFakeTwilio(t *testing.T, status int, got *map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/2010-04-01/Accounts/AC123/Messages.json" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "AC123" || pass != "secret" {
+			t.Errorf("unexpected credentials %s:%s", user, pass)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		*got = map[string]string{
+			"From": r.PostForm.Get("From"),
+			"To":   r.PostForm.Get("To"),
+			"Body": r.PostForm.Get("Body"),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if status == http.StatusCreated {
+			json.NewEncoder(w).Encode(map[string]string{"sid": "SM123", "status": "queued"})
+		} else {
+			json.NewEncoder(w).Encode(map[string]interface{}{"status": status, "code": 21211, "message": "Invalid 'To' Phone Number"})
+		}
+	}))
+}
+
+func newTwilioTransport(url string) *TwilioTransport {
+	config := viper.New()
+	config.Set("twilio_account_sid", "AC123")
+	config.Set("twilio_authtoken", "secret")
+	config.Set("twilio_from", "+15005550006")
+	config.Set("twilio_base_url", url)
+	return NewTwilioTransport(config)
+}
+
+func TestTwilioTransport_Send(t *testing.T) {
+	var got map[string]string
+	server := newFakeTwilio(t, http.StatusCreated, &got)
+	defer server.Close()
+
+	AddTransport("tel", newTwilioTransport(server.URL))
+
+	msg := Message{
+		Recipient: "tel:+46701234567",
+		Templates: Templates{Text: "Join {{ .realm }} at {{ .link }}"},
+		Data:      map[string]interface{}{"realm": "example.com", "link": "https://example.com/i"},
+	}
+
+	transport, err := LookupTransport(msg)
+	if err != nil {
+		t.Fatalf("LookupTransport() error = %v", err)
+	}
+
+	if err := transport.Validate(msg); err != nil {
+		t.Fatalf("TwilioTransport.Validate() error = %v", err)
+	}
+
+	id, err := transport.Send(msg)
+	if err != nil {
+		t.Fatalf("TwilioTransport.Send() error = %v", err)
+	}
+	if id != "SM123" {
+		t.Errorf("TwilioTransport.Send() = %v, want %v", id, "SM123")
+	}
+
+	want := map[string]string{
+		"From": "+15005550006",
+		"To":   "+46701234567",
+		"Body": "Join example.com at https://example.com/i",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("TwilioTransport.Send() sent %s = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestTwilioTransport_Send_error(t *testing.T) {
+	var got map[string]string
+	server := newFakeTwilio(t, http.StatusBadRequest, &got)
+	defer server.Close()
+
+	transport := newTwilioTransport(server.URL)
+	msg := Message{
+		Recipient: "tel:+46701234567",
+		Templates: Templates{Text: "hello"},
+	}
+
+	if _, err := transport.Send(msg); err == nil {
+		t.Error("TwilioTransport.Send() expected error")
+	}
+}
+
+func TestTwilioTransport_Validate(t *testing.T) {
+	transport := newTwilioTransport("")
+	tests := []struct {
+		name    string
+		msg     Message
+		wantErr bool
+	}{
+		{"Valid", Message{Recipient: "tel:+46701234567", Templates: Templates{Text: "hi"}}, false},
+		{"No_text", Message{Recipient: "tel:+46701234567"}, true},
+		{"Not_E164", Message{Recipient: "tel:0701-234567", Templates: Templates{Text: "hi"}}, true},
+		{"Attachments", Message{Recipient: "tel:+46701234567", Templates: Templates{Text: "hi"}, Attachments: []string{"a.png"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := transport.Validate(tt.msg); (err != nil) != tt.wantErr {
+				t.Errorf("TwilioTransport.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLookupTransport_unknown_scheme(t *testing.T) {
+	if _, err := LookupTransport(Message{Recipient: "fax:+46701234567"}); err == nil {
+		t.Error("LookupTransport() expected error for unknown scheme")
+	}
+}
@@ -0,0 +1,125 @@
+package signer
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	keys "github.com/IpsoVeritas/keys"
+	logger "github.com/IpsoVeritas/logger"
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// KEKRing holds the versions of the key encryption key. The current version,
+// kek_version, is configured as kek, and the previous versions as kek_<version>
+// until the stored keys have been re-encrypted with the current one.
+type KEKRing struct {
+	current int
+	keks    map[int][]byte
+}
+
+// NewKEKRing loads the KEK versions from the configuration. An empty current KEK
+// is refused in production.
+func NewKEKRing(config *viper.Viper) (*KEKRing, error) {
+	config.SetDefault("kek_version", 1)
+
+	r := &KEKRing{
+		current: config.GetInt("kek_version"),
+		keks:    make(map[int][]byte),
+	}
+	if r.current < 1 {
+		return nil, fmt.Errorf("Invalid KEK version %d", r.current)
+	}
+
+	current := config.GetString("kek")
+	if current == "" {
+		if config.GetBool("prod") {
+			return nil, errors.New("No KEK configured")
+		}
+		logger.Warning("No KEK configured, realm keys are encrypted with an empty KEK")
+	}
+	r.keks[r.current] = hash(current)
+
+	for v := 1; v < r.current; v++ {
+		if previous := config.GetString(fmt.Sprintf("kek_%d", v)); previous != "" {
+			r.keks[v] = hash(previous)
+		}
+	}
+
+	return r, nil
+}
+
+func hash(kek string) []byte {
+	sum := sha256.Sum256([]byte(kek))
+	return sum[:]
+}
+
+// Current returns the version and the KEK that keys are encrypted with.
+func (r *KEKRing) Current() (int, []byte) {
+	return r.current, r.keks[r.current]
+}
+
+// Get returns the KEK of a version. Version 0 is the first version, for keys
+// stored before KEK versions were tracked.
+func (r *KEKRing) Get(version int) ([]byte, error) {
+	if version == 0 {
+		version = 1
+	}
+
+	kek, ok := r.keks[version]
+	if !ok {
+		return nil, fmt.Errorf("KEK version %d is not configured", version)
+	}
+
+	return kek, nil
+}
+
+// Reencrypt encrypts the stored keys again with the current KEK, skipping keys
+// that already are. It returns the number of re-encrypted keys, and stops at
+// the first failure so the caller can roll back.
+func (r *KEKRing) Reencrypt(sks keys.StoredKeyService, versions realm.KEKVersionProvider, keyIDs []string) (int, error) {
+	current, kek := r.Current()
+
+	count := 0
+	for _, keyID := range keyIDs {
+		version, err := versions.Get(keyID)
+		if err != nil {
+			return count, errors.Wrapf(err, "failed to get KEK version of key %s", keyID)
+		}
+		if version == current {
+			continue
+		}
+
+		previous, err := r.Get(version)
+		if err != nil {
+			return count, errors.Wrapf(err, "failed to decrypt key %s", keyID)
+		}
+
+		skey, err := sks.Get(keyID)
+		if err != nil {
+			return count, errors.Wrapf(err, "failed to get key %s", keyID)
+		}
+
+		key, err := skey.Decrypt(previous)
+		if err != nil {
+			return count, errors.Wrapf(err, "failed to decrypt key %s", keyID)
+		}
+
+		if err := skey.Encrypt(key, kek); err != nil {
+			return count, errors.Wrapf(err, "failed to encrypt key %s", keyID)
+		}
+
+		if err := sks.Save(skey); err != nil {
+			return count, errors.Wrapf(err, "failed to save key %s", keyID)
+		}
+
+		if err := versions.Set(keyID, current); err != nil {
+			return count, errors.Wrapf(err, "failed to save KEK version of key %s", keyID)
+		}
+
+		count++
+	}
+
+	return count, nil
+}
@@ -0,0 +1,53 @@
+package signer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestNewKEKRing(t *testing.T) {
+	config := viper.New()
+	config.Set("kek", "new")
+	config.Set("kek_1", "old")
+	config.Set("kek_version", 2)
+
+	r, err := NewKEKRing(config)
+	if err != nil {
+		t.Fatalf("NewKEKRing() error = %v", err)
+	}
+
+	version, current := r.Current()
+	if version != 2 || !bytes.Equal(current, hash("new")) {
+		t.Errorf("KEKRing.Current() = %d, %x", version, current)
+	}
+
+	previous, err := r.Get(1)
+	if err != nil || !bytes.Equal(previous, hash("old")) {
+		t.Errorf("KEKRing.Get(1) = %x, %v", previous, err)
+	}
+
+	untracked, err := r.Get(0)
+	if err != nil || !bytes.Equal(untracked, previous) {
+		t.Errorf("KEKRing.Get(0) = %x, %v, want version 1", untracked, err)
+	}
+
+	if _, err := r.Get(3); err == nil {
+		t.Errorf("KEKRing.Get(3) should fail")
+	}
+}
+
+func TestNewKEKRing_Prod(t *testing.T) {
+	config := viper.New()
+	config.Set("prod", true)
+
+	if _, err := NewKEKRing(config); err == nil {
+		t.Errorf("NewKEKRing() without KEK in production should fail")
+	}
+
+	config.Set("prod", false)
+	if _, err := NewKEKRing(config); err != nil {
+		t.Errorf("NewKEKRing() without KEK outside production error = %v", err)
+	}
+}
@@ -0,0 +1,179 @@
+//go:build pkcs11
+// +build pkcs11
+
+package signer
+
+import (
+	gocrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	crypto "github.com/IpsoVeritas/crypto"
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/ThalesIgnite/crypto11"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	jose "gopkg.in/square/go-jose.v1"
+)
+
+// PKCS11Signer keeps the private keys in a PKCS#11 token, like an HSM, and lets
+// the token sign with them. The private keys never leave the token.
+type PKCS11Signer struct {
+	ctx *crypto11.Context
+}
+
+// NewPKCS11Signer loads the PKCS#11 module from the pkcs11_* configuration keys.
+func NewPKCS11Signer(config *viper.Viper) (realm.Signer, error) {
+	return newPKCS11Signer(config)
+}
+
+func newPKCS11Signer(config *viper.Viper) (*PKCS11Signer, error) {
+	if config.GetString("pkcs11_module") == "" {
+		return nil, errors.New("No PKCS#11 module configured")
+	}
+
+	ctx, err := crypto11.Configure(&crypto11.Config{
+		Path:       config.GetString("pkcs11_module"),
+		TokenLabel: config.GetString("pkcs11_token_label"),
+		Pin:        config.GetString("pkcs11_pin"),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load PKCS#11 module")
+	}
+
+	return &PKCS11Signer{ctx: ctx}, nil
+}
+
+// Close releases the PKCS#11 sessions.
+func (s *PKCS11Signer) Close() error {
+	return s.ctx.Close()
+}
+
+func (s *PKCS11Signer) Create(keyID string) (*jose.JsonWebKey, error) {
+	if existing, _ := s.ctx.FindKeyPair(nil, []byte(keyID)); existing != nil {
+		return nil, fmt.Errorf("Key %s already exists", keyID)
+	}
+
+	key, err := s.ctx.GenerateECDSAKeyPairWithLabel([]byte(keyID), []byte(keyID), elliptic.P256())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create key")
+	}
+
+	return publicKey(key)
+}
+
+func (s *PKCS11Signer) Import(keyID string, key *jose.JsonWebKey) error {
+	return errors.New("Importing keys to a PKCS#11 token is not supported")
+}
+
+func (s *PKCS11Signer) Key(keyID string) (*jose.JsonWebKey, error) {
+	return nil, realm.ErrKeyNotExportable
+}
+
+func (s *PKCS11Signer) PublicKey(keyID string) (*jose.JsonWebKey, error) {
+	key, err := s.find(keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return publicKey(key)
+}
+
+func (s *PKCS11Signer) Sign(keyID string, payload []byte) (*jose.JsonWebSignature, error) {
+	compact, err := s.sign(keyID, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return jose.ParseSigned(compact)
+}
+
+func (s *PKCS11Signer) Delete(keyID string) error {
+	key, err := s.find(keyID)
+	if err != nil {
+		return err
+	}
+
+	return key.Delete()
+}
+
+func (s *PKCS11Signer) find(keyID string) (crypto11.Signer, error) {
+	key, err := s.ctx.FindKeyPair(nil, []byte(keyID))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find key")
+	}
+	if key == nil {
+		return nil, fmt.Errorf("Key %s not found", keyID)
+	}
+
+	return key, nil
+}
+
+func publicKey(key gocrypto.Signer) (*jose.JsonWebKey, error) {
+	pub, ok := key.Public().(*ecdsa.PublicKey)
+	if !ok || pub.Curve != elliptic.P256() {
+		return nil, errors.New("Only P-256 keys are supported")
+	}
+
+	jwk := &jose.JsonWebKey{
+		Key:       pub,
+		Algorithm: string(jose.ES256),
+	}
+	jwk.KeyID = crypto.Thumbprint(jwk)
+
+	return jwk, nil
+}
+
+// sign returns the compact serialization of an ES256 JWS with the public key
+// embedded in the protected header, the same way the realm keys in the database sign.
+func (s *PKCS11Signer) sign(keyID string, payload []byte) (string, error) {
+	key, err := s.find(keyID)
+	if err != nil {
+		return "", err
+	}
+
+	pub, err := publicKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	header := map[string]interface{}{
+		"alg": string(jose.ES256),
+		"jwk": pub,
+	}
+	if pub.KeyID != "" {
+		header["kid"] = pub.KeyID
+	}
+	headerBytes, err := json.Marshal(header)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal header")
+	}
+
+	input := base64.RawURLEncoding.EncodeToString(headerBytes) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+
+	der, err := key.Sign(rand.Reader, digest[:], gocrypto.SHA256)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to sign")
+	}
+
+	// the token returns an ASN.1 signature, while JWS uses R and S as 32 bytes each
+	var sig struct {
+		R, S *big.Int
+	}
+	if _, err := asn1.Unmarshal(der, &sig); err != nil {
+		return "", errors.Wrap(err, "failed to parse signature")
+	}
+	raw := make([]byte, 64)
+	sig.R.FillBytes(raw[:32])
+	sig.S.FillBytes(raw[32:])
+
+	return input + "." + base64.RawURLEncoding.EncodeToString(raw), nil
+}
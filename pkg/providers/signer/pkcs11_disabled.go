@@ -0,0 +1,16 @@
+//go:build !pkcs11
+// +build !pkcs11
+
+package signer
+
+import (
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// NewPKCS11Signer fails in builds without the pkcs11 tag, since the PKCS#11
+// module is loaded with cgo.
+func NewPKCS11Signer(config *viper.Viper) (realm.Signer, error) {
+	return nil, errors.New("Built without PKCS#11 support, build with -tags pkcs11")
+}
@@ -0,0 +1,106 @@
+//go:build pkcs11
+// +build pkcs11
+
+package signer
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/spf13/viper"
+)
+
+// The tests run against SoftHSM, with a token initialized by:
+//
+//	softhsm2-util --init-token --free --label realm --pin 1234 --so-pin 1234
+//	PKCS11_MODULE=/usr/lib/softhsm/libsofthsm2.so go test -tags pkcs11 ./pkg/providers/signer/
+func newTestSigner(t *testing.T) *PKCS11Signer {
+	module := os.Getenv("PKCS11_MODULE")
+	if module == "" {
+		t.Skip("PKCS11_MODULE not set")
+	}
+
+	config := viper.New()
+	config.Set("pkcs11_module", module)
+	config.Set("pkcs11_token_label", "realm")
+	config.Set("pkcs11_pin", "1234")
+	if label := os.Getenv("PKCS11_TOKEN_LABEL"); label != "" {
+		config.Set("pkcs11_token_label", label)
+	}
+	if pin := os.Getenv("PKCS11_PIN"); pin != "" {
+		config.Set("pkcs11_pin", pin)
+	}
+
+	s, err := newPKCS11Signer(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	return s
+}
+
+func TestNewPKCS11Signer(t *testing.T) {
+	if _, err := NewPKCS11Signer(viper.New()); err == nil {
+		t.Errorf("NewPKCS11Signer() without module should fail")
+	}
+}
+
+func TestPKCS11Signer(t *testing.T) {
+	s := newTestSigner(t)
+	keyID := "test.example.com#" + t.Name()
+	s.Delete(keyID)
+
+	pub, err := s.Create(keyID)
+	if err != nil {
+		t.Fatalf("PKCS11Signer.Create() error = %v", err)
+	}
+	defer s.Delete(keyID)
+
+	if _, err := s.Create(keyID); err == nil {
+		t.Errorf("PKCS11Signer.Create() of existing key should fail")
+	}
+
+	found, err := s.PublicKey(keyID)
+	if err != nil {
+		t.Fatalf("PKCS11Signer.PublicKey() error = %v", err)
+	}
+	if !found.Key.(*ecdsa.PublicKey).Equal(pub.Key) {
+		t.Errorf("PKCS11Signer.PublicKey() does not match the created key")
+	}
+
+	if _, err := s.Key(keyID); err != realm.ErrKeyNotExportable {
+		t.Errorf("PKCS11Signer.Key() error = %v, want %v", err, realm.ErrKeyNotExportable)
+	}
+
+	compact, err := s.sign(keyID, []byte(`{"hello":"world"}`))
+	if err != nil {
+		t.Fatalf("PKCS11Signer.sign() error = %v", err)
+	}
+	parts := strings.Split(compact, ".")
+	if len(parts) != 3 {
+		t.Fatalf("PKCS11Signer.sign() = %s", compact)
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(raw) != 64 {
+		t.Fatalf("PKCS11Signer.sign() signature = %x, %v", raw, err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r, ss := new(big.Int).SetBytes(raw[:32]), new(big.Int).SetBytes(raw[32:])
+	if !ecdsa.Verify(pub.Key.(*ecdsa.PublicKey), digest[:], r, ss) {
+		t.Errorf("PKCS11Signer.sign() signature does not verify")
+	}
+
+	if err := s.Delete(keyID); err != nil {
+		t.Fatalf("PKCS11Signer.Delete() error = %v", err)
+	}
+	if _, err := s.PublicKey(keyID); err == nil {
+		t.Errorf("PKCS11Signer.PublicKey() of deleted key should fail")
+	}
+}
@@ -0,0 +1,113 @@
+package signer
+
+import (
+	crypto "github.com/IpsoVeritas/crypto"
+	keys "github.com/IpsoVeritas/keys"
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/pkg/errors"
+	jose "gopkg.in/square/go-jose.v1"
+)
+
+// StoredKeySigner keeps the private keys in a StoredKeyService, encrypted with the
+// current KEK, and decrypts them for every signature with the KEK version they
+// were encrypted with.
+type StoredKeySigner struct {
+	sks      keys.StoredKeyService
+	versions realm.KEKVersionProvider
+	keks     *KEKRing
+}
+
+func NewStoredKeySigner(sks keys.StoredKeyService, versions realm.KEKVersionProvider, keks *KEKRing) realm.Signer {
+	return &StoredKeySigner{
+		sks:      sks,
+		versions: versions,
+		keks:     keks,
+	}
+}
+
+func (s *StoredKeySigner) Create(keyID string) (*jose.JsonWebKey, error) {
+	key, err := crypto.NewKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create key")
+	}
+
+	if err := s.Import(keyID, key); err != nil {
+		return nil, err
+	}
+
+	return crypto.NewPublicKey(key)
+}
+
+func (s *StoredKeySigner) Import(keyID string, key *jose.JsonWebKey) error {
+	version, kek := s.keks.Current()
+
+	skey := keys.NewStoredKey(keyID)
+	if err := skey.Encrypt(key, kek); err != nil {
+		return errors.Wrap(err, "failed to encrypt private key")
+	}
+
+	if err := s.sks.Save(skey); err != nil {
+		return errors.Wrap(err, "failed to save key")
+	}
+
+	if err := s.versions.Set(keyID, version); err != nil {
+		return errors.Wrap(err, "failed to save KEK version")
+	}
+
+	return nil
+}
+
+func (s *StoredKeySigner) Key(keyID string) (*jose.JsonWebKey, error) {
+	skey, err := s.sks.Get(keyID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get key")
+	}
+
+	version, err := s.versions.Get(keyID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get KEK version")
+	}
+
+	kek, err := s.keks.Get(version)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := skey.Decrypt(kek)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decrypt key")
+	}
+
+	return key, nil
+}
+
+func (s *StoredKeySigner) PublicKey(keyID string) (*jose.JsonWebKey, error) {
+	key, err := s.Key(keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return crypto.NewPublicKey(key)
+}
+
+func (s *StoredKeySigner) Sign(keyID string, payload []byte) (*jose.JsonWebSignature, error) {
+	key, err := s.Key(keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := crypto.NewSigner(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create signer")
+	}
+
+	return signer.Sign(payload)
+}
+
+func (s *StoredKeySigner) Delete(keyID string) error {
+	if err := s.sks.Delete(keyID); err != nil {
+		return err
+	}
+
+	return s.versions.Delete(keyID)
+}
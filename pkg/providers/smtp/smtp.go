@@ -0,0 +1,385 @@
+package smtp
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	logger "github.com/IpsoVeritas/logger"
+	realm "github.com/IpsoVeritas/realm"
+	messaging "github.com/IpsoVeritas/realm/pkg/providers/messaging"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"github.com/spf13/viper"
+)
+
+// TLS modes for the connection to the mail relay.
+const (
+	TLSNone     = "none"
+	TLSStartTLS = "starttls"
+	TLSImplicit = "tls"
+)
+
+// SMTPProvider sends email through an SMTP relay.
+type SMTPProvider struct {
+	host       string
+	port       int
+	from       *mail.Address
+	username   string
+	password   string
+	auth       string
+	tlsMode    string
+	skipVerify bool
+	timeout    time.Duration
+}
+
+// NewSMTPProvider creates a provider from the smtp_* configuration keys.
+func NewSMTPProvider(config *viper.Viper) (realm.EmailProvider, error) {
+	config.SetDefault("smtp_port", 587)
+	config.SetDefault("smtp_tls", TLSStartTLS)
+	config.SetDefault("smtp_auth", "plain")
+	config.SetDefault("smtp_timeout", "30s")
+
+	host := config.GetString("smtp_host")
+	if host == "" {
+		return nil, errors.New("No SMTP host configured")
+	}
+
+	from, err := mail.ParseAddress(config.GetString("smtp_from"))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse SMTP from address")
+	}
+
+	p := &SMTPProvider{
+		host:       host,
+		port:       config.GetInt("smtp_port"),
+		from:       from,
+		username:   config.GetString("smtp_username"),
+		password:   config.GetString("smtp_password"),
+		auth:       strings.ToLower(config.GetString("smtp_auth")),
+		tlsMode:    strings.ToLower(config.GetString("smtp_tls")),
+		skipVerify: config.GetBool("smtp_skip_verify"),
+		timeout:    config.GetDuration("smtp_timeout"),
+	}
+
+	switch p.tlsMode {
+	case TLSNone, TLSStartTLS, TLSImplicit:
+	default:
+		return nil, fmt.Errorf("Unknown SMTP TLS mode %s", p.tlsMode)
+	}
+
+	switch p.auth {
+	case "plain", "login":
+	default:
+		return nil, fmt.Errorf("Unknown SMTP auth mechanism %s", p.auth)
+	}
+
+	return p, nil
+}
+
+func (p *SMTPProvider) Validate(msg messaging.Message) error {
+	if msg.Templates.Subject == "" {
+		return errors.New("Subject is empty")
+	}
+	if msg.Templates.Text == "" {
+		return errors.New("Text is empty")
+	}
+	_, err := recipient(msg)
+
+	return err
+}
+
+func (p *SMTPProvider) Send(msg messaging.Message) (*realm.EmailStatus, error) {
+	status := &realm.EmailStatus{}
+
+	to, err := recipient(msg)
+	if err != nil {
+		return status, err
+	}
+
+	status.MessageID = fmt.Sprintf("<%s@%s>", uuid.NewV4().String(), p.host)
+
+	body, subject, err := p.build(msg, to, status.MessageID)
+	if err != nil {
+		return status, err
+	}
+	status.Subject = subject
+
+	if err := p.deliver(to.Address, body); err != nil {
+		return status, err
+	}
+
+	status.Sent = true
+
+	return status, nil
+}
+
+func recipient(msg messaging.Message) (*mail.Address, error) {
+	u, err := url.Parse(msg.Recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	address := u.Opaque
+	if address == "" {
+		address = msg.Recipient
+	}
+
+	return mail.ParseAddress(address)
+}
+
+// build renders the message as multipart/related MIME holding a multipart/alternative
+// with the text and HTML parts, followed by the attachments as inline CID images.
+func (p *SMTPProvider) build(msg messaging.Message, to *mail.Address, messageID string) ([]byte, string, error) {
+	var subject, text, html bytes.Buffer
+
+	subjectTemplate, err := template.New("subject").Parse(msg.Templates.Subject)
+	if err == nil {
+		err = subjectTemplate.Execute(&subject, msg.Data)
+	}
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to render subject")
+	}
+
+	textTemplate, err := template.New("text").Parse(msg.Templates.Text)
+	if err == nil {
+		err = textTemplate.Execute(&text, msg.Data)
+	}
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to render text")
+	}
+
+	if msg.Templates.HTML != "" {
+		htmlTemplate, err := htmltemplate.New("html").Parse(msg.Templates.HTML)
+		if err == nil {
+			err = htmlTemplate.Execute(&html, msg.Data)
+		}
+		if err != nil {
+			return nil, "", errors.Wrap(err, "failed to render HTML")
+		}
+	}
+
+	var buf bytes.Buffer
+	related := multipart.NewWriter(&buf)
+
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	header("From", p.from.String())
+	header("To", to.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", subject.String()))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", messageID)
+	header("MIME-Version", "1.0")
+	header("Content-Type", fmt.Sprintf("multipart/related; boundary=%s; type=\"multipart/alternative\"", related.Boundary()))
+	buf.WriteString("\r\n")
+
+	var alternativeBuf bytes.Buffer
+	alternative := multipart.NewWriter(&alternativeBuf)
+	if err := writeQuotedPrintable(alternative, "text/plain; charset=utf-8", text.Bytes()); err != nil {
+		return nil, "", err
+	}
+	if html.Len() > 0 {
+		if err := writeQuotedPrintable(alternative, "text/html; charset=utf-8", html.Bytes()); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := alternative.Close(); err != nil {
+		return nil, "", err
+	}
+
+	part, err := related.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {fmt.Sprintf("multipart/alternative; boundary=%s", alternative.Boundary())},
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := io.Copy(part, &alternativeBuf); err != nil {
+		return nil, "", err
+	}
+
+	for _, f := range msg.Attachments {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			logger.Warningf("Failed to read attachment %s", f)
+			continue
+		}
+
+		name := filepath.Base(f)
+		contentType := mime.TypeByExtension(filepath.Ext(f))
+		if contentType == "" {
+			contentType = "image" + strings.Replace(filepath.Ext(f), ".", "/", 1)
+		}
+
+		part, err := related.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {fmt.Sprintf("%s; name=%q", contentType, name)},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {fmt.Sprintf("inline; filename=%q", name)},
+			"Content-ID":                {fmt.Sprintf("<%s>", name)},
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		if err := writeBase64(part, b); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := related.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return buf.Bytes(), subject.String(), nil
+}
+
+func writeQuotedPrintable(w *multipart.Writer, contentType string, body []byte) error {
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+
+	qp := quotedprintable.NewWriter(part)
+	if _, err := qp.Write(body); err != nil {
+		return err
+	}
+
+	return qp.Close()
+}
+
+func writeBase64(w io.Writer, body []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(body)
+	for len(encoded) > 76 {
+		if _, err := io.WriteString(w, encoded[:76]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err := io.WriteString(w, encoded+"\r\n")
+
+	return err
+}
+
+func (p *SMTPProvider) deliver(to string, body []byte) error {
+	addr := net.JoinHostPort(p.host, fmt.Sprintf("%d", p.port))
+	tlsConfig := &tls.Config{
+		ServerName:         p.host,
+		InsecureSkipVerify: p.skipVerify,
+	}
+
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{Timeout: p.timeout}
+	if p.tlsMode == TLSImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to SMTP server")
+	}
+	conn.SetDeadline(time.Now().Add(p.timeout))
+
+	c, err := smtp.NewClient(conn, p.host)
+	if err != nil {
+		conn.Close()
+		return errors.Wrap(err, "failed to start SMTP session")
+	}
+	defer c.Close()
+
+	if p.tlsMode == TLSStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("SMTP server does not support STARTTLS")
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return errors.Wrap(err, "failed to start TLS")
+		}
+	}
+
+	if p.username != "" {
+		var auth smtp.Auth
+		if p.auth == "login" {
+			auth = &loginAuth{username: p.username, password: p.password, host: p.host}
+		} else {
+			auth = smtp.PlainAuth("", p.username, p.password, p.host)
+		}
+		if err := c.Auth(auth); err != nil {
+			return errors.Wrap(err, "failed to authenticate")
+		}
+	}
+
+	if err := c.Mail(p.from.Address); err != nil {
+		return errors.Wrap(err, "failed to set sender")
+	}
+	if err := c.Rcpt(to); err != nil {
+		return errors.Wrap(err, "failed to set recipient")
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return errors.Wrap(err, "failed to start message data")
+	}
+	if _, err := w.Write(body); err != nil {
+		return errors.Wrap(err, "failed to write message data")
+	}
+	if err := w.Close(); err != nil {
+		return errors.Wrap(err, "failed to send message")
+	}
+
+	return c.Quit()
+}
+
+// loginAuth implements the LOGIN authentication mechanism, which net/smtp lacks.
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(string(fromServer))) {
+	case "username:":
+		return []byte(a.username), nil
+	case "password:":
+		return []byte(a.password), nil
+	}
+
+	return nil, fmt.Errorf("unexpected LOGIN challenge %q", fromServer)
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
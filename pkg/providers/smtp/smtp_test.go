@@ -0,0 +1,376 @@
+package smtp
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io/ioutil"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	realm "github.com/IpsoVeritas/realm"
+	messaging "github.com/IpsoVeritas/realm/pkg/providers/messaging"
+	"github.com/spf13/viper"
+)
+
+// fakeServer is a minimal SMTP server that accepts a single session and records
+// the authentication and message it received.
+type fakeServer struct {
+	t        *testing.T
+	listener net.Listener
+	tls      *tls.Config
+	startTLS bool
+	username string
+	password string
+
+	auth string
+	from string
+	rcpt string
+	data []byte
+	done chan struct{}
+}
+
+func selfSignedConfig(t *testing.T) *tls.Config {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+}
+
+func newFakeServer(t *testing.T, tlsMode string) *fakeServer {
+	s := &fakeServer{
+		t:        t,
+		tls:      selfSignedConfig(t),
+		startTLS: tlsMode == TLSStartTLS,
+		username: "user",
+		password: "secret",
+		done:     make(chan struct{}),
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tlsMode == TLSImplicit {
+		l = tls.NewListener(l, s.tls)
+	}
+	s.listener = l
+
+	go s.serve()
+
+	return s
+}
+
+func (s *fakeServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeServer) Close() {
+	s.listener.Close()
+}
+
+func (s *fakeServer) serve() {
+	defer close(s.done)
+
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 127.0.0.1 ESMTP")
+
+	secure := !s.startTLS
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch verb {
+		case "EHLO":
+			text.PrintfLine("250-127.0.0.1")
+			if s.startTLS && !secure {
+				text.PrintfLine("250-STARTTLS")
+			}
+			text.PrintfLine("250 AUTH PLAIN LOGIN")
+		case "STARTTLS":
+			text.PrintfLine("220 Ready to start TLS")
+			tlsConn := tls.Server(conn, s.tls)
+			if err := tlsConn.Handshake(); err != nil {
+				s.t.Errorf("TLS handshake failed: %s", err)
+				return
+			}
+			conn = tlsConn
+			text = textproto.NewConn(conn)
+			secure = true
+		case "AUTH":
+			if !s.authenticate(text, line) {
+				text.PrintfLine("535 Authentication failed")
+				continue
+			}
+			text.PrintfLine("235 Authentication successful")
+		case "MAIL":
+			s.from = line
+			text.PrintfLine("250 OK")
+		case "RCPT":
+			s.rcpt = line
+			text.PrintfLine("250 OK")
+		case "DATA":
+			text.PrintfLine("354 Go ahead")
+			s.data, err = text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			text.PrintfLine("250 Queued")
+		case "QUIT":
+			text.PrintfLine("221 Bye")
+			return
+		default:
+			text.PrintfLine("502 Not implemented")
+		}
+	}
+}
+
+func (s *fakeServer) authenticate(text *textproto.Conn, line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return false
+	}
+	s.auth = strings.ToUpper(fields[1])
+
+	switch s.auth {
+	case "PLAIN":
+		if len(fields) < 3 {
+			return false
+		}
+		b, err := base64.StdEncoding.DecodeString(fields[2])
+		if err != nil {
+			return false
+		}
+		return string(b) == "\x00"+s.username+"\x00"+s.password
+	case "LOGIN":
+		text.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte("Username:")))
+		username, _ := text.ReadLine()
+		text.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte("Password:")))
+		password, _ := text.ReadLine()
+		u, _ := base64.StdEncoding.DecodeString(username)
+		p, _ := base64.StdEncoding.DecodeString(password)
+		return string(u) == s.username && string(p) == s.password
+	}
+
+	return false
+}
+
+func newProvider(t *testing.T, port int, tlsMode, auth, password string) realm.EmailProvider {
+	config := viper.New()
+	config.Set("smtp_host", "127.0.0.1")
+	config.Set("smtp_port", port)
+	config.Set("smtp_from", "Realm <realm@example.com>")
+	config.Set("smtp_username", "user")
+	config.Set("smtp_password", password)
+	config.Set("smtp_auth", auth)
+	config.Set("smtp_tls", tlsMode)
+	config.Set("smtp_skip_verify", true)
+	config.Set("smtp_timeout", "5s")
+
+	p, err := NewSMTPProvider(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return p
+}
+
+func TestNewSMTPProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name:   "Defaults",
+			config: map[string]interface{}{"smtp_host": "mail.example.com", "smtp_from": "realm@example.com"},
+		},
+		{
+			name:    "No host",
+			config:  map[string]interface{}{"smtp_from": "realm@example.com"},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid from",
+			config:  map[string]interface{}{"smtp_host": "mail.example.com", "smtp_from": "not an address"},
+			wantErr: true,
+		},
+		{
+			name:    "Unknown TLS mode",
+			config:  map[string]interface{}{"smtp_host": "mail.example.com", "smtp_from": "realm@example.com", "smtp_tls": "ssl3"},
+			wantErr: true,
+		},
+		{
+			name:    "Unknown auth",
+			config:  map[string]interface{}{"smtp_host": "mail.example.com", "smtp_from": "realm@example.com", "smtp_auth": "cram-md5"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := viper.New()
+			for k, v := range tt.config {
+				config.Set(k, v)
+			}
+			_, err := NewSMTPProvider(config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewSMTPProvider() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSMTPProvider_Send(t *testing.T) {
+	attachment := filepath.Join(t.TempDir(), "logo.png")
+	logo := []byte("\x89PNG\r\n\x1a\nnot really a png")
+	if err := ioutil.WriteFile(attachment, logo, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		tls      string
+		auth     string
+		password string
+		wantAuth string
+		wantErr  bool
+	}{
+		{name: "Plain without TLS", tls: TLSNone, auth: "plain", password: "secret", wantAuth: "PLAIN"},
+		{name: "Login with STARTTLS", tls: TLSStartTLS, auth: "login", password: "secret", wantAuth: "LOGIN"},
+		{name: "Plain with implicit TLS", tls: TLSImplicit, auth: "plain", password: "secret", wantAuth: "PLAIN"},
+		{name: "Wrong password", tls: TLSStartTLS, auth: "plain", password: "wrong", wantAuth: "PLAIN", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeServer(t, tt.tls)
+			defer server.Close()
+
+			p := newProvider(t, server.port(), tt.tls, tt.auth, tt.password)
+			msg := messaging.Message{
+				Recipient: "mailto:alice@example.com",
+				Templates: messaging.Templates{
+					Subject: "Welcome to {{ .realm }}",
+					Text:    "Join {{ .realm }} at {{ .link }}",
+					HTML:    `<p>Join <a href="{{ .link }}">{{ .realm }}</a></p><img src="cid:logo.png">`,
+				},
+				Data:        map[string]interface{}{"realm": "example.com", "link": "https://example.com/i"},
+				Attachments: []string{attachment},
+			}
+
+			status, err := p.Send(msg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SMTPProvider.Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			<-server.done
+
+			if server.auth != tt.wantAuth {
+				t.Errorf("SMTPProvider.Send() auth = %s, want %s", server.auth, tt.wantAuth)
+			}
+			if tt.wantErr {
+				if status.Sent {
+					t.Errorf("SMTPProvider.Send() sent = true on failure")
+				}
+				return
+			}
+
+			if !status.Sent || status.Subject != "Welcome to example.com" || status.MessageID == "" {
+				t.Errorf("SMTPProvider.Send() status = %+v", status)
+			}
+			if server.from != "MAIL FROM:<realm@example.com>" || !strings.HasPrefix(server.rcpt, "RCPT TO:<alice@example.com>") {
+				t.Errorf("SMTPProvider.Send() envelope = %s, %s", server.from, server.rcpt)
+			}
+
+			checkMessage(t, server.data, logo)
+		})
+	}
+}
+
+func checkMessage(t *testing.T, data, logo []byte) {
+	m, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/related" {
+		t.Fatalf("Content-Type = %s, %v", mediaType, err)
+	}
+
+	related := multipart.NewReader(m.Body, params["boundary"])
+	part, err := related.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaType, params, err = mime.ParseMediaType(part.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("first part Content-Type = %s, %v", mediaType, err)
+	}
+
+	bodies := make(map[string]string)
+	alternative := multipart.NewReader(part, params["boundary"])
+	for {
+		p, err := alternative.NextPart()
+		if err != nil {
+			break
+		}
+		b, _ := ioutil.ReadAll(p)
+		contentType, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		bodies[contentType] = string(b)
+	}
+	if bodies["text/plain"] != "Join example.com at https://example.com/i" {
+		t.Errorf("text part = %q", bodies["text/plain"])
+	}
+	if !strings.Contains(bodies["text/html"], `<a href="https://example.com/i">example.com</a>`) {
+		t.Errorf("html part = %q", bodies["text/html"])
+	}
+
+	part, err = related.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if part.Header.Get("Content-ID") != "<logo.png>" {
+		t.Errorf("attachment Content-ID = %s", part.Header.Get("Content-ID"))
+	}
+	encoded, _ := ioutil.ReadAll(bufio.NewReader(part))
+	decoded, err := base64.StdEncoding.DecodeString(strings.Replace(string(encoded), "\r\n", "", -1))
+	if err != nil || !bytes.Equal(decoded, logo) {
+		t.Errorf("attachment = %q, %v", decoded, err)
+	}
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/pkg/errors"
+)
+
+type AuditService struct {
+	p       realm.AuditProvider
+	realmID string
+}
+
+func (a *AuditService) List(filter realm.AuditFilter) ([]*realm.AuditEntry, error) {
+	return a.p.List(a.realmID, filter)
+}
+
+// Record appends an entry to the audit log. Before and after are the entity
+// before and after the operation, either can be nil for creates and deletes.
+func (a *AuditService) Record(actor realm.AuditActor, entityType, entityID, operation string, before, after interface{}) error {
+	entry := &realm.AuditEntry{
+		Actor:      actor,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Operation:  operation,
+	}
+
+	var err error
+	if entry.Before, err = marshalAuditValue(before); err != nil {
+		return errors.Wrap(err, "failed to marshal before value")
+	}
+	if entry.After, err = marshalAuditValue(after); err != nil {
+		return errors.Wrap(err, "failed to marshal after value")
+	}
+
+	if entry.Diff, err = auditDiff(entry.Before, entry.After); err != nil {
+		return errors.Wrap(err, "failed to diff values")
+	}
+
+	return a.p.Append(a.realmID, entry)
+}
+
+// Verify walks the audit log of the realm and checks that the hash chain is intact.
+func (a *AuditService) Verify() error {
+	entries, err := a.List(realm.AuditFilter{})
+	if err != nil {
+		return errors.Wrap(err, "failed to list audit log")
+	}
+
+	previous := ""
+	for i, entry := range entries {
+		if entry.Sequence != int64(i+1) {
+			return fmt.Errorf("audit entry %s has sequence %d, expected %d", entry.ID, entry.Sequence, i+1)
+		}
+
+		if entry.PreviousHash != previous {
+			return fmt.Errorf("audit entry %d does not link to the previous entry", entry.Sequence)
+		}
+
+		hash, err := entry.ComputeHash()
+		if err != nil {
+			return err
+		}
+
+		if hash != entry.Hash {
+			return fmt.Errorf("audit entry %d has been modified", entry.Sequence)
+		}
+
+		previous = entry.Hash
+	}
+
+	return nil
+}
+
+func marshalAuditValue(v interface{}) (json.RawMessage, error) {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return nil, nil
+	}
+
+	return json.Marshal(v)
+}
+
+// auditDiff compares the top level fields of two JSON objects and returns the ones that changed.
+// Values that aren't JSON objects don't produce a diff.
+func auditDiff(before, after json.RawMessage) (map[string]*realm.AuditChange, error) {
+	b := make(map[string]interface{})
+	if len(before) > 0 {
+		if err := json.Unmarshal(before, &b); err != nil {
+			return nil, nil
+		}
+	}
+
+	a := make(map[string]interface{})
+	if len(after) > 0 {
+		if err := json.Unmarshal(after, &a); err != nil {
+			return nil, nil
+		}
+	}
+
+	diff := make(map[string]*realm.AuditChange)
+	for k, bv := range b {
+		av, ok := a[k]
+		if !ok || !reflect.DeepEqual(av, bv) {
+			diff[k] = &realm.AuditChange{Before: bv, After: av}
+		}
+	}
+	for k, av := range a {
+		if _, ok := b[k]; !ok {
+			diff[k] = &realm.AuditChange{After: av}
+		}
+	}
+
+	if len(diff) == 0 {
+		return nil, nil
+	}
+
+	return diff, nil
+}
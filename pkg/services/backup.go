@@ -0,0 +1,544 @@
+package services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	crypto "github.com/IpsoVeritas/crypto"
+	logger "github.com/IpsoVeritas/logger"
+	realm "github.com/IpsoVeritas/realm"
+	filestore "github.com/IpsoVeritas/realm/pkg/providers/filestore"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+	jose "gopkg.in/square/go-jose.v1"
+)
+
+// Export writes the realm and everything that belongs to it to a gzipped JWS,
+// signed with the current key of the realm. The private keys are included,
+// encrypted with the passphrase, only if a passphrase is given.
+func (r *RealmService) Export(passphrase string) ([]byte, error) {
+	realmData, err := r.Realm()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get realm")
+	}
+
+	backup := &realm.Backup{
+		Type:         realm.BackupType,
+		Version:      realm.BackupVersion,
+		Created:      time.Now().UTC(),
+		Realm:        realmData,
+		InviteTokens: make(map[string]string),
+		Settings:     make(map[string]string),
+	}
+
+	roles, err := r.Roles().List()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list roles")
+	}
+	for _, role := range roles {
+		withPermissions, err := r.Roles().WithPermissions(role)
+		if err != nil {
+			return nil, err
+		}
+		backup.Roles = append(backup.Roles, withPermissions)
+	}
+
+	if backup.Controllers, err = r.p.controllers.List(r.realmID); err != nil {
+		return nil, errors.Wrap(err, "failed to list controllers")
+	}
+
+	if backup.Actions, err = r.p.actions.List(r.realmID); err != nil {
+		return nil, errors.Wrap(err, "failed to list actions")
+	}
+
+	if backup.Mandates, err = r.p.mandates.List(r.realmID); err != nil {
+		return nil, errors.Wrap(err, "failed to list mandates")
+	}
+
+	if backup.Revocations, err = r.p.revocations.List(r.realmID); err != nil {
+		return nil, errors.Wrap(err, "failed to list revocations")
+	}
+
+	if backup.Tickets, err = r.p.mandateTickets.List(r.realmID); err != nil {
+		return nil, errors.Wrap(err, "failed to list tickets")
+	}
+
+	if backup.Invites, err = r.p.invites.List(r.realmID); err != nil {
+		return nil, errors.Wrap(err, "failed to list invites")
+	}
+	for _, invite := range backup.Invites {
+		if invite.TokenHash != "" {
+			backup.InviteTokens[invite.ID] = invite.TokenHash
+		}
+	}
+
+	settings, err := r.Settings().List()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list settings")
+	}
+	for _, setting := range settings {
+		backup.Settings[setting.Key] = setting.Value
+	}
+
+	if r.p.filestore != nil {
+		names, err := r.Files().List()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list files")
+		}
+		for _, name := range names {
+			data, err := r.Files().Read(name)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to read file %s", name)
+			}
+			backup.Files = append(backup.Files, &realm.BackupFile{Name: name, Data: data})
+		}
+	}
+
+	if passphrase != "" {
+		keys := make(map[string]*jose.JsonWebKey)
+		for _, keyID := range realmKeyIDs(realmData, backup.Created) {
+			if keys[keyID], err = r.p.getKey(keyID); err != nil {
+				return nil, errors.Wrapf(err, "failed to get key %s", keyID)
+			}
+		}
+
+		if backup.Keys, err = encryptBackupKeys(keys, passphrase); err != nil {
+			return nil, err
+		}
+	}
+
+	payload, err := json.Marshal(backup)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal backup")
+	}
+
+	jws, err := r.Sign(payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign backup")
+	}
+
+	compact, err := jws.CompactSerialize()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to serialize backup")
+	}
+
+	buf := &bytes.Buffer{}
+	zw := gzip.NewWriter(buf)
+	if _, err := io.WriteString(zw, compact); err != nil {
+		return nil, errors.Wrap(err, "failed to compress backup")
+	}
+	if err := zw.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to compress backup")
+	}
+
+	r.publish(realm.EventRealmExported, r.realmID, nil)
+
+	return buf.Bytes(), nil
+}
+
+// ReadBackup unpacks an archive written by Export, and verifies that it's
+// signed by the key of the realm in it. It returns the thumbprint of that key.
+func ReadBackup(archive []byte) (*realm.Backup, string, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to decompress backup")
+	}
+
+	compact, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to decompress backup")
+	}
+
+	jws, err := jose.ParseSigned(string(compact))
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to parse backup")
+	}
+
+	if len(jws.Signatures) != 1 || jws.Signatures[0].Header.JsonWebKey == nil {
+		return nil, "", realm.ErrBackupSignature
+	}
+	signer := jws.Signatures[0].Header.JsonWebKey
+
+	payload, err := jws.Verify(signer)
+	if err != nil {
+		return nil, "", realm.ErrBackupSignature
+	}
+
+	backup := &realm.Backup{}
+	if err := json.Unmarshal(payload, backup); err != nil {
+		return nil, "", errors.Wrap(err, "failed to unmarshal backup")
+	}
+
+	if backup.Type != realm.BackupType || backup.Realm == nil {
+		return nil, "", errors.New("Not a realm backup")
+	}
+
+	if backup.Version > realm.BackupVersion {
+		return nil, "", fmt.Errorf("Unsupported backup version %d", backup.Version)
+	}
+
+	tp := crypto.Thumbprint(signer)
+	if tp != crypto.Thumbprint(backup.Realm.PublicKey) {
+		return nil, "", realm.ErrBackupSignature
+	}
+
+	return backup, tp, nil
+}
+
+// Import restores an archive written by Export to the realm. The realm in the
+// archive must be this realm, and if the realm already exists the archive must
+// be signed by one of its current keys. The conflict policy decides what happens
+// to entities that exist both in the realm and in the archive.
+func (r *RealmService) Import(archive []byte, passphrase, conflict string) (*realm.BackupImport, error) {
+	switch conflict {
+	case "":
+		conflict = realm.BackupConflictFail
+	case realm.BackupConflictFail, realm.BackupConflictSkip, realm.BackupConflictReplace:
+	default:
+		return nil, fmt.Errorf("Unknown conflict policy %s", conflict)
+	}
+
+	backup, tp, err := ReadBackup(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	if backup.Realm.ID != r.realmID {
+		return nil, fmt.Errorf("Backup is for realm %s", backup.Realm.ID)
+	}
+
+	for _, file := range backup.Files {
+		if err := filestore.ValidateName(file.Name); err != nil {
+			return nil, errors.Wrapf(err, "backup has file %q", file.Name)
+		}
+	}
+
+	now := time.Now()
+	existing, err := r.p.realms.Get(r.realmID)
+	exists := err == nil
+	if exists {
+		if conflict == realm.BackupConflictFail {
+			return nil, realm.ErrBackupConflict
+		}
+		if !keyThumbprints(existing, now)[tp] {
+			return nil, realm.ErrBackupSignature
+		}
+	}
+
+	if r.p.importer == nil {
+		return nil, errors.New("No realm importer configured")
+	}
+
+	if err := r.importKeys(backup, passphrase); err != nil {
+		return nil, err
+	}
+
+	result := &realm.BackupImport{
+		Imported: make(map[string]int),
+		Skipped:  make(map[string]int),
+	}
+
+	// entities are written if they're new, or replace existing ones
+	write := func(kind string, found bool, set func() error) error {
+		if found && conflict == realm.BackupConflictSkip {
+			result.Skipped[kind]++
+			return nil
+		}
+		if err := set(); err != nil {
+			return errors.Wrapf(err, "failed to import %s", kind)
+		}
+		result.Imported[kind]++
+		return nil
+	}
+
+	// files are written first, since the descriptor points to them, and writing
+	// them again when an import is retried does no harm
+	locations := make(map[string]string)
+	if r.p.filestore != nil {
+		names, err := r.Files().List()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list files")
+		}
+		stored := make(map[string]bool)
+		for _, name := range names {
+			stored[name] = true
+		}
+
+		for _, file := range backup.Files {
+			file := file
+			if err := write("files", stored[file.Name], func() error {
+				location, err := r.Files().Write(file.Name, bytes.NewReader(file.Data))
+				locations[file.Name] = location
+				return err
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	realmData := backup.Realm
+	r.relocate(realmData, locations)
+	realmData.SignedDescriptor, err = r.p.signDescriptor(realmData)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign descriptor")
+	}
+	if exists && conflict == realm.BackupConflictSkip {
+		realmData = existing
+	}
+
+	// the entities are written in one transaction, so a failed import changes nothing
+	err = r.p.importer.Import(func(store *realm.RealmStore) error {
+		if err := write("realms", exists, func() error {
+			return store.Realms.Set(backup.Realm)
+		}); err != nil {
+			return err
+		}
+
+		for _, role := range backup.Roles {
+			role := role
+			_, err := store.Roles.Get(r.realmID, role.ID)
+			if err := write("roles", err == nil, func() error {
+				if err := store.Roles.Set(r.realmID, &role.Role); err != nil {
+					return err
+				}
+				return store.Permissions.Set(r.realmID, role.Name, role.Permissions)
+			}); err != nil {
+				return err
+			}
+		}
+
+		for _, controller := range backup.Controllers {
+			controller := controller
+			_, err := store.Controllers.Get(r.realmID, controller.ID)
+			if err := write("controllers", err == nil, func() error {
+				return store.Controllers.Set(r.realmID, controller)
+			}); err != nil {
+				return err
+			}
+		}
+
+		for _, action := range backup.Actions {
+			action := action
+			_, err := store.Actions.Get(r.realmID, action.ID)
+			if err := write("actions", err == nil, func() error {
+				return store.Actions.Set(r.realmID, action)
+			}); err != nil {
+				return err
+			}
+		}
+
+		for _, mandate := range backup.Mandates {
+			mandate := mandate
+			_, err := store.Mandates.Get(r.realmID, mandate.ID)
+			if err := write("mandates", err == nil, func() error {
+				return store.Mandates.Set(r.realmID, mandate)
+			}); err != nil {
+				return err
+			}
+		}
+
+		for _, revocation := range backup.Revocations {
+			revocation := revocation
+			_, err := store.Revocations.Get(r.realmID, revocation.MandateID)
+			if err := write("revocations", err == nil, func() error {
+				return store.Revocations.Set(r.realmID, revocation)
+			}); err != nil {
+				return err
+			}
+		}
+
+		for _, ticket := range backup.Tickets {
+			ticket := ticket
+			_, err := store.MandateTickets.Get(r.realmID, ticket.ID)
+			if err := write("tickets", err == nil, func() error {
+				return store.MandateTickets.Set(r.realmID, ticket)
+			}); err != nil {
+				return err
+			}
+		}
+
+		for _, invite := range backup.Invites {
+			invite := invite
+			invite.TokenHash = backup.InviteTokens[invite.ID]
+			_, err := store.Invites.Get(r.realmID, invite.ID)
+			if err := write("invites", err == nil, func() error {
+				return store.Invites.Set(r.realmID, invite)
+			}); err != nil {
+				return err
+			}
+		}
+
+		for key, value := range backup.Settings {
+			key, value := key, value
+			_, err := store.Settings.Get(r.realmID, key)
+			if err := write("settings", err == nil, func() error {
+				return store.Settings.Set(r.realmID, key, value)
+			}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.realm = realmData
+	result.Realm = realmData
+	if r.realmID == r.p.bootstrapRealmID && r.p.bootstrapRealm != nil {
+		r.p.bootstrapRealm = realmData
+	}
+
+	r.publish(realm.EventRealmImported, r.realmID, nil)
+
+	return result, nil
+}
+
+// importKeys adds the private keys in the archive to the signer, unless it
+// already has them, and makes sure the signer has the current key of the realm.
+func (r *RealmService) importKeys(backup *realm.Backup, passphrase string) error {
+	if backup.Keys != nil {
+		if passphrase == "" {
+			return errors.Wrap(realm.ErrBackupPassphrase, "the backup has encrypted keys")
+		}
+
+		keys, err := decryptBackupKeys(backup.Keys, passphrase)
+		if err != nil {
+			return err
+		}
+
+		for keyID, key := range keys {
+			if _, err := r.p.signer.PublicKey(keyID); err == nil {
+				continue
+			}
+			if err := r.p.signer.Import(keyID, key); err != nil {
+				return errors.Wrapf(err, "failed to import key %s", keyID)
+			}
+		}
+	}
+
+	keyID := backup.Realm.SigningKeyID()
+	if _, err := r.p.signer.PublicKey(keyID); err != nil {
+		return errors.Wrapf(err, "key %s of the realm is missing, export the realm with a passphrase to include it", keyID)
+	}
+
+	// retired keys that weren't exported are dropped, since the descriptor is signed with them
+	retired := make([]*realm.RetiredKey, 0)
+	for _, k := range backup.Realm.RetiredKeys {
+		if _, err := r.p.signer.PublicKey(k.KeyID); err != nil {
+			logger.Warningf("Dropping retired key %s of realm %s: %s", k.KeyID, r.realmID, err)
+			continue
+		}
+		retired = append(retired, k)
+	}
+	backup.Realm.RetiredKeys = retired
+
+	return nil
+}
+
+// relocate points the descriptor of an imported realm to this realm server, and
+// to the imported icon and banner.
+func (r *RealmService) relocate(realmData *realm.Realm, locations map[string]string) {
+	if realmData.Descriptor == nil {
+		return
+	}
+
+	realmData.Descriptor.Services = fmt.Sprintf("%s/realm/v2/realms/%s/services", r.base, realmData.ID)
+
+	for name, location := range locations {
+		suffix := fmt.Sprintf("%s/%s", r.Files().dir(), name)
+		if realmData.Descriptor.Icon != "" && strings.HasSuffix(realmData.Descriptor.Icon, suffix) {
+			realmData.Descriptor.Icon = location
+		}
+		if realmData.Descriptor.Banner != "" && strings.HasSuffix(realmData.Descriptor.Banner, suffix) {
+			realmData.Descriptor.Banner = location
+		}
+	}
+}
+
+// realmKeyIDs returns the IDs of the current key and the retired keys still in
+// their grace period.
+func realmKeyIDs(realmData *realm.Realm, now time.Time) []string {
+	keyIDs := []string{realmData.SigningKeyID()}
+	for _, k := range realmData.RetiredKeys {
+		if now.Before(k.ValidUntil) {
+			keyIDs = append(keyIDs, k.KeyID)
+		}
+	}
+
+	return keyIDs
+}
+
+func backupKey(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to derive key from passphrase")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func encryptBackupKeys(keys map[string]*jose.JsonWebKey, passphrase string) (*realm.BackupKeys, error) {
+	plaintext, err := json.Marshal(keys)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal keys")
+	}
+
+	encrypted := &realm.BackupKeys{
+		Salt: make([]byte, 16),
+	}
+	if _, err := rand.Read(encrypted.Salt); err != nil {
+		return nil, err
+	}
+
+	aead, err := backupKey(passphrase, encrypted.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(encrypted.Nonce); err != nil {
+		return nil, err
+	}
+	encrypted.Ciphertext = aead.Seal(nil, encrypted.Nonce, plaintext, nil)
+
+	return encrypted, nil
+}
+
+func decryptBackupKeys(encrypted *realm.BackupKeys, passphrase string) (map[string]*jose.JsonWebKey, error) {
+	aead, err := backupKey(passphrase, encrypted.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(encrypted.Nonce) != aead.NonceSize() {
+		return nil, errors.New("Invalid nonce for backup keys")
+	}
+
+	plaintext, err := aead.Open(nil, encrypted.Nonce, encrypted.Ciphertext, nil)
+	if err != nil {
+		return nil, realm.ErrBackupPassphrase
+	}
+
+	keys := make(map[string]*jose.JsonWebKey)
+	if err := json.Unmarshal(plaintext, &keys); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal keys")
+	}
+
+	return keys, nil
+}
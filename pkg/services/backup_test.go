@@ -0,0 +1,229 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/IpsoVeritas/crypto"
+	realm "github.com/IpsoVeritas/realm"
+	perrors "github.com/pkg/errors"
+)
+
+// newBackupProvider returns a provider whose test realm has a key in the signer,
+// a role and a setting.
+func newBackupProvider(t *testing.T, signer *testSigner) *RealmsServiceProvider {
+	p := newProvider(t)
+	p.signer = signer
+
+	realmData, err := p.realms.Get(testRealm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if realmData.PublicKey, err = signer.Create(realmData.SigningKeyID()); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.realms.Set(realmData); err != nil {
+		t.Fatal(err)
+	}
+
+	setRole(t, p, "user@"+testRealm, "invites:read")
+	if err := p.Get(testRealm).Settings().Set("key", "exported"); err != nil {
+		t.Fatal(err)
+	}
+
+	return p
+}
+
+func TestReadBackup(t *testing.T) {
+	p := newBackupProvider(t, newTestSigner())
+	context := p.Get(testRealm)
+	realmData, err := context.Realm()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := context.Export("")
+	if err != nil {
+		t.Fatalf("RealmService.Export() error = %v", err)
+	}
+
+	backup, tp, err := ReadBackup(archive)
+	if err != nil {
+		t.Fatalf("ReadBackup() error = %v", err)
+	}
+	if tp != crypto.Thumbprint(realmData.PublicKey) {
+		t.Errorf("ReadBackup() thumbprint = %v, want the realm key %v", tp, crypto.Thumbprint(realmData.PublicKey))
+	}
+	if backup.Realm.ID != testRealm || len(backup.Roles) != 1 || backup.Settings["key"] != "exported" {
+		t.Errorf("ReadBackup() = %+v, want the realm, its role and its setting", backup)
+	}
+	if backup.Keys != nil {
+		t.Errorf("ReadBackup() has keys, want none without a passphrase")
+	}
+
+	// a backup signed by another key than the key of the realm in it
+	other := newTestSigner()
+	otherKey, err := other.Create(testRealm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crypto.Thumbprint(otherKey) == tp {
+		t.Skip("keys have the same thumbprint")
+	}
+	p.signer = other
+	forged, err := p.Get(testRealm).Export("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ReadBackup(forged); err != realm.ErrBackupSignature {
+		t.Errorf("ReadBackup() error = %v, want %v", err, realm.ErrBackupSignature)
+	}
+}
+
+func TestRealmService_Import_passphrase(t *testing.T) {
+	signer := newTestSigner()
+	archive, err := newBackupProvider(t, signer).Get(testRealm).Export("secret")
+	if err != nil {
+		t.Fatalf("RealmService.Export() error = %v", err)
+	}
+	unencrypted, err := newBackupProvider(t, signer).Get(testRealm).Export("")
+	if err != nil {
+		t.Fatalf("RealmService.Export() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		archive    []byte
+		passphrase string
+		wantErr    error
+	}{
+		{name: "Import_passphrase", archive: archive, passphrase: "secret"},
+		{name: "Import_no_passphrase", archive: archive, passphrase: "", wantErr: realm.ErrBackupPassphrase},
+		{name: "Import_wrong_passphrase", archive: archive, passphrase: "wrong", wantErr: realm.ErrBackupPassphrase},
+	}
+	for _, tt := range tests {
+		p := newProvider(t)
+		p.signer = newTestSigner()
+		if err := p.realms.Delete(testRealm); err != nil {
+			t.Fatal(err)
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := p.Get(testRealm).Import(tt.archive, tt.passphrase, realm.BackupConflictFail)
+			if perrors.Cause(err) != tt.wantErr {
+				t.Fatalf("RealmService.Import() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if _, err := p.realms.Get(testRealm); err == nil {
+					t.Errorf("RealmService.Import() stored the realm after an error")
+				}
+				return
+			}
+
+			if result.Imported["realms"] != 1 || result.Imported["roles"] != 1 || result.Imported["settings"] != 1 {
+				t.Errorf("RealmService.Import() imported %v, want the realm, its role and its setting", result.Imported)
+			}
+			if _, err := p.signer.PublicKey(result.Realm.SigningKeyID()); err != nil {
+				t.Errorf("RealmService.Import() did not import the realm key: %v", err)
+			}
+		})
+	}
+
+	t.Run("Import_without_keys", func(t *testing.T) {
+		p := newProvider(t)
+		p.signer = newTestSigner()
+		if err := p.realms.Delete(testRealm); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := p.Get(testRealm).Import(unencrypted, "", realm.BackupConflictFail); err == nil {
+			t.Errorf("RealmService.Import() error = nil, want the missing realm key")
+		}
+	})
+}
+
+func TestRealmService_Import_conflict(t *testing.T) {
+	tests := []struct {
+		name         string
+		conflict     string
+		wantErr      error
+		wantImported int
+		wantSkipped  int
+		wantSetting  string
+	}{
+		{name: "Import_fail", conflict: realm.BackupConflictFail, wantErr: realm.ErrBackupConflict, wantSetting: "changed"},
+		{name: "Import_default", conflict: "", wantErr: realm.ErrBackupConflict, wantSetting: "changed"},
+		{name: "Import_skip", conflict: realm.BackupConflictSkip, wantSkipped: 1, wantSetting: "changed"},
+		{name: "Import_replace", conflict: realm.BackupConflictReplace, wantImported: 1, wantSetting: "exported"},
+	}
+	for _, tt := range tests {
+		p := newBackupProvider(t, newTestSigner())
+		archive, err := p.Get(testRealm).Export("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Get(testRealm).Settings().Set("key", "changed"); err != nil {
+			t.Fatal(err)
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := p.Get(testRealm).Import(archive, "", tt.conflict)
+			if err != tt.wantErr {
+				t.Fatalf("RealmService.Import() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (result.Imported["roles"] != tt.wantImported || result.Skipped["roles"] != tt.wantSkipped) {
+				t.Errorf("RealmService.Import() roles imported = %d, skipped = %d, want %d, %d",
+					result.Imported["roles"], result.Skipped["roles"], tt.wantImported, tt.wantSkipped)
+			}
+
+			setting, err := p.Get(testRealm).Settings().Get("key")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if setting != tt.wantSetting {
+				t.Errorf("RealmService.Import() setting = %v, want %v", setting, tt.wantSetting)
+			}
+		})
+	}
+
+	t.Run("Import_unknown", func(t *testing.T) {
+		p := newBackupProvider(t, newTestSigner())
+		archive, err := p.Get(testRealm).Export("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := p.Get(testRealm).Import(archive, "", "merge"); err == nil {
+			t.Errorf("RealmService.Import() error = nil, want an unknown conflict policy")
+		}
+	})
+}
+
+func TestRealmService_Import_signer(t *testing.T) {
+	signer := newTestSigner()
+	archive, err := newBackupProvider(t, signer).Get(testRealm).Export("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the realm exists here with another key, which didn't sign the archive
+	p := newBackupProvider(t, newTestSigner())
+	before, err := p.Get(testRealm).Realm()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exported, err := signer.PublicKey(testRealm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crypto.Thumbprint(exported) == crypto.Thumbprint(before.PublicKey) {
+		t.Skip("keys have the same thumbprint")
+	}
+
+	if _, err := p.Get(testRealm).Import(archive, "secret", realm.BackupConflictReplace); err != realm.ErrBackupSignature {
+		t.Errorf("RealmService.Import() error = %v, want %v", err, realm.ErrBackupSignature)
+	}
+
+	after, err := p.realms.Get(testRealm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crypto.Thumbprint(after.PublicKey) != crypto.Thumbprint(before.PublicKey) {
+		t.Errorf("RealmService.Import() replaced the realm key")
+	}
+}
@@ -52,11 +52,24 @@ func (c *ControllerService) Delete(id string) error {
 		return errors.Wrap(err, "failed to get controller")
 	}
 
+	mandate, err := c.realm.Mandates().Get(controller.MandateID)
+	if err == nil && mandate.Status != document.MandateRevoked {
+		if _, err := c.realm.Mandates().Revoke(mandate); err != nil {
+			return errors.Wrap(err, "failed to revoke mandate for controller")
+		}
+	}
+
 	if err := c.realm.Mandates().Delete(controller.MandateID); err != nil {
 		return errors.Wrap(err, "failed to delete mandate for controller")
 	}
 
-	return c.p.Delete(c.realmID, id)
+	if err := c.p.Delete(c.realmID, id); err != nil {
+		return err
+	}
+
+	c.realm.publish(realm.EventControllerDeleted, id, nil)
+
+	return nil
 }
 
 func (c *ControllerService) Bind(controller *realm.Controller) (*jose.JsonWebSignature, error) {
@@ -86,13 +99,7 @@ func (c *ControllerService) Bind(controller *realm.Controller) (*jose.JsonWebSig
 		return nil, errors.Wrapf(err, "failed to get role %s", controller.MandateRole)
 	}
 
-	realmKey, err := c.realm.Key()
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to get realm key")
-	}
-
-	cert, err := crypto.CreateCertificate(realmKey,
-		controller.Descriptor.Key, role.KeyLevel, purposes, 0, "")
+	cert, err := c.certificate(realmData, controller.Descriptor.Key, role.KeyLevel, purposes)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create certificate")
 	}
@@ -139,7 +146,45 @@ func (c *ControllerService) Bind(controller *realm.Controller) (*jose.JsonWebSig
 		return nil, errors.Wrap(err, "failed to marshal json")
 	}
 
-	return c.realm.Sign(bytes)
+	jws, err := c.realm.Sign(bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	c.realm.publish(realm.EventControllerBound, controller.ID, map[string]string{
+		"mandateId": controller.MandateID,
+	})
+
+	return jws, nil
+}
+
+// certificate creates a certificate for the subject key, issued by the current
+// realm key. It's signed through the signer, so it also works with keys that
+// can't be exported, like keys held by an HSM.
+func (c *ControllerService) certificate(realmData *realm.Realm, subject *jose.JsonWebKey, keyLevel int, documentTypes []string) (string, error) {
+	now := time.Now().UTC()
+	cert := &document.Certificate{
+		Base: document.Base{
+			Type:      "certificate",
+			Timestamp: &now,
+		},
+		Issuer:        realmData.PublicKey,
+		Subject:       subject,
+		KeyLevel:      keyLevel,
+		DocumentTypes: documentTypes,
+	}
+
+	bytes, err := json.Marshal(cert)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal certificate")
+	}
+
+	jws, err := c.realm.Sign(bytes)
+	if err != nil {
+		return "", err
+	}
+
+	return jws.CompactSerialize()
 }
 
 func (c *ControllerService) UpdateActions(controllerID string, mp *document.Multipart, adminKey *jose.JsonWebKey) error {
@@ -249,5 +294,7 @@ func (c *ControllerService) UpdateActions(controllerID string, mp *document.Mult
 		}
 	}
 
+	c.realm.publish(realm.EventActionsUpdated, controllerID, nil)
+
 	return nil
 }
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/url"
+	"text/template"
+
+	"github.com/pkg/errors"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// DefaultDeepLink is the link template used by realms that have not configured
+// their own wallet app.
+const DefaultDeepLink = "https://app.plusintegrity.com?data={{ query .url }}"
+
+// QRCodeSize is the default size in pixels of generated QR codes.
+const QRCodeSize = 256
+
+// ErrInvalidDeepLink is the cause of errors for link templates that can not be used.
+var ErrInvalidDeepLink = errors.New("invalid deep link template")
+
+var deepLinkFuncs = template.FuncMap{
+	"query":  url.QueryEscape,
+	"path":   url.PathEscape,
+	"base64": func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) },
+}
+
+// ValidateDeepLink checks that a link template parses and renders to an absolute
+// URL. An empty template is valid and means the default link is used.
+func ValidateDeepLink(link string) error {
+	if link == "" {
+		return nil
+	}
+
+	_, err := renderDeepLink(link, "https://realm.example.com/realm/v2/realms/example.com/invites/token/sample/fetch")
+
+	return err
+}
+
+func renderDeepLink(link, u string) (string, error) {
+	tmpl, err := template.New("link").Funcs(deepLinkFuncs).Option("missingkey=error").Parse(link)
+	if err != nil {
+		return "", errors.Wrapf(ErrInvalidDeepLink, "%s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, map[string]interface{}{"url": u}); err != nil {
+		return "", errors.Wrapf(ErrInvalidDeepLink, "%s", err)
+	}
+
+	parsed, err := url.Parse(buf.String())
+	if err != nil || parsed.Scheme == "" {
+		return "", errors.Wrapf(ErrInvalidDeepLink, "%q is not an absolute URL", buf.String())
+	}
+
+	return buf.String(), nil
+}
+
+// Link returns the link that opens the URL in the realm's wallet app, using the
+// deep link template of the realm.
+func (r *RealmService) Link(u string) (string, error) {
+	link := DefaultDeepLink
+	if realmData, err := r.Realm(); err == nil && realmData.DeepLink != "" {
+		link = realmData.DeepLink
+	}
+
+	return renderDeepLink(link, u)
+}
+
+// QRCode returns a PNG image of a QR code holding the content.
+func QRCode(content string, size int) ([]byte, error) {
+	if size <= 0 {
+		size = QRCodeSize
+	}
+
+	b, err := qrcode.Encode(content, qrcode.Medium, size)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode QR code")
+	}
+
+	return b, nil
+}
+
+// QRCodeText returns a QR code holding the content drawn with block characters,
+// for printing in a terminal.
+func QRCodeText(content string) (string, error) {
+	q, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encode QR code")
+	}
+
+	return q.ToSmallString(false), nil
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestRenderDeepLink(t *testing.T) {
+	u := "https://realm.example.com/realm/v2/realms/test.realm/invites/token/abc/fetch"
+
+	tests := []struct {
+		name    string
+		link    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "renderDeepLink_default",
+			link: DefaultDeepLink,
+			want: "https://app.plusintegrity.com?data=https%3A%2F%2Frealm.example.com%2Frealm%2Fv2%2Frealms%2Ftest.realm%2Finvites%2Ftoken%2Fabc%2Ffetch",
+		},
+		{
+			name: "renderDeepLink_custom_scheme",
+			link: "wallet://open/{{ path .url }}",
+			want: "wallet://open/https:%2F%2Frealm.example.com%2Frealm%2Fv2%2Frealms%2Ftest.realm%2Finvites%2Ftoken%2Fabc%2Ffetch",
+		},
+		{
+			name: "renderDeepLink_base64",
+			link: "https://wallet.example.com/#{{ base64 .url }}",
+			want: "https://wallet.example.com/#aHR0cHM6Ly9yZWFsbS5leGFtcGxlLmNvbS9yZWFsbS92Mi9yZWFsbXMvdGVzdC5yZWFsbS9pbnZpdGVzL3Rva2VuL2FiYy9mZXRjaA",
+		},
+		{
+			name: "renderDeepLink_url",
+			link: "{{ .url }}",
+			want: u,
+		},
+		{
+			name:    "renderDeepLink_relative",
+			link:    "/open?data={{ query .url }}",
+			wantErr: true,
+		},
+		{
+			name:    "renderDeepLink_no_scheme",
+			link:    "app.example.com/open?data={{ query .url }}",
+			wantErr: true,
+		},
+		{
+			name:    "renderDeepLink_empty",
+			link:    "",
+			wantErr: true,
+		},
+		{
+			name:    "renderDeepLink_unparsable",
+			link:    "https://app.example.com?data={{ query .url",
+			wantErr: true,
+		},
+		{
+			name:    "renderDeepLink_unknown_function",
+			link:    "https://app.example.com?data={{ escape .url }}",
+			wantErr: true,
+		},
+		{
+			name:    "renderDeepLink_unknown_key",
+			link:    "https://app.example.com?data={{ .token }}",
+			wantErr: true,
+		},
+		{
+			name:    "renderDeepLink_invalid_url",
+			link:    "https://app example.com:port?data={{ query .url }}",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderDeepLink(tt.link, u)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("renderDeepLink() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr && errors.Cause(err) != ErrInvalidDeepLink {
+				t.Errorf("renderDeepLink() error = %v, want cause %v", err, ErrInvalidDeepLink)
+			}
+			if got != tt.want {
+				t.Errorf("renderDeepLink() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateDeepLink(t *testing.T) {
+	tests := []struct {
+		name    string
+		link    string
+		wantErr bool
+	}{
+		{name: "ValidateDeepLink_empty", link: "", wantErr: false},
+		{name: "ValidateDeepLink", link: "https://app.example.com?data={{ query .url }}", wantErr: false},
+		{name: "ValidateDeepLink_relative", link: "open?data={{ query .url }}", wantErr: true},
+		{name: "ValidateDeepLink_unparsable", link: "https://app.example.com?data={{ .url", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateDeepLink(tt.link); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateDeepLink() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRealmService_Link(t *testing.T) {
+	tests := []struct {
+		name     string
+		deepLink string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "Link_default",
+			deepLink: "",
+			want:     "https://app.plusintegrity.com?data=https%3A%2F%2Frealm.example.com%2Ffetch",
+		},
+		{
+			name:     "Link_custom",
+			deepLink: "wallet://open?data={{ query .url }}",
+			want:     "wallet://open?data=https%3A%2F%2Frealm.example.com%2Ffetch",
+		},
+		{
+			name:     "Link_relative",
+			deepLink: "/open?data={{ query .url }}",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		p := newProvider(t)
+		t.Run(tt.name, func(t *testing.T) {
+			context := p.Get(testRealm)
+			realmData, err := context.Realm()
+			if err != nil {
+				t.Fatal(err)
+			}
+			realmData.DeepLink = tt.deepLink
+			if err := p.realms.Set(realmData); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := context.Link("https://realm.example.com/fetch")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RealmService.Link() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("RealmService.Link() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/IpsoVeritas/crypto"
+	"github.com/IpsoVeritas/document"
+	logger "github.com/IpsoVeritas/logger"
+	"github.com/pkg/errors"
+	jose "gopkg.in/square/go-jose.v1"
+)
+
+// ScopeResponse is the verified content of a scope-response sent back by a user.
+type ScopeResponse struct {
+	Key      *jose.JsonWebKey
+	Contract *document.Contract
+	Facts    []*document.Fact
+	Rejected map[string]error
+}
+
+// parseScopeResponse verifies the signature of a scope-response and extracts the
+// user key, the accepted contract and the facts signed by one of the trusted issuers.
+// Facts that can not be verified are left out, and facts that are issued to another
+// key or have expired are left out and recorded in Rejected by their type.
+func parseScopeResponse(jws *jose.JsonWebSignature, keyLevel int, issuers *jose.JsonWebKeySet) (*ScopeResponse, error) {
+	if len(jws.Signatures) < 1 {
+		return nil, errors.New("no key in signature")
+	}
+
+	payload, err := jws.Verify(jws.Signatures[0].Header.JsonWebKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to verify signature")
+	}
+
+	var multipart document.Multipart
+	if err := json.Unmarshal(payload, &multipart); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal scope-response")
+	}
+
+	response := newScopeResponse(jws.Signatures[0].Header.JsonWebKey)
+
+	if multipart.Certificate != "" {
+		certificate, err := crypto.VerifyCertificate(multipart.Certificate, keyLevel)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to verify certificate")
+		}
+		response.Key = certificate.Issuer
+	}
+
+	now := time.Now()
+	for _, part := range multipart.Parts {
+		if part.Name == "contract" {
+			if err := json.Unmarshal([]byte(part.Document), &response.Contract); err != nil {
+				return nil, errors.Wrap(err, "failed to unmarshal contract")
+			}
+			continue
+		}
+
+		if part.Encoding != "application/json+jws" {
+			continue
+		}
+
+		fact, err := verifyFact(part.Document, issuers)
+		if err != nil {
+			logger.Debugf("Ignoring fact %s: %s", part.Name, err)
+			continue
+		}
+
+		response.addFact(fact, now)
+	}
+
+	return response, nil
+}
+
+func newScopeResponse(key *jose.JsonWebKey) *ScopeResponse {
+	return &ScopeResponse{
+		Key:      key,
+		Facts:    make([]*document.Fact, 0),
+		Rejected: make(map[string]error),
+	}
+}
+
+// addFact adds a verified fact to the response if it is issued to the key of the
+// response and has not expired.
+func (s *ScopeResponse) addFact(fact *document.Fact, now time.Time) {
+	if err := checkFact(fact, s.Key, now); err != nil {
+		logger.Debugf("Ignoring fact %s: %s", factType(fact), err)
+		s.Rejected[factType(fact)] = err
+		return
+	}
+
+	s.Facts = append(s.Facts, fact)
+}
+
+// checkFact checks that a fact is issued to the key and has not expired. Facts
+// without a recipient are rejected, since anyone who has seen them could send them.
+func checkFact(fact *document.Fact, key *jose.JsonWebKey, now time.Time) error {
+	if fact.Recipient == nil {
+		return errors.New("has no recipient")
+	}
+
+	if key == nil || crypto.Thumbprint(fact.Recipient) != crypto.Thumbprint(key) {
+		return errors.New("is issued to another key")
+	}
+
+	if fact.TTL > 0 {
+		if fact.Timestamp == nil {
+			return errors.New("has a TTL but no timestamp")
+		}
+		if now.After(fact.Timestamp.Add(time.Duration(fact.TTL) * time.Second)) {
+			return errors.New("has expired")
+		}
+	}
+
+	return nil
+}
+
+// checkContract checks that the contract accepted by the user is the one that
+// was sent to them.
+func (s *ScopeResponse) checkContract(text string) error {
+	if s.Contract == nil {
+		return errors.New("contract not accepted")
+	}
+
+	if s.Contract.Text != text {
+		return errors.New("accepted contract does not match")
+	}
+
+	return nil
+}
+
+// verifyFact checks that a fact is signed by one of the keys in the issuers keyset.
+func verifyFact(signed string, issuers *jose.JsonWebKeySet) (*document.Fact, error) {
+	jws, err := crypto.UnmarshalSignature([]byte(signed))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal fact")
+	}
+
+	if len(jws.Signatures) < 1 || jws.Signatures[0].Header.JsonWebKey == nil {
+		return nil, errors.New("no key in signature")
+	}
+
+	if issuers == nil {
+		return nil, errors.New("no trusted issuers")
+	}
+
+	signer := crypto.Thumbprint(jws.Signatures[0].Header.JsonWebKey)
+	for _, key := range issuers.Keys {
+		if crypto.Thumbprint(&key) != signer {
+			continue
+		}
+
+		payload, err := jws.Verify(&key)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to verify signature")
+		}
+
+		fact := &document.Fact{}
+		if err := json.Unmarshal(payload, fact); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal fact")
+		}
+
+		return fact, nil
+	}
+
+	return nil, fmt.Errorf("issuer %s is not trusted", signer)
+}
+
+// FactError describes why a fact required by a mandate ticket was not accepted.
+type FactError struct {
+	Fact   string `json:"fact"`
+	Reason string `json:"reason"`
+}
+
+// FactErrors is returned when one or more required facts are not accepted.
+type FactErrors []FactError
+
+func (e FactErrors) Error() string {
+	reasons := make([]string, 0, len(e))
+	for _, f := range e {
+		reasons = append(reasons, fmt.Sprintf("fact %s %s", f.Fact, f.Reason))
+	}
+
+	return strings.Join(reasons, "; ")
+}
+
+// matchFacts checks that every expected fact was provided by a trusted issuer
+// with the expected value. Each expected fact must be matched by a single fact
+// of its type, so values can't be combined from facts of other types.
+func (s *ScopeResponse) matchFacts(expected map[string]string) error {
+	failed := make(FactErrors, 0)
+	for _, key := range sortedKeys(expected) {
+		facts := s.factsOfType(key)
+		if len(facts) == 0 {
+			if err, rejected := s.Rejected[key]; rejected {
+				failed = append(failed, FactError{Fact: key, Reason: fmt.Sprintf("was rejected: %s", err)})
+			} else {
+				failed = append(failed, FactError{Fact: key, Reason: "was not provided"})
+			}
+			continue
+		}
+
+		if !hasValue(facts, key, expected[key]) {
+			failed = append(failed, FactError{Fact: key, Reason: "does not match the required value"})
+		}
+	}
+
+	if len(failed) > 0 {
+		return failed
+	}
+
+	return nil
+}
+
+func hasValue(facts []*document.Fact, key, value string) bool {
+	for _, fact := range facts {
+		if v, ok := fact.Data[key]; ok && fmt.Sprint(v) == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// factScope returns the scope used to request a fact with the given name.
+func factScope(name string) document.Scope {
+	return document.Scope{
+		Name:     "https://schema.brickchain.com/v2/fact.json#" + name,
+		Required: true,
+	}
+}
+
+// scopeName returns the short name of a scope, which is the fragment of the
+// schema URI, e.g. "name" for https://schema.brickchain.com/v2/fact.json#name.
+func scopeName(scope document.Scope) string {
+	return fragment(scope.Name)
+}
+
+// factType returns the short type of a fact, in the same form as scopeName.
+func factType(fact *document.Fact) string {
+	return fragment(fact.Type)
+}
+
+func fragment(uri string) string {
+	if i := strings.LastIndex(uri, "#"); i >= 0 {
+		return uri[i+1:]
+	}
+
+	return uri
+}
+
+// factsOfType returns the facts of the response with the given short type.
+func (s *ScopeResponse) factsOfType(name string) []*document.Fact {
+	facts := make([]*document.Fact, 0)
+	for _, fact := range s.Facts {
+		if factType(fact) == name {
+			facts = append(facts, fact)
+		}
+	}
+
+	return facts
+}
+
+// missingScopes returns the names of the required scopes that have no verified fact.
+func (s *ScopeResponse) missingScopes(scopes []document.Scope) []string {
+	missing := make([]string, 0)
+	for _, scope := range scopes {
+		if !scope.Required {
+			continue
+		}
+		if len(s.factsOfType(scopeName(scope))) == 0 {
+			missing = append(missing, scopeName(scope))
+		}
+	}
+
+	return missing
+}
+
+// recipientName picks a display name for the user from the verified facts.
+func (s *ScopeResponse) recipientName() string {
+	for _, key := range []string{"name", "email", "phone"} {
+		for _, fact := range s.factsOfType(key) {
+			if v, ok := fact.Data[key].(string); ok && v != "" {
+				return v
+			}
+		}
+	}
+
+	return crypto.Thumbprint(s.Key)
+}
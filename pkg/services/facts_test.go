@@ -0,0 +1,203 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/IpsoVeritas/crypto"
+	"github.com/IpsoVeritas/document"
+	jose "gopkg.in/square/go-jose.v1"
+)
+
+// newUserKey returns the public key of a new user key.
+func newUserKey(t *testing.T) *jose.JsonWebKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &jose.JsonWebKey{Key: &key.PublicKey, Algorithm: "ES256"}
+}
+
+func newFact(name, value string, recipient *jose.JsonWebKey, timestamp time.Time, ttl int) *document.Fact {
+	fact := &document.Fact{
+		TTL:       ttl,
+		Recipient: recipient,
+		Data:      map[string]interface{}{name: value},
+	}
+	fact.Type = factScope(name).Name
+	fact.Timestamp = &timestamp
+
+	return fact
+}
+
+func TestCheckFact(t *testing.T) {
+	now := time.Now()
+	key := newUserKey(t)
+	other := newUserKey(t)
+
+	tests := []struct {
+		name     string
+		fact     *document.Fact
+		otherKey bool
+		wantErr  bool
+	}{
+		{
+			name:    "checkFact",
+			fact:    newFact("email", "alice@example.com", key, now.Add(-time.Minute), 3600),
+			wantErr: false,
+		},
+		{
+			name:    "checkFact_without_ttl",
+			fact:    newFact("email", "alice@example.com", key, now.Add(-24*time.Hour), 0),
+			wantErr: false,
+		},
+		{
+			name:    "checkFact_replayed_without_recipient",
+			fact:    newFact("email", "alice@example.com", nil, now.Add(-time.Minute), 3600),
+			wantErr: true,
+		},
+		{
+			name:     "checkFact_replayed_from_another_key",
+			fact:     newFact("email", "alice@example.com", other, now.Add(-time.Minute), 3600),
+			otherKey: true,
+			wantErr:  true,
+		},
+		{
+			name:    "checkFact_expired",
+			fact:    newFact("email", "alice@example.com", key, now.Add(-2*time.Hour), 3600),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.otherKey && crypto.Thumbprint(key) == crypto.Thumbprint(other) {
+				t.Skip("keys can't be told apart by their thumbprints")
+			}
+			err := checkFact(tt.fact, key, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkFact() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScopeResponse_missingScopes(t *testing.T) {
+	now := time.Now()
+	key := newUserKey(t)
+	scopes := []document.Scope{factScope("email"), {Name: factScope("phone").Name}}
+
+	tests := []struct {
+		name  string
+		facts []*document.Fact
+		want  []string
+	}{
+		{
+			name:  "missingScopes",
+			facts: []*document.Fact{newFact("email", "alice@example.com", key, now, 0)},
+			want:  []string{},
+		},
+		{
+			name:  "missingScopes_other_type",
+			facts: []*document.Fact{newFact("name", "alice@example.com", key, now, 0)},
+			want:  []string{"email"},
+		},
+		{
+			name:  "missingScopes_expired",
+			facts: []*document.Fact{newFact("email", "alice@example.com", key, now.Add(-2*time.Hour), 3600)},
+			want:  []string{"email"},
+		},
+		{
+			name:  "missingScopes_replayed",
+			facts: []*document.Fact{newFact("email", "alice@example.com", nil, now, 0)},
+			want:  []string{"email"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := newScopeResponse(key)
+			for _, fact := range tt.facts {
+				response.addFact(fact, now)
+			}
+			got := response.missingScopes(scopes)
+			if len(got) != len(tt.want) || (len(got) > 0 && got[0] != tt.want[0]) {
+				t.Errorf("ScopeResponse.missingScopes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRealmService_checkJoinResponse(t *testing.T) {
+	now := time.Now()
+	key := newUserKey(t)
+
+	tests := []struct {
+		name     string
+		contract *document.Contract
+		facts    []*document.Fact
+		wantErr  bool
+	}{
+		{
+			name:     "checkJoinResponse",
+			contract: &document.Contract{Text: "Guest terms"},
+			facts:    []*document.Fact{newFact("email", "alice@example.com", key, now, 60)},
+			wantErr:  false,
+		},
+		{
+			name:    "checkJoinResponse_no_contract",
+			facts:   []*document.Fact{newFact("email", "alice@example.com", key, now, 60)},
+			wantErr: true,
+		},
+		{
+			name:     "checkJoinResponse_wrong_contract",
+			contract: &document.Contract{Text: "Other terms"},
+			facts:    []*document.Fact{newFact("email", "alice@example.com", key, now, 60)},
+			wantErr:  true,
+		},
+		{
+			name:     "checkJoinResponse_replayed_fact",
+			contract: &document.Contract{Text: "Guest terms"},
+			facts:    []*document.Fact{newFact("email", "alice@example.com", nil, now, 60)},
+			wantErr:  true,
+		},
+		{
+			name:     "checkJoinResponse_expired_fact",
+			contract: &document.Contract{Text: "Guest terms"},
+			facts:    []*document.Fact{newFact("email", "alice@example.com", key, now.Add(-time.Hour), 60)},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		p := newProvider(t)
+		setRole(t, p, "guest@test.realm")
+		t.Run(tt.name, func(t *testing.T) {
+			context := p.Get(testRealm)
+			realmData, err := context.Realm()
+			if err != nil {
+				t.Fatal(err)
+			}
+			realmData.GuestRole = "guest@test.realm"
+			realmData.GuestContract = "Guest terms"
+			realmData.GuestScopes = []document.Scope{factScope("email")}
+
+			role, err := context.Roles().ByName(realmData.GuestRole)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			response := newScopeResponse(key)
+			response.Contract = tt.contract
+			for _, fact := range tt.facts {
+				response.addFact(fact, now)
+			}
+
+			err = context.checkJoinResponse(realmData, role, response)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RealmService.checkJoinResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
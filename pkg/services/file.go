@@ -14,9 +14,33 @@ type FileService struct {
 }
 
 func (f *FileService) Read(name string) ([]byte, error) {
-	return f.p.Read(fmt.Sprintf("%s/%s", strings.Replace(f.realmID, ":", "_", -1), name))
+	return f.p.Read(fmt.Sprintf("%s/%s", f.dir(), name))
 }
 
 func (f *FileService) Write(name string, file io.Reader) (string, error) {
-	return f.p.Write(fmt.Sprintf("%s/%s", strings.Replace(f.realmID, ":", "_", -1), name), file)
+	return f.p.Write(fmt.Sprintf("%s/%s", f.dir(), name), file)
+}
+
+func (f *FileService) Delete(name string) error {
+	return f.p.Delete(fmt.Sprintf("%s/%s", f.dir(), name))
+}
+
+// List returns the names of all files of the realm.
+func (f *FileService) List() ([]string, error) {
+	prefix := f.dir() + "/"
+	names, err := f.p.List(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]string, 0, len(names))
+	for _, name := range names {
+		out = append(out, strings.TrimPrefix(name, prefix))
+	}
+
+	return out, nil
+}
+
+func (f *FileService) dir() string {
+	return strings.Replace(f.realmID, ":", "_", -1)
 }
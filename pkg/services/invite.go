@@ -1,24 +1,28 @@
 package services
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"net/url"
-	"os"
-	"path/filepath"
+	"strings"
+	"time"
 
-	crypto "github.com/IpsoVeritas/crypto"
 	document "github.com/IpsoVeritas/document"
 	logger "github.com/IpsoVeritas/logger"
 	realm "github.com/IpsoVeritas/realm"
 	messaging "github.com/IpsoVeritas/realm/pkg/providers/messaging"
 	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
 	jose "gopkg.in/square/go-jose.v1"
 )
 
 type InviteService struct {
 	base    string
 	p       realm.InviteProvider
+	outbox  realm.OutboxProvider
 	realmID string
 	realm   *RealmService
 	email   realm.EmailProvider
@@ -35,9 +39,69 @@ func (i *InviteService) Get(id string) (*realm.Invite, error) {
 
 func (i *InviteService) Set(invite *realm.Invite) error {
 	invite.Realm = i.realmID
+
+	// the lifecycle fields are only changed by Send, Fetch, Callback and Cancel
+	existing, err := i.Get(invite.ID)
+	if invite.ID != "" && err == nil {
+		invite.Status = existing.Status
+		invite.Sent = existing.Sent
+		invite.Created = existing.Created
+		invite.SentAt = existing.SentAt
+		invite.MandateID = existing.MandateID
+		invite.TokenHash = existing.TokenHash
+	} else {
+		now := time.Now().UTC()
+		invite.Status = realm.InviteStatusDraft
+		invite.Sent = false
+		invite.Created = &now
+		invite.SentAt = nil
+		invite.MandateID = ""
+		invite.TokenHash = ""
+	}
+
 	return i.p.Set(i.realmID, invite)
 }
 
+// Cancel closes an invite that has not been accepted.
+func (i *InviteService) Cancel(id string) (*realm.Invite, error) {
+	invite, err := i.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if invite.Status == realm.InviteStatusAccepted {
+		return nil, realm.ErrInviteClosed
+	}
+
+	invite.Status = realm.InviteStatusCancelled
+	if err := i.p.Set(i.realmID, invite); err != nil {
+		return nil, err
+	}
+
+	return invite, nil
+}
+
+// checkOpen returns an error if the invite can no longer be used, marking
+// invites that have passed their TTL as expired.
+func (i *InviteService) checkOpen(invite *realm.Invite) error {
+	if !invite.Open() {
+		if invite.Status == realm.InviteStatusExpired {
+			return realm.ErrInviteExpired
+		}
+		return realm.ErrInviteClosed
+	}
+
+	if invite.Expired(time.Now()) {
+		invite.Status = realm.InviteStatusExpired
+		if _, err := i.p.SetIfOpen(i.realmID, invite); err != nil {
+			logger.Warningf("Failed to mark invite %s as expired: %s", invite.ID, err)
+		}
+		return realm.ErrInviteExpired
+	}
+
+	return nil
+}
+
 func (i *InviteService) Delete(id string) error {
 	return i.p.Delete(i.realmID, id)
 }
@@ -46,90 +110,296 @@ func (i *InviteService) ListForRole(role string) ([]*realm.Invite, error) {
 	return i.p.ListForRole(i.realmID, role)
 }
 
-func (i *InviteService) Send(invite *realm.Invite) (*realm.EmailStatus, error) {
+// Send queues the invite message in the outbox and makes a first delivery attempt.
+// Failed attempts are retried by the outbox worker.
+func (i *InviteService) Send(invite *realm.Invite) (*realm.OutboxMessage, error) {
+	if err := i.checkOpen(invite); err != nil {
+		return nil, err
+	}
+
 	role, err := i.realm.Roles().ByName(invite.Role)
 	if err != nil {
 		return nil, err
 	}
 
-	templateDir := "invite_email"
-	templateFile := func(name string) string {
-		return fmt.Sprintf("%s/%s", templateDir, name)
+	realmData, err := i.realm.Realm()
+	if err != nil {
+		return nil, err
+	}
+
+	label := realmData.Label
+	if label == "" {
+		label = realmData.ID
+	}
+
+	data := map[string]interface{}{
+		"role":     invite.Role,
+		"roleName": role.Description,
+		"realm":    label,
+		"text":     invite.Text,
+		"icon":     "",
+		"banner":   "",
+	}
+	if realmData.Descriptor != nil {
+		data["icon"] = realmData.Descriptor.Icon
+		data["banner"] = realmData.Descriptor.Banner
 	}
-	attachmentDir := templateFile("attachments")
-	attachmentFile := func(name string) string {
-		return fmt.Sprintf("%s/%s", attachmentDir, name)
+
+	// the message is leased to this attempt, so the worker leaves it alone until it's done
+	now := time.Now().UTC()
+	lease := now.Add(outboxLease)
+	msg := &realm.OutboxMessage{
+		ID:          uuid.NewV4().String(),
+		Realm:       i.realmID,
+		InviteID:    invite.ID,
+		Recipient:   invite.MessageURI,
+		Locale:      invite.Locale,
+		Data:        data,
+		Status:      realm.OutboxPending,
+		Created:     now,
+		NextAttempt: &lease,
 	}
 
-	u := fmt.Sprintf("%s/realm/v2/realms/%s/invites/id/%s/fetch", i.base, i.realmID, invite.ID)
-	logger.Debugf("Invite link: %s", u)
+	if err := i.outbox.Set(i.realmID, msg); err != nil {
+		return nil, errors.Wrap(err, "failed to queue message")
+	}
 
-	encoded := url.QueryEscape(u)
-	link := fmt.Sprintf("https://app.plusintegrity.com?data=%s", encoded)
+	if err := i.Deliver(msg); err != nil {
+		logger.Warningf("Failed to send invite %s: %s", invite.ID, err)
+	}
 
-	templateSubject := `Invitation to join {{ .realm }} as {{ .roleName }}`
+	return msg, nil
+}
+
+// Deliveries returns the messages queued for an invite, newest first.
+func (i *InviteService) Deliveries(inviteID string) ([]*realm.OutboxMessage, error) {
+	return i.outbox.List(i.realmID, inviteID)
+}
+
+func (i *InviteService) Delivery(id string) (*realm.OutboxMessage, error) {
+	return i.outbox.Get(i.realmID, id)
+}
 
-	templateHTML, err := i.assets.Read(templateFile("template.html"))
+// Deliver makes one delivery attempt for a queued message and records the outcome.
+// A failed attempt is rescheduled with exponential backoff, and the message is moved
+// to the dead-letter state after outboxMaxAttempts attempts.
+func (i *InviteService) Deliver(msg *realm.OutboxMessage) error {
+	invite, err := i.Get(msg.InviteID)
+	if err == nil {
+		err = i.checkOpen(invite)
+	}
 	if err != nil {
-		return nil, errors.Wrap(err, "Couldn't read HTML email template")
+		msg.Status = realm.OutboxDead
+		msg.NextAttempt = nil
+		msg.Error = fmt.Sprintf("invite can not be sent: %s", err)
+		return i.outbox.Set(i.realmID, msg)
 	}
 
-	templateText, err := i.assets.Read(templateFile("template.txt"))
+	// Every attempt gets a new link token, so the token itself is never stored. The
+	// token replaces the one of the links sent before only once it has been sent, so
+	// a failed attempt leaves the links the recipient already has working.
+	token, err := newInviteToken()
 	if err != nil {
-		return nil, errors.Wrap(err, "Couldn't read text email template")
+		return errors.Wrap(err, "failed to create invite token")
 	}
 
-	realmData, err := i.realm.Realm()
+	data := make(map[string]interface{})
+	for k, v := range msg.Data {
+		data[k] = v
+	}
+	data["url"], data["link"], err = i.links(token)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	msg.Attempts++
+	msg.LastAttempt = &now
+
+	var status *realm.EmailStatus
+	if strings.HasPrefix(msg.Recipient, "tel:") {
+		status, err = i.sendText(msg, data)
+	} else {
+		status, err = i.sendEmail(msg, data)
+	}
+
+	if err != nil {
+		msg.Error = err.Error()
+		if msg.Attempts >= outboxMaxAttempts {
+			msg.Status = realm.OutboxDead
+			msg.NextAttempt = nil
+		} else {
+			next := now.Add(backoff(msg.Attempts, outboxInitialBackoff, outboxMaxBackoff))
+			msg.NextAttempt = &next
+		}
+	} else {
+		msg.Status = realm.OutboxSent
+		msg.NextAttempt = nil
+		msg.SentAt = &now
+		msg.MessageID = status.MessageID
+		msg.Subject = status.Subject
+		msg.Error = ""
+	}
+
+	if err := i.outbox.Set(i.realmID, msg); err != nil {
+		return errors.Wrap(err, "failed to update message")
+	}
+
+	if msg.Status != realm.OutboxSent {
+		return errors.New(msg.Error)
+	}
+
+	// the invite is read again, since it may have been changed while the message
+	// was sent, and only updated if it wasn't accepted or cancelled meanwhile
+	invite, err = i.Get(msg.InviteID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get invite")
+	}
+	invite.TokenHash = hashInviteToken(token)
+	invite.Sent = true
+	invite.SentAt = &now
+	if invite.Status != realm.InviteStatusOpened {
+		invite.Status = realm.InviteStatusSent
+	}
+	updated, err := i.p.SetIfOpen(i.realmID, invite)
+	if err != nil {
+		return errors.Wrap(err, "failed to update invite")
+	}
+	if !updated {
+		return realm.ErrInviteClosed
+	}
+
+	i.realm.publish(realm.EventInviteSent, invite.ID, map[string]string{
+		"role": invite.Role,
+	})
+
+	return nil
+}
+
+// Redeliver resets a message so it's attempted again, regardless of its current status.
+func (i *InviteService) Redeliver(id string) (*realm.OutboxMessage, error) {
+	msg, err := i.Delivery(id)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get message")
+	}
+
+	now := time.Now().UTC()
+	msg.Status = realm.OutboxPending
+	msg.Attempts = 0
+	msg.NextAttempt = &now
+	msg.Error = ""
+
+	if err := i.outbox.Set(i.realmID, msg); err != nil {
+		return nil, errors.Wrap(err, "failed to save message")
+	}
+
+	return msg, nil
+}
+
+// links returns the URL for fetching the invite with the token, and the link
+// that opens it in the realm's wallet app.
+func (i *InviteService) links(token string) (string, string, error) {
+	u := fmt.Sprintf("%s/realm/v2/realms/%s/invites/token/%s/fetch", i.base, i.realmID, token)
+	link, err := i.realm.Link(u)
+	if err != nil {
+		return "", "", err
+	}
+
+	return u, link, nil
+}
+
+// issueToken creates a new link token for the invite, invalidating the links
+// that were sent before.
+func (i *InviteService) issueToken(invite *realm.Invite) (string, error) {
+	token, err := newInviteToken()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create invite token")
+	}
+	invite.TokenHash = hashInviteToken(token)
+	updated, err := i.p.SetIfOpen(i.realmID, invite)
 	if err != nil {
+		return "", errors.Wrap(err, "failed to update invite")
+	}
+	if !updated {
+		return "", realm.ErrInviteClosed
+	}
+
+	return token, nil
+}
+
+// QRCode issues the link of an open invite that has no link yet, and returns a
+// PNG image of a QR code holding the fetch URL. Since only a hash of the token
+// is stored, the link of an invite that was already sent can't be shown again,
+// and realm.ErrInviteLinkIssued is returned instead.
+func (i *InviteService) QRCode(id string, size int) ([]byte, error) {
+	return i.qrCode(id, size, false)
+}
+
+// RotateQRCode issues a new link for an open invite and returns a PNG image of
+// a QR code holding the fetch URL. Links sent to the recipient before stop working.
+func (i *InviteService) RotateQRCode(id string, size int) ([]byte, error) {
+	return i.qrCode(id, size, true)
+}
+
+func (i *InviteService) qrCode(id string, size int, rotate bool) ([]byte, error) {
+	invite, err := i.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.checkOpen(invite); err != nil {
 		return nil, err
 	}
+	if invite.TokenHash != "" && !rotate {
+		return nil, realm.ErrInviteLinkIssued
+	}
 
-	label := realmData.Label
-	if label == "" {
-		label = realmData.ID
+	token, err := i.issueToken(invite)
+	if err != nil {
+		return nil, err
 	}
 
-	message := messaging.Message{
-		Recipient: invite.MessageURI,
-		Templates: messaging.Templates{
-			Subject: templateSubject,
-			Text:    string(templateText),
-			HTML:    string(templateHTML),
-		},
-		Data: map[string]interface{}{
-			"role":     invite.Role,
-			"roleName": role.Description,
-			"realm":    label,
-			"url":      u,
-			"text":     invite.Text,
-			"link":     link,
-		},
+	u, _, err := i.links(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return QRCode(u, size)
+}
+
+// newInviteToken returns a random token for an invite link.
+func newInviteToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashInviteToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ByToken returns the invite for the token of an invite link.
+func (i *InviteService) ByToken(token string) (*realm.Invite, error) {
+	if token == "" {
+		return nil, realm.ErrInviteToken
 	}
 
-	attachments, err := i.assets.List(attachmentDir)
+	invite, err := i.p.GetByToken(i.realmID, hashInviteToken(token))
 	if err != nil {
-		return nil, errors.Wrap(err, "Couldn't list attachments directory")
+		return nil, realm.ErrInviteToken
 	}
 
-	cleanup := make([]string, 0)
-	defer func() {
-		for _, f := range cleanup {
-			os.RemoveAll(f)
-		}
-	}()
-
-	if len(attachments) > 0 {
-		message.Attachments = make([]string, 0)
-		for _, attachment := range attachments {
-			filename, err := i.assets.CopyToTempFile(attachmentFile(attachment))
-			// logger.Errorf("Got file %s", filename)
-			if err != nil {
-				// logger.Error(err)
-				return nil, errors.Wrapf(err, "Could not get attachment %s", attachment)
-			}
-			cleanup = append(cleanup, filepath.Dir(filename))
-			message.Attachments = append(message.Attachments, filename)
-		}
+	return invite, nil
+}
+
+func (i *InviteService) sendEmail(msg *realm.OutboxMessage, data map[string]interface{}) (*realm.EmailStatus, error) {
+	message, cleanup, err := i.realm.Templates().Message(msg.Recipient, data, i.realm.Locales(msg.Locale))
+	defer cleanup()
+	if err != nil {
+		return nil, err
 	}
 
 	if err := i.email.Validate(message); err != nil {
@@ -139,10 +409,62 @@ func (i *InviteService) Send(invite *realm.Invite) (*realm.EmailStatus, error) {
 	return i.email.Send(message)
 }
 
-func (i *InviteService) Fetch(inviteID string) (*jose.JsonWebSignature, error) {
-	invite, err := i.Get(inviteID)
+// sendText sends the invite as a text message through the messaging transport
+// registered for the scheme of the message URI, e.g. tel.
+func (i *InviteService) sendText(msg *realm.OutboxMessage, data map[string]interface{}) (*realm.EmailStatus, error) {
+	templateText, err := readLocalized(i.assets, "invite_sms", "template.txt", i.realm.Locales(msg.Locale))
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to get invite")
+		return nil, errors.Wrap(err, "Couldn't read text message template")
+	}
+
+	message := messaging.Message{
+		Recipient: msg.Recipient,
+		Templates: messaging.Templates{
+			Text: strings.TrimSpace(string(templateText)),
+		},
+		Data: data,
+	}
+
+	transport, err := messaging.LookupTransport(message)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find messaging transport")
+	}
+
+	if err := transport.Validate(message); err != nil {
+		return nil, errors.Wrap(err, "failed to validate message")
+	}
+
+	id, err := transport.Send(message)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to send text message")
+	}
+
+	return &realm.EmailStatus{
+		MessageID: id,
+		Sent:      true,
+	}, nil
+}
+
+// Fetch returns the signed scope-request for the invite with the link token.
+func (i *InviteService) Fetch(token string) (*jose.JsonWebSignature, error) {
+	invite, err := i.ByToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := i.checkOpen(invite); err != nil {
+		return nil, err
+	}
+
+	if invite.Status != realm.InviteStatusOpened {
+		invite.Status = realm.InviteStatusOpened
+		opened, err := i.p.SetIfOpen(i.realmID, invite)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to update invite")
+		}
+		if !opened {
+			return nil, realm.ErrInviteClosed
+		}
 	}
 
 	realm, err := i.realm.Realm()
@@ -161,12 +483,18 @@ func (i *InviteService) Fetch(inviteID string) (*jose.JsonWebSignature, error) {
 
 	scopeRequest := document.NewScopeRequest(invite.KeyLevel)
 	scopeRequest.ReplyTo = []string{
-		fmt.Sprintf("%s/realm/v2/realms/%s/invites/id/%s/callback", i.base, i.realmID, inviteID),
+		fmt.Sprintf("%s/realm/v2/realms/%s/invites/token/%s/callback", i.base, i.realmID, token),
 	}
 	scopeRequest.KeyLevel = invite.KeyLevel
 
 	scopeRequest.Contract = document.NewContract()
-	scopeRequest.Contract.Text = fmt.Sprintf("Become a member of %s at %s?", role.Description, label)
+	scopeRequest.Contract.Text, err = renderLocalized(i.assets, "invite_contract", "template.txt", i.realm.Locales(invite.Locale), map[string]interface{}{
+		"roleName": role.Description,
+		"realm":    label,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to render contract")
+	}
 
 	scopeReqBytes, err := json.Marshal(scopeRequest)
 	if err != nil {
@@ -181,48 +509,48 @@ func (i *InviteService) Fetch(inviteID string) (*jose.JsonWebSignature, error) {
 	return jws, nil
 }
 
-func (i *InviteService) Callback(inviteID string, jws *jose.JsonWebSignature) (*document.Multipart, error) {
-
-	invite, err := i.Get(inviteID)
+// Callback issues the mandate for the invite with the link token to the user
+// that signed the scope-response.
+func (i *InviteService) Callback(token string, jws *jose.JsonWebSignature) (*document.Multipart, error) {
+	invite, err := i.ByToken(token)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to get invite")
-	}
-
-	var userKey *jose.JsonWebKey
-	var scopeResponse document.Multipart
-	var certificate *document.Certificate
-
-	if len(jws.Signatures) < 1 {
-		return nil, errors.Wrap(err, "no key in signature")
+		return nil, err
 	}
 
-	scopeDataBytes, err := jws.Verify(jws.Signatures[0].Header.JsonWebKey)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to verify signature")
+	if err := i.checkOpen(invite); err != nil {
+		return nil, err
 	}
 
-	if err := json.Unmarshal(scopeDataBytes, &scopeResponse); err != nil {
-		return nil, errors.Wrap(err, "failed to unmarshal scope-request")
+	if invite.NotYetValid(time.Now()) {
+		return nil, realm.ErrInviteNotYetValid
 	}
 
-	if scopeResponse.Certificate != "" {
-		certificate, err = crypto.VerifyCertificate(scopeResponse.Certificate, invite.KeyLevel)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to verify certificate")
-		}
+	keyset, err := i.realm.Keyset()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get trusted issuers")
 	}
 
-	if certificate != nil {
-		userKey = certificate.Issuer
-	} else {
-		userKey = jws.Signatures[0].Header.JsonWebKey
+	response, err := parseScopeResponse(jws, invite.KeyLevel, keyset)
+	if err != nil {
+		return nil, err
 	}
+	userKey := response.Key
 
 	role, err := i.realm.Roles().ByName(invite.Role)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not get role")
 	}
 
+	// the invite is claimed before the mandate is issued, so concurrent callbacks
+	// with the same token can't both get a mandate
+	claimed, err := i.p.Claim(i.realmID, invite.TokenHash)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to claim invite")
+	}
+	if !claimed {
+		return nil, realm.ErrInviteClosed
+	}
+
 	mandate := document.NewMandate(invite.Role)
 	mandate.Realm = i.realmID
 	mandate.RoleName = role.Description
@@ -233,6 +561,10 @@ func (i *InviteService) Callback(inviteID string, jws *jose.JsonWebSignature) (*
 
 	issued, err := i.realm.Mandates().Issue(mandate, invite.Name)
 	if err != nil {
+		// open the invite again, so the user can retry
+		if err := i.p.Set(i.realmID, invite); err != nil {
+			logger.Warningf("Failed to release claim on invite %s: %s", invite.ID, err)
+		}
 		return nil, errors.Wrap(err, "could not issue mandate")
 	}
 
@@ -244,9 +576,17 @@ func (i *InviteService) Callback(inviteID string, jws *jose.JsonWebSignature) (*
 	multipart := document.NewMultipart()
 	multipart.Append(part)
 
-	if err := i.Delete(invite.ID); err != nil {
-		return nil, errors.Wrap(err, "failed to delete invite")
+	invite.Status = realm.InviteStatusAccepted
+	invite.MandateID = issued.ID
+	invite.TokenHash = ""
+	if err := i.p.Set(i.realmID, invite); err != nil {
+		return nil, errors.Wrap(err, "failed to update invite")
 	}
 
+	i.realm.publish(realm.EventInviteAccepted, invite.ID, map[string]string{
+		"role":      invite.Role,
+		"mandateId": issued.ID,
+	})
+
 	return multipart, nil
 }
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"strings"
+	"time"
+
+	document "github.com/IpsoVeritas/document"
+	realm "github.com/IpsoVeritas/realm"
+	messaging "github.com/IpsoVeritas/realm/pkg/providers/messaging"
+	"github.com/pkg/errors"
+)
+
+// ParseInviteImport reads the rows of a bulk import, either a JSON array or CSV
+// with a header row naming the columns: name, messageURI, role, validFrom,
+// validUntil, locale and text. Dates are RFC 3339 timestamps or YYYY-MM-DD.
+func ParseInviteImport(contentType string, body []byte) ([]*realm.InviteImportRow, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch mediaType {
+	case "text/csv":
+		return parseInviteCSV(body)
+	case "application/json", "":
+		rows := make([]*realm.InviteImportRow, 0)
+		if err := json.Unmarshal(body, &rows); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal rows")
+		}
+		return rows, nil
+	}
+
+	return nil, fmt.Errorf("Unsupported content type %s", mediaType)
+}
+
+func parseInviteCSV(body []byte) ([]*realm.InviteImportRow, error) {
+	r := csv.NewReader(bytes.NewReader(body))
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read CSV header")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"messageURI", "role"} {
+		if _, ok := columns[strings.ToLower(required)]; !ok {
+			return nil, fmt.Errorf("CSV header is missing column %s", required)
+		}
+	}
+
+	rows := make([]*realm.InviteImportRow, 0)
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read CSV row %d", line)
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row := &realm.InviteImportRow{
+			Name:       field("name"),
+			MessageURI: field("messageuri"),
+			Role:       field("role"),
+			Locale:     field("locale"),
+			Text:       field("text"),
+		}
+		if row.ValidFrom, err = parseImportDate(field("validfrom")); err != nil {
+			return nil, errors.Wrapf(err, "invalid validFrom in CSV row %d", line)
+		}
+		if row.ValidUntil, err = parseImportDate(field("validuntil")); err != nil {
+			return nil, errors.Wrapf(err, "invalid validUntil in CSV row %d", line)
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func parseImportDate(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t, nil
+		}
+	}
+
+	return nil, fmt.Errorf("can not parse date %s", s)
+}
+
+// Import validates all rows and, unless it's a dry-run or any row is invalid,
+// creates an invite for each row and optionally sends it. It returns the outcome
+// of each row and whether all rows were valid.
+func (i *InviteService) Import(rows []*realm.InviteImportRow, send, dryRun bool) ([]*realm.InviteImportResult, bool) {
+	results := make([]*realm.InviteImportResult, 0, len(rows))
+	v := &importValidator{
+		roles:     make(map[string]*document.Role),
+		templates: make(map[string]*realm.InviteTemplates),
+	}
+	valid := true
+
+	for n, row := range rows {
+		result := &realm.InviteImportResult{
+			Row:    n + 1,
+			Status: realm.InviteImportValid,
+		}
+
+		role, err := i.validateImportRow(row, v)
+		if err != nil {
+			result.Status = realm.InviteImportInvalid
+			result.Error = err.Error()
+			valid = false
+		} else {
+			result.Invite = &realm.Invite{
+				Name:       row.Name,
+				Role:       row.Role,
+				MessageURI: row.MessageURI,
+				Text:       row.Text,
+				KeyLevel:   role.KeyLevel,
+				ValidFrom:  row.ValidFrom,
+				ValidUntil: row.ValidUntil,
+				Locale:     row.Locale,
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	if dryRun || !valid {
+		return results, valid
+	}
+
+	for _, result := range results {
+		if err := i.Set(result.Invite); err != nil {
+			result.Status = realm.InviteImportFailed
+			result.Error = fmt.Sprintf("failed to create invite: %s", err)
+			continue
+		}
+		result.Status = realm.InviteImportCreated
+
+		if !send {
+			continue
+		}
+
+		// the invite stays created when it can't be sent, so it can be sent later
+		msg, err := i.Send(result.Invite)
+		if err != nil {
+			result.Error = fmt.Sprintf("failed to send invite: %s", err)
+			continue
+		}
+		result.Status = realm.InviteImportQueued
+		result.Delivery = msg
+	}
+
+	return results, true
+}
+
+// importValidator caches the roles and templates looked up while validating rows.
+type importValidator struct {
+	roles     map[string]*document.Role
+	templates map[string]*realm.InviteTemplates
+}
+
+// validateImportRow checks the role, the validity and that the message URI can
+// be delivered through the email provider or a messaging transport.
+func (i *InviteService) validateImportRow(row *realm.InviteImportRow, v *importValidator) (*document.Role, error) {
+	if row.Role == "" {
+		return nil, errors.New("role is required")
+	}
+
+	role, ok := v.roles[row.Role]
+	if !ok {
+		var err error
+		role, err = i.realm.Roles().ByName(row.Role)
+		if err != nil {
+			return nil, fmt.Errorf("role %s does not exist", row.Role)
+		}
+		v.roles[row.Role] = role
+	}
+
+	if row.ValidUntil != nil {
+		if !row.ValidUntil.After(time.Now()) {
+			return nil, errors.New("validUntil has passed")
+		}
+		if row.ValidFrom != nil && !row.ValidUntil.After(*row.ValidFrom) {
+			return nil, errors.New("validUntil is before validFrom")
+		}
+	}
+
+	if row.Locale != "" && len(localeCandidates(row.Locale)) == 0 {
+		return nil, fmt.Errorf("invalid locale %s", row.Locale)
+	}
+
+	if row.MessageURI == "" {
+		return nil, errors.New("messageURI is required")
+	}
+
+	u, err := url.Parse(row.MessageURI)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid messageURI")
+	}
+
+	locales := i.realm.Locales(row.Locale)
+	switch u.Scheme {
+	case "mailto":
+		templates, ok := v.templates[row.Locale]
+		if !ok {
+			templates, err = i.realm.Templates().Email(locales)
+			if err != nil {
+				return nil, err
+			}
+			v.templates[row.Locale] = templates
+		}
+
+		message := messaging.Message{
+			Recipient: row.MessageURI,
+			Templates: messaging.Templates{
+				Subject: templates.Subject,
+				Text:    templates.Text,
+				HTML:    templates.HTML,
+			},
+		}
+		if err := i.email.Validate(message); err != nil {
+			return nil, errors.Wrap(err, "invalid messageURI")
+		}
+	case "tel":
+		text, err := readLocalized(i.assets, "invite_sms", "template.txt", locales)
+		if err != nil {
+			return nil, errors.Wrap(err, "Couldn't read text message template")
+		}
+
+		message := messaging.Message{
+			Recipient: row.MessageURI,
+			Templates: messaging.Templates{Text: string(text)},
+		}
+		transport, err := messaging.LookupTransport(message)
+		if err != nil {
+			return nil, errors.Wrap(err, "no transport for messageURI")
+		}
+		if err := transport.Validate(message); err != nil {
+			return nil, errors.Wrap(err, "invalid messageURI")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported messageURI scheme %s", u.Scheme)
+	}
+
+	return role, nil
+}
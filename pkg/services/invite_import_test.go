@@ -0,0 +1,233 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/IpsoVeritas/realm/pkg/providers/assets"
+	"github.com/IpsoVeritas/realm/pkg/providers/dummy"
+)
+
+func TestParseInviteImport(t *testing.T) {
+	validFrom := time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC)
+	validUntil := time.Date(2030, 2, 3, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        []realm.InviteImportRow
+		wantErr     bool
+	}{
+		{
+			name:        "ParseInviteImport_CSV",
+			contentType: "text/csv",
+			body: "name,messageURI,role,validFrom,validUntil,locale,text\n" +
+				"Alice,mailto:alice@example.com,member@test.realm,2030-01-02,2030-02-03T12:00:00Z,sv,Hej\n" +
+				"Bob,tel:+46701234567,member@test.realm,,,,\n",
+			want: []realm.InviteImportRow{
+				{Name: "Alice", MessageURI: "mailto:alice@example.com", Role: "member@test.realm", ValidFrom: &validFrom, ValidUntil: &validUntil, Locale: "sv", Text: "Hej"},
+				{Name: "Bob", MessageURI: "tel:+46701234567", Role: "member@test.realm"},
+			},
+		},
+		{
+			name:        "ParseInviteImport_CSV_column_order_and_case",
+			contentType: "text/csv; charset=utf-8",
+			body:        "ROLE, MessageUri\nmember@test.realm, mailto:alice@example.com\n",
+			want: []realm.InviteImportRow{
+				{MessageURI: "mailto:alice@example.com", Role: "member@test.realm"},
+			},
+		},
+		{
+			name:        "ParseInviteImport_CSV_header_only",
+			contentType: "text/csv",
+			body:        "messageURI,role\n",
+			want:        []realm.InviteImportRow{},
+		},
+		{
+			name:        "ParseInviteImport_CSV_missing_column",
+			contentType: "text/csv",
+			body:        "name,messageURI\nAlice,mailto:alice@example.com\n",
+			wantErr:     true,
+		},
+		{
+			name:        "ParseInviteImport_CSV_bad_date",
+			contentType: "text/csv",
+			body:        "messageURI,role,validUntil\nmailto:alice@example.com,member@test.realm,tomorrow\n",
+			wantErr:     true,
+		},
+		{
+			name:        "ParseInviteImport_CSV_empty",
+			contentType: "text/csv",
+			body:        "",
+			wantErr:     true,
+		},
+		{
+			name:        "ParseInviteImport_JSON",
+			contentType: "application/json",
+			body:        `[{"name":"Alice","messageURI":"mailto:alice@example.com","role":"member@test.realm","validUntil":"2030-02-03T12:00:00Z","locale":"sv"}]`,
+			want: []realm.InviteImportRow{
+				{Name: "Alice", MessageURI: "mailto:alice@example.com", Role: "member@test.realm", ValidUntil: &validUntil, Locale: "sv"},
+			},
+		},
+		{
+			name:        "ParseInviteImport_JSON_without_content_type",
+			contentType: "",
+			body:        `[{"messageURI":"tel:+46701234567","role":"member@test.realm"}]`,
+			want: []realm.InviteImportRow{
+				{MessageURI: "tel:+46701234567", Role: "member@test.realm"},
+			},
+		},
+		{
+			name:        "ParseInviteImport_JSON_not_an_array",
+			contentType: "application/json",
+			body:        `{"messageURI":"tel:+46701234567","role":"member@test.realm"}`,
+			wantErr:     true,
+		},
+		{
+			name:        "ParseInviteImport_unsupported_content_type",
+			contentType: "application/xml",
+			body:        "<invites/>",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseInviteImport(tt.contentType, []byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseInviteImport() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseInviteImport() = %d rows, want %d rows", len(got), len(tt.want))
+			}
+			for n, row := range got {
+				want := tt.want[n]
+				if row.Name != want.Name || row.MessageURI != want.MessageURI || row.Role != want.Role || row.Locale != want.Locale || row.Text != want.Text {
+					t.Errorf("ParseInviteImport() row %d = %+v, want %+v", n+1, *row, want)
+				}
+				if !sameTime(row.ValidFrom, want.ValidFrom) || !sameTime(row.ValidUntil, want.ValidUntil) {
+					t.Errorf("ParseInviteImport() row %d = ValidFrom: %v, ValidUntil: %v, want ValidFrom: %v, ValidUntil: %v", n+1, row.ValidFrom, row.ValidUntil, want.ValidFrom, want.ValidUntil)
+				}
+			}
+		})
+	}
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Equal(*b)
+}
+
+func TestInviteService_Import(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	valid := &realm.InviteImportRow{Name: "Alice", MessageURI: "mailto:alice@example.com", Role: "member@test.realm"}
+
+	tests := []struct {
+		name        string
+		rows        []*realm.InviteImportRow
+		dryRun      bool
+		wantValid   bool
+		wantStatus  []string
+		wantInvites int
+	}{
+		{
+			name:        "Import",
+			rows:        []*realm.InviteImportRow{valid, {MessageURI: "mailto:bob@example.com", Role: "member@test.realm", Locale: "sv"}},
+			wantValid:   true,
+			wantStatus:  []string{realm.InviteImportCreated, realm.InviteImportCreated},
+			wantInvites: 2,
+		},
+		{
+			name:        "Import_dry_run",
+			rows:        []*realm.InviteImportRow{valid},
+			dryRun:      true,
+			wantValid:   true,
+			wantStatus:  []string{realm.InviteImportValid},
+			wantInvites: 0,
+		},
+		{
+			name:        "Import_unknown_role",
+			rows:        []*realm.InviteImportRow{valid, {MessageURI: "mailto:bob@example.com", Role: "other@test.realm"}},
+			wantValid:   false,
+			wantStatus:  []string{realm.InviteImportValid, realm.InviteImportInvalid},
+			wantInvites: 0,
+		},
+		{
+			name:        "Import_missing_role",
+			rows:        []*realm.InviteImportRow{{MessageURI: "mailto:bob@example.com"}, valid},
+			wantValid:   false,
+			wantStatus:  []string{realm.InviteImportInvalid, realm.InviteImportValid},
+			wantInvites: 0,
+		},
+		{
+			name:        "Import_bad_email",
+			rows:        []*realm.InviteImportRow{valid, {MessageURI: "mailto:not an address", Role: "member@test.realm"}},
+			wantValid:   false,
+			wantStatus:  []string{realm.InviteImportValid, realm.InviteImportInvalid},
+			wantInvites: 0,
+		},
+		{
+			name:        "Import_unsupported_scheme",
+			rows:        []*realm.InviteImportRow{{MessageURI: "fax:+46701234567", Role: "member@test.realm"}},
+			wantValid:   false,
+			wantStatus:  []string{realm.InviteImportInvalid},
+			wantInvites: 0,
+		},
+		{
+			name:        "Import_expired",
+			rows:        []*realm.InviteImportRow{valid, {MessageURI: "mailto:bob@example.com", Role: "member@test.realm", ValidUntil: &past}},
+			wantValid:   false,
+			wantStatus:  []string{realm.InviteImportValid, realm.InviteImportInvalid},
+			wantInvites: 0,
+		},
+		{
+			name:        "Import_invalid_locale",
+			rows:        []*realm.InviteImportRow{{MessageURI: "mailto:bob@example.com", Role: "member@test.realm", Locale: "not a locale"}, valid},
+			wantValid:   false,
+			wantStatus:  []string{realm.InviteImportInvalid, realm.InviteImportValid},
+			wantInvites: 0,
+		},
+	}
+	for _, tt := range tests {
+		p := newProvider(t)
+		p.assets = assets.NewAssetsProvider("../../assets")
+		email, err := dummy.NewDummyEmailProvider()
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.email = email
+		setRole(t, p, "member@test.realm")
+
+		t.Run(tt.name, func(t *testing.T) {
+			invites := p.Get(testRealm).Invites()
+			results, valid := invites.Import(tt.rows, false, tt.dryRun)
+			if valid != tt.wantValid {
+				t.Errorf("InviteService.Import() valid = %v, want %v", valid, tt.wantValid)
+			}
+			if len(results) != len(tt.wantStatus) {
+				t.Fatalf("InviteService.Import() = %d results, want %d", len(results), len(tt.wantStatus))
+			}
+			for n, result := range results {
+				if result.Row != n+1 || result.Status != tt.wantStatus[n] {
+					t.Errorf("InviteService.Import() result %d = Row: %d, Status: %s (%s), want Row: %d, Status: %s", n, result.Row, result.Status, result.Error, n+1, tt.wantStatus[n])
+				}
+			}
+
+			created, err := invites.List()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(created) != tt.wantInvites {
+				t.Errorf("InviteService.List() = %d invites, want %d", len(created), tt.wantInvites)
+			}
+		})
+	}
+}
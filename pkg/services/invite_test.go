@@ -0,0 +1,222 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/IpsoVeritas/realm/pkg/providers/assets"
+	"github.com/IpsoVeritas/realm/pkg/providers/dummy"
+	messaging "github.com/IpsoVeritas/realm/pkg/providers/messaging"
+	jose "gopkg.in/square/go-jose.v1"
+)
+
+func TestInviteService_Callback(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		invite   realm.Invite
+		wantErr  error
+		wantOpen bool
+	}{
+		{
+			name:     "Callback_before_valid_from",
+			invite:   realm.Invite{ValidFrom: &future},
+			wantErr:  realm.ErrInviteNotYetValid,
+			wantOpen: true,
+		},
+		{
+			name:    "Callback_accepted",
+			invite:  realm.Invite{ValidFrom: &past, Status: realm.InviteStatusAccepted},
+			wantErr: realm.ErrInviteClosed,
+		},
+		{
+			name:    "Callback_expired",
+			invite:  realm.Invite{ValidUntil: &past},
+			wantErr: realm.ErrInviteExpired,
+		},
+	}
+	for _, tt := range tests {
+		p := newProvider(t)
+		setRole(t, p, "support@test.realm")
+		t.Run(tt.name, func(t *testing.T) {
+			invite := tt.invite
+			invite.ID = "abc"
+			invite.Role = "support@test.realm"
+			invite.TokenHash = hashInviteToken("token")
+			if invite.Status == "" {
+				invite.Status = realm.InviteStatusSent
+			}
+			if err := p.invites.Set(testRealm, &invite); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := p.Get(testRealm).Invites().Callback("token", &jose.JsonWebSignature{})
+			if err != tt.wantErr {
+				t.Errorf("InviteService.Callback() error = %v, want %v", err, tt.wantErr)
+			}
+
+			got, err := p.invites.Get(testRealm, "abc")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Open() != tt.wantOpen {
+				t.Errorf("InviteService.Callback() = Status: %v, want open %v", got.Status, tt.wantOpen)
+			}
+		})
+	}
+}
+
+func TestInviteService_Deliver(t *testing.T) {
+	tests := []struct {
+		name        string
+		recipient   string
+		wantErr     bool
+		wantOldLink bool
+	}{
+		{
+			name:        "Deliver",
+			recipient:   "mailto:alice@example.com",
+			wantErr:     false,
+			wantOldLink: false,
+		},
+		{
+			name:        "Deliver_failed",
+			recipient:   "mailto:not an address",
+			wantErr:     true,
+			wantOldLink: true,
+		},
+	}
+	for _, tt := range tests {
+		p := newProvider(t)
+		p.assets = assets.NewAssetsProvider("../../assets")
+		email, err := dummy.NewDummyEmailProvider()
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.email = email
+		setRole(t, p, "support@test.realm")
+		t.Run(tt.name, func(t *testing.T) {
+			invites := p.Get(testRealm).Invites()
+			invite := &realm.Invite{
+				ID:         "abc",
+				Role:       "support@test.realm",
+				MessageURI: tt.recipient,
+				Status:     realm.InviteStatusSent,
+				TokenHash:  hashInviteToken("token"),
+			}
+			if err := p.invites.Set(testRealm, invite); err != nil {
+				t.Fatal(err)
+			}
+
+			err := invites.Deliver(&realm.OutboxMessage{ID: "msg", InviteID: "abc", Recipient: tt.recipient, Status: realm.OutboxPending})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("InviteService.Deliver() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			_, err = invites.ByToken("token")
+			if (err == nil) != tt.wantOldLink {
+				t.Errorf("InviteService.ByToken() error = %v, want old link working %v", err, tt.wantOldLink)
+			}
+		})
+	}
+}
+
+// claimingEmail claims the invite while the message is sent, like a callback
+// for a link sent before would.
+type claimingEmail struct {
+	realm.EmailProvider
+	claim func()
+}
+
+func (e *claimingEmail) Send(msg messaging.Message) (*realm.EmailStatus, error) {
+	e.claim()
+	return e.EmailProvider.Send(msg)
+}
+
+func TestInviteService_Deliver_accepted(t *testing.T) {
+	p := newProvider(t)
+	p.assets = assets.NewAssetsProvider("../../assets")
+	email, err := dummy.NewDummyEmailProvider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.email = &claimingEmail{EmailProvider: email, claim: func() {
+		if _, err := p.invites.Claim(testRealm, hashInviteToken("token")); err != nil {
+			t.Fatal(err)
+		}
+	}}
+	setRole(t, p, "support@test.realm")
+
+	invite := &realm.Invite{
+		ID:         "abc",
+		Role:       "support@test.realm",
+		MessageURI: "mailto:alice@example.com",
+		Status:     realm.InviteStatusSent,
+		TokenHash:  hashInviteToken("token"),
+	}
+	if err := p.invites.Set(testRealm, invite); err != nil {
+		t.Fatal(err)
+	}
+
+	err = p.Get(testRealm).Invites().Deliver(&realm.OutboxMessage{ID: "msg", InviteID: "abc", Recipient: invite.MessageURI, Status: realm.OutboxPending})
+	if err != realm.ErrInviteClosed {
+		t.Errorf("InviteService.Deliver() error = %v, want %v", err, realm.ErrInviteClosed)
+	}
+
+	got, err := p.invites.Get(testRealm, "abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != realm.InviteStatusAccepted || got.TokenHash != "" {
+		t.Errorf("InviteService.Deliver() = Status: %v, TokenHash: %v, want Status: %v", got.Status, got.TokenHash, realm.InviteStatusAccepted)
+	}
+}
+
+func TestInviteService_QRCode(t *testing.T) {
+	tests := []struct {
+		name        string
+		tokenHash   string
+		status      string
+		rotate      bool
+		wantErr     error
+		wantOldLink bool
+	}{
+		{name: "QRCode", status: realm.InviteStatusDraft},
+		{name: "QRCode_sent", tokenHash: hashInviteToken("token"), status: realm.InviteStatusSent, wantErr: realm.ErrInviteLinkIssued, wantOldLink: true},
+		{name: "QRCode_rotate", tokenHash: hashInviteToken("token"), status: realm.InviteStatusSent, rotate: true},
+		{name: "QRCode_accepted", status: realm.InviteStatusAccepted, rotate: true, wantErr: realm.ErrInviteClosed},
+	}
+	for _, tt := range tests {
+		p := newProvider(t)
+		setRole(t, p, "support@test.realm")
+		t.Run(tt.name, func(t *testing.T) {
+			invite := &realm.Invite{ID: "abc", Role: "support@test.realm", Status: tt.status, TokenHash: tt.tokenHash}
+			if err := p.invites.Set(testRealm, invite); err != nil {
+				t.Fatal(err)
+			}
+
+			invites := p.Get(testRealm).Invites()
+			qrCode := invites.QRCode
+			if tt.rotate {
+				qrCode = invites.RotateQRCode
+			}
+			if _, err := qrCode("abc", 0); err != tt.wantErr {
+				t.Fatalf("InviteService.QRCode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			got, err := p.invites.Get(testRealm, "abc")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr == nil && (got.TokenHash == "" || got.TokenHash == tt.tokenHash) {
+				t.Errorf("InviteService.QRCode() did not issue a new link")
+			}
+			if _, err := invites.ByToken("token"); tt.tokenHash != "" && (err == nil) != tt.wantOldLink {
+				t.Errorf("InviteService.ByToken() error = %v, want old link working %v", err, tt.wantOldLink)
+			}
+		})
+	}
+}
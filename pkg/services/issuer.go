@@ -0,0 +1,125 @@
+package services
+
+import (
+	"encoding/json"
+
+	"github.com/IpsoVeritas/crypto"
+	"github.com/pkg/errors"
+	jose "gopkg.in/square/go-jose.v1"
+)
+
+// issuersSetting is the realm setting holding the trusted fact issuers as a JWKS.
+const issuersSetting = "trusted_issuers"
+
+// IssuerService manages the keys a realm trusts to sign facts. The keys are
+// combined with the global keyset configured for the service.
+type IssuerService struct {
+	settings *SettingService
+	global   *jose.JsonWebKeySet
+}
+
+// List returns the trusted issuer keys configured for the realm.
+func (i *IssuerService) List() ([]jose.JsonWebKey, error) {
+	value, err := i.settings.Get(issuersSetting)
+	if err != nil || value == "" {
+		return []jose.JsonWebKey{}, nil
+	}
+
+	keyset := &jose.JsonWebKeySet{}
+	if err := json.Unmarshal([]byte(value), keyset); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal trusted issuers")
+	}
+
+	return keyset.Keys, nil
+}
+
+// Get returns the trusted issuer key with the given thumbprint.
+func (i *IssuerService) Get(id string) (*jose.JsonWebKey, error) {
+	keys, err := i.List()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		if crypto.Thumbprint(&key) == id {
+			return &key, nil
+		}
+	}
+
+	return nil, errors.Errorf("issuer %s not found", id)
+}
+
+// Set adds a public key to the trusted issuers of the realm, replacing any key
+// with the same thumbprint.
+func (i *IssuerService) Set(key *jose.JsonWebKey) error {
+	if key == nil || key.Key == nil || !key.Valid() {
+		return errors.New("invalid key")
+	}
+
+	if !key.IsPublic() {
+		return errors.New("only public keys can be trusted issuers")
+	}
+
+	keys, err := i.List()
+	if err != nil {
+		return err
+	}
+
+	id := crypto.Thumbprint(key)
+	out := make([]jose.JsonWebKey, 0, len(keys)+1)
+	for _, k := range keys {
+		if crypto.Thumbprint(&k) != id {
+			out = append(out, k)
+		}
+	}
+	out = append(out, *key)
+
+	return i.store(out)
+}
+
+// Delete removes the trusted issuer key with the given thumbprint.
+func (i *IssuerService) Delete(id string) error {
+	keys, err := i.List()
+	if err != nil {
+		return err
+	}
+
+	out := make([]jose.JsonWebKey, 0, len(keys))
+	for _, k := range keys {
+		if crypto.Thumbprint(&k) != id {
+			out = append(out, k)
+		}
+	}
+
+	if len(out) == len(keys) {
+		return errors.Errorf("issuer %s not found", id)
+	}
+
+	return i.store(out)
+}
+
+// Keyset returns the keys that apply to the realm, the global keyset followed
+// by the keys configured for the realm.
+func (i *IssuerService) Keyset() (*jose.JsonWebKeySet, error) {
+	keys, err := i.List()
+	if err != nil {
+		return nil, err
+	}
+
+	keyset := &jose.JsonWebKeySet{}
+	if i.global != nil {
+		keyset.Keys = append(keyset.Keys, i.global.Keys...)
+	}
+	keyset.Keys = append(keyset.Keys, keys...)
+
+	return keyset, nil
+}
+
+func (i *IssuerService) store(keys []jose.JsonWebKey) error {
+	b, err := json.Marshal(&jose.JsonWebKeySet{Keys: keys})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal trusted issuers")
+	}
+
+	return i.settings.Set(issuersSetting, string(b))
+}
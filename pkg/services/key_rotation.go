@@ -0,0 +1,212 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	crypto "github.com/IpsoVeritas/crypto"
+	"github.com/IpsoVeritas/document"
+	logger "github.com/IpsoVeritas/logger"
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	jose "gopkg.in/square/go-jose.v1"
+)
+
+// DefaultKeyGrace is how long mandates signed by a retired realm key are accepted
+// after a key rotation, unless another grace period is given.
+const DefaultKeyGrace = 30 * 24 * time.Hour
+
+// MinKeyGrace is the shortest grace period of a key rotation, which leaves time to
+// re-issue the mandates signed by the previous key.
+const MinKeyGrace = time.Hour
+
+// ErrKeyGrace is returned by RotateKey for a grace period shorter than MinKeyGrace.
+var ErrKeyGrace = errors.Errorf("grace period must be at least %s", MinKeyGrace)
+
+// RotateKey creates a new key for the realm. The previous key is kept as a retired
+// key for the grace period, during which mandates signed by it are still accepted
+// and the descriptor is signed by both keys. Retired keys past their grace period
+// are removed.
+func (r *RealmService) RotateKey(grace time.Duration) (*realm.Realm, error) {
+	if grace < MinKeyGrace {
+		return nil, ErrKeyGrace
+	}
+
+	realmData, err := r.Realm()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get realm")
+	}
+
+	keyID := fmt.Sprintf("%s#%s", r.realmID, uuid.NewV4().String())
+	pk, err := r.p.signer.Create(keyID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create key")
+	}
+
+	now := time.Now().UTC()
+	retired := []*realm.RetiredKey{
+		{
+			KeyID:      realmData.SigningKeyID(),
+			PublicKey:  realmData.PublicKey,
+			Retired:    now,
+			ValidUntil: now.Add(grace),
+		},
+	}
+	expired := make([]string, 0)
+	for _, k := range realmData.RetiredKeys {
+		if now.Before(k.ValidUntil) {
+			retired = append(retired, k)
+		} else {
+			expired = append(expired, k.KeyID)
+		}
+	}
+
+	realmData.KeyID = keyID
+	realmData.PublicKey = pk
+	realmData.RetiredKeys = retired
+	if realmData.Descriptor != nil {
+		realmData.Descriptor.PublicKey = pk
+	}
+
+	if err := r.Set(realmData); err != nil {
+		r.p.deleteKey(keyID)
+		return nil, errors.Wrap(err, "failed to save realm")
+	}
+
+	if r.realmID == r.p.bootstrapRealmID && r.p.bootstrapRealm != nil {
+		r.p.bootstrapRealm = realmData
+	}
+
+	for _, id := range expired {
+		r.p.deleteKey(id)
+	}
+
+	r.publish(realm.EventRealmKeyRotated, r.realmID, map[string]string{
+		"thumbprint": crypto.Thumbprint(pk),
+	})
+
+	return realmData, nil
+}
+
+// keyThumbprints returns the thumbprints of the keys whose signatures are
+// accepted for the realm: the current key and the retired keys in their grace period.
+func keyThumbprints(realmData *realm.Realm, now time.Time) map[string]bool {
+	tps := make(map[string]bool)
+	if realmData == nil {
+		return tps
+	}
+
+	tps[crypto.Thumbprint(realmData.PublicKey)] = true
+	for _, k := range realmData.RetiredKeys {
+		if now.Before(k.ValidUntil) {
+			tps[crypto.Thumbprint(k.PublicKey)] = true
+		}
+	}
+
+	return tps
+}
+
+// Reissue signs the active mandates of the realm again with the current key,
+// so they stay valid when the grace period of a retired key ends. It returns
+// the number of re-issued mandates.
+func (m *MandateService) Reissue() (int, error) {
+	realmData, err := m.realmContext.Realm()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get realm")
+	}
+	current := crypto.Thumbprint(realmData.PublicKey)
+
+	mandates, err := m.List()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to list mandates")
+	}
+
+	now := time.Now()
+	count := 0
+	for _, issued := range mandates {
+		if issued.Status != document.MandateActive {
+			continue
+		}
+		if issued.ValidUntil != nil && issued.ValidUntil.Before(now) {
+			continue
+		}
+		if jws, err := crypto.UnmarshalSignature([]byte(issued.Signed)); err == nil && len(jws.Signatures) > 0 && jws.Signatures[0].Header.JsonWebKey != nil {
+			if crypto.Thumbprint(jws.Signatures[0].Header.JsonWebKey) == current {
+				continue
+			}
+		}
+
+		bytes, err := json.Marshal(issued.Mandate)
+		if err != nil {
+			return count, err
+		}
+
+		jws, err := m.realmContext.Sign(bytes)
+		if err != nil {
+			return count, errors.Wrapf(err, "failed to sign mandate %s", issued.ID)
+		}
+
+		issued.Signed, err = jws.CompactSerialize()
+		if err != nil {
+			return count, err
+		}
+
+		if err := m.Set(issued); err != nil {
+			return count, errors.Wrapf(err, "failed to save mandate %s", issued.ID)
+		}
+		count++
+	}
+
+	logger.Infof("Re-issued %d mandates for realm %s", count, m.realmID)
+
+	return count, nil
+}
+
+// mergeSignatures combines signatures of the same payload into one JWS in the
+// general JSON serialization, which has a signature for each key.
+func mergeSignatures(signatures []*jose.JsonWebSignature) (string, error) {
+	if len(signatures) == 1 {
+		return signatures[0].FullSerialize(), nil
+	}
+
+	type signature struct {
+		Protected string          `json:"protected,omitempty"`
+		Header    json.RawMessage `json:"header,omitempty"`
+		Signature string          `json:"signature"`
+	}
+	type serialized struct {
+		Payload    string      `json:"payload"`
+		Signatures []signature `json:"signatures,omitempty"`
+		signature
+	}
+
+	merged := serialized{}
+	for _, jws := range signatures {
+		s := serialized{}
+		if err := json.Unmarshal([]byte(jws.FullSerialize()), &s); err != nil {
+			return "", errors.Wrap(err, "failed to parse signature")
+		}
+		if merged.Payload == "" {
+			merged.Payload = s.Payload
+		} else if merged.Payload != s.Payload {
+			return "", errors.New("signatures are for different payloads")
+		}
+
+		if s.Signature != "" {
+			merged.Signatures = append(merged.Signatures, s.signature)
+		}
+		merged.Signatures = append(merged.Signatures, s.Signatures...)
+	}
+
+	b, err := json.Marshal(struct {
+		Payload    string      `json:"payload"`
+		Signatures []signature `json:"signatures"`
+	}{merged.Payload, merged.Signatures})
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
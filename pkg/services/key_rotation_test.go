@@ -0,0 +1,39 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRealmService_RotateKey_grace(t *testing.T) {
+	tests := []struct {
+		name  string
+		grace time.Duration
+	}{
+		{name: "RotateKey_without_grace", grace: 0},
+		{name: "RotateKey_negative_grace", grace: -time.Hour},
+		{name: "RotateKey_short_grace", grace: MinKeyGrace - time.Minute},
+	}
+	for _, tt := range tests {
+		p := newProvider(t)
+		t.Run(tt.name, func(t *testing.T) {
+			context := p.Get(testRealm)
+			before, err := context.Realm()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := context.RotateKey(tt.grace); err != ErrKeyGrace {
+				t.Errorf("RealmService.RotateKey() error = %v, want %v", err, ErrKeyGrace)
+			}
+
+			after, err := context.Realm()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if after.KeyID != before.KeyID || len(after.RetiredKeys) != 0 {
+				t.Errorf("RealmService.RotateKey() = KeyID: %v, RetiredKeys: %d, want the key unchanged", after.KeyID, len(after.RetiredKeys))
+			}
+		})
+	}
+}
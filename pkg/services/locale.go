@@ -0,0 +1,95 @@
+package services
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"text/template"
+
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/pkg/errors"
+)
+
+// DefaultLocale is the language of the assets in the root of each template directory.
+// Translations live in a subdirectory per locale, e.g. invite_email/sv/template.html.
+const DefaultLocale = "en"
+
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// Locales returns the locales to look for content in, starting with the given
+// locale, e.g. the locale of an invite, followed by the default locale of the realm.
+func (r *RealmService) Locales(locale string) []string {
+	locales := []string{locale}
+	if realmData, err := r.Realm(); err == nil {
+		locales = append(locales, realmData.Locale)
+	}
+
+	return localeCandidates(locales...)
+}
+
+// localeCandidates normalizes the locales and adds the base language after each
+// regional variant, e.g. sv-FI, sv. Invalid locales are left out.
+func localeCandidates(locales ...string) []string {
+	seen := make(map[string]bool)
+	out := make([]string, 0)
+	add := func(locale string) {
+		if !seen[locale] {
+			seen[locale] = true
+			out = append(out, locale)
+		}
+	}
+
+	for _, locale := range locales {
+		locale = strings.ToLower(strings.Replace(locale, "_", "-", -1))
+		if !localePattern.MatchString(locale) {
+			continue
+		}
+		add(locale)
+		if i := strings.Index(locale, "-"); i > 0 {
+			add(locale[:i])
+		}
+	}
+
+	return out
+}
+
+// readLocalized reads the asset for the first of the locales that has a translation,
+// falling back to the English asset in the root of the directory. Locales after
+// DefaultLocale are not considered, since English is always available.
+func readLocalized(assets realm.AssetProvider, dir, name string, locales []string) ([]byte, error) {
+	for _, locale := range locales {
+		if isDefaultLocale(locale) {
+			break
+		}
+		if b, err := assets.Read(dir + "/" + locale + "/" + name); err == nil {
+			return b, nil
+		}
+	}
+
+	return assets.Read(dir + "/" + name)
+}
+
+// isDefaultLocale returns true for DefaultLocale and its regional variants.
+func isDefaultLocale(locale string) bool {
+	return locale == DefaultLocale || strings.HasPrefix(locale, DefaultLocale+"-")
+}
+
+// renderLocalized renders the localized template with the data.
+func renderLocalized(assets realm.AssetProvider, dir, name string, locales []string, data interface{}) (string, error) {
+	b, err := readLocalized(assets, dir, name, locales)
+	if err != nil {
+		return "", errors.Wrapf(err, "Couldn't read template %s/%s", dir, name)
+	}
+
+	tmpl, err := template.New(name).Parse(strings.TrimSpace(string(b)))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse template %s/%s", dir, name)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", errors.Wrapf(err, "failed to render template %s/%s", dir, name)
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/IpsoVeritas/crypto"
+	"github.com/IpsoVeritas/document"
+	"github.com/IpsoVeritas/httphandler"
+	realm "github.com/IpsoVeritas/realm"
+	gormprovider "github.com/IpsoVeritas/realm/pkg/providers/gorm"
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	jose "gopkg.in/square/go-jose.v1"
+)
+
+const (
+	testBootstrapRealm = "bootstrap"
+	testRealm          = "test.realm"
+)
+
+// newProvider returns a provider on an in-memory database with a bootstrap realm
+// and a test realm, which have the admin roles admin@bootstrap and admin@test.realm.
+func newProvider(t *testing.T) *RealmsServiceProvider {
+	db, err := gorm.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	realms, err := gormprovider.NewGormRealmService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions, err := gormprovider.NewGormActionService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	controllers, err := gormprovider.NewGormControllerService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	invites, err := gormprovider.NewGormInviteService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mandates, err := gormprovider.NewGormMandateService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	revocations, err := gormprovider.NewGormRevocationService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tickets, err := gormprovider.NewGormMandateTicketService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roles, err := gormprovider.NewGormRoleService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	permissions, err := gormprovider.NewGormPermissionService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings, err := gormprovider.NewGormSettingService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	webhooks, err := gormprovider.NewGormWebhookService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deliveries, err := gormprovider.NewGormWebhookDeliveryService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outbox, err := gormprovider.NewGormOutboxService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	audit, err := gormprovider.NewGormAuditService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewRealmsServiceProvider("http://localhost", realms, actions, controllers, invites, mandates,
+		revocations, tickets, roles, permissions, settings, webhooks, deliveries, outbox, audit,
+		nil, "", nil, nil, nil)
+	p.SetImporter(gormprovider.NewGormRealmImporter(db))
+
+	for _, id := range []string{testBootstrapRealm, testRealm} {
+		if err := realms.Set(&realm.Realm{
+			ID:         id,
+			PublicKey:  newPublicKey(t),
+			Descriptor: &document.RealmDescriptor{},
+			AdminRoles: []string{"admin@" + id},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := p.LoadBootstrapRealm(testBootstrapRealm); err != nil {
+		t.Fatal(err)
+	}
+
+	return p
+}
+
+func newPublicKey(t *testing.T) *jose.JsonWebKey {
+	key, err := crypto.NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, err := crypto.NewPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pk
+}
+
+// setRole creates the role in the test realm with the permissions.
+func setRole(t *testing.T, p *RealmsServiceProvider, name string, permissions ...string) {
+	context := p.Get(testRealm)
+	if err := context.Roles().Set(document.NewRole(name)); err != nil {
+		t.Fatal(err)
+	}
+	if err := context.Roles().SetPermissions(name, permissions); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// mandateFor returns a mandate for the role signed by the key of the test realm.
+func mandateFor(t *testing.T, p *RealmsServiceProvider, id, role string) []httphandler.AuthenticatedMandate {
+	realmData, err := p.Get(testRealm).Realm()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mandate := document.NewMandate(role)
+	mandate.ID = id
+	mandate.Realm = testRealm
+
+	return []httphandler.AuthenticatedMandate{{Mandate: mandate, Signer: realmData.PublicKey}}
+}
+
+// testSigner keeps the private keys in memory. Every key gets a random JWK key ID,
+// so keys of the same realm in different signers are told apart.
+type testSigner struct {
+	keys map[string]*jose.JsonWebKey
+}
+
+func newTestSigner() *testSigner {
+	return &testSigner{keys: make(map[string]*jose.JsonWebKey)}
+}
+
+func (s *testSigner) Create(keyID string) (*jose.JsonWebKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	kid := make([]byte, 8)
+	if _, err := rand.Read(kid); err != nil {
+		return nil, err
+	}
+
+	s.keys[keyID] = &jose.JsonWebKey{Key: key, KeyID: hex.EncodeToString(kid), Algorithm: "ES256"}
+
+	return s.PublicKey(keyID)
+}
+
+func (s *testSigner) Import(keyID string, key *jose.JsonWebKey) error {
+	s.keys[keyID] = key
+	return nil
+}
+
+func (s *testSigner) Key(keyID string) (*jose.JsonWebKey, error) {
+	key, ok := s.keys[keyID]
+	if !ok {
+		return nil, errors.New("key not found")
+	}
+	return key, nil
+}
+
+func (s *testSigner) PublicKey(keyID string) (*jose.JsonWebKey, error) {
+	key, err := s.Key(keyID)
+	if err != nil {
+		return nil, err
+	}
+	pk := &jose.JsonWebKey{KeyID: key.KeyID, Algorithm: key.Algorithm}
+	if private, ok := key.Key.(*ecdsa.PrivateKey); ok {
+		pk.Key = &private.PublicKey
+	}
+	return pk, nil
+}
+
+func (s *testSigner) Sign(keyID string, payload []byte) (*jose.JsonWebSignature, error) {
+	key, err := s.Key(keyID)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := crypto.NewSigner(key)
+	if err != nil {
+		return nil, err
+	}
+	return signer.Sign(payload)
+}
+
+func (s *testSigner) Delete(keyID string) error {
+	delete(s.keys, keyID)
+	return nil
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/IpsoVeritas/document"
 
@@ -73,6 +74,10 @@ func (m *MandateService) Issue(mandate *document.Mandate, label string) (*realm.
 		return nil, err
 	}
 
+	m.realmContext.publish(realm.EventMandateIssued, issued.ID, map[string]string{
+		"role": issued.Role,
+	})
+
 	return issued, nil
 }
 
@@ -83,7 +88,17 @@ func (m *MandateService) Revoke(issued *realm.IssuedMandate) (*realm.IssuedManda
 		return nil, errors.Wrap(err, "failed to unmarshal signature")
 	}
 
-	// TODO: Implement revocations logic
+	revocation := &realm.Revocation{
+		MandateID: issued.ID,
+		Realm:     m.realmID,
+		Role:      issued.Role,
+		Checksum:  crypto.Sha256(issued.Signed),
+		Revoked:   time.Now().UTC(),
+	}
+
+	if err := m.realmContext.Revocations().Set(revocation); err != nil {
+		return nil, errors.Wrap(err, "failed to save revocation")
+	}
 
 	issued.Status = document.MandateRevoked
 
@@ -91,6 +106,11 @@ func (m *MandateService) Revoke(issued *realm.IssuedMandate) (*realm.IssuedManda
 		return nil, err
 	}
 
+	m.realmContext.publish(realm.EventMandateRevoked, issued.ID, map[string]string{
+		"role":     issued.Role,
+		"checksum": revocation.Checksum,
+	})
+
 	return issued, nil
 }
 
@@ -1,8 +1,14 @@
 package services
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/IpsoVeritas/document"
 	realm "github.com/IpsoVeritas/realm"
+	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
+	jose "gopkg.in/square/go-jose.v1"
 )
 
 type MandateTicketService struct {
@@ -25,9 +31,120 @@ func (m *MandateTicketService) Set(mandateTicket *realm.MandateTicket) error {
 	}
 
 	mandateTicket.Realm = m.realmID
+
+	if !mandateTicket.Static {
+		mandateTicket.MaxRedemptions = 1
+	}
+
+	if mandateTicket.MaxRedemptions < 0 {
+		return errors.New("max redemptions can not be negative")
+	}
+
+	// the redemption counter is only changed by Redeem
+	mandateTicket.Redemptions = 0
+	if existing, err := m.Get(mandateTicket.ID); err == nil {
+		mandateTicket.Redemptions = existing.Redemptions
+	}
+
+	if mandateTicket.Mandate == nil || mandateTicket.Mandate.Role == "" {
+		return errors.New("ticket needs a mandate with a role")
+	}
+
+	role, err := m.realmContext.Roles().ByName(mandateTicket.Mandate.Role)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get role %s", mandateTicket.Mandate.Role)
+	}
+
+	mandateTicket.Mandate.Realm = m.realmID
+	if mandateTicket.Mandate.RoleName == "" {
+		mandateTicket.Mandate.RoleName = role.Description
+	}
+	if mandateTicket.Mandate.Sender == "" {
+		mandateTicket.Mandate.Sender = "ticket"
+	}
+
+	if mandateTicket.ScopeRequest == nil {
+		mandateTicket.ScopeRequest = document.NewScopeRequest(role.KeyLevel)
+	}
+
+	if mandateTicket.ScopeRequest.Contract == nil {
+		realmData, err := m.realmContext.Realm()
+		if err != nil {
+			return errors.Wrap(err, "failed to get realm")
+		}
+		label := realmData.Label
+		if label == "" {
+			label = realmData.ID
+		}
+
+		mandateTicket.ScopeRequest.Contract = document.NewContract()
+		mandateTicket.ScopeRequest.Contract.Text, err = renderLocalized(m.realmContext.p.assets, "invite_contract", "template.txt", localeCandidates(realmData.Locale), map[string]interface{}{
+			"roleName": role.Description,
+			"realm":    label,
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to render contract")
+		}
+	}
+
+	if len(mandateTicket.Facts) > 0 {
+
+		requested := make(map[string]bool)
+		for _, scope := range mandateTicket.ScopeRequest.Scopes {
+			requested[scopeName(scope)] = true
+		}
+		for _, name := range sortedKeys(mandateTicket.Facts) {
+			if !requested[name] {
+				mandateTicket.ScopeRequest.Scopes = append(mandateTicket.ScopeRequest.Scopes, factScope(name))
+			}
+		}
+	}
+
 	return m.p.Set(m.realmID, mandateTicket)
 }
 
+// Redeem counts a redemption of the ticket, failing with realm.ErrTicketNotRedeemable
+// if the ticket has expired or has been redeemed the maximum number of times.
+func (m *MandateTicketService) Redeem(id string) (*realm.MandateTicket, error) {
+	return m.p.Redeem(m.realmID, id, time.Now().UTC())
+}
+
+// URL returns the shareable URL of a ticket, which a user opens to redeem it.
+func (m *MandateTicketService) URL(ticket *realm.MandateTicket) string {
+	return fmt.Sprintf("%s/realm/v2/realms/%s/tickets/%s/issue", m.realmContext.base, m.realmID, ticket.ID)
+}
+
+// Link returns the link that opens the URL of a ticket in the realm's wallet app.
+func (m *MandateTicketService) Link(ticket *realm.MandateTicket) (string, error) {
+	return m.realmContext.Link(m.URL(ticket))
+}
+
+// VerifyFacts checks that the scope-response to a ticket contains every fact
+// required by the ticket, signed by a trusted issuer and with the required value.
+// The returned error is a FactErrors when facts are missing or do not match.
+func (m *MandateTicketService) VerifyFacts(ticket *realm.MandateTicket, jws *jose.JsonWebSignature) error {
+	if len(ticket.Facts) == 0 {
+		return nil
+	}
+
+	keyLevel := 10
+	if ticket.ScopeRequest != nil && ticket.ScopeRequest.KeyLevel > 0 {
+		keyLevel = ticket.ScopeRequest.KeyLevel
+	}
+
+	keyset, err := m.realmContext.Keyset()
+	if err != nil {
+		return errors.Wrap(err, "failed to get trusted issuers")
+	}
+
+	response, err := parseScopeResponse(jws, keyLevel, keyset)
+	if err != nil {
+		return err
+	}
+
+	return response.matchFacts(ticket.Facts)
+}
+
 func (m *MandateTicketService) Delete(id string) error {
 	return m.p.Delete(m.realmID, id)
 }
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/IpsoVeritas/document"
+	realm "github.com/IpsoVeritas/realm"
+	jose "gopkg.in/square/go-jose.v1"
+)
+
+func TestMandateTicketService_Redeem_with_facts(t *testing.T) {
+	now := time.Now()
+	key := newUserKey(t)
+
+	otherType := newFact("name", "Alice", key, now, 60)
+	otherType.Data["email"] = "alice@corp.com"
+
+	tests := []struct {
+		name    string
+		facts   []*document.Fact
+		wantErr bool
+	}{
+		{
+			name:    "Redeem_with_facts",
+			facts:   []*document.Fact{newFact("email", "alice@corp.com", key, now, 60)},
+			wantErr: false,
+		},
+		{
+			name:    "Redeem_with_facts_one_matches",
+			facts:   []*document.Fact{newFact("email", "bob@corp.com", key, now, 60), newFact("email", "alice@corp.com", key, now, 60)},
+			wantErr: false,
+		},
+		{
+			name:    "Redeem_with_facts_wrong_value",
+			facts:   []*document.Fact{newFact("email", "bob@corp.com", key, now, 60)},
+			wantErr: true,
+		},
+		{
+			name:    "Redeem_with_facts_value_from_other_type",
+			facts:   []*document.Fact{otherType},
+			wantErr: true,
+		},
+		{
+			name:    "Redeem_with_facts_replayed",
+			facts:   []*document.Fact{newFact("email", "alice@corp.com", nil, now, 60)},
+			wantErr: true,
+		},
+		{
+			name:    "Redeem_with_facts_expired",
+			facts:   []*document.Fact{newFact("email", "alice@corp.com", key, now.Add(-time.Hour), 60)},
+			wantErr: true,
+		},
+		{
+			name:    "Redeem_with_facts_not_provided",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		p := newProvider(t)
+		setRole(t, p, "support@test.realm", realm.PermissionInvitesRead)
+		t.Run(tt.name, func(t *testing.T) {
+			tickets := p.Get(testRealm).MandateTickets()
+
+			ticket := realm.NewMandateTicket()
+			ticket.Mandate = document.NewMandate("support@test.realm")
+			ticket.ScopeRequest = document.NewScopeRequest(1)
+			ticket.ScopeRequest.Contract = &document.Contract{Text: "Terms"}
+			ticket.Facts["email"] = "alice@corp.com"
+			ticket.MaxRedemptions = 1
+			if err := tickets.Set(ticket); err != nil {
+				t.Fatal(err)
+			}
+
+			ticket, err := tickets.Get(ticket.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// a response that isn't signed never reaches the facts
+			if err := tickets.VerifyFacts(ticket, &jose.JsonWebSignature{}); err == nil {
+				t.Fatal("MandateTicketService.VerifyFacts() expected error for an unsigned response")
+			}
+
+			response := newScopeResponse(key)
+			for _, fact := range tt.facts {
+				response.addFact(fact, now)
+			}
+
+			err = response.matchFacts(ticket.Facts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ScopeResponse.matchFacts() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				if _, ok := err.(FactErrors); !ok {
+					t.Errorf("ScopeResponse.matchFacts() error = %T, want FactErrors", err)
+				}
+				return
+			}
+
+			redeemed, err := tickets.Redeem(ticket.ID)
+			if err != nil {
+				t.Fatalf("MandateTicketService.Redeem() error = %v", err)
+			}
+			if redeemed.Redemptions != 1 {
+				t.Errorf("MandateTicketService.Redeem() = Redemptions: %d, want Redemptions: 1", redeemed.Redemptions)
+			}
+		})
+	}
+}
+
+func TestRealmsServiceProvider_BootstrapTicket(t *testing.T) {
+	p := newProvider(t)
+
+	ticket, err := p.BootstrapTicket()
+	if err != nil {
+		t.Fatalf("RealmsServiceProvider.BootstrapTicket() error = %v", err)
+	}
+	if ticket.ValidUntil == nil || ticket.ValidUntil.After(time.Now().Add(BootstrapTicketTTL)) {
+		t.Errorf("RealmsServiceProvider.BootstrapTicket() = ValidUntil: %v, want within %v", ticket.ValidUntil, BootstrapTicketTTL)
+	}
+
+	tickets := p.Get(testBootstrapRealm).MandateTickets()
+	if _, err := tickets.Redeem(ticket.ID); err != nil {
+		t.Fatalf("MandateTicketService.Redeem() error = %v", err)
+	}
+	if _, err := tickets.Redeem(ticket.ID); err != realm.ErrTicketNotRedeemable {
+		t.Errorf("MandateTicketService.Redeem() error = %v, want %v", err, realm.ErrTicketNotRedeemable)
+	}
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	logger "github.com/IpsoVeritas/logger"
+	realm "github.com/IpsoVeritas/realm"
+)
+
+const (
+	outboxMaxAttempts    = 8
+	outboxInitialBackoff = time.Minute
+	outboxMaxBackoff     = 6 * time.Hour
+	// outboxLease is how long a message is reserved for the attempt that claimed it.
+	// It must be longer than it takes the email provider or transport to time out.
+	outboxLease = 5 * time.Minute
+)
+
+// OutboxWorker delivers queued invite messages with a pool of workers, retrying
+// failed messages with exponential backoff.
+type OutboxWorker struct {
+	p       *RealmsServiceProvider
+	workers int
+	stop    chan struct{}
+	mu      sync.Mutex
+}
+
+func NewOutboxWorker(p *RealmsServiceProvider, workers int) *OutboxWorker {
+	if workers < 1 {
+		workers = 1
+	}
+
+	return &OutboxWorker{
+		p:       p,
+		workers: workers,
+		stop:    make(chan struct{}),
+	}
+}
+
+func (w *OutboxWorker) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				w.Process()
+			case <-w.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (w *OutboxWorker) Stop() {
+	close(w.stop)
+}
+
+// Process attempts all messages that are due and waits for the attempts to finish.
+func (w *OutboxWorker) Process() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	messages, err := w.p.outbox.ListDue(time.Now().UTC())
+	if err != nil {
+		logger.Warningf("Failed to list outbox messages: %s", err)
+		return
+	}
+
+	jobs := make(chan *realm.OutboxMessage)
+	var wg sync.WaitGroup
+	for n := 0; n < w.workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for msg := range jobs {
+				w.attempt(msg)
+			}
+		}()
+	}
+
+	for _, msg := range messages {
+		jobs <- msg
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+func (w *OutboxWorker) attempt(msg *realm.OutboxMessage) {
+	now := time.Now().UTC()
+	claimed, err := w.p.outbox.Claim(msg.Realm, msg.ID, now, now.Add(outboxLease))
+	if err != nil {
+		logger.Warningf("Failed to claim outbox message %s: %s", msg.ID, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	if err := w.p.Get(msg.Realm).Invites().Deliver(msg); err != nil {
+		logger.Warningf("Failed to deliver outbox message %s: %s", msg.ID, err)
+	}
+}
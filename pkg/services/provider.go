@@ -4,14 +4,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"strings"
+	"time"
 
 	crypto "github.com/IpsoVeritas/crypto"
 	document "github.com/IpsoVeritas/document"
 	httphandler "github.com/IpsoVeritas/httphandler"
-	keys "github.com/IpsoVeritas/keys"
+	logger "github.com/IpsoVeritas/logger"
 	realm "github.com/IpsoVeritas/realm"
+	events "github.com/IpsoVeritas/realm/pkg/providers/events"
 	filestore "github.com/IpsoVeritas/realm/pkg/providers/filestore"
 	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
 	"github.com/spf13/viper"
 	jose "gopkg.in/square/go-jose.v1"
 )
@@ -23,13 +27,22 @@ type RealmsServiceProvider struct {
 	controllers           realm.ControllerProvider
 	invites               realm.InviteProvider
 	mandates              realm.IssuedMandateProvider
+	revocations           realm.RevocationProvider
 	mandateTickets        realm.MandateTicketProvider
 	roles                 realm.RoleProvider
+	permissions           realm.PermissionProvider
 	settings              realm.SettingProvider
+	webhooks              realm.WebhookProvider
+	webhookDeliveries     realm.WebhookDeliveryProvider
+	outbox                realm.OutboxProvider
+	audit                 realm.AuditProvider
 	filestore             filestore.Filestore
-	sks                   keys.StoredKeyService
-	kek                   []byte
+	purger                realm.RealmPurger
+	importer              realm.RealmImporter
+	retention             time.Duration
+	signer                realm.Signer
 	realmTopic            string
+	events                realm.EventBus
 	bootstrapRealmID      string
 	bootstrapRealmContext *RealmService
 	bootstrapRealm        *realm.Realm
@@ -45,11 +58,16 @@ func NewRealmsServiceProvider(
 	controllers realm.ControllerProvider,
 	invites realm.InviteProvider,
 	mandates realm.IssuedMandateProvider,
+	revocations realm.RevocationProvider,
 	mandateTickets realm.MandateTicketProvider,
 	roles realm.RoleProvider,
+	permissions realm.PermissionProvider,
 	settings realm.SettingProvider,
-	sks keys.StoredKeyService,
-	kek []byte,
+	webhooks realm.WebhookProvider,
+	webhookDeliveries realm.WebhookDeliveryProvider,
+	outbox realm.OutboxProvider,
+	audit realm.AuditProvider,
+	signer realm.Signer,
 	realmTopic string,
 	keyset *jose.JsonWebKeySet,
 	email realm.EmailProvider,
@@ -57,21 +75,27 @@ func NewRealmsServiceProvider(
 ) *RealmsServiceProvider {
 
 	r := &RealmsServiceProvider{
-		base:           base,
-		realms:         realms,
-		actions:        actions,
-		controllers:    controllers,
-		invites:        invites,
-		mandates:       mandates,
-		mandateTickets: mandateTickets,
-		roles:          roles,
-		settings:       settings,
-		sks:            sks,
-		kek:            kek,
-		realmTopic:     realmTopic,
-		keyset:         keyset,
-		email:          email,
-		assets:         assets,
+		base:              base,
+		realms:            realms,
+		actions:           actions,
+		controllers:       controllers,
+		invites:           invites,
+		mandates:          mandates,
+		revocations:       revocations,
+		mandateTickets:    mandateTickets,
+		roles:             roles,
+		permissions:       permissions,
+		settings:          settings,
+		webhooks:          webhooks,
+		webhookDeliveries: webhookDeliveries,
+		outbox:            outbox,
+		audit:             audit,
+		signer:            signer,
+		realmTopic:        realmTopic,
+		events:            events.NewInProcessEventBus(),
+		keyset:            keyset,
+		email:             email,
+		assets:            assets,
 	}
 
 	return r
@@ -85,6 +109,46 @@ func (p *RealmsServiceProvider) SetFilestore(filestore filestore.Filestore) {
 	p.filestore = filestore
 }
 
+// SetPurger sets how deleted realms are purged, and how long they are kept
+// before that, during which they can be restored.
+func (p *RealmsServiceProvider) SetPurger(purger realm.RealmPurger, retention time.Duration) {
+	p.purger = purger
+	p.retention = retention
+}
+
+// SetImporter sets how the entities of restored realms are written.
+func (p *RealmsServiceProvider) SetImporter(importer realm.RealmImporter) {
+	p.importer = importer
+}
+
+func (p *RealmsServiceProvider) SetEventBus(events realm.EventBus) {
+	p.events = events
+}
+
+func (p *RealmsServiceProvider) Events() realm.EventBus {
+	return p.events
+}
+
+func (p *RealmsServiceProvider) RealmTopic() string {
+	return p.realmTopic
+}
+
+// Publish queues deliveries of the event for the webhooks of the realm, and sends
+// it on the realm topic. The deliveries are queued here, by the instance that made
+// the change, since the event bus may drop events. Failing to queue or publish is
+// logged but never fails the operation that triggered the event.
+func (p *RealmsServiceProvider) Publish(event *realm.RealmEvent) {
+	p.Get(event.Realm).Webhooks().queueEvent(event)
+
+	if p.events == nil || p.realmTopic == "" {
+		return
+	}
+
+	if err := p.events.Publish(p.realmTopic, event); err != nil {
+		logger.Warningf("Failed to publish %s event for realm %s: %s", event.Type, event.Realm, err)
+	}
+}
+
 func (p *RealmsServiceProvider) LoadBootstrapRealm(bootstrapRealmID string) error {
 	p.bootstrapRealmID = bootstrapRealmID
 	p.bootstrapRealmContext = p.Get(bootstrapRealmID)
@@ -120,12 +184,29 @@ func (p *RealmsServiceProvider) Bootstrap(password string) (*realm.MandateTicket
 		return nil, errors.New("Wrong password for bootstrapping realm")
 	}
 
+	return p.BootstrapTicket()
+}
+
+// BootstrapTicketTTL is how long a bootstrap ticket can be redeemed.
+const BootstrapTicketTTL = time.Hour
+
+// BootstrapTicket creates a ticket for an admin mandate for the bootstrap realm,
+// and marks the realm as bootstrapped, without checking the password. The ticket
+// can be redeemed once, within BootstrapTicketTTL.
+func (p *RealmsServiceProvider) BootstrapTicket() (*realm.MandateTicket, error) {
+	if p.bootstrapRealm == nil {
+		return nil, errors.New("Bootstrap realm not loaded")
+	}
+
 	if len(p.bootstrapRealm.AdminRoles) < 1 {
 		return nil, errors.New("No admin roles for realm")
 	}
 
+	validUntil := time.Now().UTC().Add(BootstrapTicketTTL)
 	ticket := realm.NewMandateTicket()
 	ticket.Realm = p.bootstrapRealm.ID
+	ticket.MaxRedemptions = 1
+	ticket.ValidUntil = &validUntil
 
 	ticket.Mandate = document.NewMandate(p.bootstrapRealm.AdminRoles[0])
 	ticket.Mandate.Realm = ticket.Realm
@@ -152,12 +233,62 @@ func (p *RealmsServiceProvider) Bootstrap(password string) (*realm.MandateTicket
 	return ticket, nil
 }
 
+// BootstrapPassword returns the password for bootstrapping the bootstrap realm.
+func (p *RealmsServiceProvider) BootstrapPassword() (string, error) {
+	if p.bootstrapRealm == nil {
+		return "", errors.New("Bootstrap realm not loaded")
+	}
+
+	return p.bootstrapRealmContext.Settings().Get("password")
+}
+
+// ResetBootstrap sets a new bootstrap password, with which the bootstrap realm
+// can be bootstrapped again, and returns it.
+func (p *RealmsServiceProvider) ResetBootstrap() (string, error) {
+	if p.bootstrapRealm == nil {
+		return "", errors.New("Bootstrap realm not loaded")
+	}
+
+	password, err := NewBootstrapPassword()
+	if err != nil {
+		return "", err
+	}
+
+	if err := p.bootstrapRealmContext.Settings().Set("password", password); err != nil {
+		return "", errors.Wrap(err, "failed to save bootstrap password")
+	}
+
+	if err := p.bootstrapRealmContext.Settings().Set("bootstrapped", "false"); err != nil {
+		return "", errors.Wrap(err, "failed to save bootstrap status")
+	}
+
+	return password, nil
+}
+
+// NewBootstrapPassword returns a random password for bootstrapping.
+func NewBootstrapPassword() (string, error) {
+	password, err := crypto.GenerateRandomString(16)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to generate password")
+	}
+
+	return strings.Replace(strings.Replace(password, "-", "", -1), "_", "", -1), nil
+}
+
+// HasMandateForBootstrapRealm checks if any of the mandates is an admin mandate for
+// the bootstrap realm, which administers all realms.
 func (p *RealmsServiceProvider) HasMandateForBootstrapRealm(mandates []httphandler.AuthenticatedMandate) bool {
-	bootstrapRealmTP := crypto.Thumbprint(p.bootstrapRealm.PublicKey)
+	bootstrapRealmTPs := keyThumbprints(p.bootstrapRealm, time.Now())
 	for _, m := range mandates {
 		signerTP := crypto.Thumbprint(m.Signer)
-		if signerTP == bootstrapRealmTP {
-			if m.Mandate.Realm == p.bootstrapRealm.ID {
+		if !bootstrapRealmTPs[signerTP] || m.Mandate.Realm != p.bootstrapRealm.ID {
+			continue
+		}
+		if p.bootstrapRealmContext.Revocations().IsMandateRevoked(m.Mandate) {
+			continue
+		}
+		for _, role := range p.bootstrapRealm.AdminRoles {
+			if m.Mandate.Role == role {
 				return true
 			}
 		}
@@ -167,25 +298,70 @@ func (p *RealmsServiceProvider) HasMandateForBootstrapRealm(mandates []httphandl
 }
 
 func (p *RealmsServiceProvider) ListRealms() ([]*realm.Realm, error) {
-	return p.realms.List()
+	return p.listRealms(false)
 }
 
-func (p *RealmsServiceProvider) Get(realmID string) *RealmService {
-	return NewRealmService(p.base, p, realmID)
+// ListDeletedRealms returns the realms that are deleted but not yet purged.
+func (p *RealmsServiceProvider) ListDeletedRealms() ([]*realm.Realm, error) {
+	return p.listRealms(true)
 }
 
-func (p *RealmsServiceProvider) New(realmData *realm.Realm, key *jose.JsonWebKey) (*realm.Realm, error) {
+func (p *RealmsServiceProvider) listRealms(deleted bool) ([]*realm.Realm, error) {
+	realms, err := p.realms.List()
+	if err != nil {
+		return nil, err
+	}
 
-	if key == nil {
-		var err error
-		key, err = crypto.NewKey()
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to create key for realm")
+	out := make([]*realm.Realm, 0)
+	for _, r := range realms {
+		if (r.Deleted != nil) == deleted {
+			out = append(out, r)
 		}
 	}
 
+	return out, nil
+}
+
+// PurgeDeleted purges the deleted realms whose retention period has ended, and
+// returns the number of purged realms.
+func (p *RealmsServiceProvider) PurgeDeleted(now time.Time) (int, error) {
+	realms, err := p.ListDeletedRealms()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to list deleted realms")
+	}
+
+	count := 0
+	for _, r := range realms {
+		if now.Before(r.Deleted.Add(p.retention)) {
+			continue
+		}
+		if err := p.Get(r.ID).Purge(); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+func (p *RealmsServiceProvider) Get(realmID string) *RealmService {
+	return NewRealmService(p.base, p, realmID)
+}
+
+func (p *RealmsServiceProvider) New(realmData *realm.Realm, key *jose.JsonWebKey) (*realm.Realm, error) {
+	// keys are only set up here and by RealmService.RotateKey
+	realmData.KeyID = ""
+	realmData.RetiredKeys = nil
+
+	var pk *jose.JsonWebKey
+	var err error
 	if realmData.ID == "" {
-		realmData.ID = fmt.Sprintf("%s.%s", crypto.Thumbprint(key), viper.GetString("proxy_domain"))
+		// the realm ID is derived from the key, so the key is created first
+		realmData.KeyID = uuid.NewV4().String()
+		if pk, err = p.createKey(realmData.KeyID, key); err != nil {
+			return nil, err
+		}
+		realmData.ID = fmt.Sprintf("%s.%s", crypto.Thumbprint(pk), viper.GetString("proxy_domain"))
 	}
 
 	re, err := regexp.Compile(`^[0-9|a-z|A-Z||\-\.\:]*$`)
@@ -194,17 +370,20 @@ func (p *RealmsServiceProvider) New(realmData *realm.Realm, key *jose.JsonWebKey
 	}
 
 	if !re.MatchString(realmData.ID) {
+		p.deleteKey(realmData.KeyID)
 		return nil, errors.New("Bad realm ID")
 	}
 
 	_, err = p.realms.Get(realmData.ID)
 	if err == nil {
+		p.deleteKey(realmData.KeyID)
 		return nil, errors.New("Realm already exists")
 	}
 
-	pk, err := crypto.NewPublicKey(key)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to get public key")
+	if pk == nil {
+		if pk, err = p.createKey(realmData.SigningKeyID(), key); err != nil {
+			return nil, err
+		}
 	}
 
 	if len(realmData.AdminRoles) < 1 {
@@ -216,15 +395,6 @@ func (p *RealmsServiceProvider) New(realmData *realm.Realm, key *jose.JsonWebKey
 	realmData.Descriptor = document.NewRealmDescriptor(realmData.ID, pk, fmt.Sprintf("%s/realm/v2/realms/%s/services", p.base, realmData.ID))
 	realmData.Descriptor.Label = realmData.Label
 
-	skey := keys.NewStoredKey(realmData.ID)
-	if err := skey.Encrypt(key, p.kek); err != nil {
-		return nil, errors.Wrap(err, "failed to encrypt private key for realm")
-	}
-
-	if err := p.sks.Save(skey); err != nil {
-		return nil, errors.Wrap(err, "failed to save key for realm")
-	}
-
 	realmData.SignedDescriptor, err = p.signDescriptor(realmData)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to sign descriptor")
@@ -241,51 +411,73 @@ func (p *RealmsServiceProvider) New(realmData *realm.Realm, key *jose.JsonWebKey
 		}
 	}
 
+	p.Publish(realm.NewRealmEvent(realm.EventRealmCreated, realmData.ID, realmData.ID))
+
 	return realmData, nil
 }
 
-func (p *RealmsServiceProvider) signDescriptor(realmData *realm.Realm) (string, error) {
-	descBytes, err := json.Marshal(realmData.Descriptor)
-	if err != nil {
-		return "", errors.Wrap(err, "failed to marshal realm descriptor")
+// createKey stores the given private key for the realm, or creates a new one if
+// none is given, and returns the public key.
+func (p *RealmsServiceProvider) createKey(keyID string, key *jose.JsonWebKey) (*jose.JsonWebKey, error) {
+	if key == nil {
+		pk, err := p.signer.Create(keyID)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create key for realm")
+		}
+		return pk, nil
 	}
 
-	descSigned, err := p.signPayload(realmData.ID, descBytes)
+	if err := p.signer.Import(keyID, key); err != nil {
+		return nil, errors.Wrap(err, "failed to save key for realm")
+	}
+
+	pk, err := crypto.NewPublicKey(key)
 	if err != nil {
-		return "", errors.Wrap(err, "failed to sign descriptor")
+		return nil, errors.Wrap(err, "failed to get public key")
 	}
 
-	return descSigned.FullSerialize(), nil
+	return pk, nil
 }
 
-func (p *RealmsServiceProvider) getKey(realmID string) (*jose.JsonWebKey, error) {
-	skey, err := p.sks.Get(realmID)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to get key")
+func (p *RealmsServiceProvider) deleteKey(keyID string) {
+	if keyID == "" {
+		return
 	}
 
-	return skey.Decrypt(p.kek)
+	if err := p.signer.Delete(keyID); err != nil {
+		logger.Warningf("Failed to delete key %s: %s", keyID, err)
+	}
 }
 
-func (p *RealmsServiceProvider) signPayload(realmID string, payload []byte) (*jose.JsonWebSignature, error) {
-	skey, err := p.sks.Get(realmID)
+// signDescriptor signs the descriptor with the current key of the realm, and with
+// the retired keys that are still accepted, so clients that only know a previous
+// key can verify the descriptor and learn the new key from it.
+func (p *RealmsServiceProvider) signDescriptor(realmData *realm.Realm) (string, error) {
+	descBytes, err := json.Marshal(realmData.Descriptor)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to get key")
+		return "", errors.Wrap(err, "failed to marshal realm descriptor")
 	}
 
-	key, err := skey.Decrypt(p.kek)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to decrypt key")
+	signatures := make([]*jose.JsonWebSignature, 0)
+	for _, keyID := range realmKeyIDs(realmData, time.Now()) {
+		descSigned, err := p.signPayload(keyID, descBytes)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to sign descriptor")
+		}
+		signatures = append(signatures, descSigned)
 	}
 
-	signer, err := crypto.NewSigner(key)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to create signer")
-	}
+	return mergeSignatures(signatures)
+}
 
-	jws, err := signer.Sign(payload)
+func (p *RealmsServiceProvider) getKey(keyID string) (*jose.JsonWebKey, error) {
+	return p.signer.Key(keyID)
+}
+
+func (p *RealmsServiceProvider) signPayload(keyID string, payload []byte) (*jose.JsonWebSignature, error) {
+	jws, err := p.signer.Sign(keyID, payload)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to sign descriptor")
+		return nil, errors.Wrap(err, "failed to sign payload")
 	}
 
 	return jws, nil
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter counts failed attempts per key, e.g. per client address, and blocks
+// a key that has failed max times until the window since its first failure has passed.
+type RateLimiter struct {
+	max      int
+	window   time.Duration
+	failures map[string]*failureWindow
+	mu       sync.Mutex
+}
+
+type failureWindow struct {
+	count int
+	start time.Time
+}
+
+func NewRateLimiter(max int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		max:      max,
+		window:   window,
+		failures: make(map[string]*failureWindow),
+	}
+}
+
+// Blocked returns true if the key has failed too many times within the window.
+func (l *RateLimiter) Blocked(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	w, ok := l.failures[key]
+	if !ok {
+		return false
+	}
+
+	if time.Since(w.start) > l.window {
+		delete(l.failures, key)
+		return false
+	}
+
+	return w.count >= l.max
+}
+
+// Fail records a failed attempt for the key.
+func (l *RateLimiter) Fail(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	w, ok := l.failures[key]
+	if !ok || now.Sub(w.start) > l.window {
+		if len(l.failures) >= 1024 {
+			l.prune(now)
+		}
+		l.failures[key] = &failureWindow{count: 1, start: now}
+		return
+	}
+
+	w.count++
+}
+
+// prune drops the windows that have passed, so keys that stop failing don't
+// accumulate.
+func (l *RateLimiter) prune(now time.Time) {
+	for key, w := range l.failures {
+		if now.Sub(w.start) > l.window {
+			delete(l.failures, key)
+		}
+	}
+}
@@ -2,6 +2,9 @@ package services
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/IpsoVeritas/crypto"
@@ -29,64 +32,166 @@ func NewRealmService(base string, p *RealmsServiceProvider, realmID string) *Rea
 }
 
 func (r *RealmService) Realm() (*realm.Realm, error) {
-	var err error
 	if r.realm == nil {
-		if r.realm, err = r.p.realms.Get(r.realmID); err != nil {
+		realmData, err := r.p.realms.Get(r.realmID)
+		if err != nil {
 			return nil, err
 		}
+		if realmData.Deleted != nil {
+			return nil, realm.ErrRealmDeleted
+		}
+		r.realm = realmData
 	}
 
 	return r.realm, nil
 }
 
-func (r *RealmService) Set(realm *realm.Realm) error {
+func (r *RealmService) Set(realmData *realm.Realm) error {
 	var err error
-	realm.SignedDescriptor, err = r.p.signDescriptor(realm)
+	realmData.SignedDescriptor, err = r.p.signDescriptor(realmData)
 	if err != nil {
 		return err
 	}
 
-	return r.p.realms.Set(realm)
+	if err := r.p.realms.Set(realmData); err != nil {
+		return err
+	}
+
+	r.realm = realmData
+	r.publish(realm.EventRealmUpdated, realmData.ID, nil)
+
+	return nil
 }
 
+// Delete marks the realm as deleted. The realm stops working right away, but it
+// can be restored until it's purged at the end of the retention period.
 func (r *RealmService) Delete() error {
-	actions, err := r.Actions().List()
-	if err == nil {
-		for _, action := range actions {
-			r.Actions().Delete(action.ID)
-		}
+	if r.realmID == r.p.bootstrapRealmID {
+		return errors.New("The bootstrap realm can't be deleted")
 	}
 
-	controllers, err := r.Controllers().List()
-	if err == nil {
-		for _, controller := range controllers {
-			r.Controllers().Delete(controller.ID)
-		}
+	realmData, err := r.Realm()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	realmData.Deleted = &now
+	if err := r.p.realms.Set(realmData); err != nil {
+		return err
+	}
+	r.realm = nil
+
+	r.publish(realm.EventRealmDeleted, r.realmID, map[string]string{
+		"purgeAfter": now.Add(r.p.retention).Format(time.RFC3339),
+	})
+
+	if r.p.retention <= 0 {
+		return r.Purge()
+	}
+
+	return nil
+}
+
+// Restore undoes the deletion of a realm that hasn't been purged yet.
+func (r *RealmService) Restore() (*realm.Realm, error) {
+	realmData, err := r.p.realms.Get(r.realmID)
+	if err != nil {
+		return nil, err
+	}
+
+	if realmData.Deleted == nil {
+		return nil, errors.New("Realm is not deleted")
+	}
+
+	realmData.Deleted = nil
+	if err := r.p.realms.Set(realmData); err != nil {
+		return nil, err
 	}
+	r.realm = realmData
 
-	mandates, err := r.Mandates().List()
-	if err == nil {
-		for _, mandate := range mandates {
-			r.Mandates().Delete(mandate.ID)
+	r.publish(realm.EventRealmRestored, r.realmID, nil)
+
+	return realmData, nil
+}
+
+// Purge permanently deletes the realm and everything that belongs to it. The
+// database is purged in one transaction, after which the keys and files are
+// deleted. Failing to delete a file is only logged.
+func (r *RealmService) Purge() error {
+	if r.p.purger == nil {
+		return errors.New("No realm purger configured")
+	}
+
+	if r.realmID == r.p.bootstrapRealmID {
+		return errors.New("The bootstrap realm can't be deleted")
+	}
+
+	realmData, err := r.p.realms.Get(r.realmID)
+	if err != nil {
+		return err
+	}
+
+	keyIDs := []string{realmData.SigningKeyID()}
+	for _, k := range realmData.RetiredKeys {
+		keyIDs = append(keyIDs, k.KeyID)
+	}
+
+	names := make([]string, 0)
+	if r.p.filestore != nil {
+		if names, err = r.Files().List(); err != nil {
+			return errors.Wrap(err, "failed to list files")
 		}
 	}
 
-	roles, err := r.Roles().List()
-	if err == nil {
-		for _, role := range roles {
-			r.Roles().Delete(role.ID)
+	if err := r.p.purger.Purge(r.realmID, keyIDs); err != nil {
+		return errors.Wrap(err, "failed to purge realm")
+	}
+	r.realm = nil
+
+	// keys that aren't stored in the database, like in a PKCS#11 token, are deleted by the signer
+	for _, keyID := range keyIDs {
+		r.p.deleteKey(keyID)
+	}
+
+	for _, name := range names {
+		if err := r.Files().Delete(name); err != nil {
+			logger.Warningf("Failed to delete file %s of realm %s: %s", name, r.realmID, err)
 		}
 	}
 
-	return r.p.realms.Delete(r.realmID)
+	r.publish(realm.EventRealmPurged, r.realmID, nil)
+
+	return nil
 }
 
+func (r *RealmService) publish(eventType, entityID string, data map[string]string) {
+	event := realm.NewRealmEvent(eventType, r.realmID, entityID)
+	for k, v := range data {
+		event.Data[k] = v
+	}
+
+	r.p.Publish(event)
+}
+
+// Sign signs the payload with the current key of the realm.
 func (r *RealmService) Sign(payload []byte) (*jose.JsonWebSignature, error) {
-	return r.p.signPayload(r.realmID, payload)
+	realmData, err := r.Realm()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get realm")
+	}
+
+	return r.p.signPayload(realmData.SigningKeyID(), payload)
 }
 
+// Key returns the current private key of the realm, if the signer can export it.
 func (r *RealmService) Key() (*jose.JsonWebKey, error) {
-	return r.p.getKey(r.realmID)
+	realmData, err := r.Realm()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get realm")
+	}
+
+	return r.p.getKey(realmData.SigningKeyID())
 }
 
 func (r *RealmService) HasMandateForRealm(mandates []httphandler.AuthenticatedMandate) bool {
@@ -101,25 +206,31 @@ func (r *RealmService) MandatesForRealm(mandates []httphandler.AuthenticatedMand
 		return out
 	}
 
-	// make sure we never trigger the case where everything fails if the bootstrap realm hasn't been loaded
-	bootstrapRealmTP := "something that should never match a thumbprint..."
-	if r.p.bootstrapRealm != nil {
-		bootstrapRealmTP = crypto.Thumbprint(r.p.bootstrapRealm.PublicKey)
-	}
+	// keys of retired realm keys are accepted until their grace period ends, and
+	// an empty set never matches if the bootstrap realm hasn't been loaded
+	now := time.Now()
+	realmTPs := keyThumbprints(realm, now)
+	bootstrapRealmTPs := keyThumbprints(r.p.bootstrapRealm, now)
 
 	realmTP := crypto.Thumbprint(realm.PublicKey)
 	for _, m := range mandates {
 		signerTP := crypto.Thumbprint(m.Signer)
-		switch signerTP {
-		case realmTP:
-			if m.Mandate.Realm == r.realmID {
-				out = append(out, m)
-			} else {
+		switch {
+		case realmTPs[signerTP]:
+			if m.Mandate.Realm != r.realmID {
 				logger.Debugf("Mandate realm does not match context: %s != %s", m.Mandate.Realm, r.realmID)
+			} else if r.Revocations().IsMandateRevoked(m.Mandate) {
+				logger.Debugf("Mandate %s for role %s has been revoked", m.Mandate.ID, m.Mandate.Role)
+			} else {
+				out = append(out, m)
 			}
-		case bootstrapRealmTP:
+		case bootstrapRealmTPs[signerTP]:
 			if m.Mandate.Realm == r.p.bootstrapRealm.ID {
-				out = append(out, m)
+				if r.p.bootstrapRealmContext.Revocations().IsMandateRevoked(m.Mandate) {
+					logger.Debugf("Mandate %s for role %s has been revoked", m.Mandate.ID, m.Mandate.Role)
+				} else {
+					out = append(out, m)
+				}
 			}
 		default:
 			logger.Debugf("Mandate for role %s not signed by realm key. %s != %s", m.Mandate.Role, signerTP, realmTP)
@@ -144,6 +255,9 @@ func (r *RealmService) HasAdminMandateForRealm(mandates []httphandler.Authentica
 			}
 		}
 
+		if r.p.bootstrapRealm == nil {
+			continue
+		}
 		for _, role := range r.p.bootstrapRealm.AdminRoles {
 			if m.Mandate.Role == role {
 				return true
@@ -154,6 +268,140 @@ func (r *RealmService) HasAdminMandateForRealm(mandates []httphandler.Authentica
 	return false
 }
 
+// HasPermission checks if any of the mandates valid for the realm grants the permission.
+// Mandates for an admin role have all permissions.
+func (r *RealmService) HasPermission(mandates []httphandler.AuthenticatedMandate, permission string) bool {
+	for _, p := range r.PermissionsFor(mandates) {
+		if p == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PermissionsFor returns the permissions granted by the mandates valid for the realm.
+func (r *RealmService) PermissionsFor(mandates []httphandler.AuthenticatedMandate) []string {
+	if r.HasAdminMandateForRealm(mandates) {
+		return realm.Permissions
+	}
+
+	granted := make(map[string]bool)
+	for _, m := range r.MandatesForRealm(mandates) {
+		permissions, err := r.Roles().Permissions(m.Mandate.Role)
+		if err != nil {
+			logger.Debugf("Failed to get permissions for role %s: %s", m.Mandate.Role, err)
+			continue
+		}
+		for _, p := range permissions {
+			granted[p] = true
+		}
+	}
+
+	out := make([]string, 0)
+	for _, p := range realm.Permissions {
+		if granted[p] {
+			out = append(out, p)
+		}
+	}
+
+	return out
+}
+
+// IsAdminRole checks if mandates for the role are admin mandates for the realm.
+func (r *RealmService) IsAdminRole(role string) (bool, error) {
+	realmData, err := r.Realm()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get realm")
+	}
+
+	adminRoles := append([]string{}, realmData.AdminRoles...)
+	if r.p.bootstrapRealm != nil {
+		adminRoles = append(adminRoles, r.p.bootstrapRealm.AdminRoles...)
+	}
+
+	for _, name := range adminRoles {
+		if name == role {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// CanGrant checks if the mandates may hand out the role, by issuing a mandate or
+// through an invite or a ticket. Admin roles can only be handed out by admins, and
+// other roles only if the mandates have all the permissions of the role.
+func (r *RealmService) CanGrant(mandates []httphandler.AuthenticatedMandate, role string) error {
+	if r.HasAdminMandateForRealm(mandates) {
+		return nil
+	}
+
+	isAdmin, err := r.IsAdminRole(role)
+	if err != nil {
+		return err
+	}
+	if isAdmin {
+		return errors.Errorf("Only admins can grant the admin role %s", role)
+	}
+
+	permissions, err := r.Roles().Permissions(role)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get permissions for role %s", role)
+	}
+
+	return r.CanGrantPermissions(mandates, permissions)
+}
+
+// CanGrantPermissions checks that the mandates have all of the permissions.
+func (r *RealmService) CanGrantPermissions(mandates []httphandler.AuthenticatedMandate, permissions []string) error {
+	granted := make(map[string]bool)
+	for _, p := range r.PermissionsFor(mandates) {
+		granted[p] = true
+	}
+
+	for _, p := range permissions {
+		if !granted[p] {
+			return errors.Errorf("Can't grant permission %s without having it", p)
+		}
+	}
+
+	return nil
+}
+
+// CanSetAdminRoles checks if the mandates may change the admin roles of the realm
+// to adminRoles, which only admins can.
+func (r *RealmService) CanSetAdminRoles(mandates []httphandler.AuthenticatedMandate, adminRoles []string) error {
+	realmData, err := r.Realm()
+	if err != nil {
+		return errors.Wrap(err, "failed to get realm")
+	}
+
+	if sameRoles(realmData.AdminRoles, adminRoles) || r.HasAdminMandateForRealm(mandates) {
+		return nil
+	}
+
+	return errors.New("Only admins can change the admin roles")
+}
+
+func sameRoles(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	a = append([]string{}, a...)
+	b = append([]string{}, b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
 func (r *RealmService) Actions() *ActionService {
 	return &ActionService{
 		base:             r.base,
@@ -176,6 +424,7 @@ func (r *RealmService) Invites() *InviteService {
 	return &InviteService{
 		base:    r.base,
 		p:       r.p.invites,
+		outbox:  r.p.outbox,
 		realmID: r.realmID,
 		realm:   r,
 		email:   r.p.email,
@@ -191,17 +440,27 @@ func (r *RealmService) Mandates() *MandateService {
 	}
 }
 
+func (r *RealmService) Revocations() *RevocationService {
+	return &RevocationService{
+		p:       r.p.revocations,
+		realmID: r.realmID,
+		realm:   r,
+	}
+}
+
 func (r *RealmService) MandateTickets() *MandateTicketService {
 	return &MandateTicketService{
-		p:       r.p.mandateTickets,
-		realmID: r.realmID,
+		p:            r.p.mandateTickets,
+		realmID:      r.realmID,
+		realmContext: r,
 	}
 }
 
 func (r *RealmService) Roles() *RoleService {
 	return &RoleService{
-		p:       r.p.roles,
-		realmID: r.realmID,
+		p:           r.p.roles,
+		permissions: r.p.permissions,
+		realmID:     r.realmID,
 	}
 }
 
@@ -212,6 +471,61 @@ func (r *RealmService) Settings() *SettingService {
 	}
 }
 
+func (r *RealmService) Issuers() *IssuerService {
+	return &IssuerService{
+		settings: r.Settings(),
+		global:   r.p.keyset,
+	}
+}
+
+// Keyset returns the trusted fact issuers for the realm.
+func (r *RealmService) Keyset() (*jose.JsonWebKeySet, error) {
+	return r.Issuers().Keyset()
+}
+
+func (r *RealmService) Webhooks() *WebhookService {
+	return &WebhookService{
+		p:          r.p.webhooks,
+		deliveries: r.p.webhookDeliveries,
+		realmID:    r.realmID,
+		realm:      r,
+	}
+}
+
+func (r *RealmService) Audit() *AuditService {
+	return &AuditService{
+		p:       r.p.audit,
+		realmID: r.realmID,
+	}
+}
+
+// AuditActor describes the caller from the key of the request and the first of its mandates that is valid for the realm.
+func (r *RealmService) AuditActor(key *jose.JsonWebKey, mandates []httphandler.AuthenticatedMandate) realm.AuditActor {
+	actor := realm.AuditActor{}
+	if key != nil {
+		actor.Key = crypto.Thumbprint(key)
+	}
+
+	realmMandates := r.MandatesForRealm(mandates)
+	if len(realmMandates) > 0 {
+		m := realmMandates[0]
+		actor.MandateID = m.Mandate.ID
+		actor.Role = m.Mandate.Role
+		actor.Signer = crypto.Thumbprint(m.Signer)
+	}
+
+	return actor
+}
+
+func (r *RealmService) Templates() *TemplateService {
+	return &TemplateService{
+		files:   r.Files(),
+		assets:  r.p.assets,
+		realmID: r.realmID,
+		realm:   r,
+	}
+}
+
 func (r *RealmService) Files() *FileService {
 	return &FileService{
 		p:       r.p.filestore,
@@ -219,12 +533,13 @@ func (r *RealmService) Files() *FileService {
 	}
 }
 
-func (r *RealmService) Join(jws *jose.JsonWebSignature) (*document.Multipart, error) {
-
-	var userKey *jose.JsonWebKey
-	var action document.Action
-	var certificate *document.Certificate
+// ErrJoinRejected is the cause of JoinCallback errors where the user did not
+// accept the contract or did not provide the required facts.
+var ErrJoinRejected = errors.New("join rejected")
 
+// JoinRequest returns a signed scope-request asking the user for the facts
+// required to join the realm as a guest and for accepting the guest contract.
+func (r *RealmService) JoinRequest() (*jose.JsonWebSignature, error) {
 	realm, err := r.Realm()
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get realm")
@@ -234,30 +549,89 @@ func (r *RealmService) Join(jws *jose.JsonWebSignature) (*document.Multipart, er
 		return nil, errors.New("No public role set")
 	}
 
-	if len(jws.Signatures) < 1 {
-		return nil, errors.Wrap(err, "no key in signature")
+	role, err := r.Roles().ByName(realm.GuestRole)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get role")
+	}
+
+	scopeRequest := document.NewScopeRequest(role.KeyLevel)
+	scopeRequest.ReplyTo = []string{
+		fmt.Sprintf("%s/realm/v2/realms/%s/do/join/callback", r.base, r.realmID),
+	}
+	scopeRequest.KeyLevel = role.KeyLevel
+	scopeRequest.Scopes = realm.GuestScopes
+
+	scopeRequest.Contract = document.NewContract()
+	scopeRequest.Contract.Text, err = r.joinContract(realm, role)
+	if err != nil {
+		return nil, err
 	}
 
-	actionBytes, err := jws.Verify(jws.Signatures[0].Header.JsonWebKey)
+	scopeReqBytes, err := json.Marshal(scopeRequest)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to verify signature")
+		return nil, errors.Wrap(err, "failed to marshal scope-request")
 	}
 
-	if err := json.Unmarshal(actionBytes, &action); err != nil {
-		return nil, errors.Wrap(err, "failed to unmarshal scope-request")
+	jws, err := r.Sign(scopeReqBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign scope-request")
 	}
 
-	if action.Certificate != "" {
-		certificate, err = crypto.VerifyCertificate(action.Certificate, 1000)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to verify certificate")
-		}
+	return jws, nil
+}
+
+// joinContract returns the text of the contract for joining the realm as a guest.
+func (r *RealmService) joinContract(realm *realm.Realm, role *document.Role) (string, error) {
+	if realm.GuestContract != "" {
+		return realm.GuestContract, nil
+	}
+
+	label := realm.Label
+	if label == "" {
+		label = realm.ID
+	}
+
+	text, err := renderLocalized(r.p.assets, "join_contract", "template.txt", localeCandidates(realm.Locale), map[string]interface{}{
+		"roleName": role.Description,
+		"realm":    label,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to render contract")
+	}
+
+	return text, nil
+}
+
+// checkJoinResponse checks that the user accepted the contract of the realm and
+// provided a fact for every required guest scope.
+func (r *RealmService) checkJoinResponse(realm *realm.Realm, role *document.Role, response *ScopeResponse) error {
+	contract, err := r.joinContract(realm, role)
+	if err != nil {
+		return err
+	}
+
+	if err := response.checkContract(contract); err != nil {
+		return errors.Wrap(ErrJoinRejected, err.Error())
+	}
+
+	if missing := response.missingScopes(realm.GuestScopes); len(missing) > 0 {
+		return errors.Wrapf(ErrJoinRejected, "missing facts %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// JoinCallback verifies the scope-response to a JoinRequest and issues a
+// mandate for the guest role when the contract is accepted and all required
+// facts are provided by trusted issuers.
+func (r *RealmService) JoinCallback(jws *jose.JsonWebSignature) (*document.Multipart, error) {
+	realm, err := r.Realm()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get realm")
 	}
 
-	if certificate != nil {
-		userKey = certificate.Issuer
-	} else {
-		userKey = jws.Signatures[0].Header.JsonWebKey
+	if realm.GuestRole == "" {
+		return nil, errors.New("No public role set")
 	}
 
 	role, err := r.Roles().ByName(realm.GuestRole)
@@ -265,16 +639,30 @@ func (r *RealmService) Join(jws *jose.JsonWebSignature) (*document.Multipart, er
 		return nil, errors.Wrap(err, "could not get role")
 	}
 
+	keyset, err := r.Keyset()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get trusted issuers")
+	}
+
+	response, err := parseScopeResponse(jws, role.KeyLevel, keyset)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.checkJoinResponse(realm, role, response); err != nil {
+		return nil, err
+	}
+
 	mandate := document.NewMandate(realm.GuestRole)
 	mandate.Realm = r.realmID
 	mandate.RoleName = role.Description
 
 	now := time.Now().UTC()
 	mandate.ValidFrom = &now
-	mandate.Recipient = userKey
+	mandate.Recipient = response.Key
 	mandate.Sender = "realm"
 
-	issued, err := r.Mandates().Issue(mandate, crypto.Thumbprint(userKey))
+	issued, err := r.Mandates().Issue(mandate, response.recipientName())
 	if err != nil {
 		return nil, errors.Wrap(err, "could not issue mandate")
 	}
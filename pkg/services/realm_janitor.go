@@ -0,0 +1,50 @@
+package services
+
+import (
+	"time"
+
+	logger "github.com/IpsoVeritas/logger"
+)
+
+// RealmJanitor periodically purges deleted realms at the end of their retention period.
+type RealmJanitor struct {
+	p    *RealmsServiceProvider
+	stop chan struct{}
+}
+
+func NewRealmJanitor(p *RealmsServiceProvider) *RealmJanitor {
+	return &RealmJanitor{
+		p:    p,
+		stop: make(chan struct{}),
+	}
+}
+
+func (j *RealmJanitor) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				j.Process()
+			case <-j.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (j *RealmJanitor) Stop() {
+	close(j.stop)
+}
+
+// Process purges all deleted realms whose retention period has ended.
+func (j *RealmJanitor) Process() {
+	count, err := j.p.PurgeDeleted(time.Now().UTC())
+	if count > 0 {
+		logger.Infof("Purged %d deleted realms", count)
+	}
+	if err != nil {
+		logger.Warningf("Failed to purge deleted realms: %s", err)
+	}
+}
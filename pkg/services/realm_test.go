@@ -0,0 +1,250 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/IpsoVeritas/document"
+	"github.com/IpsoVeritas/httphandler"
+	realm "github.com/IpsoVeritas/realm"
+)
+
+// helpdesk has the permissions to hand out roles, but not audit:read.
+var helpdeskPermissions = []string{
+	realm.PermissionRolesWrite,
+	realm.PermissionInvitesWrite,
+	realm.PermissionInvitesSend,
+	realm.PermissionMandatesIssue,
+	realm.PermissionRealmWrite,
+	realm.PermissionControllersBind,
+}
+
+func newGrantProvider(t *testing.T) *RealmsServiceProvider {
+	p := newProvider(t)
+	setRole(t, p, "helpdesk@test.realm", helpdeskPermissions...)
+	setRole(t, p, "support@test.realm", realm.PermissionInvitesWrite)
+	setRole(t, p, "auditor@test.realm", realm.PermissionAuditRead)
+	setRole(t, p, "admin@test.realm")
+
+	return p
+}
+
+func TestRealmService_CanGrant(t *testing.T) {
+	tests := []struct {
+		name    string
+		caller  string
+		revoked bool
+		role    string
+		wantErr bool
+	}{
+		{
+			name:    "CanGrant_role_with_fewer_permissions",
+			caller:  "helpdesk@test.realm",
+			role:    "support@test.realm",
+			wantErr: false,
+		},
+		{
+			name:    "CanGrant_own_role",
+			caller:  "helpdesk@test.realm",
+			role:    "helpdesk@test.realm",
+			wantErr: false,
+		},
+		{
+			name:    "CanGrant_role_with_other_permissions",
+			caller:  "helpdesk@test.realm",
+			role:    "auditor@test.realm",
+			wantErr: true,
+		},
+		{
+			name:    "CanGrant_admin_role",
+			caller:  "helpdesk@test.realm",
+			role:    "admin@test.realm",
+			wantErr: true,
+		},
+		{
+			name:    "CanGrant_bootstrap_admin_role",
+			caller:  "helpdesk@test.realm",
+			role:    "admin@bootstrap",
+			wantErr: true,
+		},
+		{
+			name:    "CanGrant_admin_grants_admin_role",
+			caller:  "admin@test.realm",
+			role:    "admin@test.realm",
+			wantErr: false,
+		},
+		{
+			name:    "CanGrant_admin_grants_role_with_other_permissions",
+			caller:  "admin@test.realm",
+			role:    "auditor@test.realm",
+			wantErr: false,
+		},
+		{
+			name:    "CanGrant_revoked_admin",
+			caller:  "admin@test.realm",
+			revoked: true,
+			role:    "admin@test.realm",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		p := newGrantProvider(t)
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.revoked {
+				if err := p.revocations.Set(testRealm, &realm.Revocation{MandateID: "caller"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err := p.Get(testRealm).CanGrant(mandateFor(t, p, "caller", tt.caller), tt.role)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RealmService.CanGrant() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRealmService_CanGrantPermissions(t *testing.T) {
+	tests := []struct {
+		name        string
+		caller      string
+		permissions []string
+		wantErr     bool
+	}{
+		{
+			name:        "CanGrantPermissions_subset",
+			caller:      "helpdesk@test.realm",
+			permissions: []string{realm.PermissionInvitesWrite},
+			wantErr:     false,
+		},
+		{
+			name:        "CanGrantPermissions_own",
+			caller:      "helpdesk@test.realm",
+			permissions: helpdeskPermissions,
+			wantErr:     false,
+		},
+		{
+			name:        "CanGrantPermissions_add_to_own_role",
+			caller:      "helpdesk@test.realm",
+			permissions: append([]string{realm.PermissionAuditRead}, helpdeskPermissions...),
+			wantErr:     true,
+		},
+		{
+			name:        "CanGrantPermissions_admin",
+			caller:      "admin@test.realm",
+			permissions: []string{realm.PermissionAuditRead, realm.PermissionRealmDelete},
+			wantErr:     false,
+		},
+	}
+	for _, tt := range tests {
+		p := newGrantProvider(t)
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.Get(testRealm).CanGrantPermissions(mandateFor(t, p, "caller", tt.caller), tt.permissions)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RealmService.CanGrantPermissions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRealmService_CanSetAdminRoles(t *testing.T) {
+	tests := []struct {
+		name       string
+		caller     string
+		adminRoles []string
+		wantErr    bool
+	}{
+		{
+			name:       "CanSetAdminRoles_unchanged",
+			caller:     "helpdesk@test.realm",
+			adminRoles: []string{"admin@test.realm"},
+			wantErr:    false,
+		},
+		{
+			name:       "CanSetAdminRoles_add_own_role",
+			caller:     "helpdesk@test.realm",
+			adminRoles: []string{"admin@test.realm", "helpdesk@test.realm"},
+			wantErr:    true,
+		},
+		{
+			name:       "CanSetAdminRoles_remove",
+			caller:     "helpdesk@test.realm",
+			adminRoles: []string{},
+			wantErr:    true,
+		},
+		{
+			name:       "CanSetAdminRoles_admin",
+			caller:     "admin@test.realm",
+			adminRoles: []string{"admin@test.realm", "helpdesk@test.realm"},
+			wantErr:    false,
+		},
+	}
+	for _, tt := range tests {
+		p := newGrantProvider(t)
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.Get(testRealm).CanSetAdminRoles(mandateFor(t, p, "caller", tt.caller), tt.adminRoles)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RealmService.CanSetAdminRoles() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRealmService_IsAdminRole(t *testing.T) {
+	tests := []struct {
+		name    string
+		realmID string
+		role    string
+		want    bool
+		wantErr bool
+	}{
+		{name: "IsAdminRole", realmID: testRealm, role: "admin@test.realm", want: true},
+		{name: "IsAdminRole_bootstrap", realmID: testRealm, role: "admin@bootstrap", want: true},
+		{name: "IsAdminRole_not_admin", realmID: testRealm, role: "helpdesk@test.realm", want: false},
+		{name: "IsAdminRole_unknown_realm", realmID: "unknown.realm", role: "admin@unknown.realm", wantErr: true},
+	}
+	p := newGrantProvider(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.Get(tt.realmID).IsAdminRole(tt.role)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RealmService.IsAdminRole() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("RealmService.IsAdminRole() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRealmsServiceProvider_HasMandateForBootstrapRealm(t *testing.T) {
+	tests := []struct {
+		name    string
+		realmID string
+		role    string
+		revoked bool
+		want    bool
+	}{
+		{name: "HasMandateForBootstrapRealm_admin", realmID: testBootstrapRealm, role: "admin@bootstrap", want: true},
+		{name: "HasMandateForBootstrapRealm_not_admin", realmID: testBootstrapRealm, role: "user@bootstrap", want: false},
+		{name: "HasMandateForBootstrapRealm_revoked", realmID: testBootstrapRealm, role: "admin@bootstrap", revoked: true, want: false},
+		{name: "HasMandateForBootstrapRealm_other_realm", realmID: testRealm, role: "admin@bootstrap", want: false},
+	}
+	for _, tt := range tests {
+		p := newProvider(t)
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.revoked {
+				if err := p.revocations.Set(testBootstrapRealm, &realm.Revocation{MandateID: "caller"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			mandate := document.NewMandate(tt.role)
+			mandate.ID = "caller"
+			mandate.Realm = tt.realmID
+			mandates := []httphandler.AuthenticatedMandate{{Mandate: mandate, Signer: p.bootstrapRealm.PublicKey}}
+
+			if got := p.HasMandateForBootstrapRealm(mandates); got != tt.want {
+				t.Errorf("RealmsServiceProvider.HasMandateForBootstrapRealm() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"encoding/json"
+	"time"
+
+	crypto "github.com/IpsoVeritas/crypto"
+	document "github.com/IpsoVeritas/document"
+	logger "github.com/IpsoVeritas/logger"
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	jose "gopkg.in/square/go-jose.v1"
+)
+
+type RevocationService struct {
+	p       realm.RevocationProvider
+	realmID string
+	realm   *RealmService
+}
+
+func (r *RevocationService) List() ([]*realm.Revocation, error) {
+	return r.p.List(r.realmID)
+}
+
+func (r *RevocationService) ListSince(since time.Time) ([]*realm.Revocation, error) {
+	return r.p.ListSince(r.realmID, since)
+}
+
+func (r *RevocationService) Get(mandateID string) (*realm.Revocation, error) {
+	return r.p.Get(r.realmID, mandateID)
+}
+
+func (r *RevocationService) Set(revocation *realm.Revocation) error {
+	revocation.Realm = r.realmID
+	return r.p.Set(r.realmID, revocation)
+}
+
+// IsRevoked checks if the mandate with the ID, or the signed mandate with the
+// checksum, has been revoked. Either can be empty. A mandate is treated as
+// revoked if the revocations can't be read.
+func (r *RevocationService) IsRevoked(mandateID, checksum string) bool {
+	if mandateID != "" && r.revoked(r.p.Get(r.realmID, mandateID)) {
+		return true
+	}
+
+	if checksum != "" && r.revoked(r.p.GetByChecksum(r.realmID, checksum)) {
+		return true
+	}
+
+	return false
+}
+
+// IsMandateRevoked checks if an authenticated mandate has been revoked, by its ID
+// or by the checksum of the signed mandate the realm issued with that ID.
+func (r *RevocationService) IsMandateRevoked(mandate *document.Mandate) bool {
+	checksum := ""
+	if issued, err := r.realm.p.mandates.Get(r.realmID, mandate.ID); err == nil && issued.Signed != "" {
+		checksum = crypto.Sha256(issued.Signed)
+	}
+
+	return r.IsRevoked(mandate.ID, checksum)
+}
+
+func (r *RevocationService) revoked(_ *realm.Revocation, err error) bool {
+	if err == nil {
+		return true
+	}
+
+	if !gorm.IsRecordNotFoundError(err) {
+		logger.Errorf("Failed to check revocations for realm %s: %s", r.realmID, err)
+		return true
+	}
+
+	return false
+}
+
+// SignedList returns the revocation list for the realm signed by the realm key.
+// If since is set only revocations made after that time are included, which lets
+// bound controllers fetch the changes since their last poll.
+func (r *RevocationService) SignedList(since *time.Time) (*jose.JsonWebSignature, error) {
+	var revocations []*realm.Revocation
+	var err error
+	if since != nil {
+		revocations, err = r.ListSince(*since)
+	} else {
+		revocations, err = r.List()
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list revocations")
+	}
+
+	now := time.Now().UTC()
+	list := &realm.RevocationList{
+		Base: document.Base{
+			Type:      "revocation-list",
+			Timestamp: &now,
+			Realm:     r.realmID,
+		},
+		Since:       since,
+		Revocations: revocations,
+	}
+
+	bytes, err := json.Marshal(list)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal revocation list")
+	}
+
+	return r.realm.Sign(bytes)
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/IpsoVeritas/crypto"
+	"github.com/IpsoVeritas/document"
+	realm "github.com/IpsoVeritas/realm"
+)
+
+func TestRevocationService_IsMandateRevoked(t *testing.T) {
+	tests := []struct {
+		name       string
+		revocation *realm.Revocation
+		want       bool
+	}{
+		{name: "IsMandateRevoked_not_revoked", revocation: nil, want: false},
+		{name: "IsMandateRevoked_by_id", revocation: &realm.Revocation{MandateID: "caller"}, want: true},
+		{name: "IsMandateRevoked_by_checksum", revocation: &realm.Revocation{MandateID: "other", Checksum: crypto.Sha256("signed")}, want: true},
+		{name: "IsMandateRevoked_other_mandate", revocation: &realm.Revocation{MandateID: "other", Checksum: crypto.Sha256("other")}, want: false},
+	}
+	for _, tt := range tests {
+		p := newProvider(t)
+		t.Run(tt.name, func(t *testing.T) {
+			mandate := document.NewMandate("admin@test.realm")
+			mandate.ID = "caller"
+			if err := p.mandates.Set(testRealm, &realm.IssuedMandate{Mandate: *mandate, Signed: "signed"}); err != nil {
+				t.Fatal(err)
+			}
+			if tt.revocation != nil {
+				if err := p.revocations.Set(testRealm, tt.revocation); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if got := p.Get(testRealm).Revocations().IsMandateRevoked(mandate); got != tt.want {
+				t.Errorf("RevocationService.IsMandateRevoked() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,13 +1,22 @@
 package services
 
 import (
+	"fmt"
+
 	document "github.com/IpsoVeritas/document"
 	realm "github.com/IpsoVeritas/realm"
+	"github.com/pkg/errors"
 )
 
+// ErrRoleRenamed is returned by Set for an existing role with a new name. Roles
+// are referred to by name, by mandates, invites and permissions, so they can't be
+// renamed.
+var ErrRoleRenamed = errors.New("Roles can't be renamed")
+
 type RoleService struct {
-	p       realm.RoleProvider
-	realmID string
+	p           realm.RoleProvider
+	permissions realm.PermissionProvider
+	realmID     string
 }
 
 func (r *RoleService) List() ([]*document.Role, error) {
@@ -23,9 +32,48 @@ func (r *RoleService) ByName(name string) (*document.Role, error) {
 }
 
 func (r *RoleService) Set(role *document.Role) error {
+	if role.ID != "" {
+		if existing, err := r.Get(role.ID); err == nil && existing.Name != role.Name {
+			return ErrRoleRenamed
+		}
+	}
+
 	return r.p.Set(r.realmID, role)
 }
 
 func (r *RoleService) Delete(id string) error {
+	role, err := r.Get(id)
+	if err == nil {
+		if err := r.permissions.Delete(r.realmID, role.Name); err != nil {
+			return errors.Wrap(err, "failed to delete permissions for role")
+		}
+	}
+
 	return r.p.Delete(r.realmID, id)
 }
+
+func (r *RoleService) Permissions(name string) ([]string, error) {
+	return r.permissions.Get(r.realmID, name)
+}
+
+func (r *RoleService) SetPermissions(name string, permissions []string) error {
+	for _, p := range permissions {
+		if !realm.IsPermission(p) {
+			return fmt.Errorf("unknown permission %s", p)
+		}
+	}
+
+	return r.permissions.Set(r.realmID, name, permissions)
+}
+
+func (r *RoleService) WithPermissions(role *document.Role) (*realm.RoleWithPermissions, error) {
+	permissions, err := r.Permissions(role.Name)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get permissions for role")
+	}
+
+	return &realm.RoleWithPermissions{
+		Role:        *role,
+		Permissions: permissions,
+	}, nil
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/IpsoVeritas/document"
+)
+
+func TestRoleService_Set(t *testing.T) {
+	tests := []struct {
+		name    string
+		newName string
+		wantErr error
+	}{
+		{name: "Set_same_name", newName: "support@test.realm", wantErr: nil},
+		{name: "Set_rename", newName: "helpdesk@test.realm", wantErr: ErrRoleRenamed},
+	}
+	for _, tt := range tests {
+		p := newProvider(t)
+		setRole(t, p, "support@test.realm", "invites:read")
+		t.Run(tt.name, func(t *testing.T) {
+			roles := p.Get(testRealm).Roles()
+			role, err := roles.ByName("support@test.realm")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			role.Name = tt.newName
+			role.Description = "Support"
+			if err := roles.Set(role); err != tt.wantErr {
+				t.Fatalf("RoleService.Set() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			permissions, err := roles.Permissions("support@test.realm")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(permissions) != 1 {
+				t.Errorf("RoleService.Permissions() = %v, want the permissions kept", permissions)
+			}
+		})
+	}
+}
+
+func TestRoleService_Set_new(t *testing.T) {
+	p := newProvider(t)
+	if err := p.Get(testRealm).Roles().Set(document.NewRole("support@test.realm")); err != nil {
+		t.Errorf("RoleService.Set() error = %v", err)
+	}
+}
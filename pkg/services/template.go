@@ -0,0 +1,487 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	realm "github.com/IpsoVeritas/realm"
+	messaging "github.com/IpsoVeritas/realm/pkg/providers/messaging"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultTemplateDir = "invite_email"
+	templateDir        = "templates/invite_email"
+	// qrAttachment is the inline attachment holding a QR code of the invite URL,
+	// which the HTML template shows with cid:invite-qr.png.
+	qrAttachment = "invite-qr.png"
+)
+
+// ErrInvalidTemplate is the cause of errors for templates or attachments that
+// can not be used.
+var ErrInvalidTemplate = errors.New("invalid template")
+
+var attachmentName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_\-\.]*\.(png|jpg|jpeg|gif)$`)
+
+// TemplateService manages the invite email templates of a realm. Customized
+// templates and attachments are stored in the Filestore, and anything the
+// realm has not customized is read from the default assets.
+type TemplateService struct {
+	files   *FileService
+	assets  realm.AssetProvider
+	realmID string
+	realm   *RealmService
+}
+
+// Custom returns the templates the realm has customized for the locale. The
+// templates for the locale of the realm are stored without a locale, along with
+// the attachments, and are returned for an empty locale.
+func (t *TemplateService) Custom(locale string) (*realm.InviteTemplates, error) {
+	templates := &realm.InviteTemplates{}
+	if t.files.p == nil {
+		return templates, nil
+	}
+
+	b, err := t.files.Read(t.customPath(locale))
+	if err != nil {
+		// nothing has been customized yet
+		return templates, nil
+	}
+
+	if err := json.Unmarshal(b, templates); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal templates")
+	}
+
+	return templates, nil
+}
+
+// customPath returns the file of the templates customized for the locale.
+func (t *TemplateService) customPath(locale string) string {
+	candidates := localeCandidates(locale)
+	if len(candidates) == 0 || candidates[0] == t.realmLocale() {
+		return templateDir + "/templates.json"
+	}
+
+	return templateDir + "/" + candidates[0] + "/templates.json"
+}
+
+func (t *TemplateService) realmLocale() string {
+	if realmData, err := t.realm.Realm(); err == nil {
+		if candidates := localeCandidates(realmData.Locale); len(candidates) > 0 {
+			return candidates[0]
+		}
+	}
+
+	return DefaultLocale
+}
+
+// Email returns the templates used to send invite emails for the realm. Each
+// template is taken from the first of the locales that has a customized or a
+// default translation. The templates the realm has customized without a locale
+// are only used when none of the locales has one.
+func (t *TemplateService) Email(locales []string) (*realm.InviteTemplates, error) {
+	custom, err := t.Custom("")
+	if err != nil {
+		return nil, err
+	}
+
+	templates := &realm.InviteTemplates{}
+
+	if templates.Subject, err = t.localized("subject.txt", locales, custom, func(c *realm.InviteTemplates) string { return c.Subject }); err != nil {
+		return nil, errors.Wrap(err, "Couldn't read email subject template")
+	}
+
+	if templates.Text, err = t.localized("template.txt", locales, custom, func(c *realm.InviteTemplates) string { return c.Text }); err != nil {
+		return nil, errors.Wrap(err, "Couldn't read text email template")
+	}
+
+	if templates.HTML, err = t.localized("template.html", locales, custom, func(c *realm.InviteTemplates) string { return c.HTML }); err != nil {
+		return nil, errors.Wrap(err, "Couldn't read HTML email template")
+	}
+
+	attachments, err := t.assets.List(defaultTemplateDir + "/attachments")
+	if err != nil {
+		return nil, errors.Wrap(err, "Couldn't list attachments directory")
+	}
+	templates.Attachments = mergeNames(attachments, custom.Attachments)
+
+	return templates, nil
+}
+
+// localized returns the template picked from the customized templates or read
+// from the default asset name, for the first of the locales that has one. English
+// is always available, so locales after DefaultLocale are not considered.
+func (t *TemplateService) localized(name string, locales []string, custom *realm.InviteTemplates, pick func(*realm.InviteTemplates) string) (string, error) {
+	english := false
+	for _, locale := range locales {
+		if english && !isDefaultLocale(locale) {
+			break
+		}
+
+		localeCustom, err := t.Custom(locale)
+		if err != nil {
+			return "", err
+		}
+		if v := pick(localeCustom); v != "" {
+			return v, nil
+		}
+
+		// a regional variant of English is followed by English, which may be customized
+		if isDefaultLocale(locale) {
+			english = true
+			continue
+		}
+		if v, err := t.readDefault(name, locale); err == nil {
+			return v, nil
+		}
+	}
+
+	if !english {
+		if v := pick(custom); v != "" {
+			return v, nil
+		}
+	}
+
+	return t.readDefault(name, "")
+}
+
+// readDefault reads the default template for the locale, or the English one if
+// the locale is empty.
+func (t *TemplateService) readDefault(name, locale string) (string, error) {
+	path := defaultTemplateDir + "/" + name
+	if locale != "" {
+		path = defaultTemplateDir + "/" + locale + "/" + name
+	}
+
+	b, err := t.assets.Read(path)
+	if err != nil {
+		return "", err
+	}
+
+	if name == "subject.txt" {
+		return strings.TrimSpace(string(b)), nil
+	}
+
+	return string(b), nil
+}
+
+// Set validates the templates with a test render and saves them for the locale.
+// Attachments are managed with AddAttachment and RemoveAttachment.
+func (t *TemplateService) Set(templates *realm.InviteTemplates, locale string) error {
+	if t.files.p == nil {
+		return errors.New("No filestore configured")
+	}
+
+	// an invalid locale would be stored as the templates of the realm locale
+	if locale != "" && len(localeCandidates(locale)) == 0 {
+		return errors.Wrapf(ErrInvalidTemplate, "locale %s", locale)
+	}
+
+	if err := validateTemplates(templates, t.SampleData()); err != nil {
+		return err
+	}
+
+	custom, err := t.Custom(locale)
+	if err != nil {
+		return err
+	}
+	templates.Attachments = custom.Attachments
+
+	return t.save(templates, locale)
+}
+
+func (t *TemplateService) save(templates *realm.InviteTemplates, locale string) error {
+	b, err := json.Marshal(templates)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal templates")
+	}
+
+	if _, err := t.files.Write(t.customPath(locale), bytes.NewReader(b)); err != nil {
+		return errors.Wrap(err, "failed to write templates")
+	}
+
+	return nil
+}
+
+// AddAttachment stores an inline image that the HTML template can refer to as cid:<name>.
+// An attachment with the same name as a default attachment replaces it.
+func (t *TemplateService) AddAttachment(name string, file io.Reader) (string, error) {
+	if t.files.p == nil {
+		return "", errors.New("No filestore configured")
+	}
+
+	if !attachmentName.MatchString(name) || name == qrAttachment {
+		return "", errors.Wrapf(ErrInvalidTemplate, "attachment name %s", name)
+	}
+
+	custom, err := t.Custom("")
+	if err != nil {
+		return "", err
+	}
+
+	location, err := t.files.Write(templateDir+"/attachments/"+name, file)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to write attachment")
+	}
+
+	custom.Attachments = mergeNames(custom.Attachments, []string{name})
+	if err := t.save(custom, ""); err != nil {
+		return "", err
+	}
+
+	return location, nil
+}
+
+// RemoveAttachment stops using an uploaded attachment.
+func (t *TemplateService) RemoveAttachment(name string) error {
+	custom, err := t.Custom("")
+	if err != nil {
+		return err
+	}
+
+	attachments := make([]string, 0)
+	for _, a := range custom.Attachments {
+		if a != name {
+			attachments = append(attachments, a)
+		}
+	}
+	if len(attachments) == len(custom.Attachments) {
+		return fmt.Errorf("Attachment %s not found", name)
+	}
+	custom.Attachments = attachments
+
+	return t.save(custom, "")
+}
+
+// attachment reads an attachment uploaded by the realm, or the default one.
+func (t *TemplateService) attachment(name string, custom *realm.InviteTemplates) ([]byte, error) {
+	for _, a := range custom.Attachments {
+		if a == name {
+			return t.files.Read(templateDir + "/attachments/" + name)
+		}
+	}
+
+	return t.assets.Read(defaultTemplateDir + "/attachments/" + name)
+}
+
+// Message builds the invite email for a recipient. The attachments are copied to
+// temporary files, which are removed by calling the returned cleanup function.
+func (t *TemplateService) Message(recipient string, data map[string]interface{}, locales []string) (messaging.Message, func(), error) {
+	cleanup := func() {}
+
+	templates, err := t.Email(locales)
+	if err != nil {
+		return messaging.Message{}, cleanup, err
+	}
+
+	custom, err := t.Custom("")
+	if err != nil {
+		return messaging.Message{}, cleanup, err
+	}
+
+	message := messaging.Message{
+		Recipient: recipient,
+		Templates: messaging.Templates{
+			Subject: templates.Subject,
+			Text:    templates.Text,
+			HTML:    templates.HTML,
+		},
+		Data: data,
+	}
+
+	var qr []byte
+	if u, ok := data["url"].(string); ok && templates.HTML != "" {
+		if qr, err = QRCode(u, QRCodeSize); err != nil {
+			return messaging.Message{}, cleanup, err
+		}
+	}
+
+	if len(templates.Attachments) == 0 && qr == nil {
+		return message, cleanup, nil
+	}
+
+	dir, err := ioutil.TempDir(".", ".templates-")
+	if err != nil {
+		return messaging.Message{}, cleanup, errors.Wrap(err, "Could not create temporary directory")
+	}
+	cleanup = func() {
+		os.RemoveAll(dir)
+	}
+
+	message.Attachments = make([]string, 0)
+	for _, name := range templates.Attachments {
+		b, err := t.attachment(name, custom)
+		if err != nil {
+			cleanup()
+			return messaging.Message{}, func() {}, errors.Wrapf(err, "Could not get attachment %s", name)
+		}
+
+		filename := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(filename, b, 0644); err != nil {
+			cleanup()
+			return messaging.Message{}, func() {}, errors.Wrap(err, "Could not write temporary file")
+		}
+		message.Attachments = append(message.Attachments, filename)
+	}
+
+	if qr != nil {
+		filename := filepath.Join(dir, qrAttachment)
+		if err := ioutil.WriteFile(filename, qr, 0644); err != nil {
+			cleanup()
+			return messaging.Message{}, func() {}, errors.Wrap(err, "Could not write temporary file")
+		}
+		message.Attachments = append(message.Attachments, filename)
+	}
+
+	return message, cleanup, nil
+}
+
+// Preview renders the templates with sample data. Empty templates are taken
+// from the templates the realm currently uses for the locales.
+func (t *TemplateService) Preview(templates *realm.InviteTemplates, locales []string) (*realm.EmailStatus, error) {
+	current, err := t.Email(locales)
+	if err != nil {
+		return nil, err
+	}
+
+	if templates == nil {
+		templates = &realm.InviteTemplates{}
+	}
+	if templates.Subject == "" {
+		templates.Subject = current.Subject
+	}
+	if templates.Text == "" {
+		templates.Text = current.Text
+	}
+	if templates.HTML == "" {
+		templates.HTML = current.HTML
+	}
+
+	data := t.SampleData()
+	if err := validateTemplates(templates, data); err != nil {
+		return nil, err
+	}
+
+	custom, err := t.Custom("")
+	if err != nil {
+		return nil, err
+	}
+
+	status := &realm.EmailStatus{
+		Attachments: make(map[string]string),
+	}
+
+	var subject, rendered bytes.Buffer
+	template.Must(template.New("subject").Parse(templates.Subject)).Execute(&subject, data)
+	status.Subject = subject.String()
+
+	if templates.HTML != "" {
+		htmltemplate.Must(htmltemplate.New("html").Parse(templates.HTML)).Execute(&rendered, data)
+	} else {
+		template.Must(template.New("text").Parse(templates.Text)).Execute(&rendered, data)
+	}
+	status.Rendered = rendered.String()
+
+	for _, name := range current.Attachments {
+		b, err := t.attachment(name, custom)
+		if err != nil {
+			continue
+		}
+		imageType := strings.Replace(filepath.Ext(name), ".", "/", 1)
+		status.Attachments["cid:"+name] = fmt.Sprintf("data:image%s;base64,%s", imageType, base64.StdEncoding.EncodeToString(b))
+	}
+
+	if qr, err := QRCode(data["url"].(string), QRCodeSize); err == nil {
+		status.Attachments["cid:"+qrAttachment] = "data:image/png;base64," + base64.StdEncoding.EncodeToString(qr)
+	}
+
+	return status, nil
+}
+
+// SampleData returns template variables for rendering previews and validating templates.
+func (t *TemplateService) SampleData() map[string]interface{} {
+	label := t.realmID
+	icon, banner := "", ""
+	if realmData, err := t.realm.Realm(); err == nil {
+		if realmData.Label != "" {
+			label = realmData.Label
+		}
+		if realmData.Descriptor != nil {
+			icon = realmData.Descriptor.Icon
+			banner = realmData.Descriptor.Banner
+		}
+	}
+
+	u := fmt.Sprintf("%s/realm/v2/realms/%s/invites/token/sample/fetch", t.realm.base, t.realmID)
+	link, err := t.realm.Link(u)
+	if err != nil {
+		link = u
+	}
+
+	return map[string]interface{}{
+		"role":     "member@" + t.realmID,
+		"roleName": "Member",
+		"realm":    label,
+		"url":      u,
+		"text":     "Welcome!",
+		"link":     link,
+		"icon":     icon,
+		"banner":   banner,
+	}
+}
+
+// validateTemplates parses the templates and renders them with the sample data,
+// failing on unknown variables.
+func validateTemplates(templates *realm.InviteTemplates, data map[string]interface{}) error {
+	render := func(name, text string) error {
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return err
+		}
+		return tmpl.Execute(ioutil.Discard, data)
+	}
+
+	if err := render("subject", templates.Subject); err != nil {
+		return errors.Wrapf(ErrInvalidTemplate, "subject: %s", err)
+	}
+	if err := render("text", templates.Text); err != nil {
+		return errors.Wrapf(ErrInvalidTemplate, "text: %s", err)
+	}
+
+	if templates.HTML != "" {
+		tmpl, err := htmltemplate.New("html").Option("missingkey=error").Parse(templates.HTML)
+		if err == nil {
+			err = tmpl.Execute(ioutil.Discard, data)
+		}
+		if err != nil {
+			return errors.Wrapf(ErrInvalidTemplate, "HTML: %s", err)
+		}
+	}
+
+	return nil
+}
+
+func mergeNames(a, b []string) []string {
+	seen := make(map[string]bool)
+	out := make([]string, 0, len(a)+len(b))
+	for _, name := range append(append([]string{}, a...), b...) {
+		if !seen[name] {
+			seen[name] = true
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+
+	return out
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"testing"
+
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/IpsoVeritas/realm/pkg/providers/assets"
+	filestore "github.com/IpsoVeritas/realm/pkg/providers/filestore"
+	"github.com/pkg/errors"
+)
+
+func TestTemplateService_Email(t *testing.T) {
+	const (
+		english = "Invitation to join {{ .realm }} as {{ .roleName }}"
+		swedish = "Inbjudan till {{ .realm }} som {{ .roleName }}"
+	)
+
+	tests := []struct {
+		name        string
+		realmLocale string
+		custom      map[string]string
+		locale      string
+		want        string
+	}{
+		{
+			name:   "Email_default",
+			locale: "",
+			want:   english,
+		},
+		{
+			name:   "Email_default_translation",
+			locale: "sv",
+			want:   swedish,
+		},
+		{
+			name:   "Email_custom",
+			custom: map[string]string{"": "Custom"},
+			locale: "",
+			want:   "Custom",
+		},
+		{
+			name:   "Email_custom_for_locale_without_translation",
+			custom: map[string]string{"": "Custom"},
+			locale: "fi",
+			want:   "Custom",
+		},
+		{
+			name:   "Email_translation_before_custom",
+			custom: map[string]string{"": "Custom"},
+			locale: "sv-FI",
+			want:   swedish,
+		},
+		{
+			name:   "Email_custom_for_locale",
+			custom: map[string]string{"": "Custom", "de": "Einladung"},
+			locale: "de-AT",
+			want:   "Einladung",
+		},
+		{
+			name:        "Email_custom_for_realm_locale",
+			realmLocale: "sv",
+			custom:      map[string]string{"": "Anpassad"},
+			locale:      "",
+			want:        "Anpassad",
+		},
+		{
+			name:        "Email_english_in_realm_with_other_locale",
+			realmLocale: "sv",
+			custom:      map[string]string{"": "Anpassad"},
+			locale:      "en",
+			want:        english,
+		},
+		{
+			name:        "Email_custom_english_in_realm_with_other_locale",
+			realmLocale: "sv",
+			custom:      map[string]string{"": "Anpassad", "en": "Custom"},
+			locale:      "en-GB",
+			want:        "Custom",
+		},
+	}
+	for _, tt := range tests {
+		p := newProvider(t)
+		p.assets = assets.NewAssetsProvider("../../assets")
+		fs, err := filestore.NewFilesystem("http://localhost/files", t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.SetFilestore(fs)
+
+		t.Run(tt.name, func(t *testing.T) {
+			realmData, err := p.Get(testRealm).Realm()
+			if err != nil {
+				t.Fatal(err)
+			}
+			realmData.Locale = tt.realmLocale
+			if err := p.realms.Set(realmData); err != nil {
+				t.Fatal(err)
+			}
+
+			context := p.Get(testRealm)
+			for locale, subject := range tt.custom {
+				if err := context.Templates().Set(&realm.InviteTemplates{Subject: subject}, locale); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := context.Templates().Email(context.Locales(tt.locale))
+			if err != nil {
+				t.Fatalf("TemplateService.Email() error = %v", err)
+			}
+			if got.Subject != tt.want {
+				t.Errorf("TemplateService.Email() = Subject: %q, want Subject: %q", got.Subject, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateService_Set_locale(t *testing.T) {
+	tests := []struct {
+		name    string
+		locale  string
+		wantErr bool
+	}{
+		{name: "Set_without_locale", locale: "", wantErr: false},
+		{name: "Set_locale", locale: "sv-FI", wantErr: false},
+		{name: "Set_invalid_locale", locale: "../sv", wantErr: true},
+		{name: "Set_unparsable_locale", locale: "swedish!", wantErr: true},
+	}
+	for _, tt := range tests {
+		p := newProvider(t)
+		p.assets = assets.NewAssetsProvider("../../assets")
+		fs, err := filestore.NewFilesystem("http://localhost/files", t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.SetFilestore(fs)
+
+		t.Run(tt.name, func(t *testing.T) {
+			templates := p.Get(testRealm).Templates()
+			err := templates.Set(&realm.InviteTemplates{Subject: "Custom"}, tt.locale)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TemplateService.Set() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && errors.Cause(err) != ErrInvalidTemplate {
+				t.Errorf("TemplateService.Set() error = %v, want %v", err, ErrInvalidTemplate)
+			}
+
+			// a rejected locale leaves the templates of the realm locale alone
+			custom, err := templates.Custom("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := custom.Subject == "Custom"; got != (tt.locale == "") {
+				t.Errorf("TemplateService.Custom() = %v, want the realm templates changed %v", custom.Subject, tt.locale == "")
+			}
+		})
+	}
+}
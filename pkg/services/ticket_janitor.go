@@ -0,0 +1,52 @@
+package services
+
+import (
+	"time"
+
+	logger "github.com/IpsoVeritas/logger"
+)
+
+// TicketJanitor periodically purges expired mandate tickets.
+type TicketJanitor struct {
+	p    *RealmsServiceProvider
+	stop chan struct{}
+}
+
+func NewTicketJanitor(p *RealmsServiceProvider) *TicketJanitor {
+	return &TicketJanitor{
+		p:    p,
+		stop: make(chan struct{}),
+	}
+}
+
+func (j *TicketJanitor) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				j.Process()
+			case <-j.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (j *TicketJanitor) Stop() {
+	close(j.stop)
+}
+
+// Process deletes all tickets that have expired.
+func (j *TicketJanitor) Process() {
+	count, err := j.p.mandateTickets.DeleteExpired(time.Now().UTC())
+	if err != nil {
+		logger.Warningf("Failed to delete expired tickets: %s", err)
+		return
+	}
+
+	if count > 0 {
+		logger.Debugf("Deleted %d expired tickets", count)
+	}
+}
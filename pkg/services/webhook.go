@@ -0,0 +1,216 @@
+package services
+
+import (
+	"encoding/json"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	crypto "github.com/IpsoVeritas/crypto"
+	logger "github.com/IpsoVeritas/logger"
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+type WebhookService struct {
+	p          realm.WebhookProvider
+	deliveries realm.WebhookDeliveryProvider
+	realmID    string
+	realm      *RealmService
+}
+
+func (w *WebhookService) List() ([]*realm.Webhook, error) {
+	return w.p.List(w.realmID)
+}
+
+func (w *WebhookService) Get(id string) (*realm.Webhook, error) {
+	return w.p.Get(w.realmID, id)
+}
+
+func (w *WebhookService) Set(webhook *realm.Webhook) error {
+	if webhook.ID == "" {
+		webhook.ID = uuid.NewV4().String()
+	}
+
+	u, err := url.Parse(webhook.URL)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse webhook URL")
+	}
+
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return errors.New("Webhook URL needs to be http or https")
+	}
+
+	if err := checkWebhookHost(u.Hostname()); err != nil {
+		return err
+	}
+
+	webhook.Realm = w.realmID
+	return w.p.Set(w.realmID, webhook)
+}
+
+func (w *WebhookService) Delete(id string) error {
+	if err := w.deliveries.DeleteForWebhook(w.realmID, id); err != nil {
+		return errors.Wrap(err, "failed to delete deliveries")
+	}
+
+	return w.p.Delete(w.realmID, id)
+}
+
+func (w *WebhookService) Deliveries(webhookID string) ([]*realm.WebhookDelivery, error) {
+	return w.deliveries.List(w.realmID, webhookID)
+}
+
+func (w *WebhookService) Delivery(id string) (*realm.WebhookDelivery, error) {
+	return w.deliveries.Get(w.realmID, id)
+}
+
+// queueEvent queues a delivery of the event for every active webhook of the realm
+// that subscribes to it.
+func (w *WebhookService) queueEvent(event *realm.RealmEvent) {
+	webhooks, err := w.List()
+	if err != nil {
+		logger.Warningf("Failed to list webhooks for realm %s: %s", w.realmID, err)
+		return
+	}
+
+	for _, webhook := range webhooks {
+		if !webhook.Active || !webhook.Matches(event.Type) {
+			continue
+		}
+
+		if _, err := w.Queue(webhook, event); err != nil {
+			logger.Warningf("Failed to queue %s event for webhook %s: %s", event.Type, webhook.ID, err)
+		}
+	}
+}
+
+// Queue signs the event with the realm key and stores a pending delivery for the webhook.
+// The delivery ID is derived from the webhook and the event, so an event is only queued
+// once for a webhook.
+func (w *WebhookService) Queue(webhook *realm.Webhook, event *realm.RealmEvent) (*realm.WebhookDelivery, error) {
+	bytes, err := json.Marshal(event)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal event")
+	}
+
+	jws, err := w.realm.Sign(bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign event")
+	}
+
+	payload, err := jws.CompactSerialize()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to serialize event")
+	}
+
+	now := time.Now().UTC()
+	delivery := &realm.WebhookDelivery{
+		ID:          deliveryID(webhook, event),
+		Realm:       w.realmID,
+		WebhookID:   webhook.ID,
+		Event:       event,
+		Payload:     payload,
+		Status:      realm.WebhookDeliveryPending,
+		Created:     now,
+		NextAttempt: &now,
+	}
+
+	added, err := w.deliveries.Add(w.realmID, delivery)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to save delivery")
+	}
+
+	if !added {
+		return w.Delivery(delivery.ID)
+	}
+
+	return delivery, nil
+}
+
+func deliveryID(webhook *realm.Webhook, event *realm.RealmEvent) string {
+	return crypto.Sha256(strings.Join([]string{
+		webhook.ID,
+		event.Type,
+		event.Realm,
+		event.EntityID,
+		event.Timestamp.UTC().Format(time.RFC3339Nano),
+	}, "\n"))
+}
+
+// Redeliver resets a delivery so it's attempted again, regardless of its current status.
+func (w *WebhookService) Redeliver(id string) (*realm.WebhookDelivery, error) {
+	delivery, err := w.Delivery(id)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get delivery")
+	}
+
+	now := time.Now().UTC()
+	delivery.Status = realm.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttempt = &now
+	delivery.Error = ""
+
+	if err := w.deliveries.Set(w.realmID, delivery); err != nil {
+		return nil, errors.Wrap(err, "failed to save delivery")
+	}
+
+	return delivery, nil
+}
+
+var nonPublicNetworks = parseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"::/128",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+)
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	out := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		out = append(out, n)
+	}
+	return out
+}
+
+// isPublicIP checks that the address isn't a loopback, private or link-local one,
+// which webhooks are not allowed to target.
+func isPublicIP(ip net.IP) bool {
+	for _, n := range nonPublicNetworks {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkWebhookHost rejects webhook hosts that are loopback, private or link-local
+// addresses. Host names are checked again when connecting, against the address
+// they resolve to then.
+func checkWebhookHost(host string) error {
+	if host == "" {
+		return errors.New("Webhook URL needs a host")
+	}
+
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return errors.New("Webhook URL can't be a loopback address")
+	}
+
+	if ip := net.ParseIP(host); ip != nil && !isPublicIP(ip) {
+		return errors.New("Webhook URL can't be a loopback, private or link-local address")
+	}
+
+	return nil
+}
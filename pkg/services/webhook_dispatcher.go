@@ -0,0 +1,172 @@
+package services
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+
+	logger "github.com/IpsoVeritas/logger"
+	realm "github.com/IpsoVeritas/realm"
+	"github.com/pkg/errors"
+	resty "gopkg.in/resty.v1"
+)
+
+const (
+	webhookMaxAttempts    = 8
+	webhookInitialBackoff = 30 * time.Second
+	webhookMaxBackoff     = 6 * time.Hour
+	webhookTimeout        = 10 * time.Second
+	// webhookLease is how long a delivery is reserved for the attempt that claimed it.
+	// It must be longer than webhookTimeout.
+	webhookLease = time.Minute
+)
+
+// WebhookDispatcher posts the deliveries queued when events are published, on every
+// tick, retrying failed deliveries with exponential backoff.
+type WebhookDispatcher struct {
+	p      *RealmsServiceProvider
+	client *resty.Client
+	stop   chan struct{}
+	mu     sync.Mutex
+}
+
+func NewWebhookDispatcher(p *RealmsServiceProvider) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		p:      p,
+		client: resty.New().SetTimeout(webhookTimeout).SetTransport(webhookTransport()),
+		stop:   make(chan struct{}),
+	}
+}
+
+// webhookTransport only connects to public addresses, so a webhook host name can't
+// be pointed at the internal network after the webhook was saved.
+func webhookTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return errors.Errorf("webhook address %s is not public", host)
+			}
+			return nil
+		},
+	}
+
+	return &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: webhookTimeout,
+	}
+}
+
+func (d *WebhookDispatcher) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				d.Process()
+			case <-d.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (d *WebhookDispatcher) Stop() {
+	close(d.stop)
+}
+
+// Process attempts all deliveries that are due.
+func (d *WebhookDispatcher) Process() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	deliveries, err := d.p.webhookDeliveries.ListDue(time.Now().UTC())
+	if err != nil {
+		logger.Warningf("Failed to list webhook deliveries: %s", err)
+		return
+	}
+
+	for _, delivery := range deliveries {
+		now := time.Now().UTC()
+		claimed, err := d.p.webhookDeliveries.Claim(delivery.Realm, delivery.ID, now, now.Add(webhookLease))
+		if err != nil {
+			logger.Warningf("Failed to claim webhook delivery %s: %s", delivery.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		if err := d.attempt(delivery); err != nil {
+			logger.Warningf("Failed to deliver webhook %s: %s", delivery.ID, err)
+		}
+	}
+}
+
+func (d *WebhookDispatcher) attempt(delivery *realm.WebhookDelivery) error {
+	webhook, err := d.p.webhooks.Get(delivery.Realm, delivery.WebhookID)
+	if err != nil {
+		delivery.Status = realm.WebhookDeliveryFailed
+		delivery.NextAttempt = nil
+		delivery.Error = "webhook no longer exists"
+		return d.p.webhookDeliveries.Set(delivery.Realm, delivery)
+	}
+
+	now := time.Now().UTC()
+	delivery.Attempts++
+	delivery.LastAttempt = &now
+
+	res, err := d.client.R().
+		SetHeader("Content-Type", "application/jose").
+		SetHeader("X-Realm-Event", delivery.Event.Type).
+		SetHeader("X-Realm-Delivery", delivery.ID).
+		SetBody(delivery.Payload).
+		Post(webhook.URL)
+
+	switch {
+	case err != nil:
+		delivery.ResponseStatus = 0
+		delivery.Error = err.Error()
+	case res.StatusCode() < 200 || res.StatusCode() > 299:
+		delivery.ResponseStatus = res.StatusCode()
+		delivery.Error = fmt.Sprintf("unexpected status code %d", res.StatusCode())
+	default:
+		delivery.ResponseStatus = res.StatusCode()
+		delivery.Error = ""
+		delivery.Status = realm.WebhookDeliverySucceeded
+		delivery.NextAttempt = nil
+	}
+
+	if delivery.Status == realm.WebhookDeliveryPending {
+		if delivery.Attempts >= webhookMaxAttempts {
+			delivery.Status = realm.WebhookDeliveryFailed
+			delivery.NextAttempt = nil
+		} else {
+			next := now.Add(backoff(delivery.Attempts, webhookInitialBackoff, webhookMaxBackoff))
+			delivery.NextAttempt = &next
+		}
+	}
+
+	return d.p.webhookDeliveries.Set(delivery.Realm, delivery)
+}
+
+// backoff returns the delay before the next attempt, doubling the initial delay
+// for every failed attempt up to max.
+func backoff(attempts int, initial, max time.Duration) time.Duration {
+	delay := initial
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay > max {
+			return max
+		}
+	}
+	return delay
+}
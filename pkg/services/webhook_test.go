@@ -0,0 +1,46 @@
+package services
+
+import (
+	"testing"
+
+	realm "github.com/IpsoVeritas/realm"
+)
+
+func TestRealmsServiceProvider_Publish_webhooks(t *testing.T) {
+	tests := []struct {
+		name    string
+		webhook realm.Webhook
+		want    int
+	}{
+		{name: "Publish_all_events", webhook: realm.Webhook{Active: true}, want: 1},
+		{name: "Publish_matching_event", webhook: realm.Webhook{Active: true, Events: []string{realm.EventInviteSent}}, want: 1},
+		{name: "Publish_other_event", webhook: realm.Webhook{Active: true, Events: []string{realm.EventInviteAccepted}}, want: 0},
+		{name: "Publish_inactive", webhook: realm.Webhook{}, want: 0},
+	}
+	for _, tt := range tests {
+		p := newProvider(t)
+		p.signer = newTestSigner()
+		if _, err := p.signer.Create(testRealm); err != nil {
+			t.Fatal(err)
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			webhook := tt.webhook
+			webhook.ID = "hook"
+			webhook.URL = "https://example.com/hook"
+			if err := p.webhooks.Set(testRealm, &webhook); err != nil {
+				t.Fatal(err)
+			}
+
+			// there's no event bus, so the deliveries are queued by Publish itself
+			p.Publish(realm.NewRealmEvent(realm.EventInviteSent, testRealm, "abc"))
+
+			deliveries, err := p.Get(testRealm).Webhooks().Deliveries("hook")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(deliveries) != tt.want {
+				t.Errorf("RealmsServiceProvider.Publish() queued %d deliveries, want %d", len(deliveries), tt.want)
+			}
+		})
+	}
+}
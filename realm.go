@@ -1,10 +1,16 @@
 package realm
 
 import (
+	"errors"
+	"time"
+
 	"github.com/IpsoVeritas/document"
 	jose "gopkg.in/square/go-jose.v1"
 )
 
+// ErrRealmDeleted is returned for realms that have been deleted, but not yet purged.
+var ErrRealmDeleted = errors.New("realm is deleted")
+
 type Realm struct {
 	ID                   string                    `json:"@id,omitempty"`
 	Type                 string                    `json:"@type,omitempty"`
@@ -17,6 +23,21 @@ type Realm struct {
 	AdminRoles           []string                  `json:"adminRoles,omitempty"`
 	OwnerRealm           bool                      `json:"ownerRealm,omitempty"`
 	GuestRole            string                    `json:"guestRole,omitempty"`
+	GuestScopes          []document.Scope          `json:"guestScopes,omitempty"`
+	GuestContract        string                    `json:"guestContract,omitempty"`
+	Locale               string                    `json:"locale,omitempty"`
+	DeepLink             string                    `json:"deepLink,omitempty"`
+	KeyID                string                    `json:"keyId,omitempty"`
+	RetiredKeys          []*RetiredKey             `json:"retiredKeys,omitempty"`
+	Deleted              *time.Time                `json:"deleted,omitempty"`
+}
+
+// SigningKeyID returns the ID of the current key of the realm.
+func (r *Realm) SigningKeyID() string {
+	if r.KeyID == "" {
+		return r.ID
+	}
+	return r.KeyID
 }
 
 type RealmProvider interface {
@@ -26,7 +47,29 @@ type RealmProvider interface {
 	Delete(id string) error
 }
 
-type RealmEvent struct {
-	Type  string `json:"type"`
-	Realm string `json:"realm"`
+// RealmStore holds providers for the entities of realms that write together.
+type RealmStore struct {
+	Realms         RealmProvider
+	Roles          RoleProvider
+	Permissions    PermissionProvider
+	Controllers    ControllerProvider
+	Actions        ActionProvider
+	Mandates       IssuedMandateProvider
+	Revocations    RevocationProvider
+	MandateTickets MandateTicketProvider
+	Invites        InviteProvider
+	Settings       SettingProvider
+}
+
+// RealmImporter writes the entities of a restored realm in one transaction.
+type RealmImporter interface {
+	// Import calls write with providers that write in one transaction, which is
+	// committed if write returns nil and rolled back otherwise
+	Import(write func(store *RealmStore) error) error
+}
+
+// RealmPurger permanently deletes a realm and everything stored for it.
+type RealmPurger interface {
+	// Purge deletes the realm, its entities and the stored keys with the given IDs in one transaction
+	Purge(realmID string, keyIDs []string) error
 }
@@ -0,0 +1,32 @@
+package realm
+
+import (
+	"time"
+
+	"github.com/IpsoVeritas/document"
+)
+
+type Revocation struct {
+	MandateID string    `json:"mandateId"`
+	Realm     string    `json:"realm,omitempty"`
+	Role      string    `json:"role,omitempty"`
+	Checksum  string    `json:"checksum,omitempty"`
+	Revoked   time.Time `json:"revoked"`
+}
+
+// RevocationList is the document published by a realm listing its revoked mandates.
+// Since is set when the list only contains revocations made after that point in time.
+type RevocationList struct {
+	document.Base
+	Since       *time.Time    `json:"since,omitempty"`
+	Revocations []*Revocation `json:"revocations"`
+}
+
+type RevocationProvider interface {
+	List(realmID string) ([]*Revocation, error)
+	ListSince(realmID string, since time.Time) ([]*Revocation, error)
+	Get(realmID, mandateID string) (*Revocation, error)
+	GetByChecksum(realmID, checksum string) (*Revocation, error)
+	Set(realmID string, revocation *Revocation) error
+	Delete(realmID, mandateID string) error
+}
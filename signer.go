@@ -0,0 +1,35 @@
+package realm
+
+import (
+	"errors"
+	"time"
+
+	jose "gopkg.in/square/go-jose.v1"
+)
+
+// ErrKeyNotExportable is returned by Signer.Key when the private key never leaves
+// the key store, like keys held by an HSM.
+var ErrKeyNotExportable = errors.New("private key can not be exported")
+
+// Signer holds the private keys of realms and signs with them. Keys are identified
+// by the key ID of the realm, which is the realm ID for the first key of a realm.
+type Signer interface {
+	// Create generates a new private key and returns its public key
+	Create(keyID string) (*jose.JsonWebKey, error)
+	// Import stores an existing private key
+	Import(keyID string, key *jose.JsonWebKey) error
+	// Key returns the private key, or ErrKeyNotExportable
+	Key(keyID string) (*jose.JsonWebKey, error)
+	PublicKey(keyID string) (*jose.JsonWebKey, error)
+	Sign(keyID string, payload []byte) (*jose.JsonWebSignature, error)
+	Delete(keyID string) error
+}
+
+// RetiredKey is a previous key of a realm. Mandates signed by it are accepted
+// until ValidUntil, which gives time to re-issue them with the current key.
+type RetiredKey struct {
+	KeyID      string           `json:"keyId"`
+	PublicKey  *jose.JsonWebKey `json:"publicKey"`
+	Retired    time.Time        `json:"retired"`
+	ValidUntil time.Time        `json:"validUntil"`
+}
@@ -0,0 +1,73 @@
+package realm
+
+import "time"
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// Webhook is a subscription to realm events. An empty Events list subscribes to all event types.
+type Webhook struct {
+	ID          string    `json:"@id,omitempty"`
+	Realm       string    `json:"realm,omitempty"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Active      bool      `json:"active"`
+	Created     time.Time `json:"created,omitempty"`
+}
+
+func (w *Webhook) Matches(eventType string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+
+	for _, e := range w.Events {
+		if e == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+type WebhookProvider interface {
+	List(realmID string) ([]*Webhook, error)
+	Get(realmID, id string) (*Webhook, error)
+	Set(realmID string, webhook *Webhook) error
+	Delete(realmID, id string) error
+}
+
+type WebhookDelivery struct {
+	ID             string      `json:"@id,omitempty"`
+	Realm          string      `json:"realm,omitempty"`
+	WebhookID      string      `json:"webhookId"`
+	Event          *RealmEvent `json:"event"`
+	Payload        string      `json:"payload"`
+	Status         string      `json:"status"`
+	Attempts       int         `json:"attempts"`
+	Created        time.Time   `json:"created"`
+	LastAttempt    *time.Time  `json:"lastAttempt,omitempty"`
+	NextAttempt    *time.Time  `json:"nextAttempt,omitempty"`
+	ResponseStatus int         `json:"responseStatus,omitempty"`
+	Error          string      `json:"error,omitempty"`
+}
+
+type WebhookDeliveryProvider interface {
+	List(realmID, webhookID string) ([]*WebhookDelivery, error)
+	Get(realmID, id string) (*WebhookDelivery, error)
+	Set(realmID string, delivery *WebhookDelivery) error
+	Delete(realmID, id string) error
+	// Add stores a new delivery. It returns false, and leaves the stored delivery as
+	// it is, if a delivery with the same ID already exists.
+	Add(realmID string, delivery *WebhookDelivery) (bool, error)
+	// DeleteForWebhook deletes all deliveries for a webhook
+	DeleteForWebhook(realmID, webhookID string) error
+	// ListDue returns pending deliveries for all realms with a next attempt at or before the given time
+	ListDue(before time.Time) ([]*WebhookDelivery, error)
+	// Claim moves the next attempt of a due delivery to until, so no other instance picks
+	// it up while it's being delivered. It returns false if the delivery was not due.
+	Claim(realmID, id string, now, until time.Time) (bool, error)
+}